@@ -0,0 +1,112 @@
+// Command breachfilter builds the breached-password bloom filter used by the
+// password policy (PASSWORD_BREACHED_FILTER_PATH).
+//
+// The input has one entry per line, either a plain password or, with
+// -format sha1, a SHA-1 hash as in the Have I Been Pwned downloads
+// ("HASH" or "HASH:COUNT").
+//
+//	go run ./cmd/breachfilter -in pwned-passwords-sha1.txt -format sha1 -out breached.bloom
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/yantology/golang-starter-template/pkg/passwordpolicy"
+)
+
+func main() {
+	in := flag.String("in", "", "input file with one entry per line")
+	out := flag.String("out", "breached.bloom", "output filter file")
+	format := flag.String("format", "plain", "input format, plain or sha1")
+	falsePositiveRate := flag.Float64("fp", 0.001, "false positive rate")
+	flag.Parse()
+
+	if *in == "" || (*format != "plain" && *format != "sha1") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	// The first pass counts entries so the filter can be sized
+	count := 0
+	if err := readEntries(*in, func(string) error { count++; return nil }); err != nil {
+		log.Fatal(err)
+	}
+
+	filter := passwordpolicy.NewBloomFilter(count, *falsePositiveRate)
+	err := readEntries(*in, func(entry string) error {
+		if *format == "plain" {
+			filter.AddPassword(entry)
+			return nil
+		}
+		digest, err := parseSHA1(entry)
+		if err != nil {
+			return err
+		}
+		filter.Add(digest)
+		return nil
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	file, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	writer := bufio.NewWriter(file)
+	if _, err := filter.WriteTo(writer); err != nil {
+		log.Fatal(err)
+	}
+	if err := writer.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	if err := file.Close(); err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("Wrote %d entries to %s\n", count, *out)
+}
+
+// readEntries calls fn for every non-empty line of the file
+func readEntries(path string, fn func(entry string) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadString('\n')
+		if entry := strings.TrimRight(line, "\r\n"); entry != "" {
+			if fnErr := fn(entry); fnErr != nil {
+				return fnErr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func parseSHA1(entry string) ([sha1.Size]byte, error) {
+	var digest [sha1.Size]byte
+	hash, _, _ := strings.Cut(entry, ":")
+	decoded, err := hex.DecodeString(strings.TrimSpace(hash))
+	if err != nil || len(decoded) != sha1.Size {
+		return digest, fmt.Errorf("invalid SHA-1 entry %q", entry)
+	}
+	copy(digest[:], decoded)
+	return digest, nil
+}
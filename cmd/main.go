@@ -1,21 +1,37 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"net/mail"
+	"os"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/joho/godotenv"
+	"github.com/redis/go-redis/v9"
 	swaggerFiles "github.com/swaggo/files"
 	ginSwagger "github.com/swaggo/gin-swagger"
 	"github.com/yantology/golang-starter-template/config"
 	_ "github.com/yantology/golang-starter-template/docs"
+	"github.com/yantology/golang-starter-template/middleware"
 	"github.com/yantology/golang-starter-template/pkg/jwt"
+	"github.com/yantology/golang-starter-template/pkg/lockout"
+	"github.com/yantology/golang-starter-template/pkg/mailer"
+	"github.com/yantology/golang-starter-template/pkg/oidc"
+	"github.com/yantology/golang-starter-template/pkg/outbox"
+	"github.com/yantology/golang-starter-template/pkg/password"
+	"github.com/yantology/golang-starter-template/pkg/passwordpolicy"
+	"github.com/yantology/golang-starter-template/pkg/personaldata"
+	"github.com/yantology/golang-starter-template/pkg/ratelimit"
 	"github.com/yantology/golang-starter-template/pkg/resendutils"
+	"github.com/yantology/golang-starter-template/pkg/suppression"
+	"github.com/yantology/golang-starter-template/pkg/webauthn"
 	"github.com/yantology/golang-starter-template/routes/auth"
 )
 
@@ -41,6 +57,15 @@ func initMigrations(db *sql.DB) error {
 	return nil
 }
 
+// rateLimitRule converts a configured rule to the ratelimit package form
+func rateLimitRule(rule config.RateLimitRule) ratelimit.Rule {
+	return ratelimit.Rule{
+		Algorithm: ratelimit.Algorithm(rule.Algorithm),
+		Limit:     rule.Limit,
+		Period:    time.Duration(rule.PeriodSeconds) * time.Second,
+	}
+}
+
 // @title           Retail Pro API
 // @version         1.0
 // @description     This is a retail management system server.
@@ -72,13 +97,38 @@ func main() {
 	appConfig := config.InitAppConfig()
 	dbConfig := config.InitDatabaseConfig()
 	jwtConfig, err := config.InitJWTConfig()
-	tokenConfig := config.InitTokenConfig()
 	if err != nil {
 		log.Fatal("Failed to initialize JWT config:", err)
 	}
-	resendConfig, err := config.InitResendConfig()
+	tokenConfig := config.InitTokenConfig()
+	mfaConfig := config.InitMFAConfig()
+	webAuthnConfig := config.InitWebAuthnConfig()
+	oidcConfig := config.InitOIDCConfig()
+	passwordlessConfig := config.InitPasswordlessConfig()
+	activationConfig := config.InitActivationConfig()
+	lockoutConfig := config.InitLockoutConfig()
+	rateLimitConfig := config.InitRateLimitConfig()
+	passwordHashConfig := config.InitPasswordHashConfig()
+	passwordPolicyConfig := config.InitPasswordPolicyConfig()
+	rbacConfig := config.InitRBACConfig()
+	apiKeyConfig := config.InitAPIKeyConfig()
+	accountDeletionConfig := config.InitAccountDeletionConfig()
+	outboxConfig := config.InitOutboxConfig()
+	organizationConfig, err := config.InitOrganizationConfig()
 	if err != nil {
-		log.Fatal("Failed to initialize Resend config:", err)
+		log.Fatal("Failed to initialize organization config:", err)
+	}
+	mailConfig, err := config.InitMailConfig()
+	if err != nil {
+		log.Fatal("Failed to initialize mail config:", err)
+	}
+	// Resend settings are only needed, and only required, by the resend driver
+	resendConfig := &config.ResendApi{}
+	if mailConfig.Driver == "resend" {
+		resendConfig, err = config.InitResendConfig()
+		if err != nil {
+			log.Fatal("Failed to initialize Resend config:", err)
+		}
 	}
 
 	db := config.ConnectDatabase(dbConfig, sql.Open)
@@ -89,23 +139,184 @@ func main() {
 		log.Fatal(err)
 	}
 
-	jwtService := jwt.NewJWTService(
+	var tokenDenylist jwt.Denylist = jwt.NewPostgresDenylist(db)
+	if jwtConfig.DenylistStore == "memory" {
+		tokenDenylist = jwt.NewMemoryDenylist()
+	}
+	jwtOptions := []jwt.Option{
+		jwt.WithAudience(jwtConfig.Audience),
+		jwt.WithLeeway(jwtConfig.Leeway),
+		jwt.WithDenylist(tokenDenylist),
+	}
+	// An asymmetric signing key lets other services verify access tokens through the JWKS
+	if jwtConfig.SigningKeyFile != "" {
+		signingKey, err := jwt.LoadKeyFile(jwtConfig.SigningKeyFile)
+		if err != nil {
+			log.Fatal("Failed to load JWT signing key:", err)
+		}
+		keySet, err := jwt.NewKeySet(signingKey)
+		if err != nil {
+			log.Fatal("Failed to initialize JWT key set:", err)
+		}
+		for _, previousKey := range jwtConfig.PreviousKeys {
+			key, err := jwt.LoadKeyFile(previousKey.File)
+			if err != nil {
+				log.Fatal("Failed to load previous JWT key:", err)
+			}
+			keySet.AddVerificationKey(key, previousKey.RetireAt)
+		}
+		jwtOptions = append(jwtOptions, jwt.WithAccessKeySet(keySet))
+	}
+	// Access tokens carry the active organization next to the user's roles and permissions
+	jwtService := jwt.NewService[middleware.UserClaims](
 		jwtConfig.AccessSecret,
 		jwtConfig.RefreshSecret,
 		jwtConfig.AccessDuration,
 		jwtConfig.RefreshDuration,
 		jwtConfig.Issuer,
+		jwtOptions...,
 	)
-	if err != nil {
-		log.Fatal("Failed to initialize JWT service:", err)
+	webAuthn := webauthn.New(webauthn.Config{
+		RPID:             webAuthnConfig.RPID,
+		RPName:           webAuthnConfig.RPName,
+		RPOrigins:        webAuthnConfig.RPOrigins,
+		Timeout:          time.Duration(webAuthnConfig.TimeoutSeconds) * time.Second,
+		UserVerification: webAuthnConfig.UserVerification,
+	})
+	// Providers that cannot be discovered are skipped so an outage does not block startup
+	oidcProviders := oidc.NewRegistry()
+	for _, providerConfig := range oidcConfig.Providers {
+		provider, err := oidc.NewProvider(context.Background(), oidc.ProviderConfig{
+			Name:         providerConfig.Name,
+			IssuerURL:    providerConfig.IssuerURL,
+			ClientID:     providerConfig.ClientID,
+			ClientSecret: providerConfig.ClientSecret,
+			RedirectURL:  providerConfig.RedirectURL,
+			Scopes:       providerConfig.Scopes,
+		})
+		if err != nil {
+			log.Println("Warning: OIDC provider disabled:", err)
+			continue
+		}
+		oidcProviders.Add(provider)
+	}
+	// Email, IP and MFA share the backoff and lockout timing but have their own thresholds
+	lockoutPolicy := lockout.Policy{
+		BaseDelay:       time.Duration(lockoutConfig.BaseDelaySeconds) * time.Second,
+		MaxDelay:        time.Duration(lockoutConfig.MaxDelaySeconds) * time.Second,
+		LockoutDuration: time.Duration(lockoutConfig.DurationMinutes) * time.Minute,
+		Window:          time.Duration(lockoutConfig.WindowMinutes) * time.Minute,
+	}
+	emailPolicy, ipPolicy, mfaPolicy := lockoutPolicy, lockoutPolicy, lockoutPolicy
+	emailPolicy.Threshold = lockoutConfig.EmailThreshold
+	ipPolicy.Threshold = lockoutConfig.IPThreshold
+	mfaPolicy.Threshold = lockoutConfig.MFAThreshold
+	loginLockout := &auth.LoginLockout{
+		Email: lockout.NewPostgresTracker(db, "email", emailPolicy),
+		IP:    lockout.NewPostgresTracker(db, "ip", ipPolicy),
+		MFA:   lockout.NewPostgresTracker(db, "mfa", mfaPolicy),
+	}
+	if lockoutConfig.Store == "memory" {
+		loginLockout.Email = lockout.NewMemoryTracker(emailPolicy)
+		loginLockout.IP = lockout.NewMemoryTracker(ipPolicy)
+		loginLockout.MFA = lockout.NewMemoryTracker(mfaPolicy)
 	}
-	emailSender := resendutils.NewResendUtils(resendConfig.ApiKey, resendConfig.ResendDomain)
+	// Both algorithms stay verifiable so hashes can be migrated on login
+	bcryptHasher := password.NewBcrypt(passwordHashConfig.BcryptCost)
+	argon2Hasher := password.NewArgon2id(password.Argon2Params{
+		Memory:      uint32(passwordHashConfig.Argon2MemoryKiB),
+		Iterations:  uint32(passwordHashConfig.Argon2Iterations),
+		Parallelism: uint8(passwordHashConfig.Argon2Parallelism),
+		SaltLength:  16,
+		KeyLength:   32,
+	})
+	passwordHasher := password.NewMultiHasher(argon2Hasher, bcryptHasher)
+	if passwordHashConfig.Algorithm == "bcrypt" {
+		passwordHasher = password.NewMultiHasher(bcryptHasher, argon2Hasher)
+	}
+	passwordPolicy := &passwordpolicy.Policy{
+		MinLength:          passwordPolicyConfig.MinLength,
+		MaxLength:          passwordPolicyConfig.MaxLength,
+		RequireLowercase:   passwordPolicyConfig.RequireLowercase,
+		RequireUppercase:   passwordPolicyConfig.RequireUppercase,
+		RequireDigit:       passwordPolicyConfig.RequireDigit,
+		RequireSymbol:      passwordPolicyConfig.RequireSymbol,
+		MinStrength:        passwordPolicyConfig.MinStrength,
+		ForbidPersonalInfo: passwordPolicyConfig.ForbidPersonalInfo,
+	}
+	if passwordPolicyConfig.BreachedFilterPath != "" {
+		breached, err := passwordpolicy.LoadBloomFilter(passwordPolicyConfig.BreachedFilterPath)
+		if err != nil {
+			log.Fatal("Failed to load breached password filter:", err)
+		}
+		passwordPolicy.Breached = breached
+	}
+	// A nil store lets every request through
+	var rateLimitStore ratelimit.Store
+	if rateLimitConfig.Enabled {
+		rateLimitStore = ratelimit.NewMemoryStore()
+		if rateLimitConfig.Store == "redis" {
+			redisOptions, err := redis.ParseURL(rateLimitConfig.RedisURL)
+			if err != nil {
+				log.Fatal("Failed to parse REDIS_URL:", err)
+			}
+			redisClient := redis.NewClient(redisOptions)
+			defer redisClient.Close()
+			rateLimitStore = ratelimit.NewRedisStore(redisClient, "ratelimit:")
+		}
+	}
+	rateLimiter := middleware.NewRateLimiter(rateLimitStore)
+
+	// Addresses that hard bounced or complained, reported by the Resend webhook
+	emailSuppressions := suppression.NewPostgresStore(db)
+
+	var emailSender mailer.Sender
+	emailFrom := mail.Address{Name: mailConfig.FromName, Address: mailConfig.FromAddress}
+	switch mailConfig.Driver {
+	case "resend":
+		emailSender = resendutils.NewResendUtils(resendConfig.ApiKey, resendConfig.ResendDomain, resendConfig.ResendName, emailSuppressions)
+	case "smtp":
+		emailSender = mailer.NewSMTPSender(mailer.SMTPConfig{
+			Host:     mailConfig.SMTPHost,
+			Port:     mailConfig.SMTPPort,
+			Username: mailConfig.SMTPUsername,
+			Password: mailConfig.SMTPPassword,
+			StartTLS: mailConfig.SMTPStartTLS,
+		}, emailFrom)
+	case "file":
+		emailSender = mailer.NewFileSender(mailConfig.OutboxDir, emailFrom)
+	default:
+		emailSender = mailer.NewLogSender(os.Stdout, emailFrom)
+	}
+	log.Println("Mail driver =>", mailConfig.Driver)
 
-	// Initialize Auth middleware
-	// authMiddleware := middleware.NewAuthMiddleware(jwtService, tokenConfig)
+	// Emails are queued in the database and delivered by the worker, so a slow or failing
+	// provider neither blocks requests nor loses messages
+	emailOutbox := outbox.NewPostgresStore(db)
+	outboxWorker := outbox.NewWorker(emailOutbox, emailSender, outbox.Policy{
+		BaseDelay:   time.Duration(outboxConfig.BaseDelaySeconds) * time.Second,
+		MaxDelay:    time.Duration(outboxConfig.MaxDelaySeconds) * time.Second,
+		MaxAttempts: outboxConfig.MaxAttempts,
+	}, time.Duration(outboxConfig.PollSeconds)*time.Second)
+	go outboxWorker.Run(context.Background())
+	// Queued emails carry codes and tokens, so old ones are not kept
+	outboxPurger := outbox.NewPurger(emailOutbox, outbox.Retention{
+		RetryWindow: time.Duration(outboxConfig.RetryWindowHours) * time.Hour,
+		MaxAge:      time.Duration(outboxConfig.RetentionDays) * 24 * time.Hour,
+	}, time.Hour)
+	go outboxPurger.Run(context.Background())
+
+	// Every module keeping user data registers its provider; the account owner, auth, comes first
+	// so it is purged last
+	personalData := personaldata.NewRegistry()
 
 	// Initialize Gin router with CORS configuration
 	router := gin.Default()
+	// Client IPs key the login lockout and rate limits, so forwarded headers are only
+	// believed from the configured proxies
+	if err := router.SetTrustedProxies(appConfig.TrustedProxies); err != nil {
+		log.Fatal("Failed to set trusted proxies:", err)
+	}
 	router.Use(config.CorsConfig())
 	router.Use(gin.Recovery())
 
@@ -113,12 +324,62 @@ func main() {
 	v1 := router.Group("/api/v1")
 	{
 		// Auth routes
-		emailTemplate := auth.NewEmailTemplate()
+		emailTemplates, err := auth.NewEmailTemplates(mailConfig.TemplateDir, mailConfig.DefaultLocale)
+		if err != nil {
+			log.Fatal("Failed to load email templates:", err)
+		}
 		authPostgres := auth.NewAuthPostgres(db)
 		authRepo := auth.NewAuthRepository(authPostgres)
-		authService := auth.NewAuthService(jwtService, tokenConfig)
-		authHandler := auth.NewAuthHandler(authService, authRepo, emailSender, emailTemplate, tokenConfig)
-		authHandler.RegisterRoutes(v1)
+		authService := auth.NewAuthService(jwtService, tokenConfig, mfaConfig, passwordlessConfig, activationConfig, passwordHasher, passwordPolicy, organizationConfig, oidcConfig)
+		authHandler := auth.NewAuthHandler(authService, authRepo, emailTemplates, tokenConfig, mfaConfig, webAuthn, oidcProviders, oidcConfig, passwordlessConfig, activationConfig, loginLockout, lockoutConfig, apiKeyConfig, rbacConfig, organizationConfig, personalData, accountDeletionConfig, emailOutbox, emailSuppressions)
+
+		authPersonalData := auth.NewPersonalDataProvider(authRepo, loginLockout)
+		personalData.Register(authPersonalData)
+		purger := personaldata.NewPurger(personalData, authPersonalData, time.Duration(accountDeletionConfig.PurgeIntervalMinutes)*time.Minute)
+		go purger.Run(context.Background())
+
+		// Initialize Auth middleware
+		authMiddleware := middleware.NewAuthMiddleware(jwtService, tokenConfig, auth.NewAPIKeyAuthenticator(authService, authRepo))
+
+		authRateLimits := auth.RateLimits{
+			Group:        rateLimiter.Limit("auth", rateLimitRule(rateLimitConfig.Auth), middleware.KeyByIP),
+			TokenRequest: rateLimiter.Limit("auth-token", rateLimitRule(rateLimitConfig.TokenRequest), middleware.KeyByIP),
+		}
+		authHandler.RegisterRoutes(v1, authMiddleware, authRateLimits)
+		router.GET("/.well-known/jwks.json", authHandler.JWKS)
+
+		// Bootstrap existing admins; users registering later get the role on their first login
+		for _, email := range rbacConfig.AdminEmails {
+			user, cuserr := authRepo.GetUserByEmail(email)
+			if cuserr != nil {
+				log.Println("Warning: admin not registered yet:", email)
+				continue
+			}
+			if cuserr := authRepo.AssignUserRole(user.ID, "admin"); cuserr != nil {
+				log.Println("Warning: failed to grant admin role:", cuserr.Original())
+			}
+		}
+
+		// Resend delivery events, authenticated by their signature
+		if resendConfig.WebhookSecret != "" {
+			webhookVerifier, err := resendutils.NewWebhookVerifier(resendConfig.WebhookSecret)
+			if err != nil {
+				log.Fatal("Failed to initialize Resend webhook:", err)
+			}
+			auth.NewEmailWebhookHandler(webhookVerifier, emailOutbox, emailSuppressions).RegisterRoutes(v1)
+		}
+
+		// Email template preview, unauthenticated so designers can open it in a browser.
+		// Sending a test email needs emails:manage and is limited like token requests,
+		// per API key for integrations and per user otherwise.
+		if mailConfig.PreviewEnabled {
+			log.Println("Warning: email preview is enabled at /api/v1/dev/emails, do not enable it in production")
+			previewRateLimits := auth.RateLimits{
+				Group:        rateLimiter.Limit("mail-preview", rateLimitRule(rateLimitConfig.Auth), middleware.KeyByIP),
+				TokenRequest: rateLimiter.Limit("mail-preview-send", rateLimitRule(rateLimitConfig.TokenRequest), middleware.KeyByAPIKey),
+			}
+			auth.NewMailPreviewHandler(mailConfig.TemplateDir, mailConfig.DefaultLocale, emailSender).RegisterRoutes(v1, authMiddleware, previewRateLimits)
+		}
 
 	}
 
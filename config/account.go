@@ -0,0 +1,35 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+type AccountDeletionConfig struct {
+	// GraceDays is how long a requested deletion can be cancelled before the account is purged,
+	// 0 purges on the next run of the purger
+	GraceDays int
+	// PurgeIntervalMinutes is how often the purger looks for accounts whose grace period has ended
+	PurgeIntervalMinutes int
+}
+
+func InitAccountDeletionConfig() *AccountDeletionConfig {
+	graceDays := 14
+	if env := os.Getenv("ACCOUNT_DELETION_GRACE_DAYS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= 0 {
+			graceDays = parsed
+		}
+	}
+
+	purgeIntervalMinutes := 60
+	if env := os.Getenv("ACCOUNT_PURGE_INTERVAL_MINUTES"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			purgeIntervalMinutes = parsed
+		}
+	}
+
+	return &AccountDeletionConfig{
+		GraceDays:            graceDays,
+		PurgeIntervalMinutes: purgeIntervalMinutes,
+	}
+}
@@ -0,0 +1,66 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/config"
+)
+
+func TestInitAccountDeletionConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected *config.AccountDeletionConfig
+	}{
+		{
+			name:    "with default values",
+			envVars: map[string]string{},
+			expected: &config.AccountDeletionConfig{
+				GraceDays:            14,
+				PurgeIntervalMinutes: 60,
+			},
+		},
+		{
+			name: "with custom values",
+			envVars: map[string]string{
+				"ACCOUNT_DELETION_GRACE_DAYS":    "0",
+				"ACCOUNT_PURGE_INTERVAL_MINUTES": "5",
+			},
+			expected: &config.AccountDeletionConfig{
+				GraceDays:            0,
+				PurgeIntervalMinutes: 5,
+			},
+		},
+		{
+			name: "with invalid values",
+			envVars: map[string]string{
+				"ACCOUNT_DELETION_GRACE_DAYS":    "-1",
+				"ACCOUNT_PURGE_INTERVAL_MINUTES": "0",
+			},
+			expected: &config.AccountDeletionConfig{
+				GraceDays:            14,
+				PurgeIntervalMinutes: 60,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear environment before each test
+			os.Clearenv()
+
+			// Set environment variables for test
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			// Run test
+			config := config.InitAccountDeletionConfig()
+
+			// Assert results
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
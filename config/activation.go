@@ -0,0 +1,66 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+type ActivationConfig struct {
+	CodeLength            int
+	CodeAlphabet          string
+	ExpiryMinutes         int
+	MaxAttempts           int
+	ResendCooldownSeconds int
+}
+
+func InitActivationConfig() *ActivationConfig {
+	codeLength := 6
+	if env := os.Getenv("ACTIVATION_CODE_LENGTH"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= 4 && parsed <= 64 {
+			codeLength = parsed
+		}
+	}
+
+	// The alphabet needs at least two distinct characters to carry any entropy
+	codeAlphabet := "0123456789"
+	if env := os.Getenv("ACTIVATION_CODE_ALPHABET"); env != "" && countDistinct(env) >= 2 {
+		codeAlphabet = env
+	}
+
+	expiryMinutes := 15
+	if env := os.Getenv("ACTIVATION_EXPIRY_MINUTES"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			expiryMinutes = parsed
+		}
+	}
+
+	maxAttempts := 5
+	if env := os.Getenv("ACTIVATION_MAX_ATTEMPTS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			maxAttempts = parsed
+		}
+	}
+
+	resendCooldownSeconds := 60
+	if env := os.Getenv("ACTIVATION_RESEND_COOLDOWN_SECONDS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= 0 {
+			resendCooldownSeconds = parsed
+		}
+	}
+
+	return &ActivationConfig{
+		CodeLength:            codeLength,
+		CodeAlphabet:          codeAlphabet,
+		ExpiryMinutes:         expiryMinutes,
+		MaxAttempts:           maxAttempts,
+		ResendCooldownSeconds: resendCooldownSeconds,
+	}
+}
+
+func countDistinct(s string) int {
+	seen := map[rune]bool{}
+	for _, r := range s {
+		seen[r] = true
+	}
+	return len(seen)
+}
@@ -0,0 +1,81 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/config"
+)
+
+func TestInitActivationConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected *config.ActivationConfig
+	}{
+		{
+			name:    "with default values",
+			envVars: map[string]string{},
+			expected: &config.ActivationConfig{
+				CodeLength:            6,
+				CodeAlphabet:          "0123456789",
+				ExpiryMinutes:         15,
+				MaxAttempts:           5,
+				ResendCooldownSeconds: 60,
+			},
+		},
+		{
+			name: "with custom values",
+			envVars: map[string]string{
+				"ACTIVATION_CODE_LENGTH":             "8",
+				"ACTIVATION_CODE_ALPHABET":           "ABCDEFGHJKLMNPQRSTUVWXYZ23456789",
+				"ACTIVATION_EXPIRY_MINUTES":          "10",
+				"ACTIVATION_MAX_ATTEMPTS":            "3",
+				"ACTIVATION_RESEND_COOLDOWN_SECONDS": "0",
+			},
+			expected: &config.ActivationConfig{
+				CodeLength:            8,
+				CodeAlphabet:          "ABCDEFGHJKLMNPQRSTUVWXYZ23456789",
+				ExpiryMinutes:         10,
+				MaxAttempts:           3,
+				ResendCooldownSeconds: 0,
+			},
+		},
+		{
+			name: "with invalid values",
+			envVars: map[string]string{
+				"ACTIVATION_CODE_LENGTH":             "2",
+				"ACTIVATION_CODE_ALPHABET":           "aaaa",
+				"ACTIVATION_EXPIRY_MINUTES":          "0",
+				"ACTIVATION_MAX_ATTEMPTS":            "invalid",
+				"ACTIVATION_RESEND_COOLDOWN_SECONDS": "-1",
+			},
+			expected: &config.ActivationConfig{
+				CodeLength:            6,
+				CodeAlphabet:          "0123456789",
+				ExpiryMinutes:         15,
+				MaxAttempts:           5,
+				ResendCooldownSeconds: 60,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear environment before each test
+			os.Clearenv()
+
+			// Set environment variables for test
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			// Run test
+			config := config.InitActivationConfig()
+
+			// Assert results
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+type APIKeyConfig struct {
+	// Prefix starts every generated key, e.g. rp_3f9a1c2b7d4e5f60_<secret>
+	Prefix string
+	// MaxExpiryDays caps the lifetime of a key and is used when none is requested,
+	// 0 allows keys that never expire
+	MaxExpiryDays int
+}
+
+func InitAPIKeyConfig() *APIKeyConfig {
+	prefix := "rp"
+	if env := strings.TrimSpace(os.Getenv("API_KEY_PREFIX")); env != "" {
+		prefix = env
+	}
+
+	maxExpiryDays := 365
+	if env := os.Getenv("API_KEY_MAX_EXPIRY_DAYS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= 0 {
+			maxExpiryDays = parsed
+		}
+	}
+
+	return &APIKeyConfig{
+		Prefix:        prefix,
+		MaxExpiryDays: maxExpiryDays,
+	}
+}
@@ -0,0 +1,66 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/config"
+)
+
+func TestInitAPIKeyConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected *config.APIKeyConfig
+	}{
+		{
+			name:    "with default values",
+			envVars: map[string]string{},
+			expected: &config.APIKeyConfig{
+				Prefix:        "rp",
+				MaxExpiryDays: 365,
+			},
+		},
+		{
+			name: "with custom values",
+			envVars: map[string]string{
+				"API_KEY_PREFIX":          "retail",
+				"API_KEY_MAX_EXPIRY_DAYS": "0",
+			},
+			expected: &config.APIKeyConfig{
+				Prefix:        "retail",
+				MaxExpiryDays: 0,
+			},
+		},
+		{
+			name: "with invalid values",
+			envVars: map[string]string{
+				"API_KEY_PREFIX":          "  ",
+				"API_KEY_MAX_EXPIRY_DAYS": "-1",
+			},
+			expected: &config.APIKeyConfig{
+				Prefix:        "rp",
+				MaxExpiryDays: 365,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear environment before each test
+			os.Clearenv()
+
+			// Set environment variables for test
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			// Run test
+			config := config.InitAPIKeyConfig()
+
+			// Assert results
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"strings"
 )
 
 // AppConfig holds application configuration
@@ -10,6 +11,9 @@ type AppConfig struct {
 	Port            string
 	PublicRoute     string
 	PublicAssetsDir string
+	// TrustedProxies are the proxy IPs or CIDRs whose X-Forwarded-For is believed when
+	// resolving the client IP. Nil trusts none, so the client IP is the peer address.
+	TrustedProxies []string
 }
 
 // InitAppConfig initializes and returns a new AppConfig
@@ -22,9 +26,17 @@ func InitAppConfig() *AppConfig {
 		port = env_APP_PORT
 	}
 
+	var trustedProxies []string
+	for _, proxy := range GetEnvAsSlice("TRUSTED_PROXIES", nil) {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trustedProxies = append(trustedProxies, proxy)
+		}
+	}
+
 	return &AppConfig{
 		Port:            port,
 		PublicRoute:     "/public",
 		PublicAssetsDir: "./public",
+		TrustedProxies:  trustedProxies,
 	}
 }
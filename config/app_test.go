@@ -34,6 +34,18 @@ func TestInitAppConfig(t *testing.T) {
 				PublicAssetsDir: "./public",
 			},
 		},
+		{
+			name: "with trusted proxies",
+			envVars: map[string]string{
+				"TRUSTED_PROXIES": "10.0.0.0/8, 192.168.1.10,,",
+			},
+			expected: &config.AppConfig{
+				Port:            "3000",
+				PublicRoute:     "/public",
+				PublicAssetsDir: "./public",
+				TrustedProxies:  []string{"10.0.0.0/8", "192.168.1.10"},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -53,6 +65,7 @@ func TestInitAppConfig(t *testing.T) {
 			assert.Equal(t, tt.expected.Port, config.Port)
 			assert.Equal(t, tt.expected.PublicRoute, config.PublicRoute)
 			assert.Equal(t, tt.expected.PublicAssetsDir, config.PublicAssetsDir)
+			assert.Equal(t, tt.expected.TrustedProxies, config.TrustedProxies)
 		})
 	}
 }
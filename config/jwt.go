@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/yantology/golang-starter-template/pkg/customerror"
@@ -16,11 +17,32 @@ type JWTConfig struct {
 	AccessDuration  time.Duration
 	RefreshDuration time.Duration
 	Issuer          string
+	// SigningKeyFile is a PEM private key (RSA, ECDSA or Ed25519) signing access
+	// tokens instead of AccessSecret
+	SigningKeyFile string
+	// PreviousKeys stay verifiable after a rotation until their RetireAt
+	PreviousKeys []JWTPreviousKey
+	// Audience is set as aud on new tokens and required on validation, empty skips it
+	Audience string
+	// Leeway tolerates clock skew between servers when checking token times
+	Leeway time.Duration
+	// DenylistStore keeps revoked token IDs, "postgres" or "memory"
+	DenylistStore string
+}
+
+// JWTPreviousKey is a key that signed access tokens before the current signing key
+type JWTPreviousKey struct {
+	File string
+	// RetireAt is when tokens signed with the key stop being accepted, zero never
+	RetireAt time.Time
 }
 
 func InitJWTConfig() (*JWTConfig, *customerror.CustomError) {
+	signingKeyFile := os.Getenv("JWT_SIGNING_KEY_FILE")
+
+	// The access secret is only needed while access tokens are HMAC signed
 	accessSecret := os.Getenv("JWT_ACCESS_SECRET")
-	if accessSecret == "" {
+	if accessSecret == "" && signingKeyFile == "" {
 		log.Println("JWT access secret is not set")
 		return nil, customerror.NewCustomError(nil, "JWT access secret is not set", http.StatusUnauthorized)
 	}
@@ -53,11 +75,47 @@ func InitJWTConfig() (*JWTConfig, *customerror.CustomError) {
 		issuer = "retail-pro"
 	}
 
+	// Entries are a path, optionally followed by @ and the RFC 3339 retire time
+	previousKeys := []JWTPreviousKey{}
+	for _, entry := range strings.Split(os.Getenv("JWT_PREVIOUS_KEY_FILES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		previousKey := JWTPreviousKey{File: entry}
+		if i := strings.LastIndex(entry, "@"); i > 0 {
+			retireAt, err := time.Parse(time.RFC3339, entry[i+1:])
+			if err != nil {
+				log.Println("JWT previous key retire time is invalid:", entry)
+				return nil, customerror.NewCustomError(err, "JWT previous key retire time is invalid", http.StatusInternalServerError)
+			}
+			previousKey = JWTPreviousKey{File: entry[:i], RetireAt: retireAt}
+		}
+		previousKeys = append(previousKeys, previousKey)
+	}
+
+	leewaySeconds := 0
+	if env := os.Getenv("JWT_LEEWAY_SECONDS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= 0 {
+			leewaySeconds = parsed
+		}
+	}
+
+	denylistStore := "postgres"
+	if env := os.Getenv("JWT_DENYLIST_STORE"); env == "memory" {
+		denylistStore = env
+	}
+
 	return &JWTConfig{
 		AccessSecret:    accessSecret,
 		RefreshSecret:   refreshSecret,
 		AccessDuration:  time.Duration(accessDurationMinutes) * time.Minute,
 		RefreshDuration: time.Duration(refreshDurationDays) * 24 * time.Hour,
 		Issuer:          issuer,
+		SigningKeyFile:  signingKeyFile,
+		PreviousKeys:    previousKeys,
+		Audience:        os.Getenv("JWT_AUDIENCE"),
+		Leeway:          time.Duration(leewaySeconds) * time.Second,
+		DenylistStore:   denylistStore,
 	}, nil
 }
@@ -62,6 +62,7 @@ func TestInitJWTConfig(t *testing.T) {
 				AccessDuration:  15 * time.Minute,   // Default access duration
 				RefreshDuration: 7 * 24 * time.Hour, // Default refresh duration
 				Issuer:          "retail-pro",       // Default issuer
+				DenylistStore:   "postgres",         // Default denylist store
 			},
 			shouldError: false,
 		},
@@ -82,6 +83,57 @@ func TestInitJWTConfig(t *testing.T) {
 			},
 			shouldError: false,
 		},
+		{
+			name: "asymmetric signing key without access secret",
+			envVars: map[string]string{
+				"JWT_REFRESH_SECRET":     "test-refresh-secret",
+				"JWT_SIGNING_KEY_FILE":   "/keys/current.pem",
+				"JWT_PREVIOUS_KEY_FILES": "/keys/old.pem@2026-11-01T00:00:00Z, /keys/older.pem",
+			},
+			expectedConfig: &config.JWTConfig{
+				RefreshSecret:   "test-refresh-secret",
+				AccessDuration:  15 * time.Minute,
+				RefreshDuration: 7 * 24 * time.Hour,
+				Issuer:          "retail-pro",
+				SigningKeyFile:  "/keys/current.pem",
+				PreviousKeys: []config.JWTPreviousKey{
+					{File: "/keys/old.pem", RetireAt: time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+					{File: "/keys/older.pem"},
+				},
+			},
+			shouldError: false,
+		},
+		{
+			name: "validation and denylist settings",
+			envVars: map[string]string{
+				"JWT_ACCESS_SECRET":  "test-access-secret",
+				"JWT_REFRESH_SECRET": "test-refresh-secret",
+				"JWT_AUDIENCE":       "retail-pro-api",
+				"JWT_LEEWAY_SECONDS": "30",
+				"JWT_DENYLIST_STORE": "memory",
+			},
+			expectedConfig: &config.JWTConfig{
+				AccessSecret:    "test-access-secret",
+				RefreshSecret:   "test-refresh-secret",
+				AccessDuration:  15 * time.Minute,
+				RefreshDuration: 7 * 24 * time.Hour,
+				Issuer:          "retail-pro",
+				Audience:        "retail-pro-api",
+				Leeway:          30 * time.Second,
+				DenylistStore:   "memory",
+			},
+			shouldError: false,
+		},
+		{
+			name: "invalid previous key retire time",
+			envVars: map[string]string{
+				"JWT_REFRESH_SECRET":     "test-refresh-secret",
+				"JWT_SIGNING_KEY_FILE":   "/keys/current.pem",
+				"JWT_PREVIOUS_KEY_FILES": "/keys/old.pem@next-week",
+			},
+			expectedConfig: nil,
+			shouldError:    true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -108,6 +160,15 @@ func TestInitJWTConfig(t *testing.T) {
 				assert.Equal(t, tt.expectedConfig.AccessDuration, config.AccessDuration)
 				assert.Equal(t, tt.expectedConfig.RefreshDuration, config.RefreshDuration)
 				assert.Equal(t, tt.expectedConfig.Issuer, config.Issuer)
+				assert.Equal(t, tt.expectedConfig.SigningKeyFile, config.SigningKeyFile)
+				assert.Equal(t, tt.expectedConfig.Audience, config.Audience)
+				assert.Equal(t, tt.expectedConfig.Leeway, config.Leeway)
+				if tt.expectedConfig.DenylistStore != "" {
+					assert.Equal(t, tt.expectedConfig.DenylistStore, config.DenylistStore)
+				}
+				if tt.expectedConfig.PreviousKeys != nil {
+					assert.Equal(t, tt.expectedConfig.PreviousKeys, config.PreviousKeys)
+				}
 			}
 		})
 	}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+type LockoutConfig struct {
+	// Store is where attempts are tracked, "postgres" (shared by replicas) or "memory"
+	Store          string
+	EmailThreshold int
+	IPThreshold    int
+	// MFAThreshold is the number of wrong second factor codes that lock a user
+	MFAThreshold     int
+	BaseDelaySeconds int
+	MaxDelaySeconds  int
+	DurationMinutes  int
+	WindowMinutes    int
+	NotifyEmail      bool
+}
+
+func InitLockoutConfig() *LockoutConfig {
+	store := strings.ToLower(os.Getenv("LOGIN_LOCKOUT_STORE"))
+	if store != "memory" {
+		store = "postgres"
+	}
+
+	emailThreshold := 5
+	if env := os.Getenv("LOGIN_LOCKOUT_EMAIL_THRESHOLD"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			emailThreshold = parsed
+		}
+	}
+
+	// Several users may share one IP, e.g. a store network, so the IP limit is higher
+	ipThreshold := 20
+	if env := os.Getenv("LOGIN_LOCKOUT_IP_THRESHOLD"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			ipThreshold = parsed
+		}
+	}
+
+	mfaThreshold := 5
+	if env := os.Getenv("LOGIN_LOCKOUT_MFA_THRESHOLD"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			mfaThreshold = parsed
+		}
+	}
+
+	baseDelaySeconds := 1
+	if env := os.Getenv("LOGIN_LOCKOUT_BASE_DELAY_SECONDS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= 0 {
+			baseDelaySeconds = parsed
+		}
+	}
+
+	maxDelaySeconds := 30
+	if env := os.Getenv("LOGIN_LOCKOUT_MAX_DELAY_SECONDS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= baseDelaySeconds {
+			maxDelaySeconds = parsed
+		}
+	}
+
+	durationMinutes := 15
+	if env := os.Getenv("LOGIN_LOCKOUT_DURATION_MINUTES"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			durationMinutes = parsed
+		}
+	}
+
+	windowMinutes := 15
+	if env := os.Getenv("LOGIN_LOCKOUT_WINDOW_MINUTES"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			windowMinutes = parsed
+		}
+	}
+
+	notifyEmail := false
+	if env := os.Getenv("LOGIN_LOCKOUT_NOTIFY_EMAIL"); env != "" {
+		if parsed, err := strconv.ParseBool(env); err == nil {
+			notifyEmail = parsed
+		}
+	}
+
+	return &LockoutConfig{
+		Store:            store,
+		EmailThreshold:   emailThreshold,
+		IPThreshold:      ipThreshold,
+		MFAThreshold:     mfaThreshold,
+		BaseDelaySeconds: baseDelaySeconds,
+		MaxDelaySeconds:  maxDelaySeconds,
+		DurationMinutes:  durationMinutes,
+		WindowMinutes:    windowMinutes,
+		NotifyEmail:      notifyEmail,
+	}
+}
@@ -0,0 +1,101 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/config"
+)
+
+func TestInitLockoutConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected *config.LockoutConfig
+	}{
+		{
+			name:    "with default values",
+			envVars: map[string]string{},
+			expected: &config.LockoutConfig{
+				Store:            "postgres",
+				EmailThreshold:   5,
+				IPThreshold:      20,
+				MFAThreshold:     5,
+				BaseDelaySeconds: 1,
+				MaxDelaySeconds:  30,
+				DurationMinutes:  15,
+				WindowMinutes:    15,
+				NotifyEmail:      false,
+			},
+		},
+		{
+			name: "with custom values",
+			envVars: map[string]string{
+				"LOGIN_LOCKOUT_STORE":              "memory",
+				"LOGIN_LOCKOUT_EMAIL_THRESHOLD":    "3",
+				"LOGIN_LOCKOUT_IP_THRESHOLD":       "50",
+				"LOGIN_LOCKOUT_MFA_THRESHOLD":      "10",
+				"LOGIN_LOCKOUT_BASE_DELAY_SECONDS": "2",
+				"LOGIN_LOCKOUT_MAX_DELAY_SECONDS":  "60",
+				"LOGIN_LOCKOUT_DURATION_MINUTES":   "30",
+				"LOGIN_LOCKOUT_WINDOW_MINUTES":     "60",
+				"LOGIN_LOCKOUT_NOTIFY_EMAIL":       "true",
+			},
+			expected: &config.LockoutConfig{
+				Store:            "memory",
+				EmailThreshold:   3,
+				IPThreshold:      50,
+				MFAThreshold:     10,
+				BaseDelaySeconds: 2,
+				MaxDelaySeconds:  60,
+				DurationMinutes:  30,
+				WindowMinutes:    60,
+				NotifyEmail:      true,
+			},
+		},
+		{
+			name: "with invalid values",
+			envVars: map[string]string{
+				"LOGIN_LOCKOUT_STORE":              "redis",
+				"LOGIN_LOCKOUT_EMAIL_THRESHOLD":    "0",
+				"LOGIN_LOCKOUT_IP_THRESHOLD":       "invalid",
+				"LOGIN_LOCKOUT_MFA_THRESHOLD":      "-2",
+				"LOGIN_LOCKOUT_BASE_DELAY_SECONDS": "-1",
+				"LOGIN_LOCKOUT_MAX_DELAY_SECONDS":  "0",
+				"LOGIN_LOCKOUT_DURATION_MINUTES":   "0",
+				"LOGIN_LOCKOUT_WINDOW_MINUTES":     "-5",
+				"LOGIN_LOCKOUT_NOTIFY_EMAIL":       "maybe",
+			},
+			expected: &config.LockoutConfig{
+				Store:            "postgres",
+				EmailThreshold:   5,
+				IPThreshold:      20,
+				MFAThreshold:     5,
+				BaseDelaySeconds: 1,
+				MaxDelaySeconds:  30,
+				DurationMinutes:  15,
+				WindowMinutes:    15,
+				NotifyEmail:      false,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear environment before each test
+			os.Clearenv()
+
+			// Set environment variables for test
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			// Run test
+			config := config.InitLockoutConfig()
+
+			// Assert results
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package config
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+)
+
+type MailConfig struct {
+	// Driver delivers emails: "resend", "smtp", "file" (.eml files in OutboxDir)
+	// or "log" (printed to stdout)
+	Driver string
+
+	// FromName and FromAddress are the sender of every driver but resend, which sends
+	// from its verified domain
+	FromName    string
+	FromAddress string
+
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	// SMTPStartTLS refuses servers that cannot encrypt the connection, turn it off
+	// only for local stand-ins such as MailHog
+	SMTPStartTLS bool
+
+	OutboxDir string
+
+	// TemplateDir holds email templates replacing the embedded ones with the same path
+	TemplateDir string
+	// DefaultLocale is the language of emails when neither the user nor the request picks one
+	DefaultLocale string
+	// PreviewEnabled serves the developer routes rendering and test-sending templates,
+	// never turn it on in production
+	PreviewEnabled bool
+}
+
+func InitMailConfig() (*MailConfig, *customerror.CustomError) {
+	// A Resend API key picks the resend driver. Printing emails has to be asked for with
+	// MAIL_DRIVER=log, so a deployment missing its key fails instead of dropping emails.
+	driver := strings.ToLower(strings.TrimSpace(os.Getenv("MAIL_DRIVER")))
+	if driver == "" {
+		if os.Getenv("RESEND_API_KEY") == "" {
+			log.Println("Mail driver is not set")
+			return nil, customerror.NewCustomError(nil, "Mail driver is not set", http.StatusUnauthorized)
+		}
+		driver = "resend"
+	}
+
+	fromName := "Retail Pro"
+	if env := strings.TrimSpace(os.Getenv("MAIL_FROM_NAME")); env != "" {
+		fromName = env
+	}
+
+	smtpPort := 587
+	if env := os.Getenv("SMTP_PORT"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 && parsed <= 65535 {
+			smtpPort = parsed
+		}
+	}
+
+	smtpStartTLS := true
+	if env := os.Getenv("SMTP_STARTTLS"); env != "" {
+		if parsed, err := strconv.ParseBool(env); err == nil {
+			smtpStartTLS = parsed
+		}
+	}
+
+	outboxDir := "tmp/mail"
+	if env := strings.TrimSpace(os.Getenv("MAIL_OUTBOX_DIR")); env != "" {
+		outboxDir = env
+	}
+
+	defaultLocale := "en"
+	if env := strings.TrimSpace(os.Getenv("MAIL_DEFAULT_LOCALE")); env != "" {
+		defaultLocale = strings.ToLower(env)
+	}
+
+	previewEnabled := false
+	if env := os.Getenv("MAIL_PREVIEW_ENABLED"); env != "" {
+		if parsed, err := strconv.ParseBool(env); err == nil {
+			previewEnabled = parsed
+		}
+	}
+
+	mailConfig := &MailConfig{
+		Driver:       driver,
+		FromName:     fromName,
+		FromAddress:  strings.TrimSpace(os.Getenv("MAIL_FROM_ADDRESS")),
+		SMTPHost:     strings.TrimSpace(os.Getenv("SMTP_HOST")),
+		SMTPPort:     smtpPort,
+		SMTPUsername: os.Getenv("SMTP_USERNAME"),
+		SMTPPassword: os.Getenv("SMTP_PASSWORD"),
+		SMTPStartTLS: smtpStartTLS,
+		OutboxDir:    outboxDir,
+
+		TemplateDir:    strings.TrimSpace(os.Getenv("MAIL_TEMPLATE_DIR")),
+		DefaultLocale:  defaultLocale,
+		PreviewEnabled: previewEnabled,
+	}
+
+	switch driver {
+	case "resend":
+		if os.Getenv("RESEND_API_KEY") == "" {
+			log.Println("Resend API key is not set")
+			return nil, customerror.NewCustomError(nil, "Resend API key is not set", http.StatusUnauthorized)
+		}
+	case "smtp":
+		if mailConfig.SMTPHost == "" {
+			log.Println("SMTP host is not set")
+			return nil, customerror.NewCustomError(nil, "SMTP host is not set", http.StatusUnauthorized)
+		}
+		if mailConfig.FromAddress == "" {
+			log.Println("Mail sender address is not set")
+			return nil, customerror.NewCustomError(nil, "Mail sender address is not set", http.StatusUnauthorized)
+		}
+	case "file", "log":
+		// Nothing leaves the machine, so a placeholder sender is enough
+		if mailConfig.FromAddress == "" {
+			mailConfig.FromAddress = "activation@localhost"
+		}
+	default:
+		log.Println("Unknown mail driver:", driver)
+		return nil, customerror.NewCustomError(nil, "Unknown mail driver", http.StatusUnauthorized)
+	}
+
+	return mailConfig, nil
+}
@@ -0,0 +1,190 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/config"
+)
+
+func TestInitMailConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		envVars     map[string]string
+		expected    *config.MailConfig
+		shouldError bool
+	}{
+		{
+			name: "with default values",
+			envVars: map[string]string{
+				"MAIL_DRIVER": "log",
+			},
+			expected: &config.MailConfig{
+				Driver:        "log",
+				FromName:      "Retail Pro",
+				FromAddress:   "activation@localhost",
+				SMTPPort:      587,
+				SMTPStartTLS:  true,
+				OutboxDir:     "tmp/mail",
+				DefaultLocale: "en",
+			},
+		},
+		{
+			name: "resend by default with API key",
+			envVars: map[string]string{
+				"RESEND_API_KEY": "test-api-key",
+			},
+			expected: &config.MailConfig{
+				Driver:        "resend",
+				FromName:      "Retail Pro",
+				SMTPPort:      587,
+				SMTPStartTLS:  true,
+				OutboxDir:     "tmp/mail",
+				DefaultLocale: "en",
+			},
+		},
+		{
+			name: "with smtp values",
+			envVars: map[string]string{
+				"MAIL_DRIVER":       "SMTP",
+				"SMTP_HOST":         "localhost",
+				"SMTP_PORT":         "1025",
+				"SMTP_USERNAME":     "mailer",
+				"SMTP_PASSWORD":     "secret",
+				"SMTP_STARTTLS":     "false",
+				"MAIL_FROM_NAME":    "Toko Maju",
+				"MAIL_FROM_ADDRESS": "no-reply@tokomaju.id",
+			},
+			expected: &config.MailConfig{
+				Driver:        "smtp",
+				FromName:      "Toko Maju",
+				FromAddress:   "no-reply@tokomaju.id",
+				SMTPHost:      "localhost",
+				SMTPPort:      1025,
+				SMTPUsername:  "mailer",
+				SMTPPassword:  "secret",
+				SMTPStartTLS:  false,
+				OutboxDir:     "tmp/mail",
+				DefaultLocale: "en",
+			},
+		},
+		{
+			name: "with file values",
+			envVars: map[string]string{
+				"MAIL_DRIVER":     "file",
+				"MAIL_OUTBOX_DIR": "/var/mail/outbox",
+			},
+			expected: &config.MailConfig{
+				Driver:        "file",
+				FromName:      "Retail Pro",
+				FromAddress:   "activation@localhost",
+				SMTPPort:      587,
+				SMTPStartTLS:  true,
+				OutboxDir:     "/var/mail/outbox",
+				DefaultLocale: "en",
+			},
+		},
+		{
+			name: "with template values",
+			envVars: map[string]string{
+				"MAIL_DRIVER":          "log",
+				"MAIL_TEMPLATE_DIR":    "/etc/retail-pro/mail",
+				"MAIL_DEFAULT_LOCALE":  "ID",
+				"MAIL_PREVIEW_ENABLED": "true",
+			},
+			expected: &config.MailConfig{
+				Driver:         "log",
+				FromName:       "Retail Pro",
+				FromAddress:    "activation@localhost",
+				SMTPPort:       587,
+				SMTPStartTLS:   true,
+				OutboxDir:      "tmp/mail",
+				TemplateDir:    "/etc/retail-pro/mail",
+				DefaultLocale:  "id",
+				PreviewEnabled: true,
+			},
+		},
+		{
+			name: "with invalid values",
+			envVars: map[string]string{
+				"MAIL_DRIVER":          "log",
+				"SMTP_PORT":            "70000",
+				"SMTP_STARTTLS":        "sometimes",
+				"MAIL_PREVIEW_ENABLED": "sometimes",
+			},
+			expected: &config.MailConfig{
+				Driver:        "log",
+				FromName:      "Retail Pro",
+				FromAddress:   "activation@localhost",
+				SMTPPort:      587,
+				SMTPStartTLS:  true,
+				OutboxDir:     "tmp/mail",
+				DefaultLocale: "en",
+			},
+		},
+		{
+			name:        "without driver or API key",
+			envVars:     map[string]string{},
+			expected:    nil,
+			shouldError: true,
+		},
+		{
+			name: "resend without API key",
+			envVars: map[string]string{
+				"MAIL_DRIVER": "resend",
+			},
+			expected:    nil,
+			shouldError: true,
+		},
+		{
+			name: "smtp without host",
+			envVars: map[string]string{
+				"MAIL_DRIVER": "smtp",
+			},
+			expected:    nil,
+			shouldError: true,
+		},
+		{
+			name: "smtp without sender address",
+			envVars: map[string]string{
+				"MAIL_DRIVER": "smtp",
+				"SMTP_HOST":   "localhost",
+			},
+			expected:    nil,
+			shouldError: true,
+		},
+		{
+			name: "unknown driver",
+			envVars: map[string]string{
+				"MAIL_DRIVER": "sendmail",
+			},
+			expected:    nil,
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear environment before each test
+			os.Clearenv()
+
+			// Set environment variables for test
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			// Run test
+			config, err := config.InitMailConfig()
+
+			// Assert results
+			if tt.shouldError {
+				assert.NotNil(t, err, "Expected an error but got none")
+				assert.Nil(t, config, "Expected nil config when error occurs")
+			} else {
+				assert.Nil(t, err, "Unexpected error")
+				assert.Equal(t, tt.expected, config)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+type MFAConfig struct {
+	TOTPIssuer             string
+	TOTPSkew               int
+	ChallengeExpiryMinutes int
+	MaxAttempts            int
+	RecoveryCodeCount      int
+}
+
+func InitMFAConfig() *MFAConfig {
+	issuer := os.Getenv("MFA_TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "Retail Pro"
+	}
+
+	// Number of 30 second steps accepted before and after the current one
+	skew := 1
+	if env := os.Getenv("MFA_TOTP_SKEW"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= 0 {
+			skew = parsed
+		}
+	}
+
+	challengeExpiryMinutes := 5
+	if env := os.Getenv("MFA_CHALLENGE_EXPIRY_MINUTES"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			challengeExpiryMinutes = parsed
+		}
+	}
+
+	maxAttempts := 5
+	if env := os.Getenv("MFA_MAX_ATTEMPTS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			maxAttempts = parsed
+		}
+	}
+
+	recoveryCodeCount := 10
+	if env := os.Getenv("MFA_RECOVERY_CODE_COUNT"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			recoveryCodeCount = parsed
+		}
+	}
+
+	return &MFAConfig{
+		TOTPIssuer:             issuer,
+		TOTPSkew:               skew,
+		ChallengeExpiryMinutes: challengeExpiryMinutes,
+		MaxAttempts:            maxAttempts,
+		RecoveryCodeCount:      recoveryCodeCount,
+	}
+}
@@ -0,0 +1,80 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/config"
+)
+
+func TestInitMFAConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected *config.MFAConfig
+	}{
+		{
+			name:    "with default values",
+			envVars: map[string]string{},
+			expected: &config.MFAConfig{
+				TOTPIssuer:             "Retail Pro",
+				TOTPSkew:               1,
+				ChallengeExpiryMinutes: 5,
+				MaxAttempts:            5,
+				RecoveryCodeCount:      10,
+			},
+		},
+		{
+			name: "with custom values",
+			envVars: map[string]string{
+				"MFA_TOTP_ISSUER":              "Test Issuer",
+				"MFA_TOTP_SKEW":                "0",
+				"MFA_CHALLENGE_EXPIRY_MINUTES": "10",
+				"MFA_MAX_ATTEMPTS":             "3",
+				"MFA_RECOVERY_CODE_COUNT":      "8",
+			},
+			expected: &config.MFAConfig{
+				TOTPIssuer:             "Test Issuer",
+				TOTPSkew:               0,
+				ChallengeExpiryMinutes: 10,
+				MaxAttempts:            3,
+				RecoveryCodeCount:      8,
+			},
+		},
+		{
+			name: "with invalid values",
+			envVars: map[string]string{
+				"MFA_TOTP_SKEW":                "-1",
+				"MFA_CHALLENGE_EXPIRY_MINUTES": "invalid",
+				"MFA_MAX_ATTEMPTS":             "0",
+				"MFA_RECOVERY_CODE_COUNT":      "invalid",
+			},
+			expected: &config.MFAConfig{
+				TOTPIssuer:             "Retail Pro",
+				TOTPSkew:               1,
+				ChallengeExpiryMinutes: 5,
+				MaxAttempts:            5,
+				RecoveryCodeCount:      10,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear environment before each test
+			os.Clearenv()
+
+			// Set environment variables for test
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			// Run test
+			config := config.InitMFAConfig()
+
+			// Assert results
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type OIDCProviderConfig struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+type OIDCConfig struct {
+	Providers          []OIDCProviderConfig
+	StateExpiryMinutes int
+	// FlowSecret signs the cookie carrying state, nonce and PKCE verifier to the callback
+	FlowSecret string
+}
+
+// InitOIDCConfig reads the providers listed in OIDC_PROVIDERS. Each provider is configured
+// with OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET,
+// OIDC_<NAME>_REDIRECT_URL and optionally OIDC_<NAME>_SCOPES. Providers are only enabled
+// when OIDC_FLOW_SECRET is set.
+func InitOIDCConfig() *OIDCConfig {
+	providers := []OIDCProviderConfig{}
+	for _, name := range GetEnvAsSlice("OIDC_PROVIDERS", []string{}) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := OIDCProviderConfig{
+			Name:         name,
+			IssuerURL:    os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       GetEnvAsSlice(prefix+"SCOPES", []string{"openid", "email", "profile"}),
+		}
+
+		if provider.IssuerURL == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			log.Printf("OIDC provider %s is missing issuer, client ID or redirect URL, skipping", name)
+			continue
+		}
+		providers = append(providers, provider)
+	}
+
+	flowSecret := os.Getenv("OIDC_FLOW_SECRET")
+	if len(providers) > 0 && flowSecret == "" {
+		log.Println("OIDC_FLOW_SECRET is not set, OIDC providers are disabled")
+		providers = []OIDCProviderConfig{}
+	}
+
+	stateExpiryMinutes := 10
+	if env := os.Getenv("OIDC_STATE_EXPIRY_MINUTES"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			stateExpiryMinutes = parsed
+		}
+	}
+
+	return &OIDCConfig{
+		Providers:          providers,
+		StateExpiryMinutes: stateExpiryMinutes,
+		FlowSecret:         flowSecret,
+	}
+}
@@ -0,0 +1,106 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/config"
+)
+
+func TestInitOIDCConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected *config.OIDCConfig
+	}{
+		{
+			name:    "without providers",
+			envVars: map[string]string{},
+			expected: &config.OIDCConfig{
+				Providers:          []config.OIDCProviderConfig{},
+				StateExpiryMinutes: 10,
+			},
+		},
+		{
+			name: "with configured providers",
+			envVars: map[string]string{
+				"OIDC_PROVIDERS":                "google, my-keycloak",
+				"OIDC_GOOGLE_ISSUER":            "https://accounts.google.com",
+				"OIDC_GOOGLE_CLIENT_ID":         "google-client",
+				"OIDC_GOOGLE_CLIENT_SECRET":     "google-secret",
+				"OIDC_GOOGLE_REDIRECT_URL":      "http://localhost:3000/auth/callback/google",
+				"OIDC_MY_KEYCLOAK_ISSUER":       "https://sso.example.com/realms/retail",
+				"OIDC_MY_KEYCLOAK_CLIENT_ID":    "retail-pro",
+				"OIDC_MY_KEYCLOAK_REDIRECT_URL": "http://localhost:3000/auth/callback/my-keycloak",
+				"OIDC_MY_KEYCLOAK_SCOPES":       "openid,email",
+				"OIDC_STATE_EXPIRY_MINUTES":     "5",
+				"OIDC_FLOW_SECRET":              "flow-secret",
+			},
+			expected: &config.OIDCConfig{
+				Providers: []config.OIDCProviderConfig{
+					{
+						Name:         "google",
+						IssuerURL:    "https://accounts.google.com",
+						ClientID:     "google-client",
+						ClientSecret: "google-secret",
+						RedirectURL:  "http://localhost:3000/auth/callback/google",
+						Scopes:       []string{"openid", "email", "profile"},
+					},
+					{
+						Name:        "my-keycloak",
+						IssuerURL:   "https://sso.example.com/realms/retail",
+						ClientID:    "retail-pro",
+						RedirectURL: "http://localhost:3000/auth/callback/my-keycloak",
+						Scopes:      []string{"openid", "email"},
+					},
+				},
+				StateExpiryMinutes: 5,
+				FlowSecret:         "flow-secret",
+			},
+		},
+		{
+			name: "without flow secret",
+			envVars: map[string]string{
+				"OIDC_PROVIDERS":           "google",
+				"OIDC_GOOGLE_ISSUER":       "https://accounts.google.com",
+				"OIDC_GOOGLE_CLIENT_ID":    "google-client",
+				"OIDC_GOOGLE_REDIRECT_URL": "http://localhost:3000/auth/callback/google",
+			},
+			expected: &config.OIDCConfig{
+				Providers:          []config.OIDCProviderConfig{},
+				StateExpiryMinutes: 10,
+			},
+		},
+		{
+			name: "with incomplete provider and invalid expiry",
+			envVars: map[string]string{
+				"OIDC_PROVIDERS":            "google",
+				"OIDC_GOOGLE_ISSUER":        "https://accounts.google.com",
+				"OIDC_STATE_EXPIRY_MINUTES": "invalid",
+			},
+			expected: &config.OIDCConfig{
+				Providers:          []config.OIDCProviderConfig{},
+				StateExpiryMinutes: 10,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear environment before each test
+			os.Clearenv()
+
+			// Set environment variables for test
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			// Run test
+			config := config.InitOIDCConfig()
+
+			// Assert results
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
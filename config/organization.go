@@ -0,0 +1,41 @@
+package config
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+)
+
+type OrganizationConfig struct {
+	// InvitationExpiryHours is how long an invitation can be accepted
+	InvitationExpiryHours int
+	// InvitationSecret signs invitation tokens
+	InvitationSecret string
+	// InvitationURL is the frontend page receiving the token query parameter, empty
+	// sends the token without a link
+	InvitationURL string
+}
+
+func InitOrganizationConfig() (*OrganizationConfig, *customerror.CustomError) {
+	invitationSecret := os.Getenv("ORGANIZATION_INVITATION_SECRET")
+	if invitationSecret == "" {
+		log.Println("Organization invitation secret is not set")
+		return nil, customerror.NewCustomError(nil, "Organization invitation secret is not set", http.StatusUnauthorized)
+	}
+
+	invitationExpiryHours := 72
+	if env := os.Getenv("ORGANIZATION_INVITATION_EXPIRY_HOURS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			invitationExpiryHours = parsed
+		}
+	}
+
+	return &OrganizationConfig{
+		InvitationExpiryHours: invitationExpiryHours,
+		InvitationSecret:      invitationSecret,
+		InvitationURL:         os.Getenv("ORGANIZATION_INVITATION_URL"),
+	}, nil
+}
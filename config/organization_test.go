@@ -0,0 +1,85 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/config"
+)
+
+func TestInitOrganizationConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		envVars     map[string]string
+		expected    *config.OrganizationConfig
+		shouldError bool
+	}{
+		{
+			name: "with default values",
+			envVars: map[string]string{
+				"ORGANIZATION_INVITATION_SECRET": "test-secret",
+			},
+			expected: &config.OrganizationConfig{
+				InvitationExpiryHours: 72,
+				InvitationSecret:      "test-secret",
+			},
+		},
+		{
+			name: "with custom values",
+			envVars: map[string]string{
+				"ORGANIZATION_INVITATION_SECRET":       "test-secret",
+				"ORGANIZATION_INVITATION_EXPIRY_HOURS": "24",
+				"ORGANIZATION_INVITATION_URL":          "https://app.example.com/invitations",
+			},
+			expected: &config.OrganizationConfig{
+				InvitationExpiryHours: 24,
+				InvitationSecret:      "test-secret",
+				InvitationURL:         "https://app.example.com/invitations",
+			},
+		},
+		{
+			name: "with invalid values",
+			envVars: map[string]string{
+				"ORGANIZATION_INVITATION_SECRET":       "test-secret",
+				"ORGANIZATION_INVITATION_EXPIRY_HOURS": "0",
+			},
+			expected: &config.OrganizationConfig{
+				InvitationExpiryHours: 72,
+				InvitationSecret:      "test-secret",
+			},
+		},
+		{
+			name: "missing invitation secret",
+			envVars: map[string]string{
+				"ORGANIZATION_INVITATION_EXPIRY_HOURS": "24",
+			},
+			expected:    nil,
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear environment before each test
+			os.Clearenv()
+
+			// Set environment variables for test
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			// Run test
+			config, err := config.InitOrganizationConfig()
+
+			// Assert results
+			if tt.shouldError {
+				assert.NotNil(t, err, "Expected an error but got none")
+				assert.Nil(t, config, "Expected nil config when error occurs")
+			} else {
+				assert.Nil(t, err, "Unexpected error")
+				assert.Equal(t, tt.expected, config)
+			}
+		})
+	}
+}
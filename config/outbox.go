@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+type OutboxConfig struct {
+	// PollSeconds is how often the worker looks for due emails
+	PollSeconds int
+	// BaseDelaySeconds is the wait after the first failed delivery, doubled after every further one
+	BaseDelaySeconds int
+	MaxDelaySeconds  int
+	// MaxAttempts dead-letters an email after this many failed deliveries
+	MaxAttempts int
+	// RetryWindowHours is how long a dead email keeps its content for a retry, 0 clears it at the next purge
+	RetryWindowHours int
+	// RetentionDays deletes sent and dead emails this long after they were queued
+	RetentionDays int
+}
+
+func InitOutboxConfig() *OutboxConfig {
+	pollSeconds := 5
+	if env := os.Getenv("EMAIL_OUTBOX_POLL_SECONDS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			pollSeconds = parsed
+		}
+	}
+
+	baseDelaySeconds := 30
+	if env := os.Getenv("EMAIL_OUTBOX_BASE_DELAY_SECONDS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			baseDelaySeconds = parsed
+		}
+	}
+
+	maxDelaySeconds := 3600
+	if env := os.Getenv("EMAIL_OUTBOX_MAX_DELAY_SECONDS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			maxDelaySeconds = parsed
+		}
+	}
+	if maxDelaySeconds < baseDelaySeconds {
+		maxDelaySeconds = baseDelaySeconds
+	}
+
+	maxAttempts := 8
+	if env := os.Getenv("EMAIL_OUTBOX_MAX_ATTEMPTS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			maxAttempts = parsed
+		}
+	}
+
+	retryWindowHours := 24
+	if env := os.Getenv("EMAIL_OUTBOX_RETRY_WINDOW_HOURS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= 0 {
+			retryWindowHours = parsed
+		}
+	}
+
+	retentionDays := 30
+	if env := os.Getenv("EMAIL_OUTBOX_RETENTION_DAYS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			retentionDays = parsed
+		}
+	}
+
+	return &OutboxConfig{
+		PollSeconds:      pollSeconds,
+		BaseDelaySeconds: baseDelaySeconds,
+		MaxDelaySeconds:  maxDelaySeconds,
+		MaxAttempts:      maxAttempts,
+		RetryWindowHours: retryWindowHours,
+		RetentionDays:    retentionDays,
+	}
+}
@@ -0,0 +1,101 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/config"
+)
+
+func TestInitOutboxConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected *config.OutboxConfig
+	}{
+		{
+			name:    "with default values",
+			envVars: map[string]string{},
+			expected: &config.OutboxConfig{
+				PollSeconds:      5,
+				BaseDelaySeconds: 30,
+				MaxDelaySeconds:  3600,
+				MaxAttempts:      8,
+				RetryWindowHours: 24,
+				RetentionDays:    30,
+			},
+		},
+		{
+			name: "with custom values",
+			envVars: map[string]string{
+				"EMAIL_OUTBOX_POLL_SECONDS":       "1",
+				"EMAIL_OUTBOX_BASE_DELAY_SECONDS": "10",
+				"EMAIL_OUTBOX_MAX_DELAY_SECONDS":  "600",
+				"EMAIL_OUTBOX_MAX_ATTEMPTS":       "3",
+				"EMAIL_OUTBOX_RETRY_WINDOW_HOURS": "0",
+				"EMAIL_OUTBOX_RETENTION_DAYS":     "7",
+			},
+			expected: &config.OutboxConfig{
+				PollSeconds:      1,
+				BaseDelaySeconds: 10,
+				MaxDelaySeconds:  600,
+				MaxAttempts:      3,
+				RetryWindowHours: 0,
+				RetentionDays:    7,
+			},
+		},
+		{
+			name: "with max delay below base delay",
+			envVars: map[string]string{
+				"EMAIL_OUTBOX_BASE_DELAY_SECONDS": "120",
+				"EMAIL_OUTBOX_MAX_DELAY_SECONDS":  "60",
+			},
+			expected: &config.OutboxConfig{
+				PollSeconds:      5,
+				BaseDelaySeconds: 120,
+				MaxDelaySeconds:  120,
+				MaxAttempts:      8,
+				RetryWindowHours: 24,
+				RetentionDays:    30,
+			},
+		},
+		{
+			name: "with invalid values",
+			envVars: map[string]string{
+				"EMAIL_OUTBOX_POLL_SECONDS":       "0",
+				"EMAIL_OUTBOX_BASE_DELAY_SECONDS": "-1",
+				"EMAIL_OUTBOX_MAX_DELAY_SECONDS":  "invalid",
+				"EMAIL_OUTBOX_MAX_ATTEMPTS":       "0",
+				"EMAIL_OUTBOX_RETRY_WINDOW_HOURS": "-1",
+				"EMAIL_OUTBOX_RETENTION_DAYS":     "0",
+			},
+			expected: &config.OutboxConfig{
+				PollSeconds:      5,
+				BaseDelaySeconds: 30,
+				MaxDelaySeconds:  3600,
+				MaxAttempts:      8,
+				RetryWindowHours: 24,
+				RetentionDays:    30,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear environment before each test
+			os.Clearenv()
+
+			// Set environment variables for test
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			// Run test
+			config := config.InitOutboxConfig()
+
+			// Assert results
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
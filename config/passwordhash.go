@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+type PasswordHashConfig struct {
+	// Algorithm used for new hashes, "argon2id" or "bcrypt". Hashes made with
+	// the other algorithm still verify and are upgraded on login.
+	Algorithm         string
+	BcryptCost        int
+	Argon2MemoryKiB   int
+	Argon2Iterations  int
+	Argon2Parallelism int
+}
+
+func InitPasswordHashConfig() *PasswordHashConfig {
+	algorithm := strings.ToLower(os.Getenv("PASSWORD_HASH_ALGORITHM"))
+	if algorithm != "bcrypt" {
+		algorithm = "argon2id"
+	}
+
+	// bcrypt accepts costs between 4 and 31
+	bcryptCost := 10
+	if env := os.Getenv("PASSWORD_BCRYPT_COST"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= 4 && parsed <= 31 {
+			bcryptCost = parsed
+		}
+	}
+
+	// Defaults follow the OWASP minimum for argon2id: 19 MiB, 2 iterations, 1 lane
+	argon2MemoryKiB := 19456
+	if env := os.Getenv("PASSWORD_ARGON2_MEMORY_KIB"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= 1024 {
+			argon2MemoryKiB = parsed
+		}
+	}
+
+	argon2Iterations := 2
+	if env := os.Getenv("PASSWORD_ARGON2_ITERATIONS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			argon2Iterations = parsed
+		}
+	}
+
+	argon2Parallelism := 1
+	if env := os.Getenv("PASSWORD_ARGON2_PARALLELISM"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 && parsed <= 255 {
+			argon2Parallelism = parsed
+		}
+	}
+
+	return &PasswordHashConfig{
+		Algorithm:         algorithm,
+		BcryptCost:        bcryptCost,
+		Argon2MemoryKiB:   argon2MemoryKiB,
+		Argon2Iterations:  argon2Iterations,
+		Argon2Parallelism: argon2Parallelism,
+	}
+}
@@ -0,0 +1,81 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/config"
+)
+
+func TestInitPasswordHashConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected *config.PasswordHashConfig
+	}{
+		{
+			name:    "with default values",
+			envVars: map[string]string{},
+			expected: &config.PasswordHashConfig{
+				Algorithm:         "argon2id",
+				BcryptCost:        10,
+				Argon2MemoryKiB:   19456,
+				Argon2Iterations:  2,
+				Argon2Parallelism: 1,
+			},
+		},
+		{
+			name: "with custom values",
+			envVars: map[string]string{
+				"PASSWORD_HASH_ALGORITHM":     "bcrypt",
+				"PASSWORD_BCRYPT_COST":        "12",
+				"PASSWORD_ARGON2_MEMORY_KIB":  "65536",
+				"PASSWORD_ARGON2_ITERATIONS":  "3",
+				"PASSWORD_ARGON2_PARALLELISM": "4",
+			},
+			expected: &config.PasswordHashConfig{
+				Algorithm:         "bcrypt",
+				BcryptCost:        12,
+				Argon2MemoryKiB:   65536,
+				Argon2Iterations:  3,
+				Argon2Parallelism: 4,
+			},
+		},
+		{
+			name: "with invalid values",
+			envVars: map[string]string{
+				"PASSWORD_HASH_ALGORITHM":     "md5",
+				"PASSWORD_BCRYPT_COST":        "32",
+				"PASSWORD_ARGON2_MEMORY_KIB":  "512",
+				"PASSWORD_ARGON2_ITERATIONS":  "0",
+				"PASSWORD_ARGON2_PARALLELISM": "256",
+			},
+			expected: &config.PasswordHashConfig{
+				Algorithm:         "argon2id",
+				BcryptCost:        10,
+				Argon2MemoryKiB:   19456,
+				Argon2Iterations:  2,
+				Argon2Parallelism: 1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear environment before each test
+			os.Clearenv()
+
+			// Set environment variables for test
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			// Run test
+			config := config.InitPasswordHashConfig()
+
+			// Assert results
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
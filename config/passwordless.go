@@ -0,0 +1,26 @@
+package config
+
+import (
+	"log"
+	"os"
+)
+
+type PasswordlessConfig struct {
+	LinkURL    string
+	LinkSecret string
+}
+
+func InitPasswordlessConfig() *PasswordlessConfig {
+	// Login links are only sent when both the frontend URL and the signing secret are set
+	linkURL := os.Getenv("LOGIN_LINK_URL")
+	linkSecret := os.Getenv("LOGIN_LINK_SECRET")
+	if linkURL != "" && linkSecret == "" {
+		log.Println("LOGIN_LINK_SECRET is not set, login emails will only contain a code")
+		linkURL = ""
+	}
+
+	return &PasswordlessConfig{
+		LinkURL:    linkURL,
+		LinkSecret: linkSecret,
+	}
+}
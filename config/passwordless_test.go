@@ -0,0 +1,59 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/config"
+)
+
+func TestInitPasswordlessConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected *config.PasswordlessConfig
+	}{
+		{
+			name:     "with default values",
+			envVars:  map[string]string{},
+			expected: &config.PasswordlessConfig{},
+		},
+		{
+			name: "with custom values",
+			envVars: map[string]string{
+				"LOGIN_LINK_URL":    "http://localhost:3000/login/link",
+				"LOGIN_LINK_SECRET": "test-link-secret",
+			},
+			expected: &config.PasswordlessConfig{
+				LinkURL:    "http://localhost:3000/login/link",
+				LinkSecret: "test-link-secret",
+			},
+		},
+		{
+			name: "link without secret",
+			envVars: map[string]string{
+				"LOGIN_LINK_URL": "http://localhost:3000/login/link",
+			},
+			expected: &config.PasswordlessConfig{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear environment before each test
+			os.Clearenv()
+
+			// Set environment variables for test
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			// Run test
+			config := config.InitPasswordlessConfig()
+
+			// Assert results
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
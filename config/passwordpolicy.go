@@ -0,0 +1,68 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+type PasswordPolicyConfig struct {
+	MinLength        int
+	MaxLength        int
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	// MinStrength is the lowest accepted zxcvbn score from 0 to 4, 0 disables the check
+	MinStrength        int
+	ForbidPersonalInfo bool
+	// BreachedFilterPath is a bloom filter file built with cmd/breachfilter, empty disables the check
+	BreachedFilterPath string
+}
+
+func InitPasswordPolicyConfig() *PasswordPolicyConfig {
+	minLength := 8
+	if env := os.Getenv("PASSWORD_MIN_LENGTH"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			minLength = parsed
+		}
+	}
+
+	maxLength := 128
+	if env := os.Getenv("PASSWORD_MAX_LENGTH"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= minLength {
+			maxLength = parsed
+		}
+	}
+	if maxLength < minLength {
+		maxLength = minLength
+	}
+
+	minStrength := 2
+	if env := os.Getenv("PASSWORD_MIN_STRENGTH"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed >= 0 && parsed <= 4 {
+			minStrength = parsed
+		}
+	}
+
+	return &PasswordPolicyConfig{
+		MinLength:          minLength,
+		MaxLength:          maxLength,
+		RequireLowercase:   envBool("PASSWORD_REQUIRE_LOWERCASE", false),
+		RequireUppercase:   envBool("PASSWORD_REQUIRE_UPPERCASE", false),
+		RequireDigit:       envBool("PASSWORD_REQUIRE_DIGIT", false),
+		RequireSymbol:      envBool("PASSWORD_REQUIRE_SYMBOL", false),
+		MinStrength:        minStrength,
+		ForbidPersonalInfo: envBool("PASSWORD_FORBID_PERSONAL_INFO", true),
+		BreachedFilterPath: os.Getenv("PASSWORD_BREACHED_FILTER_PATH"),
+	}
+}
+
+// envBool reads a boolean env var, keeping the default when it is missing or invalid
+func envBool(key string, defaultValue bool) bool {
+	if env := os.Getenv(key); env != "" {
+		if parsed, err := strconv.ParseBool(env); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,98 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/config"
+)
+
+func TestInitPasswordPolicyConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected *config.PasswordPolicyConfig
+	}{
+		{
+			name:    "with default values",
+			envVars: map[string]string{},
+			expected: &config.PasswordPolicyConfig{
+				MinLength:          8,
+				MaxLength:          128,
+				MinStrength:        2,
+				ForbidPersonalInfo: true,
+			},
+		},
+		{
+			name: "with custom values",
+			envVars: map[string]string{
+				"PASSWORD_MIN_LENGTH":           "12",
+				"PASSWORD_MAX_LENGTH":           "64",
+				"PASSWORD_REQUIRE_LOWERCASE":    "true",
+				"PASSWORD_REQUIRE_UPPERCASE":    "true",
+				"PASSWORD_REQUIRE_DIGIT":        "true",
+				"PASSWORD_REQUIRE_SYMBOL":       "true",
+				"PASSWORD_MIN_STRENGTH":         "0",
+				"PASSWORD_FORBID_PERSONAL_INFO": "false",
+				"PASSWORD_BREACHED_FILTER_PATH": "/data/breached.bloom",
+			},
+			expected: &config.PasswordPolicyConfig{
+				MinLength:          12,
+				MaxLength:          64,
+				RequireLowercase:   true,
+				RequireUppercase:   true,
+				RequireDigit:       true,
+				RequireSymbol:      true,
+				MinStrength:        0,
+				ForbidPersonalInfo: false,
+				BreachedFilterPath: "/data/breached.bloom",
+			},
+		},
+		{
+			name: "with invalid values",
+			envVars: map[string]string{
+				"PASSWORD_MIN_LENGTH":        "0",
+				"PASSWORD_MAX_LENGTH":        "4",
+				"PASSWORD_REQUIRE_UPPERCASE": "maybe",
+				"PASSWORD_MIN_STRENGTH":      "5",
+			},
+			expected: &config.PasswordPolicyConfig{
+				MinLength:          8,
+				MaxLength:          128,
+				MinStrength:        2,
+				ForbidPersonalInfo: true,
+			},
+		},
+		{
+			name: "with min length above default max",
+			envVars: map[string]string{
+				"PASSWORD_MIN_LENGTH": "200",
+			},
+			expected: &config.PasswordPolicyConfig{
+				MinLength:          200,
+				MaxLength:          200,
+				MinStrength:        2,
+				ForbidPersonalInfo: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear environment before each test
+			os.Clearenv()
+
+			// Set environment variables for test
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			// Run test
+			config := config.InitPasswordPolicyConfig()
+
+			// Assert results
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// RateLimitRule allows Limit requests per PeriodSeconds using Algorithm,
+// either "token_bucket" or "sliding_window"
+type RateLimitRule struct {
+	Algorithm     string
+	Limit         int
+	PeriodSeconds int
+}
+
+type RateLimitConfig struct {
+	Enabled bool
+	// Store is where counters are kept, "memory" or "redis" (shared by replicas)
+	Store    string
+	RedisURL string
+	// Auth applies to every /auth route per client IP
+	Auth RateLimitRule
+	// TokenRequest applies to /auth/token/:type per client IP, which sends emails
+	TokenRequest RateLimitRule
+}
+
+func InitRateLimitConfig() *RateLimitConfig {
+	enabled := true
+	if env := os.Getenv("RATE_LIMIT_ENABLED"); env != "" {
+		if parsed, err := strconv.ParseBool(env); err == nil {
+			enabled = parsed
+		}
+	}
+
+	store := strings.ToLower(os.Getenv("RATE_LIMIT_STORE"))
+	if store != "redis" {
+		store = "memory"
+	}
+
+	redisURL := os.Getenv("REDIS_URL")
+	if redisURL == "" {
+		redisURL = "redis://localhost:6379/0"
+	}
+
+	return &RateLimitConfig{
+		Enabled:  enabled,
+		Store:    store,
+		RedisURL: redisURL,
+		Auth: initRateLimitRule("RATE_LIMIT_AUTH", RateLimitRule{
+			Algorithm:     "token_bucket",
+			Limit:         60,
+			PeriodSeconds: 60,
+		}),
+		TokenRequest: initRateLimitRule("RATE_LIMIT_TOKEN", RateLimitRule{
+			Algorithm:     "sliding_window",
+			Limit:         5,
+			PeriodSeconds: 900,
+		}),
+	}
+}
+
+// initRateLimitRule reads <prefix>_ALGORITHM, <prefix>_LIMIT and <prefix>_PERIOD_SECONDS
+func initRateLimitRule(prefix string, rule RateLimitRule) RateLimitRule {
+	switch algorithm := strings.ToLower(os.Getenv(prefix + "_ALGORITHM")); algorithm {
+	case "token_bucket", "sliding_window":
+		rule.Algorithm = algorithm
+	}
+
+	if env := os.Getenv(prefix + "_LIMIT"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			rule.Limit = parsed
+		}
+	}
+
+	if env := os.Getenv(prefix + "_PERIOD_SECONDS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			rule.PeriodSeconds = parsed
+		}
+	}
+
+	return rule
+}
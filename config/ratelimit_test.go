@@ -0,0 +1,87 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/config"
+)
+
+func TestInitRateLimitConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected *config.RateLimitConfig
+	}{
+		{
+			name:    "with default values",
+			envVars: map[string]string{},
+			expected: &config.RateLimitConfig{
+				Enabled:      true,
+				Store:        "memory",
+				RedisURL:     "redis://localhost:6379/0",
+				Auth:         config.RateLimitRule{Algorithm: "token_bucket", Limit: 60, PeriodSeconds: 60},
+				TokenRequest: config.RateLimitRule{Algorithm: "sliding_window", Limit: 5, PeriodSeconds: 900},
+			},
+		},
+		{
+			name: "with custom values",
+			envVars: map[string]string{
+				"RATE_LIMIT_ENABLED":              "false",
+				"RATE_LIMIT_STORE":                "redis",
+				"REDIS_URL":                       "redis://redis:6379/1",
+				"RATE_LIMIT_AUTH_ALGORITHM":       "sliding_window",
+				"RATE_LIMIT_AUTH_LIMIT":           "100",
+				"RATE_LIMIT_AUTH_PERIOD_SECONDS":  "30",
+				"RATE_LIMIT_TOKEN_ALGORITHM":      "token_bucket",
+				"RATE_LIMIT_TOKEN_LIMIT":          "3",
+				"RATE_LIMIT_TOKEN_PERIOD_SECONDS": "3600",
+			},
+			expected: &config.RateLimitConfig{
+				Enabled:      false,
+				Store:        "redis",
+				RedisURL:     "redis://redis:6379/1",
+				Auth:         config.RateLimitRule{Algorithm: "sliding_window", Limit: 100, PeriodSeconds: 30},
+				TokenRequest: config.RateLimitRule{Algorithm: "token_bucket", Limit: 3, PeriodSeconds: 3600},
+			},
+		},
+		{
+			name: "with invalid values",
+			envVars: map[string]string{
+				"RATE_LIMIT_ENABLED":              "maybe",
+				"RATE_LIMIT_STORE":                "memcached",
+				"RATE_LIMIT_AUTH_ALGORITHM":       "leaky_bucket",
+				"RATE_LIMIT_AUTH_LIMIT":           "0",
+				"RATE_LIMIT_AUTH_PERIOD_SECONDS":  "invalid",
+				"RATE_LIMIT_TOKEN_LIMIT":          "-1",
+				"RATE_LIMIT_TOKEN_PERIOD_SECONDS": "0",
+			},
+			expected: &config.RateLimitConfig{
+				Enabled:      true,
+				Store:        "memory",
+				RedisURL:     "redis://localhost:6379/0",
+				Auth:         config.RateLimitRule{Algorithm: "token_bucket", Limit: 60, PeriodSeconds: 60},
+				TokenRequest: config.RateLimitRule{Algorithm: "sliding_window", Limit: 5, PeriodSeconds: 900},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear environment before each test
+			os.Clearenv()
+
+			// Set environment variables for test
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			// Run test
+			config := config.InitRateLimitConfig()
+
+			// Assert results
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
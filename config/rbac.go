@@ -0,0 +1,26 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+type RBACConfig struct {
+	// AdminEmails are granted the admin role at startup and when they log in, so a
+	// fresh deployment has someone who can assign roles
+	AdminEmails []string
+}
+
+func InitRBACConfig() *RBACConfig {
+	adminEmails := []string{}
+	for _, email := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		email = strings.ToLower(strings.TrimSpace(email))
+		if email != "" {
+			adminEmails = append(adminEmails, email)
+		}
+	}
+
+	return &RBACConfig{
+		AdminEmails: adminEmails,
+	}
+}
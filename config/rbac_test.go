@@ -0,0 +1,52 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/config"
+)
+
+func TestInitRBACConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected *config.RBACConfig
+	}{
+		{
+			name:    "with default values",
+			envVars: map[string]string{},
+			expected: &config.RBACConfig{
+				AdminEmails: []string{},
+			},
+		},
+		{
+			name: "with admin emails",
+			envVars: map[string]string{
+				"ADMIN_EMAILS": " Admin@Example.com ,ops@example.com,",
+			},
+			expected: &config.RBACConfig{
+				AdminEmails: []string{"admin@example.com", "ops@example.com"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear environment before each test
+			os.Clearenv()
+
+			// Set environment variables for test
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			// Run test
+			config := config.InitRBACConfig()
+
+			// Assert results
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
@@ -4,22 +4,24 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/yantology/golang-starter-template/pkg/customerror"
 )
 
 type ResendApi struct {
-	ApiKey       string
+	// ApiKey is only required by the resend mail driver, see InitMailConfig
+	ApiKey string
+	// ResendDomain and ResendName form the sender of every mail driver
 	ResendDomain string
 	ResendName   string
+	// WebhookSecret is the whsec_ signing secret of the delivery event webhook, which
+	// is only served when it is set
+	WebhookSecret string
 }
 
 func InitResendConfig() (*ResendApi, *customerror.CustomError) {
 	apiKey := os.Getenv("RESEND_API_KEY")
-	if apiKey == "" {
-		log.Println("Resend API key is not set")
-		return nil, customerror.NewCustomError(nil, "Resend API key is not set", http.StatusUnauthorized)
-	}
 	resendDomain := os.Getenv("RESEND_DOMAIN")
 	log.Println("Resend domain => ", resendDomain)
 	if resendDomain == "" {
@@ -31,5 +33,10 @@ func InitResendConfig() (*ResendApi, *customerror.CustomError) {
 		return nil, customerror.NewCustomError(nil, "Resend name is not set", http.StatusUnauthorized)
 	}
 
-	return &ResendApi{ApiKey: apiKey, ResendDomain: resendDomain, ResendName: resendName}, nil
+	return &ResendApi{
+		ApiKey:        apiKey,
+		ResendDomain:  resendDomain,
+		ResendName:    resendName,
+		WebhookSecret: strings.TrimSpace(os.Getenv("RESEND_WEBHOOK_SECRET")),
+	}, nil
 }
@@ -10,9 +10,10 @@ import (
 
 func TestInitResendConfig(t *testing.T) {
 	tests := []struct {
-		name      string
-		envVars   map[string]string
-		wantError bool
+		name                  string
+		envVars               map[string]string
+		wantError             bool
+		expectedWebhookSecret string
 	}{
 		{
 			name: "with valid configuration",
@@ -24,9 +25,23 @@ func TestInitResendConfig(t *testing.T) {
 			wantError: false,
 		},
 		{
-			name:      "with missing API key",
-			envVars:   map[string]string{},
-			wantError: true,
+			name: "with webhook secret",
+			envVars: map[string]string{
+				"RESEND_API_KEY":        "test-api-key",
+				"RESEND_DOMAIN":         "test.com",
+				"RESEND_NAME":           "Test Sender",
+				"RESEND_WEBHOOK_SECRET": " whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw ",
+			},
+			wantError:             false,
+			expectedWebhookSecret: "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw",
+		},
+		{
+			name: "without API key",
+			envVars: map[string]string{
+				"RESEND_DOMAIN": "test.com",
+				"RESEND_NAME":   "Test Sender",
+			},
+			wantError: false,
 		},
 		{
 			name: "with missing domain",
@@ -64,6 +79,7 @@ func TestInitResendConfig(t *testing.T) {
 			} else {
 				assert.Nil(t, err, "Unexpected error")
 				assert.NotNil(t, config, "Expected non-nil config")
+				assert.Equal(t, tt.expectedWebhookSecret, config.WebhookSecret)
 			}
 		})
 	}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+type WebAuthnConfig struct {
+	RPID             string
+	RPName           string
+	RPOrigins        []string
+	TimeoutSeconds   int
+	UserVerification string
+}
+
+func InitWebAuthnConfig() *WebAuthnConfig {
+	rpID := os.Getenv("WEBAUTHN_RP_ID")
+	if rpID == "" {
+		rpID = "localhost"
+	}
+
+	rpName := os.Getenv("WEBAUTHN_RP_NAME")
+	if rpName == "" {
+		rpName = "Retail Pro"
+	}
+
+	// Origins must match the browser origin exactly, including scheme and port
+	origins := GetEnvAsSlice("WEBAUTHN_RP_ORIGINS", []string{"http://localhost:3000"})
+	for i, origin := range origins {
+		origins[i] = strings.TrimSpace(origin)
+	}
+
+	timeoutSeconds := 300
+	if env := os.Getenv("WEBAUTHN_TIMEOUT_SECONDS"); env != "" {
+		if parsed, err := strconv.Atoi(env); err == nil && parsed > 0 {
+			timeoutSeconds = parsed
+		}
+	}
+
+	userVerification := os.Getenv("WEBAUTHN_USER_VERIFICATION")
+	switch userVerification {
+	case "required", "preferred", "discouraged":
+	default:
+		userVerification = "preferred"
+	}
+
+	return &WebAuthnConfig{
+		RPID:             rpID,
+		RPName:           rpName,
+		RPOrigins:        origins,
+		TimeoutSeconds:   timeoutSeconds,
+		UserVerification: userVerification,
+	}
+}
@@ -0,0 +1,78 @@
+package config_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/config"
+)
+
+func TestInitWebAuthnConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		envVars  map[string]string
+		expected *config.WebAuthnConfig
+	}{
+		{
+			name:    "with default values",
+			envVars: map[string]string{},
+			expected: &config.WebAuthnConfig{
+				RPID:             "localhost",
+				RPName:           "Retail Pro",
+				RPOrigins:        []string{"http://localhost:3000"},
+				TimeoutSeconds:   300,
+				UserVerification: "preferred",
+			},
+		},
+		{
+			name: "with custom values",
+			envVars: map[string]string{
+				"WEBAUTHN_RP_ID":             "retail.example.com",
+				"WEBAUTHN_RP_NAME":           "Retail",
+				"WEBAUTHN_RP_ORIGINS":        "https://retail.example.com, https://pos.retail.example.com",
+				"WEBAUTHN_TIMEOUT_SECONDS":   "120",
+				"WEBAUTHN_USER_VERIFICATION": "required",
+			},
+			expected: &config.WebAuthnConfig{
+				RPID:             "retail.example.com",
+				RPName:           "Retail",
+				RPOrigins:        []string{"https://retail.example.com", "https://pos.retail.example.com"},
+				TimeoutSeconds:   120,
+				UserVerification: "required",
+			},
+		},
+		{
+			name: "with invalid values",
+			envVars: map[string]string{
+				"WEBAUTHN_TIMEOUT_SECONDS":   "invalid",
+				"WEBAUTHN_USER_VERIFICATION": "always",
+			},
+			expected: &config.WebAuthnConfig{
+				RPID:             "localhost",
+				RPName:           "Retail Pro",
+				RPOrigins:        []string{"http://localhost:3000"},
+				TimeoutSeconds:   300,
+				UserVerification: "preferred",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Clear environment before each test
+			os.Clearenv()
+
+			// Set environment variables for test
+			for k, v := range tt.envVars {
+				os.Setenv(k, v)
+			}
+
+			// Run test
+			config := config.InitWebAuthnConfig()
+
+			// Assert results
+			assert.Equal(t, tt.expected, config)
+		})
+	}
+}
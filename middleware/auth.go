@@ -6,26 +6,41 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/yantology/golang-starter-template/config"
+	"github.com/yantology/golang-starter-template/pkg/customerror"
 	jwtPkg "github.com/yantology/golang-starter-template/pkg/jwt"
 )
 
+// APIKeyAuthenticator resolves an API key to the claims of the user owning it
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(key string) (*UserClaims, *customerror.CustomError)
+}
+
 // AuthMiddleware is a struct for authentication middleware
 type AuthMiddleware struct {
-	jwtService  jwtPkg.JWTService
+	jwtService  jwtPkg.Service[UserClaims]
 	tokenConfig *config.TokenConfig
+	apiKeys     APIKeyAuthenticator
 }
 
-// NewAuthMiddleware creates a new instance of authentication middleware
-func NewAuthMiddleware(jwtService jwtPkg.JWTService, tokenConfig *config.TokenConfig) *AuthMiddleware {
+// NewAuthMiddleware creates a new instance of authentication middleware.
+// A nil apiKeys rejects every API key.
+func NewAuthMiddleware(jwtService jwtPkg.Service[UserClaims], tokenConfig *config.TokenConfig, apiKeys APIKeyAuthenticator) *AuthMiddleware {
 	return &AuthMiddleware{
 		jwtService:  jwtService,
 		tokenConfig: tokenConfig,
+		apiKeys:     apiKeys,
 	}
 }
 
-// AuthRequired validates JWT token from cookies or authorization header
+// AuthRequired validates an API key from the X-API-Key header or the ApiKey
+// authorization scheme, or a JWT token from cookies or the Bearer authorization scheme
 func (m *AuthMiddleware) AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if apiKey := apiKeyFromRequest(c); apiKey != "" {
+			m.authenticateAPIKey(c, apiKey)
+			return
+		}
+
 		var token string
 		var err error
 
@@ -57,7 +72,7 @@ func (m *AuthMiddleware) AuthRequired() gin.HandlerFunc {
 		}
 
 		// Validate token
-		claims, err := m.jwtService.ValidateAccessTokenClaims(token)
+		claims, err := m.jwtService.ValidateAccessToken(token)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"message": "Token tidak valid atau kadaluarsa",
@@ -67,25 +82,172 @@ func (m *AuthMiddleware) AuthRequired() gin.HandlerFunc {
 		}
 
 		// Set user info in context
-		c.Set("user_id", claims.UserID)
-		c.Set("email", claims.Email)
+		setUserClaims(c, &claims.Custom)
+		c.Next()
+	}
+}
+
+func (m *AuthMiddleware) authenticateAPIKey(c *gin.Context, apiKey string) {
+	if m.apiKeys == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"message": "API key tidak valid",
+		})
+		c.Abort()
+		return
+	}
+
+	claims, cuserr := m.apiKeys.AuthenticateAPIKey(apiKey)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), gin.H{
+			"message": cuserr.Message(),
+		})
+		c.Abort()
+		return
+	}
+
+	setUserClaims(c, claims)
+	c.Next()
+}
+
+// RejectAPIKeys only lets through users signed in with a token, for routes
+// that manage credentials. It must be used after AuthRequired.
+func (m *AuthMiddleware) RejectAPIKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if ExtractUserClaims(c).APIKeyID != "" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Endpoint ini tidak dapat diakses dengan API key",
+			})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
 
+// apiKeyFromRequest returns the key of the X-API-Key header or the ApiKey
+// authorization scheme, or an empty string when the request has none
+func apiKeyFromRequest(c *gin.Context) string {
+	if apiKey := c.GetHeader("X-API-Key"); apiKey != "" {
+		return apiKey
+	}
+
+	parts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(parts) == 2 && strings.EqualFold(parts[0], "ApiKey") {
+		return parts[1]
+	}
+	return ""
+}
+
+// RequirePermission only lets through users holding the permission.
+// It must be used after AuthRequired.
+func (m *AuthMiddleware) RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !contains(ExtractUserClaims(c).Permissions, permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Akses ditolak",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireOrganizationPermission only lets through users whose role in the active
+// organization, or one of whose global roles, holds the permission. Global routes use
+// RequirePermission, which ignores organization roles. It must be used after TenantRequired.
+func (m *AuthMiddleware) RequireOrganizationPermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		claims := ExtractUserClaims(c)
+		if !contains(claims.OrganizationPermissions, permission) && !contains(claims.Permissions, permission) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Akses ditolak",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole only lets through users holding the role.
+// It must be used after AuthRequired.
+func (m *AuthMiddleware) RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !contains(ExtractUserClaims(c).Roles, role) {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Akses ditolak",
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func setUserClaims(c *gin.Context, claims *UserClaims) {
+	c.Set("user_id", claims.UserID)
+	c.Set("email", claims.Email)
+	c.Set("roles", claims.Roles)
+	c.Set("permissions", claims.Permissions)
+	c.Set("organization_id", claims.OrganizationID)
+	c.Set("organization_role", claims.OrganizationRole)
+	c.Set("organization_permissions", claims.OrganizationPermissions)
+	c.Set("session_id", claims.SessionID)
+	c.Set("api_key_id", claims.APIKeyID)
+}
+
 // ExtractUserClaims extracts user claims from the context
 func ExtractUserClaims(c *gin.Context) *UserClaims {
 	userID, _ := c.Get("user_id")
 	email, _ := c.Get("email")
+	roles, _ := c.Get("roles")
+	permissions, _ := c.Get("permissions")
+	organizationID, _ := c.Get("organization_id")
+	organizationRole, _ := c.Get("organization_role")
+	organizationPermissions, _ := c.Get("organization_permissions")
+	sessionID, _ := c.Get("session_id")
+	apiKeyID, _ := c.Get("api_key_id")
 
-	return &UserClaims{
-		UserID: userID.(string),
-		Email:  email.(string),
-	}
+	claims := &UserClaims{}
+	claims.UserID, _ = userID.(string)
+	claims.Email, _ = email.(string)
+	claims.Roles, _ = roles.([]string)
+	claims.Permissions, _ = permissions.([]string)
+	claims.OrganizationID, _ = organizationID.(string)
+	claims.OrganizationRole, _ = organizationRole.(string)
+	claims.OrganizationPermissions, _ = organizationPermissions.([]string)
+	claims.SessionID, _ = sessionID.(string)
+	claims.APIKeyID, _ = apiKeyID.(string)
+	return claims
 }
 
-// UserClaims represents user information extracted from token
+// UserClaims represents user information extracted from token or API key.
+// It is also the custom claims type of access and refresh tokens.
 type UserClaims struct {
-	UserID string
-	Email  string
+	UserID string `json:"user_id"`
+	Email  string `json:"email"`
+	// Roles and Permissions are global and checked by RequireRole and RequirePermission
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	// OrganizationID is the active organization, empty while the user has none
+	OrganizationID string `json:"org_id,omitempty"`
+	// OrganizationRole and OrganizationPermissions come from the membership in the active
+	// organization and only count on tenant-scoped routes
+	OrganizationRole        string   `json:"org_role,omitempty"`
+	OrganizationPermissions []string `json:"org_permissions,omitempty"`
+	// SessionID is the refresh session family the access token was issued for, empty
+	// for API keys
+	SessionID string `json:"sid,omitempty"`
+	// APIKeyID is set when the request was authenticated with an API key
+	APIKeyID string `json:"-"`
 }
@@ -0,0 +1,273 @@
+package middleware_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yantology/golang-starter-template/config"
+	"github.com/yantology/golang-starter-template/middleware"
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+	jwtPkg "github.com/yantology/golang-starter-template/pkg/jwt"
+	"github.com/yantology/golang-starter-template/pkg/tenant"
+)
+
+var tokenConfig = &config.TokenConfig{AccessTokenName: "access_token"}
+
+type fakeAPIKeys struct {
+	keys map[string]*middleware.UserClaims
+}
+
+func (f *fakeAPIKeys) AuthenticateAPIKey(key string) (*middleware.UserClaims, *customerror.CustomError) {
+	claims, ok := f.keys[key]
+	if !ok {
+		return nil, customerror.NewCustomError(nil, "API key tidak valid", http.StatusUnauthorized)
+	}
+	return claims, nil
+}
+
+func newTestMiddleware() (*middleware.AuthMiddleware, jwtPkg.Service[middleware.UserClaims]) {
+	gin.SetMode(gin.TestMode)
+	jwtService := jwtPkg.NewService[middleware.UserClaims]("test-access", "test-refresh", 0, 0, "test")
+	apiKeys := &fakeAPIKeys{keys: map[string]*middleware.UserClaims{
+		"sk_valid": {UserID: "key-owner", Permissions: []string{"users:read"}, APIKeyID: "key-1"},
+	}}
+	return middleware.NewAuthMiddleware(jwtService, tokenConfig, apiKeys), jwtService
+}
+
+// serve runs the request through the handlers and a final handler that answers with
+// the claims it sees
+func serve(req *http.Request, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	router := gin.New()
+	handlers = append(handlers, func(c *gin.Context) {
+		claims := middleware.ExtractUserClaims(c)
+		organizationID, _ := tenant.ID(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{
+			"user_id":         claims.UserID,
+			"api_key_id":      claims.APIKeyID,
+			"organization_id": organizationID,
+		})
+	})
+	router.GET("/test", handlers...)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) map[string]string {
+	var body map[string]string
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body
+}
+
+func TestAuthRequired(t *testing.T) {
+	m, jwtService := newTestMiddleware()
+	token, err := jwtService.GenerateAccessToken(middleware.UserClaims{UserID: "token-user"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		setup           func(req *http.Request)
+		expectedCode    int
+		expectedUserID  string
+		expectedKeyID   string
+		expectedMessage string
+	}{
+		{
+			name: "cookie",
+			setup: func(req *http.Request) {
+				req.AddCookie(&http.Cookie{Name: tokenConfig.AccessTokenName, Value: token})
+			},
+			expectedCode:   http.StatusOK,
+			expectedUserID: "token-user",
+		},
+		{
+			name: "bearer token",
+			setup: func(req *http.Request) {
+				req.Header.Set("Authorization", "Bearer "+token)
+			},
+			expectedCode:   http.StatusOK,
+			expectedUserID: "token-user",
+		},
+		{
+			name: "API key takes precedence over cookie",
+			setup: func(req *http.Request) {
+				req.AddCookie(&http.Cookie{Name: tokenConfig.AccessTokenName, Value: token})
+				req.Header.Set("X-API-Key", "sk_valid")
+			},
+			expectedCode:   http.StatusOK,
+			expectedUserID: "key-owner",
+			expectedKeyID:  "key-1",
+		},
+		{
+			name: "ApiKey authorization scheme",
+			setup: func(req *http.Request) {
+				req.Header.Set("Authorization", "ApiKey sk_valid")
+			},
+			expectedCode:   http.StatusOK,
+			expectedUserID: "key-owner",
+			expectedKeyID:  "key-1",
+		},
+		{
+			// A valid cookie does not rescue an invalid key
+			name: "invalid API key with valid cookie",
+			setup: func(req *http.Request) {
+				req.AddCookie(&http.Cookie{Name: tokenConfig.AccessTokenName, Value: token})
+				req.Header.Set("X-API-Key", "sk_unknown")
+			},
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "API key tidak valid",
+		},
+		{
+			name:            "no credentials",
+			setup:           func(req *http.Request) {},
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "Tidak ada token autentikasi",
+		},
+		{
+			name: "invalid token",
+			setup: func(req *http.Request) {
+				req.Header.Set("Authorization", "Bearer invalid")
+			},
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "Token tidak valid atau kadaluarsa",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			tt.setup(req)
+
+			w := serve(req, m.AuthRequired())
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			body := decode(t, w)
+			if tt.expectedCode == http.StatusOK {
+				assert.Equal(t, tt.expectedUserID, body["user_id"])
+				assert.Equal(t, tt.expectedKeyID, body["api_key_id"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, body["message"])
+			}
+		})
+	}
+}
+
+func TestRejectAPIKeys(t *testing.T) {
+	m, jwtService := newTestMiddleware()
+	token, err := jwtService.GenerateAccessToken(middleware.UserClaims{UserID: "token-user"})
+	require.NoError(t, err)
+
+	t.Run("API key", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("X-API-Key", "sk_valid")
+
+		w := serve(req, m.AuthRequired(), m.RejectAPIKeys())
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "Endpoint ini tidak dapat diakses dengan API key", decode(t, w)["message"])
+	})
+
+	t.Run("token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		w := serve(req, m.AuthRequired(), m.RejectAPIKeys())
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestRequirePermission(t *testing.T) {
+	m, jwtService := newTestMiddleware()
+
+	tests := []struct {
+		name         string
+		claims       middleware.UserClaims
+		expectedCode int
+	}{
+		{
+			name:         "global permission",
+			claims:       middleware.UserClaims{UserID: "user-1", Permissions: []string{"users:read"}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "missing permission",
+			claims:       middleware.UserClaims{UserID: "user-1", Permissions: []string{"users:write"}},
+			expectedCode: http.StatusForbidden,
+		},
+		{
+			// Organization roles only count on tenant-scoped routes
+			name: "organization permission only",
+			claims: middleware.UserClaims{
+				UserID:                  "user-1",
+				OrganizationID:          "org-1",
+				OrganizationPermissions: []string{"users:read"},
+			},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwtService.GenerateAccessToken(tt.claims)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			w := serve(req, m.AuthRequired(), m.RequirePermission("users:read"))
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedCode == http.StatusForbidden {
+				assert.Equal(t, "Akses ditolak", decode(t, w)["message"])
+			}
+		})
+	}
+}
+
+func TestTenantRequired(t *testing.T) {
+	m, jwtService := newTestMiddleware()
+
+	tests := []struct {
+		name                   string
+		claims                 middleware.UserClaims
+		expectedCode           int
+		expectedOrganizationID string
+	}{
+		{
+			name:                   "active organization",
+			claims:                 middleware.UserClaims{UserID: "user-1", OrganizationID: "org-1"},
+			expectedCode:           http.StatusOK,
+			expectedOrganizationID: "org-1",
+		},
+		{
+			name:         "no active organization",
+			claims:       middleware.UserClaims{UserID: "user-1"},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := jwtService.GenerateAccessToken(tt.claims)
+			require.NoError(t, err)
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+
+			w := serve(req, m.AuthRequired(), m.TenantRequired())
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			body := decode(t, w)
+			if tt.expectedCode == http.StatusOK {
+				assert.Equal(t, tt.expectedOrganizationID, body["organization_id"])
+			} else {
+				assert.Equal(t, "Pilih organisasi terlebih dahulu", body["message"])
+			}
+		})
+	}
+}
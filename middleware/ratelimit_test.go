@@ -0,0 +1,55 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/middleware"
+)
+
+func TestKeyByAPIKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		claims      map[string]string
+		header      string
+		expectedKey string
+	}{
+		{
+			name:        "authenticated API key",
+			claims:      map[string]string{"user_id": "user-1", "api_key_id": "key-1"},
+			header:      "sk_secret",
+			expectedKey: "apikey:key-1",
+		},
+		{
+			name:        "token user",
+			claims:      map[string]string{"user_id": "user-1"},
+			expectedKey: "user:user-1",
+		},
+		{
+			// A made-up header must not open a bucket of its own
+			name:        "unauthenticated API key header",
+			header:      "sk_random",
+			expectedKey: "ip:192.0.2.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/test", nil)
+			c.Request.RemoteAddr = "192.0.2.1:1234"
+			if tt.header != "" {
+				c.Request.Header.Set("X-API-Key", tt.header)
+			}
+			for key, value := range tt.claims {
+				c.Set(key, value)
+			}
+
+			assert.Equal(t, tt.expectedKey, middleware.KeyByAPIKey(c))
+		})
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/pkg/tenant"
+)
+
+// TenantRequired resolves the active organization of the token or API key and
+// puts it into the request context, so repositories can scope their queries with
+// tenant.Require(ctx). Requests without an active organization are rejected.
+// It must be used after AuthRequired.
+func (m *AuthMiddleware) TenantRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		organizationID := ExtractUserClaims(c).OrganizationID
+		if organizationID == "" {
+			c.JSON(http.StatusForbidden, gin.H{
+				"message": "Pilih organisasi terlebih dahulu",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(tenant.WithID(c.Request.Context(), organizationID))
+		c.Next()
+	}
+}
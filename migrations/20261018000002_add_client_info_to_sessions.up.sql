@@ -0,0 +1,3 @@
+ALTER TABLE sessions
+    ADD COLUMN user_agent TEXT,
+    ADD COLUMN ip_address VARCHAR(45);
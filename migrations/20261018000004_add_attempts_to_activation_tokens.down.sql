@@ -0,0 +1 @@
+ALTER TABLE activation_tokens DROP COLUMN IF EXISTS attempts;
@@ -0,0 +1 @@
+ALTER TABLE activation_tokens ADD COLUMN attempts INT NOT NULL DEFAULT 0;
@@ -0,0 +1,54 @@
+CREATE TABLE roles (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL UNIQUE,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE permissions (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE role_permissions (
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    permission_id INT NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+    PRIMARY KEY (role_id, permission_id)
+);
+
+CREATE TABLE user_roles (
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id INT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role_id)
+);
+
+CREATE INDEX idx_user_roles_role_id ON user_roles(role_id);
+
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'HQ administrator with access to every endpoint'),
+    ('store_manager', 'Manages inventory, sales and staff of a store'),
+    ('cashier', 'Records sales at the point of sale');
+
+INSERT INTO permissions (name, description) VALUES
+    ('inventory:read', 'View products and stock'),
+    ('inventory:write', 'Change products and stock'),
+    ('sales:read', 'View sales'),
+    ('sales:write', 'Record sales'),
+    ('users:read', 'View users and their roles'),
+    ('users:unlock', 'Unlock accounts locked after failed logins'),
+    ('roles:manage', 'Assign and remove user roles');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r CROSS JOIN permissions p
+WHERE r.name = 'admin'
+UNION ALL
+SELECT r.id, p.id FROM roles r JOIN permissions p
+    ON p.name IN ('inventory:read', 'inventory:write', 'sales:read', 'sales:write', 'users:read', 'users:unlock')
+WHERE r.name = 'store_manager'
+UNION ALL
+SELECT r.id, p.id FROM roles r JOIN permissions p
+    ON p.name IN ('inventory:read', 'sales:read', 'sales:write')
+WHERE r.name = 'cashier';
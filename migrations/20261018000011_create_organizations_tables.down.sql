@@ -0,0 +1,7 @@
+ALTER TABLE api_keys DROP COLUMN IF EXISTS organization_id;
+DROP TABLE IF EXISTS organization_invitations;
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
+DELETE FROM permissions WHERE name = 'members:manage';
+DELETE FROM roles WHERE name = 'owner';
+ALTER TABLE roles DROP COLUMN IF EXISTS organization_role;
@@ -0,0 +1,48 @@
+CREATE TABLE organizations (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Only organization roles can be given to members, so global roles such as admin stay global
+ALTER TABLE roles ADD COLUMN organization_role BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE organization_members (
+    organization_id INT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    role_id INT NOT NULL REFERENCES roles(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (organization_id, user_id)
+);
+
+CREATE INDEX idx_organization_members_user_id ON organization_members(user_id);
+
+-- The invitation code itself is an activation token of type organization-invitation:<id>
+CREATE TABLE organization_invitations (
+    id SERIAL PRIMARY KEY,
+    organization_id INT NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    role_id INT NOT NULL REFERENCES roles(id),
+    invited_by INT REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (organization_id, email)
+);
+
+ALTER TABLE api_keys ADD COLUMN organization_id INT REFERENCES organizations(id) ON DELETE CASCADE;
+
+INSERT INTO roles (name, description, organization_role) VALUES
+    ('owner', 'Owns an organization and manages its members', TRUE);
+
+UPDATE roles SET organization_role = TRUE WHERE name IN ('store_manager', 'cashier');
+
+INSERT INTO permissions (name, description) VALUES
+    ('members:manage', 'Invite, remove and change the role of organization members');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p
+    ON p.name IN ('inventory:read', 'inventory:write', 'sales:read', 'sales:write', 'members:manage')
+WHERE r.name = 'owner'
+UNION ALL
+SELECT r.id, p.id FROM roles r JOIN permissions p
+    ON p.name = 'members:manage'
+WHERE r.name = 'admin';
@@ -0,0 +1,3 @@
+ALTER TABLE organization_invitations DROP COLUMN IF EXISTS sent_at;
+ALTER TABLE organization_invitations DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE organization_invitations DROP COLUMN IF EXISTS token_hash;
@@ -0,0 +1,6 @@
+-- Invitations are accepted with a signed link instead of an activation code. Only the hash of
+-- the latest token is kept, so resending or revoking invalidates earlier links. Invitations sent
+-- before get an empty hash and are already expired, so they stay listed until resent or revoked
+ALTER TABLE organization_invitations ADD COLUMN token_hash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE organization_invitations ADD COLUMN expires_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE organization_invitations ADD COLUMN sent_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
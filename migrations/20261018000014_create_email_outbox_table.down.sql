@@ -0,0 +1,2 @@
+DELETE FROM permissions WHERE name = 'emails:manage';
+DROP TABLE IF EXISTS email_outbox;
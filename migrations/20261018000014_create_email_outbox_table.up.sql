@@ -0,0 +1,26 @@
+-- Emails are written here in the transaction that creates the token or invitation they carry
+-- and delivered by a background worker
+CREATE TABLE email_outbox (
+    id SERIAL PRIMARY KEY,
+    recipients TEXT[] NOT NULL,
+    subject TEXT NOT NULL,
+    html TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    attempts INT NOT NULL DEFAULT 0,
+    last_error TEXT,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    locked_until TIMESTAMP,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_email_outbox_due ON email_outbox(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_email_outbox_status_created_at ON email_outbox(status, created_at);
+
+INSERT INTO permissions (name, description) VALUES
+    ('emails:manage', 'Inspect and retry outgoing emails');
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id FROM roles r JOIN permissions p
+    ON p.name = 'emails:manage'
+WHERE r.name = 'admin';
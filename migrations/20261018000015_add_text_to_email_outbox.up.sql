@@ -0,0 +1,2 @@
+-- Plain-text alternative sent next to the HTML body
+ALTER TABLE email_outbox ADD COLUMN text TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- Language of the emails sent to the user, empty follows the Accept-Language header
+ALTER TABLE users ADD COLUMN locale VARCHAR(35) NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+DROP TABLE IF EXISTS email_suppressions;
+
+DROP INDEX IF EXISTS idx_email_outbox_provider_message_id;
+
+ALTER TABLE email_outbox
+    DROP COLUMN IF EXISTS provider_message_id,
+    DROP COLUMN IF EXISTS delivery_status,
+    DROP COLUMN IF EXISTS delivery_detail,
+    DROP COLUMN IF EXISTS delivery_updated_at;
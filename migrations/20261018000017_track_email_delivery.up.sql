@@ -0,0 +1,19 @@
+-- Outcome reported by the mail provider's webhook, matched by the id it gave the sent email
+ALTER TABLE email_outbox
+    ADD COLUMN provider_message_id VARCHAR(255),
+    ADD COLUMN delivery_status VARCHAR(20),
+    ADD COLUMN delivery_detail TEXT,
+    ADD COLUMN delivery_updated_at TIMESTAMP;
+
+CREATE UNIQUE INDEX idx_email_outbox_provider_message_id ON email_outbox(provider_message_id)
+    WHERE provider_message_id IS NOT NULL;
+
+-- Addresses that hard bounced or complained, which are never sent to again
+CREATE TABLE email_suppressions (
+    email VARCHAR(255) PRIMARY KEY,
+    reason VARCHAR(20) NOT NULL,
+    detail TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_email_suppressions_created_at ON email_suppressions(created_at);
@@ -0,0 +1,23 @@
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT organization_role.id, rp.permission_id
+FROM roles organization_role
+JOIN roles global_role ON global_role.name = organization_role.name AND NOT global_role.organization_role
+JOIN role_permissions rp ON rp.role_id = global_role.id
+WHERE organization_role.organization_role
+ON CONFLICT DO NOTHING;
+
+INSERT INTO user_roles (user_id, role_id, created_at)
+SELECT ur.user_id, organization_role.id, ur.created_at
+FROM user_roles ur
+JOIN roles global_role ON global_role.id = ur.role_id AND NOT global_role.organization_role
+JOIN roles organization_role ON organization_role.name = global_role.name AND organization_role.organization_role
+ON CONFLICT DO NOTHING;
+
+DELETE FROM roles global_role
+USING roles organization_role
+WHERE organization_role.name = global_role.name
+  AND organization_role.organization_role
+  AND NOT global_role.organization_role;
+
+ALTER TABLE roles DROP CONSTRAINT roles_name_organization_role_key;
+ALTER TABLE roles ADD CONSTRAINT roles_name_key UNIQUE (name);
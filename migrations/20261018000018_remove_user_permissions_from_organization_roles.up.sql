@@ -0,0 +1,31 @@
+-- Roles given both globally and within organizations get a separate global row, so that
+-- narrowing the organization role leaves the global one untouched
+ALTER TABLE roles DROP CONSTRAINT roles_name_key;
+ALTER TABLE roles ADD CONSTRAINT roles_name_organization_role_key UNIQUE (name, organization_role);
+
+INSERT INTO roles (name, description, organization_role)
+SELECT name, description, FALSE FROM roles WHERE organization_role AND name <> 'owner';
+
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT global_role.id, rp.permission_id
+FROM roles global_role
+JOIN roles organization_role ON organization_role.name = global_role.name AND organization_role.organization_role
+JOIN role_permissions rp ON rp.role_id = organization_role.id
+WHERE NOT global_role.organization_role;
+
+UPDATE user_roles ur
+SET role_id = global_role.id
+FROM roles organization_role, roles global_role
+WHERE ur.role_id = organization_role.id
+  AND organization_role.organization_role
+  AND global_role.name = organization_role.name
+  AND NOT global_role.organization_role;
+
+-- Organization roles are granted by members who manage an organization, so they must not
+-- carry permissions over user accounts, which are global
+DELETE FROM role_permissions rp
+USING roles r, permissions p
+WHERE rp.role_id = r.id
+  AND rp.permission_id = p.id
+  AND r.organization_role
+  AND p.name LIKE 'users:%';
@@ -0,0 +1,115 @@
+package migrations_test
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestMigrate prepares migrations for the disposable database in TEST_DATABASE_URL and
+// rolls every migration back when the test ends
+func newTestMigrate(t *testing.T) (*migrate.Migrate, *sql.DB) {
+	databaseURL := os.Getenv("TEST_DATABASE_URL")
+	if databaseURL == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", databaseURL)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	require.NoError(t, err)
+	m, err := migrate.NewWithDatabaseInstance("file://.", "postgres", driver)
+	require.NoError(t, err)
+
+	t.Cleanup(func() {
+		if err := m.Down(); err != nil && err != migrate.ErrNoChange {
+			t.Error(err)
+		}
+	})
+	return m, db
+}
+
+// migrateTestDatabase runs every migration against the test database
+func migrateTestDatabase(t *testing.T) *sql.DB {
+	m, db := newTestMigrate(t)
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func rolePermissions(t *testing.T, db *sql.DB, role string, organizationRole bool) []string {
+	var permissions []string
+	err := db.QueryRow(`
+		SELECT COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		WHERE r.name = $1 AND r.organization_role = $2
+		GROUP BY r.id`,
+		role, organizationRole).Scan(pq.Array(&permissions))
+	require.NoError(t, err)
+	return permissions
+}
+
+func TestOrganizationRolePermissions(t *testing.T) {
+	db := migrateTestDatabase(t)
+
+	t.Run("global store_manager keeps user permissions", func(t *testing.T) {
+		permissions := rolePermissions(t, db, "store_manager", false)
+		assert.Contains(t, permissions, "users:read")
+		assert.Contains(t, permissions, "users:unlock")
+	})
+
+	t.Run("organization store_manager has no user permissions", func(t *testing.T) {
+		permissions := rolePermissions(t, db, "store_manager", true)
+		assert.NotContains(t, permissions, "users:read")
+		assert.NotContains(t, permissions, "users:unlock")
+		assert.Contains(t, permissions, "inventory:write")
+	})
+
+	t.Run("cashier exists globally and within organizations", func(t *testing.T) {
+		assert.Equal(t, rolePermissions(t, db, "cashier", false), rolePermissions(t, db, "cashier", true))
+	})
+
+	t.Run("owner is only an organization role", func(t *testing.T) {
+		var count int
+		require.NoError(t, db.QueryRow(`SELECT COUNT(*) FROM roles WHERE name = 'owner' AND NOT organization_role`).Scan(&count))
+		assert.Zero(t, count)
+	})
+}
+
+func TestOrganizationInvitationTokens(t *testing.T) {
+	m, db := newTestMigrate(t)
+	require.NoError(t, m.Migrate(20261018000011))
+
+	_, err := db.Exec(`
+		WITH organization AS (INSERT INTO organizations (name) VALUES ('Store') RETURNING id)
+		INSERT INTO organization_invitations (organization_id, email, role_id)
+		SELECT organization.id, 'invited@example.com', r.id
+		FROM organization, roles r
+		WHERE r.name = 'cashier'`)
+	require.NoError(t, err)
+
+	require.NoError(t, m.Migrate(20261018000012))
+
+	// Invitations sent before signed links are kept, but cannot be accepted until resent
+	var tokenHash string
+	var expired bool
+	err = db.QueryRow(`
+		SELECT token_hash, expires_at <= NOW()
+		FROM organization_invitations
+		WHERE email = 'invited@example.com'`).Scan(&tokenHash, &expired)
+	require.NoError(t, err)
+	assert.Empty(t, tokenHash)
+	assert.True(t, expired)
+}
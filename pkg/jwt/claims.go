@@ -0,0 +1,66 @@
+package jwt
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ErrClaimsNotObject is returned when the application claims do not encode to a JSON object
+var ErrClaimsNotObject = errors.New("jwt: custom claims must encode to a JSON object")
+
+// Claims are the claims of a token issued by Service: the registered claims, the
+// token type and the application claims C. The fields of C are stored next to the
+// registered claims so tokens stay flat, which means C must encode to a JSON object
+// and must not use the names iss, sub, aud, exp, nbf, iat, jti or type_token.
+type Claims[C any] struct {
+	jwt.RegisteredClaims
+	TypeToken string
+	Custom    C
+}
+
+// baseClaims is the JSON layout of the claims every token carries
+type baseClaims struct {
+	TypeToken string `json:"type_token"`
+	jwt.RegisteredClaims
+}
+
+func (c Claims[C]) MarshalJSON() ([]byte, error) {
+	base, err := json.Marshal(baseClaims{TypeToken: c.TypeToken, RegisteredClaims: c.RegisteredClaims})
+	if err != nil {
+		return nil, err
+	}
+	custom, err := json.Marshal(c.Custom)
+	if err != nil {
+		return nil, err
+	}
+
+	custom = bytes.TrimSpace(custom)
+	if bytes.Equal(custom, []byte("null")) || bytes.Equal(custom, []byte("{}")) {
+		return base, nil
+	}
+	if len(custom) < 2 || custom[0] != '{' {
+		return nil, ErrClaimsNotObject
+	}
+
+	// Splice the members of both objects into one
+	merged := make([]byte, 0, len(base)+len(custom))
+	merged = append(merged, base[:len(base)-1]...)
+	merged = append(merged, ',')
+	return append(merged, custom[1:]...), nil
+}
+
+func (c *Claims[C]) UnmarshalJSON(data []byte) error {
+	var base baseClaims
+	if err := json.Unmarshal(data, &base); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &c.Custom); err != nil {
+		return err
+	}
+	c.TypeToken = base.TypeToken
+	c.RegisteredClaims = base.RegisteredClaims
+	return nil
+}
@@ -0,0 +1,102 @@
+package jwt
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// Denylist stores the jti of revoked tokens until they expire
+type Denylist interface {
+	// Revoke rejects the token ID until expiresAt
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	// IsRevoked reports whether the token ID was revoked and has not expired yet
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+// pruneThreshold is the number of stored IDs above which expired IDs are swept
+const pruneThreshold = 10000
+
+// MemoryDenylist keeps revoked IDs in process memory. It only covers a single
+// API instance; use PostgresDenylist when running several replicas.
+type MemoryDenylist struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+func NewMemoryDenylist() *MemoryDenylist {
+	return &MemoryDenylist{
+		now:     time.Now,
+		revoked: make(map[string]time.Time),
+	}
+}
+
+// WithClock replaces the time source, used by tests
+func (d *MemoryDenylist) WithClock(now func() time.Time) *MemoryDenylist {
+	d.now = now
+	return d
+}
+
+func (d *MemoryDenylist) Revoke(_ context.Context, tokenID string, expiresAt time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	if len(d.revoked) >= pruneThreshold {
+		for id, expiry := range d.revoked {
+			if !now.Before(expiry) {
+				delete(d.revoked, id)
+			}
+		}
+	}
+	d.revoked[tokenID] = expiresAt
+	return nil
+}
+
+func (d *MemoryDenylist) IsRevoked(_ context.Context, tokenID string) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	expiresAt, ok := d.revoked[tokenID]
+	return ok && d.now().Before(expiresAt), nil
+}
+
+// PostgresDenylist stores revoked IDs in the revoked_tokens table so every API
+// replica rejects them
+type PostgresDenylist struct {
+	db *sql.DB
+}
+
+func NewPostgresDenylist(db *sql.DB) *PostgresDenylist {
+	return &PostgresDenylist{db: db}
+}
+
+func (d *PostgresDenylist) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	_, err := d.db.ExecContext(ctx, `
+		INSERT INTO revoked_tokens (token_id, expires_at)
+		VALUES ($1, to_timestamp($2)::timestamp)
+		ON CONFLICT (token_id) DO NOTHING`,
+		tokenID, expiresAt.Unix())
+	if err != nil {
+		return err
+	}
+
+	// Revocations are rare, so expired rows are swept on every one
+	_, err = d.db.ExecContext(ctx, `DELETE FROM revoked_tokens WHERE expires_at <= NOW()::timestamp`)
+	return err
+}
+
+func (d *PostgresDenylist) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	var revoked bool
+	err := d.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM revoked_tokens
+			WHERE token_id = $1 AND expires_at > NOW()::timestamp
+		)`,
+		tokenID,
+	).Scan(&revoked)
+	return revoked, err
+}
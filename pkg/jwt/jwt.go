@@ -1,24 +1,45 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"time"
 
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
 )
 
+// UserClaims are the application claims of the tokens issued by JWTService
+type UserClaims struct {
+	UserID      string   `json:"user_id"`
+	Email       string   `json:"email"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+}
+
 // TokenClaims represents the claims in a JWT token.
 type TokenClaims struct {
-	UserID    string `json:"user_id"`
-	Email     string `json:"email"`
-	TypeToken string `json:"type_token"`
-	jwt.StandardClaims
+	UserID      string   `json:"user_id"`
+	Email       string   `json:"email"`
+	TypeToken   string   `json:"type_token"`
+	Roles       []string `json:"roles,omitempty"`
+	Permissions []string `json:"permissions,omitempty"`
+	jwt.RegisteredClaims
 }
 
+// JWTService is the user token API kept on top of Service[UserClaims] for
+// existing callers. New modules needing other claims should use NewService.
 type JWTService interface {
 	GenerateAccesToken(userID, email string) (string, error)
+	GenerateAccessTokenWithRoles(userID, email string, roles, permissions []string) (string, error)
 	GenerateRefreshToken(userID, email string) (string, error)
 	ValidateAccessTokenClaims(token string) (*TokenClaims, error)
 	ValidateRefreshTokenClaims(token string) (*TokenClaims, error)
+	// ValidateTokenClaims validates a token against explicit options instead of the service defaults
+	ValidateTokenClaims(token string, opts ValidationOptions) (*TokenClaims, error)
+	// RevokeToken puts the jti of validated claims on the denylist until the token expires
+	RevokeToken(claims *TokenClaims) error
+	// JWKS returns the public keys verifying access tokens, empty for HMAC secrets
+	JWKS() JWKSet
 }
 
 // Berbagai konstanta dan error yang sering digunakan
@@ -41,85 +62,85 @@ const (
 )
 
 type jwtService struct {
-	accessSecret   string
-	refreshSecret  string
-	accessDuration time.Duration
-	refresDuration time.Duration
-	issuer         string
+	service Service[UserClaims]
 }
 
-func NewJWTService(accessSecret, refreshSecret string, accessDuration, refresDuration time.Duration, issuer string) JWTService {
-	if accessSecret == "" {
-		accessSecret = DefaultaccessSecret
-	}
-
-	if refreshSecret == "" {
-		refreshSecret = DefaultrefreshSecret
-	}
-
-	if accessDuration == 0 {
-		accessDuration = DefaultAccessDuration
-	}
-	if refresDuration == 0 {
-		refresDuration = DefaultRefreshDuration
-	}
-	if issuer == "" {
-		issuer = DefaultIssuer
-	}
+func NewJWTService(accessSecret, refreshSecret string, accessDuration, refresDuration time.Duration, issuer string, opts ...Option) JWTService {
 	return &jwtService{
-		accessSecret:   accessSecret,
-		refreshSecret:  refreshSecret,
-		accessDuration: accessDuration,
-		refresDuration: refresDuration,
-		issuer:         issuer,
+		service: NewService[UserClaims](accessSecret, refreshSecret, accessDuration, refresDuration, issuer, opts...),
 	}
 }
 
+// NewAsymmetricJWTService creates a JWT service signing access tokens with the
+// current key of accessKeys, so other services can verify them through the JWKS.
+// Refresh tokens are only read by this service and stay HMAC signed.
+func NewAsymmetricJWTService(accessKeys *KeySet, refreshSecret string, accessDuration, refresDuration time.Duration, issuer string, opts ...Option) JWTService {
+	opts = append([]Option{WithAccessKeySet(accessKeys)}, opts...)
+	return NewJWTService("", refreshSecret, accessDuration, refresDuration, issuer, opts...)
+}
+
 func (j *jwtService) GenerateAccesToken(userID, email string) (string, error) {
-	claims := TokenClaims{
-		UserID:    userID,
-		Email:     email,
-		TypeToken: "access",
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(j.accessDuration).Unix(),
-			IssuedAt:  time.Now().Unix(),
-			Issuer:    j.issuer,
-		},
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.accessSecret))
+	return j.GenerateAccessTokenWithRoles(userID, email, nil, nil)
+}
+
+// GenerateAccessTokenWithRoles creates an access token carrying the user's roles
+// and permissions, so authorization needs no database lookup per request
+func (j *jwtService) GenerateAccessTokenWithRoles(userID, email string, roles, permissions []string) (string, error) {
+	return j.service.GenerateAccessToken(UserClaims{
+		UserID:      userID,
+		Email:       email,
+		Roles:       roles,
+		Permissions: permissions,
+	})
 }
 
 func (j *jwtService) GenerateRefreshToken(userID, email string) (string, error) {
-	claims := TokenClaims{
-		UserID:    userID,
-		Email:     email,
-		TypeToken: "refresh",
-		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(j.refresDuration).Unix(),
-			IssuedAt:  time.Now().Unix(),
-			Issuer:    j.issuer,
-		},
-	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.refreshSecret))
+	return j.service.GenerateRefreshToken(UserClaims{
+		UserID: userID,
+		Email:  email,
+	})
 }
 
 func (j *jwtService) ValidateAccessTokenClaims(token string) (*TokenClaims, error) {
-	return j.GetTokenClaims(token, j.accessSecret)
+	return newTokenClaims(j.service.ValidateAccessToken(token))
 }
 
 func (j *jwtService) ValidateRefreshTokenClaims(token string) (*TokenClaims, error) {
-	return j.GetTokenClaims(token, j.refreshSecret)
+	return newTokenClaims(j.service.ValidateRefreshToken(token))
 }
 
-func (j *jwtService) GetTokenClaims(token string, secret string) (*TokenClaims, error) {
-	claims := &TokenClaims{}
-	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
-	})
+func (j *jwtService) ValidateTokenClaims(token string, opts ValidationOptions) (*TokenClaims, error) {
+	return newTokenClaims(j.service.ValidateToken(token, opts))
+}
+
+func (j *jwtService) RevokeToken(claims *TokenClaims) error {
+	return j.service.RevokeToken(&Claims[UserClaims]{RegisteredClaims: claims.RegisteredClaims})
+}
+
+func (j *jwtService) JWKS() JWKSet {
+	return j.service.JWKS()
+}
+
+// newTokenClaims flattens validated claims into the TokenClaims of JWTService
+func newTokenClaims(claims *Claims[UserClaims], err error) (*TokenClaims, error) {
 	if err != nil {
 		return nil, err
 	}
-	return claims, nil
+	return &TokenClaims{
+		UserID:           claims.Custom.UserID,
+		Email:            claims.Custom.Email,
+		TypeToken:        claims.TypeToken,
+		Roles:            claims.Custom.Roles,
+		Permissions:      claims.Custom.Permissions,
+		RegisteredClaims: claims.RegisteredClaims,
+	}, nil
+}
+
+// newTokenID generates a random identifier for the jti claim
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }
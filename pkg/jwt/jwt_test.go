@@ -0,0 +1,572 @@
+package jwt_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"testing"
+	"time"
+
+	jwtgo "github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/pkg/jwt"
+)
+
+func generateKeys(t *testing.T) (*rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	return rsaKey, ecKey, edKey
+}
+
+func encodePEM(blockType string, der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+func TestParseKeyPEM(t *testing.T) {
+	rsaKey, ecKey, edKey := generateKeys(t)
+	weakKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.Nil(t, err)
+
+	pkcs8 := func(key interface{}) []byte {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		assert.Nil(t, err)
+		return encodePEM("PRIVATE KEY", der)
+	}
+	pkix := func(key interface{}) []byte {
+		der, err := x509.MarshalPKIXPublicKey(key)
+		assert.Nil(t, err)
+		return encodePEM("PUBLIC KEY", der)
+	}
+	sec1, err := x509.MarshalECPrivateKey(ecKey)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name          string
+		data          []byte
+		wantAlgorithm string
+		wantCanSign   bool
+		wantErr       error
+	}{
+		{
+			name:          "RSA PKCS#8",
+			data:          pkcs8(rsaKey),
+			wantAlgorithm: "RS256",
+			wantCanSign:   true,
+		},
+		{
+			name:          "RSA PKCS#1",
+			data:          encodePEM("RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey)),
+			wantAlgorithm: "RS256",
+			wantCanSign:   true,
+		},
+		{
+			name:          "ECDSA SEC 1",
+			data:          encodePEM("EC PRIVATE KEY", sec1),
+			wantAlgorithm: "ES256",
+			wantCanSign:   true,
+		},
+		{
+			name:          "Ed25519 PKCS#8",
+			data:          pkcs8(edKey),
+			wantAlgorithm: "EdDSA",
+			wantCanSign:   true,
+		},
+		{
+			name:          "ECDSA public key",
+			data:          pkix(&ecKey.PublicKey),
+			wantAlgorithm: "ES256",
+			wantCanSign:   false,
+		},
+		{
+			name:    "RSA key shorter than 2048 bits",
+			data:    pkcs8(weakKey),
+			wantErr: jwt.ErrWeakKey,
+		},
+		{
+			name:    "no PEM data",
+			data:    []byte("not a key"),
+			wantErr: jwt.ErrNoPEMBlock,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := jwt.ParseKeyPEM(tt.data)
+			if tt.wantErr != nil {
+				assert.Equal(t, tt.wantErr, err)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.wantAlgorithm, key.Algorithm)
+			assert.Equal(t, tt.wantCanSign, key.CanSign())
+			assert.NotEqual(t, "", key.ID)
+		})
+	}
+}
+
+func TestAsymmetricJWTService(t *testing.T) {
+	rsaKey, ecKey, edKey := generateKeys(t)
+
+	tests := []struct {
+		name    string
+		key     interface{}
+		wantKty string
+	}{
+		{name: "RS256", key: rsaKey, wantKty: "RSA"},
+		{name: "ES256", key: ecKey, wantKty: "EC"},
+		{name: "EdDSA", key: edKey, wantKty: "OKP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := jwt.NewKey(tt.key)
+			assert.Nil(t, err)
+			keySet, err := jwt.NewKeySet(key)
+			assert.Nil(t, err)
+			service := jwt.NewAsymmetricJWTService(keySet, "refresh", 0, 0, "")
+
+			token, err := service.GenerateAccessTokenWithRoles("1", "user@example.com", []string{"cashier"}, []string{"sales:write"})
+			assert.Nil(t, err)
+
+			parsed, _, err := jwtgo.NewParser().ParseUnverified(token, &jwt.TokenClaims{})
+			assert.Nil(t, err)
+			assert.Equal(t, tt.name, parsed.Header["alg"])
+			assert.Equal(t, key.ID, parsed.Header["kid"])
+
+			claims, err := service.ValidateAccessTokenClaims(token)
+			assert.Nil(t, err)
+			assert.Equal(t, "1", claims.UserID)
+			assert.Equal(t, []string{"sales:write"}, claims.Permissions)
+
+			jwks := service.JWKS()
+			assert.Equal(t, 1, len(jwks.Keys))
+			assert.Equal(t, key.ID, jwks.Keys[0].KeyID)
+			assert.Equal(t, tt.wantKty, jwks.Keys[0].KeyType)
+			assert.Equal(t, tt.name, jwks.Keys[0].Algorithm)
+		})
+	}
+}
+
+func TestAsymmetricJWTService_RejectsForeignTokens(t *testing.T) {
+	rsaKey, _, _ := generateKeys(t)
+	key, err := jwt.NewKey(rsaKey)
+	assert.Nil(t, err)
+	keySet, err := jwt.NewKeySet(key)
+	assert.Nil(t, err)
+	service := jwt.NewAsymmetricJWTService(keySet, "refresh", 0, 0, "")
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	assert.Nil(t, err)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	claims := jwt.TokenClaims{
+		UserID:           "1",
+		TypeToken:        "access",
+		RegisteredClaims: jwtgo.RegisteredClaims{Issuer: jwt.DefaultIssuer, ExpiresAt: jwtgo.NewNumericDate(time.Now().Add(time.Minute))},
+	}
+
+	tests := []struct {
+		name   string
+		method jwtgo.SigningMethod
+		kid    string
+		key    interface{}
+	}{
+		{
+			name:   "HMAC signed with the public key",
+			method: jwtgo.SigningMethodHS256,
+			kid:    key.ID,
+			key:    encodePEM("PUBLIC KEY", publicDER),
+		},
+		{
+			name:   "signed by an unknown key",
+			method: jwtgo.SigningMethodRS256,
+			kid:    "unknown",
+			key:    otherKey,
+		},
+		{
+			name:   "signed by another key under a known kid",
+			method: jwtgo.SigningMethodRS256,
+			kid:    key.ID,
+			key:    otherKey,
+		},
+		{
+			name:   "HMAC signed with the access secret default",
+			method: jwtgo.SigningMethodHS256,
+			kid:    "",
+			key:    []byte(jwt.DefaultaccessSecret),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := jwtgo.NewWithClaims(tt.method, claims)
+			token.Header["kid"] = tt.kid
+			signed, err := token.SignedString(tt.key)
+			assert.Nil(t, err)
+
+			validated, err := service.ValidateAccessTokenClaims(signed)
+			assert.NotNil(t, err)
+			assert.Nil(t, validated)
+		})
+	}
+}
+
+func TestKeySet_Rotate(t *testing.T) {
+	_, ecKey, edKey := generateKeys(t)
+	oldKey, err := jwt.NewKey(ecKey)
+	assert.Nil(t, err)
+	newKey, err := jwt.NewKey(edKey)
+	assert.Nil(t, err)
+
+	now := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+	keySet, err := jwt.NewKeySet(oldKey)
+	assert.Nil(t, err)
+	keySet.WithClock(func() time.Time { return now })
+	service := jwt.NewAsymmetricJWTService(keySet, "refresh", time.Hour, 0, "")
+
+	oldToken, err := service.GenerateAccesToken("1", "user@example.com")
+	assert.Nil(t, err)
+
+	assert.Nil(t, keySet.Rotate(newKey, 15*time.Minute))
+	assert.Equal(t, newKey.ID, keySet.SigningKey().ID)
+
+	newToken, err := service.GenerateAccesToken("1", "user@example.com")
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name         string
+		after        time.Duration
+		wantOldValid bool
+		wantJWKS     []string
+	}{
+		{
+			name:         "during the grace period",
+			after:        10 * time.Minute,
+			wantOldValid: true,
+			wantJWKS:     []string{newKey.ID, oldKey.ID},
+		},
+		{
+			name:         "after the grace period",
+			after:        15 * time.Minute,
+			wantOldValid: false,
+			wantJWKS:     []string{newKey.ID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keySet.WithClock(func() time.Time { return now.Add(tt.after) })
+
+			_, err := service.ValidateAccessTokenClaims(oldToken)
+			assert.Equal(t, tt.wantOldValid, err == nil)
+			_, err = service.ValidateAccessTokenClaims(newToken)
+			assert.Nil(t, err)
+
+			kids := []string{}
+			for _, jwk := range keySet.JWKS().Keys {
+				kids = append(kids, jwk.KeyID)
+			}
+			assert.Equal(t, tt.wantJWKS, kids)
+		})
+	}
+}
+
+func TestKeySet_PublicKeyCannotSign(t *testing.T) {
+	_, ecKey, _ := generateKeys(t)
+	publicKey, err := jwt.NewKey(&ecKey.PublicKey)
+	assert.Nil(t, err)
+
+	keySet, err := jwt.NewKeySet(publicKey)
+	assert.Equal(t, jwt.ErrNoSigningKey, err)
+	assert.Nil(t, keySet)
+}
+
+func TestJWTService_ValidateTokenClaims(t *testing.T) {
+	denylist := jwt.NewMemoryDenylist()
+	// Access and refresh share a secret to prove the type claim keeps them apart
+	service := jwt.NewJWTService("secret", "secret", time.Minute, time.Hour, "retail-pro",
+		jwt.WithAudience("retail-pro-api"),
+		jwt.WithLeeway(30*time.Second),
+		jwt.WithDenylist(denylist),
+	)
+
+	accessToken, err := service.GenerateAccesToken("1", "user@example.com")
+	assert.Nil(t, err)
+	refreshToken, err := service.GenerateRefreshToken("1", "user@example.com")
+	assert.Nil(t, err)
+	revokedToken, err := service.GenerateAccesToken("1", "user@example.com")
+	assert.Nil(t, err)
+	revokedClaims, err := service.ValidateAccessTokenClaims(revokedToken)
+	assert.Nil(t, err)
+	assert.Nil(t, service.RevokeToken(revokedClaims))
+
+	sign := func(method jwtgo.SigningMethod, claims jwt.TokenClaims) string {
+		signed, err := jwtgo.NewWithClaims(method, claims).SignedString([]byte("secret"))
+		assert.Nil(t, err)
+		return signed
+	}
+	accessClaims := func(expiresIn time.Duration) jwt.TokenClaims {
+		return jwt.TokenClaims{
+			UserID:    "1",
+			TypeToken: jwt.TokenTypeAccess,
+			RegisteredClaims: jwtgo.RegisteredClaims{
+				Audience:  jwtgo.ClaimStrings{"retail-pro-api"},
+				Issuer:    "retail-pro",
+				ExpiresAt: jwtgo.NewNumericDate(time.Now().Add(expiresIn)),
+			},
+		}
+	}
+	otherAudience := accessClaims(time.Minute)
+	otherAudience.Audience = jwtgo.ClaimStrings{"other-api"}
+	otherIssuer := accessClaims(time.Minute)
+	otherIssuer.Issuer = "other"
+	noneToken, err := jwtgo.NewWithClaims(jwtgo.SigningMethodNone, accessClaims(time.Minute)).SignedString(jwtgo.UnsafeAllowNoneSignatureType)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name      string
+		token     string
+		tokenType string
+		wantErr   bool
+	}{
+		{name: "access token", token: accessToken, tokenType: jwt.TokenTypeAccess},
+		{name: "refresh token", token: refreshToken, tokenType: jwt.TokenTypeRefresh},
+		{name: "access token used as refresh token", token: accessToken, tokenType: jwt.TokenTypeRefresh, wantErr: true},
+		{name: "refresh token used as access token", token: refreshToken, tokenType: jwt.TokenTypeAccess, wantErr: true},
+		{name: "other audience", token: sign(jwtgo.SigningMethodHS256, otherAudience), tokenType: jwt.TokenTypeAccess, wantErr: true},
+		{name: "other issuer", token: sign(jwtgo.SigningMethodHS256, otherIssuer), tokenType: jwt.TokenTypeAccess, wantErr: true},
+		{name: "algorithm other than HS256", token: sign(jwtgo.SigningMethodHS512, accessClaims(time.Minute)), tokenType: jwt.TokenTypeAccess, wantErr: true},
+		{name: "unsigned token", token: noneToken, tokenType: jwt.TokenTypeAccess, wantErr: true},
+		{name: "expired within leeway", token: sign(jwtgo.SigningMethodHS256, accessClaims(-10*time.Second)), tokenType: jwt.TokenTypeAccess},
+		{name: "expired beyond leeway", token: sign(jwtgo.SigningMethodHS256, accessClaims(-time.Minute)), tokenType: jwt.TokenTypeAccess, wantErr: true},
+		{name: "revoked token", token: revokedToken, tokenType: jwt.TokenTypeAccess, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var claims *jwt.TokenClaims
+			var err error
+			if tt.tokenType == jwt.TokenTypeAccess {
+				claims, err = service.ValidateAccessTokenClaims(tt.token)
+			} else {
+				claims, err = service.ValidateRefreshTokenClaims(tt.token)
+			}
+
+			if tt.wantErr {
+				assert.NotNil(t, err)
+				assert.Nil(t, claims)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, "1", claims.UserID)
+		})
+	}
+}
+
+func TestJWTService_UniqueTokenIDs(t *testing.T) {
+	service := jwt.NewJWTService("access", "refresh", 0, 0, "")
+
+	first, err := service.GenerateAccesToken("1", "user@example.com")
+	assert.Nil(t, err)
+	second, err := service.GenerateAccesToken("1", "user@example.com")
+	assert.Nil(t, err)
+
+	firstClaims, err := service.ValidateAccessTokenClaims(first)
+	assert.Nil(t, err)
+	secondClaims, err := service.ValidateAccessTokenClaims(second)
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", firstClaims.ID)
+	assert.NotEqual(t, firstClaims.ID, secondClaims.ID)
+}
+
+func TestMemoryDenylist(t *testing.T) {
+	now := time.Date(2026, 10, 18, 8, 0, 0, 0, time.UTC)
+	denylist := jwt.NewMemoryDenylist().WithClock(func() time.Time { return now })
+	ctx := context.Background()
+
+	assert.Nil(t, denylist.Revoke(ctx, "revoked", now.Add(time.Minute)))
+	assert.Nil(t, denylist.Revoke(ctx, "expired", now))
+
+	tests := []struct {
+		name    string
+		tokenID string
+		want    bool
+	}{
+		{name: "revoked token", tokenID: "revoked", want: true},
+		{name: "revoked token past its expiry", tokenID: "expired", want: false},
+		{name: "unknown token", tokenID: "unknown", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revoked, err := denylist.IsRevoked(ctx, tt.tokenID)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, revoked)
+		})
+	}
+}
+
+type storeClaims struct {
+	UserID   string `json:"user_id"`
+	TenantID string `json:"tenant_id"`
+	StoreIDs []int  `json:"store_ids,omitempty"`
+}
+
+func TestService_CustomClaims(t *testing.T) {
+	service := jwt.NewService[storeClaims]("access", "refresh", time.Minute, time.Hour, "", jwt.WithAudience("retail-pro-api"))
+	custom := storeClaims{UserID: "1", TenantID: "acme", StoreIDs: []int{3, 7}}
+
+	accessToken, err := service.GenerateAccessToken(custom)
+	assert.Nil(t, err)
+	refreshToken, err := service.GenerateRefreshToken(custom)
+	assert.Nil(t, err)
+
+	// Application claims sit next to the registered claims instead of in a nested object
+	payload := jwtgo.MapClaims{}
+	_, _, err = jwtgo.NewParser().ParseUnverified(accessToken, payload)
+	assert.Nil(t, err)
+	assert.Equal(t, "acme", payload["tenant_id"])
+	assert.Equal(t, "access", payload["type_token"])
+	assert.Equal(t, jwt.DefaultIssuer, payload["iss"])
+
+	tests := []struct {
+		name      string
+		validate  func(string) (*jwt.Claims[storeClaims], error)
+		token     string
+		wantType  string
+		wantError bool
+	}{
+		{name: "access token", validate: service.ValidateAccessToken, token: accessToken, wantType: jwt.TokenTypeAccess},
+		{name: "refresh token", validate: service.ValidateRefreshToken, token: refreshToken, wantType: jwt.TokenTypeRefresh},
+		{name: "refresh token used as access token", validate: service.ValidateAccessToken, token: refreshToken, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.validate(tt.token)
+			if tt.wantError {
+				assert.NotNil(t, err)
+				assert.Nil(t, claims)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, custom, claims.Custom)
+			assert.Equal(t, tt.wantType, claims.TypeToken)
+			assert.Equal(t, jwtgo.ClaimStrings{"retail-pro-api"}, claims.Audience)
+			assert.NotEqual(t, "", claims.ID)
+		})
+	}
+}
+
+func TestService_ValidationErrors(t *testing.T) {
+	service := jwt.NewService[jwt.UserClaims]("secret", "secret", time.Minute, time.Hour, "")
+	guarded := jwt.NewService[jwt.UserClaims]("secret", "secret", time.Minute, time.Hour, "", jwt.WithDenylist(failingDenylist{}))
+
+	expired, err := jwtgo.NewWithClaims(jwtgo.SigningMethodHS256, jwt.TokenClaims{
+		UserID:    "1",
+		TypeToken: jwt.TokenTypeAccess,
+		RegisteredClaims: jwtgo.RegisteredClaims{
+			ID:        "expired",
+			Issuer:    jwt.DefaultIssuer,
+			ExpiresAt: jwtgo.NewNumericDate(time.Now().Add(-time.Minute)),
+		},
+	}).SignedString([]byte("secret"))
+	assert.Nil(t, err)
+	refreshToken, err := service.GenerateRefreshToken(jwt.UserClaims{UserID: "1"})
+	assert.Nil(t, err)
+	accessToken, err := service.GenerateAccessToken(jwt.UserClaims{UserID: "1"})
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name    string
+		service jwt.Service[jwt.UserClaims]
+		token   string
+		wantErr error
+	}{
+		{name: "expired token", service: service, token: expired, wantErr: jwt.ErrTokenExpired},
+		{name: "wrong token type", service: service, token: refreshToken, wantErr: jwt.ErrTokenTypeMismatch},
+		{name: "denylist unavailable", service: guarded, token: accessToken, wantErr: jwt.ErrDenylistUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := tt.service.ValidateAccessToken(tt.token)
+			assert.Nil(t, claims)
+			assert.True(t, errors.Is(err, tt.wantErr))
+		})
+	}
+}
+
+type failingDenylist struct{}
+
+func (failingDenylist) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	return errors.New("store down")
+}
+
+func (failingDenylist) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	return false, errors.New("store down")
+}
+
+func TestClaims_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		marshal func() ([]byte, error)
+		want    string
+		wantErr error
+	}{
+		{
+			name: "struct claims",
+			marshal: func() ([]byte, error) {
+				return json.Marshal(jwt.Claims[storeClaims]{TypeToken: "access", Custom: storeClaims{UserID: "1", TenantID: "acme"}})
+			},
+			want: `{"type_token":"access","user_id":"1","tenant_id":"acme"}`,
+		},
+		{
+			name: "map claims",
+			marshal: func() ([]byte, error) {
+				return json.Marshal(jwt.Claims[map[string]string]{TypeToken: "access", Custom: map[string]string{"store_id": "7"}})
+			},
+			want: `{"type_token":"access","store_id":"7"}`,
+		},
+		{
+			name: "no claims",
+			marshal: func() ([]byte, error) {
+				return json.Marshal(jwt.Claims[map[string]string]{TypeToken: "refresh"})
+			},
+			want: `{"type_token":"refresh"}`,
+		},
+		{
+			name: "claims that are not an object",
+			marshal: func() ([]byte, error) {
+				return json.Marshal(jwt.Claims[string]{TypeToken: "access", Custom: "tenant"})
+			},
+			wantErr: jwt.ErrClaimsNotObject,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := tt.marshal()
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr))
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, string(data))
+		})
+	}
+}
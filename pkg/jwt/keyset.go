@@ -0,0 +1,330 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrUnsupportedKey is returned for keys other than RSA, ECDSA P-256/P-384/P-521 and Ed25519
+	ErrUnsupportedKey = errors.New("jwt: unsupported key type")
+	// ErrWeakKey is returned for RSA keys shorter than 2048 bits
+	ErrWeakKey = errors.New("jwt: RSA key must be at least 2048 bits")
+	// ErrNoPEMBlock is returned when a key file holds no PEM data
+	ErrNoPEMBlock = errors.New("jwt: no PEM block found")
+	// ErrUnknownKeyID is returned when a token names a key that is not in the key set
+	ErrUnknownKeyID = errors.New("jwt: unknown key id")
+	// ErrNoSigningKey is returned by a key set that only holds verification keys
+	ErrNoSigningKey = errors.New("jwt: key set has no signing key")
+)
+
+const minRSABits = 2048
+
+// Key is an asymmetric key of a KeySet. Keys loaded from a public key can only verify.
+type Key struct {
+	// ID is the RFC 7638 thumbprint of the public key, sent as the kid header
+	ID        string
+	Algorithm string
+	private   crypto.PrivateKey
+	public    crypto.PublicKey
+}
+
+// NewKey wraps a private or public RSA, ECDSA or Ed25519 key
+func NewKey(key interface{}) (*Key, error) {
+	if rsaKey, ok := key.(*rsa.PrivateKey); ok && rsaKey.N.BitLen() < minRSABits {
+		return nil, ErrWeakKey
+	}
+	if rsaKey, ok := key.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < minRSABits {
+		return nil, ErrWeakKey
+	}
+
+	k := &Key{}
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		k.private, k.public = key, &key.PublicKey
+	case *ecdsa.PrivateKey:
+		k.private, k.public = key, &key.PublicKey
+	case ed25519.PrivateKey:
+		k.private, k.public = key, key.Public()
+	case *rsa.PublicKey, *ecdsa.PublicKey, ed25519.PublicKey:
+		k.public = key
+	default:
+		return nil, ErrUnsupportedKey
+	}
+
+	jwk, err := newJWK(k.public)
+	if err != nil {
+		return nil, err
+	}
+	k.Algorithm = jwk.Algorithm
+	k.ID = jwk.thumbprint()
+	return k, nil
+}
+
+// ParseKeyPEM parses a PKCS#8, PKCS#1 or SEC 1 private key or a PKIX public key
+func ParseKeyPEM(data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, ErrNoPEMBlock
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("jwt: parse %s: %w", block.Type, err)
+	}
+	return NewKey(key)
+}
+
+// LoadKeyFile reads a PEM key from a file
+func LoadKeyFile(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseKeyPEM(data)
+}
+
+// CanSign reports whether the key holds private material
+func (k *Key) CanSign() bool {
+	return k.private != nil
+}
+
+func (k *Key) signingMethod() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// KeySet signs with one key and verifies with it and every previous key still in
+// its rotation grace period. It is safe for concurrent use.
+type KeySet struct {
+	mu      sync.RWMutex
+	signing *Key
+	keys    map[string]*Key
+	// retireAt is when a previous key stops being accepted, zero never
+	retireAt map[string]time.Time
+	now      func() time.Time
+}
+
+// NewKeySet creates a key set signing with the given key
+func NewKeySet(signing *Key) (*KeySet, error) {
+	if !signing.CanSign() {
+		return nil, ErrNoSigningKey
+	}
+	return &KeySet{
+		signing:  signing,
+		keys:     map[string]*Key{signing.ID: signing},
+		retireAt: map[string]time.Time{},
+		now:      time.Now,
+	}, nil
+}
+
+// WithClock replaces the clock used for grace periods, for tests
+func (s *KeySet) WithClock(now func() time.Time) *KeySet {
+	s.now = now
+	return s
+}
+
+// AddVerificationKey keeps a previous key verifiable until retireAt. A zero
+// retireAt keeps it until it is removed from the configuration.
+func (s *KeySet) AddVerificationKey(key *Key, retireAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if key.ID == s.signing.ID {
+		return
+	}
+	s.keys[key.ID] = key
+	s.retireAt[key.ID] = retireAt
+}
+
+// Rotate makes key the signing key. The old signing key stays verifiable for grace,
+// which should be at least the access token lifetime.
+func (s *KeySet) Rotate(key *Key, grace time.Duration) error {
+	if !key.CanSign() {
+		return ErrNoSigningKey
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := s.signing
+	s.signing = key
+	s.keys[key.ID] = key
+	delete(s.retireAt, key.ID)
+	if previous.ID != key.ID {
+		s.retireAt[previous.ID] = s.now().Add(grace)
+	}
+	return nil
+}
+
+// SigningKey returns the key new tokens are signed with
+func (s *KeySet) SigningKey() *Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.signing
+}
+
+// VerificationKey returns the key with the given ID if it is still accepted
+func (s *KeySet) VerificationKey(id string) (*Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.keys[id]
+	if !ok || s.retired(id) {
+		return nil, ErrUnknownKeyID
+	}
+	return key, nil
+}
+
+// JWKS returns the public keys that are still accepted, for /.well-known/jwks.json
+func (s *KeySet) JWKS() JWKSet {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := JWKSet{Keys: []JWK{}}
+	// The signing key comes first so clients caching a single key pick the current one
+	ids := []string{s.signing.ID}
+	for id := range s.keys {
+		if id != s.signing.ID {
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range ids {
+		if s.retired(id) {
+			continue
+		}
+		jwk, err := newJWK(s.keys[id].public)
+		if err != nil {
+			continue
+		}
+		jwk.KeyID = id
+		jwk.Use = "sig"
+		set.Keys = append(set.Keys, *jwk)
+	}
+	return set
+}
+
+func (s *KeySet) retired(id string) bool {
+	retireAt, ok := s.retireAt[id]
+	return ok && !retireAt.IsZero() && !s.now().Before(retireAt)
+}
+
+// JWKSet is a JSON Web Key Set as defined by RFC 7517
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is the public part of a key as defined by RFC 7517
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg"`
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+	// ECDSA and Ed25519
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+}
+
+func newJWK(public crypto.PublicKey) (*JWK, error) {
+	encode := base64.RawURLEncoding.EncodeToString
+
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		return &JWK{
+			KeyType:   "RSA",
+			Algorithm: jwt.SigningMethodRS256.Alg(),
+			N:         encode(public.N.Bytes()),
+			E:         encode(big.NewInt(int64(public.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		var algorithm string
+		switch public.Curve {
+		case elliptic.P256():
+			algorithm = jwt.SigningMethodES256.Alg()
+		case elliptic.P384():
+			algorithm = jwt.SigningMethodES384.Alg()
+		case elliptic.P521():
+			algorithm = jwt.SigningMethodES512.Alg()
+		default:
+			return nil, ErrUnsupportedKey
+		}
+		size := (public.Curve.Params().BitSize + 7) / 8
+		return &JWK{
+			KeyType:   "EC",
+			Algorithm: algorithm,
+			Curve:     public.Curve.Params().Name,
+			X:         encode(public.X.FillBytes(make([]byte, size))),
+			Y:         encode(public.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return &JWK{
+			KeyType:   "OKP",
+			Algorithm: jwt.SigningMethodEdDSA.Alg(),
+			Curve:     "Ed25519",
+			X:         encode(public),
+		}, nil
+	}
+	return nil, ErrUnsupportedKey
+}
+
+// thumbprint computes the RFC 7638 thumbprint from the required members in lexical order
+func (j *JWK) thumbprint() string {
+	var members interface{}
+	switch j.KeyType {
+	case "RSA":
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.KeyType, j.N}
+	case "EC":
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{j.Curve, j.KeyType, j.X, j.Y}
+	default:
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Curve, j.KeyType, j.X}
+	}
+
+	data, _ := json.Marshal(members)
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,295 @@
+package jwt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	// ErrTokenExpired is returned, wrapped, for tokens past their exp claim
+	ErrTokenExpired = jwt.ErrTokenExpired
+	// ErrTokenTypeMismatch is returned when the type_token claim is not the expected type
+	ErrTokenTypeMismatch = errors.New("jwt: token type mismatch")
+	// ErrTokenRevoked is returned for tokens whose jti is on the denylist
+	ErrTokenRevoked = errors.New("jwt: token is revoked")
+	// ErrDenylistUnavailable is returned, wrapped, when the denylist could not be checked
+	ErrDenylistUnavailable = errors.New("jwt: denylist unavailable")
+)
+
+// Service issues and validates access and refresh tokens carrying the application
+// claims C, so modules can add tenant IDs, roles or store IDs to their tokens
+type Service[C any] interface {
+	GenerateAccessToken(custom C) (string, error)
+	GenerateRefreshToken(custom C) (string, error)
+	ValidateAccessToken(token string) (*Claims[C], error)
+	ValidateRefreshToken(token string) (*Claims[C], error)
+	// ValidateToken validates a token against explicit options instead of the service defaults
+	ValidateToken(token string, opts ValidationOptions) (*Claims[C], error)
+	// RevokeToken puts the jti of validated claims on the denylist until the token expires
+	RevokeToken(claims *Claims[C]) error
+	// JWKS returns the public keys verifying access tokens, empty for HMAC secrets
+	JWKS() JWKSet
+}
+
+// Token types stored in the type_token claim
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// ValidationOptions are the checks applied to a token on top of its signature
+type ValidationOptions struct {
+	// TokenType is the required type_token claim and selects the verifying key
+	TokenType string
+	// Audience must equal the aud claim when set
+	Audience string
+	// Issuer must equal the iss claim when set
+	Issuer string
+	// Algorithms limits the accepted alg headers. HMAC tokens default to HS256,
+	// asymmetric tokens are always pinned to the algorithm of their key.
+	Algorithms []string
+	// Leeway tolerates clock skew between servers when checking exp, nbf and iat
+	Leeway time.Duration
+}
+
+// Option configures a JWT service
+type Option func(*options)
+
+type options struct {
+	// accessKeys signs access tokens with asymmetric keys instead of the access secret
+	accessKeys *KeySet
+	audience   string
+	leeway     time.Duration
+	denylist   Denylist
+}
+
+// WithAccessKeySet signs access tokens with the current key of accessKeys, so
+// other services can verify them through the JWKS
+func WithAccessKeySet(accessKeys *KeySet) Option {
+	return func(o *options) {
+		o.accessKeys = accessKeys
+	}
+}
+
+// WithAudience sets the aud claim of new tokens and requires it on validation
+func WithAudience(audience string) Option {
+	return func(o *options) {
+		o.audience = audience
+	}
+}
+
+// WithLeeway tolerates clock skew when validating time claims
+func WithLeeway(leeway time.Duration) Option {
+	return func(o *options) {
+		o.leeway = leeway
+	}
+}
+
+// WithDenylist rejects tokens whose jti was revoked
+func WithDenylist(denylist Denylist) Option {
+	return func(o *options) {
+		o.denylist = denylist
+	}
+}
+
+type service[C any] struct {
+	options
+	accessSecret    string
+	refreshSecret   string
+	accessDuration  time.Duration
+	refreshDuration time.Duration
+	issuer          string
+}
+
+// NewService creates a JWT service for the application claims C. Empty secrets,
+// durations and issuer fall back to the package defaults.
+func NewService[C any](accessSecret, refreshSecret string, accessDuration, refreshDuration time.Duration, issuer string, opts ...Option) Service[C] {
+	if accessSecret == "" {
+		accessSecret = DefaultaccessSecret
+	}
+	if refreshSecret == "" {
+		refreshSecret = DefaultrefreshSecret
+	}
+	if accessDuration == 0 {
+		accessDuration = DefaultAccessDuration
+	}
+	if refreshDuration == 0 {
+		refreshDuration = DefaultRefreshDuration
+	}
+	if issuer == "" {
+		issuer = DefaultIssuer
+	}
+
+	s := &service[C]{
+		accessSecret:    accessSecret,
+		refreshSecret:   refreshSecret,
+		accessDuration:  accessDuration,
+		refreshDuration: refreshDuration,
+		issuer:          issuer,
+	}
+	for _, opt := range opts {
+		opt(&s.options)
+	}
+	return s
+}
+
+func (s *service[C]) GenerateAccessToken(custom C) (string, error) {
+	claims, err := s.newClaims(TokenTypeAccess, s.accessDuration, custom)
+	if err != nil {
+		return "", err
+	}
+
+	if s.accessKeys != nil {
+		key := s.accessKeys.SigningKey()
+		token := jwt.NewWithClaims(key.signingMethod(), claims)
+		token.Header["kid"] = key.ID
+		return token.SignedString(key.private)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.accessSecret))
+}
+
+// GenerateRefreshToken creates a refresh token. Refresh tokens are only read by
+// this service and stay HMAC signed.
+func (s *service[C]) GenerateRefreshToken(custom C) (string, error) {
+	claims, err := s.newClaims(TokenTypeRefresh, s.refreshDuration, custom)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.refreshSecret))
+}
+
+func (s *service[C]) newClaims(tokenType string, duration time.Duration, custom C) (*Claims[C], error) {
+	// Every token gets a unique ID so it can be tracked server-side and put on the denylist
+	tokenID, err := newTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	claims := &Claims[C]{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        tokenID,
+			Issuer:    s.issuer,
+			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+		TypeToken: tokenType,
+		Custom:    custom,
+	}
+	if s.audience != "" {
+		claims.Audience = jwt.ClaimStrings{s.audience}
+	}
+	return claims, nil
+}
+
+func (s *service[C]) ValidateAccessToken(token string) (*Claims[C], error) {
+	return s.ValidateToken(token, s.defaultValidationOptions(TokenTypeAccess))
+}
+
+func (s *service[C]) ValidateRefreshToken(token string) (*Claims[C], error) {
+	return s.ValidateToken(token, s.defaultValidationOptions(TokenTypeRefresh))
+}
+
+func (s *service[C]) defaultValidationOptions(tokenType string) ValidationOptions {
+	return ValidationOptions{
+		TokenType: tokenType,
+		Audience:  s.audience,
+		Issuer:    s.issuer,
+		Leeway:    s.leeway,
+	}
+}
+
+func (s *service[C]) ValidateToken(token string, opts ValidationOptions) (*Claims[C], error) {
+	var keyFunc jwt.Keyfunc
+	algorithms := opts.Algorithms
+	switch {
+	case opts.TokenType == TokenTypeAccess && s.accessKeys != nil:
+		keyFunc = s.keySetKeyFunc
+	case opts.TokenType == TokenTypeAccess:
+		keyFunc = hmacKeyFunc(s.accessSecret)
+	case opts.TokenType == TokenTypeRefresh:
+		keyFunc = hmacKeyFunc(s.refreshSecret)
+	default:
+		return nil, fmt.Errorf("jwt: unknown token type %q", opts.TokenType)
+	}
+	if len(algorithms) == 0 && (opts.TokenType == TokenTypeRefresh || s.accessKeys == nil) {
+		algorithms = []string{jwt.SigningMethodHS256.Alg()}
+	}
+
+	parserOptions := []jwt.ParserOption{
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(opts.Leeway),
+	}
+	if len(algorithms) > 0 {
+		parserOptions = append(parserOptions, jwt.WithValidMethods(algorithms))
+	}
+	if opts.Issuer != "" {
+		parserOptions = append(parserOptions, jwt.WithIssuer(opts.Issuer))
+	}
+	if opts.Audience != "" {
+		parserOptions = append(parserOptions, jwt.WithAudience(opts.Audience))
+	}
+
+	claims := &Claims[C]{}
+	if _, err := jwt.ParseWithClaims(token, claims, keyFunc, parserOptions...); err != nil {
+		return nil, err
+	}
+	if claims.TypeToken != opts.TokenType {
+		return nil, ErrTokenTypeMismatch
+	}
+
+	if s.denylist != nil && claims.ID != "" {
+		revoked, err := s.denylist.IsRevoked(context.Background(), claims.ID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrDenylistUnavailable, err)
+		}
+		if revoked {
+			return nil, ErrTokenRevoked
+		}
+	}
+	return claims, nil
+}
+
+func hmacKeyFunc(secret string) jwt.Keyfunc {
+	return func(t *jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	}
+}
+
+// keySetKeyFunc returns the key named by the kid header. The algorithm must
+// match the key so a public key is never used as an HMAC secret.
+func (s *service[C]) keySetKeyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, err := s.accessKeys.VerificationKey(kid)
+	if err != nil {
+		return nil, err
+	}
+	if t.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("jwt: unexpected signing method %s", t.Method.Alg())
+	}
+	return key.public, nil
+}
+
+func (s *service[C]) RevokeToken(claims *Claims[C]) error {
+	// Tokens issued before jti was added cannot be revoked and simply expire
+	if s.denylist == nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+	return s.denylist.Revoke(context.Background(), claims.ID, claims.ExpiresAt.Time)
+}
+
+func (s *service[C]) JWKS() JWKSet {
+	if s.accessKeys == nil {
+		return JWKSet{Keys: []JWK{}}
+	}
+	return s.accessKeys.JWKS()
+}
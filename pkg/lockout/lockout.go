@@ -0,0 +1,107 @@
+package lockout
+
+import (
+	"context"
+	"time"
+)
+
+// Policy describes how failed attempts slow down and eventually lock a key
+type Policy struct {
+	// Threshold is the number of failures that locks the key
+	Threshold int
+	// BaseDelay is the wait after the first failure, doubled for every further failure
+	BaseDelay time.Duration
+	// MaxDelay caps the exponential backoff
+	MaxDelay time.Duration
+	// LockoutDuration is how long a key stays locked once the threshold is reached
+	LockoutDuration time.Duration
+	// Window is how long a failure is remembered; older failures are forgotten
+	Window time.Duration
+}
+
+// State is the stored attempt history of a key
+type State struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+// Status tells whether a key may attempt again
+type Status struct {
+	Allowed    bool
+	Locked     bool
+	RetryAfter time.Duration
+	// JustLocked is set by RecordFailure when this failure locked the key
+	JustLocked bool
+}
+
+// Tracker counts failed attempts per key
+type Tracker interface {
+	// Check returns whether the key may attempt now
+	Check(ctx context.Context, key string) (Status, error)
+	// RecordFailure counts a failed attempt and returns the resulting status
+	RecordFailure(ctx context.Context, key string) (Status, error)
+	// Reset forgets the key, after a successful attempt or an admin unlock
+	Reset(ctx context.Context, key string) error
+}
+
+// Evaluate returns the status of a key with the given state at time now
+func (p Policy) Evaluate(state State, now time.Time) Status {
+	if now.Before(state.LockedUntil) {
+		return Status{Locked: true, RetryAfter: state.LockedUntil.Sub(now)}
+	}
+
+	if state.Failures == 0 || p.expired(state, now) {
+		return Status{Allowed: true}
+	}
+
+	nextAttempt := state.LastFailure.Add(p.delay(state.Failures))
+	if now.Before(nextAttempt) {
+		return Status{RetryAfter: nextAttempt.Sub(now)}
+	}
+	return Status{Allowed: true}
+}
+
+// Apply records a failure at time now and returns the new state and status
+func (p Policy) Apply(state State, now time.Time) (State, Status) {
+	lockoutEnded := !state.LockedUntil.IsZero() && !now.Before(state.LockedUntil)
+	if lockoutEnded || p.expired(state, now) {
+		// A finished lockout or an expired window starts a new series of failures
+		state = State{}
+	}
+
+	state.Failures++
+	state.LastFailure = now
+
+	if p.Threshold > 0 && state.Failures >= p.Threshold {
+		state.LockedUntil = now.Add(p.LockoutDuration)
+		status := p.Evaluate(state, now)
+		status.JustLocked = true
+		return state, status
+	}
+	return state, p.Evaluate(state, now)
+}
+
+// Stale reports whether the state no longer affects any attempt and can be removed
+func (p Policy) Stale(state State, now time.Time) bool {
+	return !now.Before(state.LockedUntil) && p.expired(state, now)
+}
+
+func (p Policy) expired(state State, now time.Time) bool {
+	return p.Window > 0 && now.Sub(state.LastFailure) > p.Window
+}
+
+// delay returns BaseDelay * 2^(failures-1), capped at MaxDelay
+func (p Policy) delay(failures int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,163 @@
+package lockout_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/pkg/lockout"
+)
+
+var testPolicy = lockout.Policy{
+	Threshold:       4,
+	BaseDelay:       time.Second,
+	MaxDelay:        4 * time.Second,
+	LockoutDuration: 15 * time.Minute,
+	Window:          time.Hour,
+}
+
+func TestPolicyEvaluate(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name            string
+		state           lockout.State
+		expectedAllowed bool
+		expectedLocked  bool
+		expectedRetry   time.Duration
+	}{
+		{
+			name:            "no failures",
+			state:           lockout.State{},
+			expectedAllowed: true,
+		},
+		{
+			name:          "first failure waits base delay",
+			state:         lockout.State{Failures: 1, LastFailure: now},
+			expectedRetry: time.Second,
+		},
+		{
+			name:          "third failure doubles twice",
+			state:         lockout.State{Failures: 3, LastFailure: now.Add(-time.Second)},
+			expectedRetry: 3 * time.Second,
+		},
+		{
+			name:            "delay passed",
+			state:           lockout.State{Failures: 2, LastFailure: now.Add(-2 * time.Second)},
+			expectedAllowed: true,
+		},
+		{
+			name:          "delay capped at max",
+			state:         lockout.State{Failures: 10, LastFailure: now},
+			expectedRetry: 4 * time.Second,
+		},
+		{
+			name:           "locked",
+			state:          lockout.State{Failures: 4, LastFailure: now, LockedUntil: now.Add(time.Minute)},
+			expectedLocked: true,
+			expectedRetry:  time.Minute,
+		},
+		{
+			name:            "lockout ended",
+			state:           lockout.State{Failures: 4, LastFailure: now.Add(-20 * time.Minute), LockedUntil: now.Add(-5 * time.Minute)},
+			expectedAllowed: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := testPolicy.Evaluate(tt.state, now)
+
+			assert.Equal(t, tt.expectedAllowed, status.Allowed)
+			assert.Equal(t, tt.expectedLocked, status.Locked)
+			assert.Equal(t, tt.expectedRetry, status.RetryAfter)
+		})
+	}
+}
+
+func TestPolicyApply(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name             string
+		state            lockout.State
+		expectedFailures int
+		expectedLocked   bool
+		expectedJust     bool
+	}{
+		{
+			name:             "first failure",
+			state:            lockout.State{},
+			expectedFailures: 1,
+		},
+		{
+			name:             "threshold reached",
+			state:            lockout.State{Failures: 3, LastFailure: now.Add(-time.Minute)},
+			expectedFailures: 4,
+			expectedLocked:   true,
+			expectedJust:     true,
+		},
+		{
+			name:             "window expired starts over",
+			state:            lockout.State{Failures: 3, LastFailure: now.Add(-2 * time.Hour)},
+			expectedFailures: 1,
+		},
+		{
+			name:             "failure after lockout starts over",
+			state:            lockout.State{Failures: 4, LastFailure: now.Add(-20 * time.Minute), LockedUntil: now.Add(-5 * time.Minute)},
+			expectedFailures: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state, status := testPolicy.Apply(tt.state, now)
+
+			assert.Equal(t, tt.expectedFailures, state.Failures)
+			assert.Equal(t, now, state.LastFailure)
+			assert.Equal(t, tt.expectedLocked, status.Locked)
+			assert.Equal(t, tt.expectedJust, status.JustLocked)
+		})
+	}
+}
+
+func TestMemoryTracker(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tracker := lockout.NewMemoryTracker(testPolicy).WithClock(func() time.Time { return now })
+
+	status, err := tracker.Check(ctx, "user@example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, true, status.Allowed)
+
+	// Every failure is recorded after the previous backoff has passed
+	for i := 1; i < testPolicy.Threshold; i++ {
+		status, err = tracker.RecordFailure(ctx, "user@example.com")
+		assert.Nil(t, err)
+		assert.Equal(t, false, status.Allowed)
+		assert.Equal(t, false, status.Locked)
+
+		now = now.Add(status.RetryAfter)
+		status, _ = tracker.Check(ctx, "user@example.com")
+		assert.Equal(t, true, status.Allowed)
+	}
+
+	status, err = tracker.RecordFailure(ctx, "user@example.com")
+	assert.Nil(t, err)
+	assert.Equal(t, true, status.Locked)
+	assert.Equal(t, true, status.JustLocked)
+
+	// Other keys are not affected
+	status, _ = tracker.Check(ctx, "other@example.com")
+	assert.Equal(t, true, status.Allowed)
+
+	now = now.Add(time.Minute)
+	status, _ = tracker.Check(ctx, "user@example.com")
+	assert.Equal(t, true, status.Locked)
+	assert.Equal(t, 14*time.Minute, status.RetryAfter)
+
+	assert.Nil(t, tracker.Reset(ctx, "user@example.com"))
+	status, _ = tracker.Check(ctx, "user@example.com")
+	assert.Equal(t, true, status.Allowed)
+}
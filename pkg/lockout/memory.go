@@ -0,0 +1,71 @@
+package lockout
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pruneThreshold is the number of stored keys above which stale keys are swept
+const pruneThreshold = 10000
+
+// MemoryTracker keeps attempts in process memory. It only protects a single
+// API instance; use PostgresTracker when running several replicas.
+type MemoryTracker struct {
+	policy Policy
+	now    func() time.Time
+
+	mu     sync.Mutex
+	states map[string]State
+}
+
+func NewMemoryTracker(policy Policy) *MemoryTracker {
+	return &MemoryTracker{
+		policy: policy,
+		now:    time.Now,
+		states: make(map[string]State),
+	}
+}
+
+// WithClock replaces the time source, used by tests
+func (t *MemoryTracker) WithClock(now func() time.Time) *MemoryTracker {
+	t.now = now
+	return t
+}
+
+func (t *MemoryTracker) Check(_ context.Context, key string) (Status, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.policy.Evaluate(t.states[key], t.now()), nil
+}
+
+func (t *MemoryTracker) RecordFailure(_ context.Context, key string) (Status, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if len(t.states) >= pruneThreshold {
+		t.prune(now)
+	}
+
+	state, status := t.policy.Apply(t.states[key], now)
+	t.states[key] = state
+	return status, nil
+}
+
+func (t *MemoryTracker) Reset(_ context.Context, key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.states, key)
+	return nil
+}
+
+func (t *MemoryTracker) prune(now time.Time) {
+	for key, state := range t.states {
+		if t.policy.Stale(state, now) {
+			delete(t.states, key)
+		}
+	}
+}
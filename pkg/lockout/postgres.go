@@ -0,0 +1,123 @@
+package lockout
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// PostgresTracker stores attempts in the login_attempts table so every API
+// replica sees the same counters. Scope separates trackers sharing the table,
+// e.g. "email" and "ip".
+type PostgresTracker struct {
+	db     *sql.DB
+	scope  string
+	policy Policy
+}
+
+func NewPostgresTracker(db *sql.DB, scope string, policy Policy) *PostgresTracker {
+	return &PostgresTracker{db: db, scope: scope, policy: policy}
+}
+
+func (t *PostgresTracker) key(key string) string {
+	return t.scope + ":" + key
+}
+
+// Times are read from the database clock so replicas with drifting clocks agree
+func (t *PostgresTracker) Check(ctx context.Context, key string) (Status, error) {
+	var now time.Time
+	var failures sql.NullInt64
+	var lastFailure, lockedUntil sql.NullTime
+
+	err := t.db.QueryRowContext(ctx, `
+		SELECT NOW()::timestamp, a.failures, a.last_failure_at, a.locked_until
+		FROM (SELECT 1) AS clock
+		LEFT JOIN login_attempts a ON a.key = $1`,
+		t.key(key),
+	).Scan(&now, &failures, &lastFailure, &lockedUntil)
+	if err != nil {
+		return Status{}, err
+	}
+
+	state := State{
+		Failures:    int(failures.Int64),
+		LastFailure: lastFailure.Time,
+		LockedUntil: lockedUntil.Time,
+	}
+	return t.policy.Evaluate(state, now), nil
+}
+
+func (t *PostgresTracker) RecordFailure(ctx context.Context, key string) (Status, error) {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return Status{}, err
+	}
+	defer tx.Rollback()
+
+	// Make sure the row exists so concurrent failures serialize on its lock
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO login_attempts (key, failures)
+		VALUES ($1, 0)
+		ON CONFLICT (key) DO NOTHING`,
+		t.key(key),
+	)
+	if err != nil {
+		return Status{}, err
+	}
+
+	var now time.Time
+	var state State
+	var lastFailure, lockedUntil sql.NullTime
+	err = tx.QueryRowContext(ctx, `
+		SELECT NOW()::timestamp, failures, last_failure_at, locked_until
+		FROM login_attempts
+		WHERE key = $1
+		FOR UPDATE`,
+		t.key(key),
+	).Scan(&now, &state.Failures, &lastFailure, &lockedUntil)
+	if err != nil {
+		return Status{}, err
+	}
+	state.LastFailure = lastFailure.Time
+	state.LockedUntil = lockedUntil.Time
+
+	state, status := t.policy.Apply(state, now)
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE login_attempts
+		SET failures = $2, last_failure_at = $3, locked_until = $4
+		WHERE key = $1`,
+		t.key(key), state.Failures, state.LastFailure, nullTime(state.LockedUntil),
+	)
+	if err != nil {
+		return Status{}, err
+	}
+
+	// Drop rows of this scope that no longer matter so the table stays small
+	if t.policy.Window > 0 {
+		_, err = tx.ExecContext(ctx, `
+			DELETE FROM login_attempts
+			WHERE key LIKE $1
+			AND (locked_until IS NULL OR locked_until <= $2)
+			AND last_failure_at < $3`,
+			t.scope+":%", now, now.Add(-t.policy.Window),
+		)
+		if err != nil {
+			return Status{}, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Status{}, err
+	}
+	return status, nil
+}
+
+func (t *PostgresTracker) Reset(ctx context.Context, key string) error {
+	_, err := t.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE key = $1`, t.key(key))
+	return err
+}
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
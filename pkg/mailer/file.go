@@ -0,0 +1,53 @@
+package mailer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+)
+
+type fileSender struct {
+	dir  string
+	from mail.Address
+}
+
+// NewFileSender creates a sender writing every email as an .eml file into dir,
+// which mail clients open to preview it
+func NewFileSender(dir string, from mail.Address) Sender {
+	return &fileSender{dir: dir, from: from}
+}
+
+func (s *fileSender) Send(email *Message) (string, *customerror.CustomError) {
+	recipients, cuserr := parseRecipients(email.To)
+	if cuserr != nil {
+		return "", cuserr
+	}
+
+	now := time.Now()
+	message, err := composeMessage(s.from, recipients, email, now)
+	if err != nil {
+		return "", customerror.NewCustomError(err, "Failed to send email", http.StatusInternalServerError)
+	}
+
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return "", customerror.NewCustomError(err, "Failed to send email", http.StatusInternalServerError)
+	}
+
+	// The timestamp sorts files by sending order and the suffix keeps concurrent emails apart
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", customerror.NewCustomError(err, "Failed to send email", http.StatusInternalServerError)
+	}
+	name := now.UTC().Format("20060102T150405.000000000") + "-" + hex.EncodeToString(suffix) + ".eml"
+
+	if err := os.WriteFile(filepath.Join(s.dir, name), message, 0o644); err != nil {
+		return "", customerror.NewCustomError(err, "Failed to send email", http.StatusInternalServerError)
+	}
+	return "", nil
+}
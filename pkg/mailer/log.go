@@ -0,0 +1,52 @@
+package mailer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"strings"
+	"sync"
+
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+)
+
+type logSender struct {
+	mu   sync.Mutex
+	w    io.Writer
+	from mail.Address
+}
+
+// NewLogSender creates a sender printing every email to w instead of delivering it.
+// The plain-text body is printed, or the HTML as is when there is none, so codes and
+// links can be read from the output.
+func NewLogSender(w io.Writer, from mail.Address) Sender {
+	return &logSender{w: w, from: from}
+}
+
+func (s *logSender) Send(email *Message) (string, *customerror.CustomError) {
+	recipients, cuserr := parseRecipients(email.To)
+	if cuserr != nil {
+		return "", cuserr
+	}
+
+	addresses := make([]string, 0, len(recipients))
+	for _, recipient := range recipients {
+		addresses = append(addresses, recipient.String())
+	}
+
+	body := email.Text
+	if body == "" {
+		body = email.HTML
+	}
+
+	// Emails sent concurrently are printed one after the other
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err := fmt.Fprintf(s.w, "----- email -----\nFrom: %s\nTo: %s\nSubject: %s\n\n%s\n----- end of email -----\n",
+		s.from.String(), strings.Join(addresses, ", "), email.Subject, body)
+	if err != nil {
+		return "", customerror.NewCustomError(err, "Failed to send email", http.StatusInternalServerError)
+	}
+	return "", nil
+}
@@ -0,0 +1,137 @@
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+)
+
+// Message is an email with an HTML body and an optional plain-text alternative
+type Message struct {
+	To      []string
+	Subject string
+	HTML    string
+	// Text is sent next to HTML for clients that do not display HTML, empty sends HTML only
+	Text string
+}
+
+// Sender delivers an email and returns the id the provider gave it, which its delivery
+// events refer to. Drivers without delivery events return an empty id. It has the same
+// shape as resendutils.ResendUtilsInterface, so the Resend client is a Sender too.
+type Sender interface {
+	Send(message *Message) (string, *customerror.CustomError)
+}
+
+// parseRecipients validates the recipient list so no address can inject headers
+func parseRecipients(to []string) ([]*mail.Address, *customerror.CustomError) {
+	if len(to) == 0 {
+		return nil, customerror.NewCustomError(nil, "No email recipient", http.StatusBadRequest)
+	}
+
+	addresses := make([]*mail.Address, 0, len(to))
+	for _, recipient := range to {
+		address, err := mail.ParseAddress(recipient)
+		if err != nil {
+			return nil, customerror.NewCustomError(err, "Invalid email recipient", http.StatusBadRequest)
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+// composeMessage builds an RFC 5322 message with a quoted-printable HTML body, wrapped in
+// multipart/alternative with the plain-text body first when the message has one
+func composeMessage(from mail.Address, to []*mail.Address, email *Message, now time.Time) ([]byte, error) {
+	recipients := make([]string, 0, len(to))
+	for _, address := range to {
+		recipients = append(recipients, address.String())
+	}
+
+	messageID, err := newMessageID(from.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	var message bytes.Buffer
+	headers := [][2]string{
+		{"From", from.String()},
+		{"To", strings.Join(recipients, ", ")},
+		// Non-ASCII and line breaks are encoded, so the subject cannot add headers
+		{"Subject", mime.QEncoding.Encode("utf-8", email.Subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", messageID},
+		{"MIME-Version", "1.0"},
+	}
+	for _, header := range headers {
+		fmt.Fprintf(&message, "%s: %s\r\n", header[0], header[1])
+	}
+
+	if email.Text == "" {
+		message.WriteString("Content-Type: text/html; charset=UTF-8\r\n")
+		message.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+		if err := writeQuotedPrintable(&message, email.HTML); err != nil {
+			return nil, err
+		}
+		message.WriteString("\r\n")
+		return message.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&message)
+	fmt.Fprintf(&message, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+
+	// Clients show the last alternative they support, so HTML comes after the text
+	alternatives := [][2]string{
+		{"text/plain; charset=UTF-8", email.Text},
+		{"text/html; charset=UTF-8", email.HTML},
+	}
+	for _, alternative := range alternatives {
+		part, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {alternative[0]},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(part, alternative[1]); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	message.WriteString("\r\n")
+	return message.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	encoder := quotedprintable.NewWriter(w)
+	if _, err := encoder.Write([]byte(body)); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+func newMessageID(from string) (string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", err
+	}
+
+	domain := "localhost"
+	if at := strings.LastIndex(from, "@"); at >= 0 && at < len(from)-1 {
+		domain = from[at+1:]
+	}
+	return "<" + hex.EncodeToString(random) + "@" + domain + ">", nil
+}
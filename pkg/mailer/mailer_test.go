@@ -0,0 +1,272 @@
+package mailer_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yantology/golang-starter-template/pkg/mailer"
+)
+
+var testFrom = mail.Address{Name: "Retail Pro", Address: "activation@example.com"}
+
+// smtpStandIn is a minimal SMTP server on localhost recording what it receives
+type smtpStandIn struct {
+	listener net.Listener
+	received chan smtpDelivery
+}
+
+type smtpDelivery struct {
+	auth       string
+	from       string
+	recipients []string
+	data       []byte
+}
+
+func startSMTPStandIn(t *testing.T) *smtpStandIn {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	standIn := &smtpStandIn{listener: listener, received: make(chan smtpDelivery, 1)}
+	go standIn.serve()
+	return standIn
+}
+
+func (s *smtpStandIn) port() int {
+	return s.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpStandIn) serve() {
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+	delivery := smtpDelivery{}
+	text.PrintfLine("220 localhost ESMTP stand-in")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch command {
+		case "EHLO":
+			text.PrintfLine("250-localhost")
+			text.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			decoded, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(line, "AUTH PLAIN "))
+			delivery.auth = string(decoded)
+			text.PrintfLine("235 2.7.0 Authentication successful")
+		case "MAIL":
+			delivery.from = line
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			delivery.recipients = append(delivery.recipients, line)
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			delivery.data, _ = text.ReadDotBytes()
+			text.PrintfLine("250 OK")
+			s.received <- delivery
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func decodeBody(t *testing.T, message []byte) (*mail.Message, string) {
+	parsed, err := mail.ReadMessage(bytes.NewReader(message))
+	require.NoError(t, err)
+	body, err := io.ReadAll(quotedprintable.NewReader(parsed.Body))
+	require.NoError(t, err)
+	return parsed, strings.TrimSpace(string(body))
+}
+
+func TestSMTPSender(t *testing.T) {
+	tests := []struct {
+		name          string
+		username      string
+		expectedAuth  string
+		subject       string
+		to            []string
+		expectedError bool
+	}{
+		{
+			name:    "without authentication",
+			subject: "Kode Aktivasi",
+			to:      []string{"user@example.com"},
+		},
+		{
+			name:         "with authentication",
+			username:     "mailer",
+			expectedAuth: "\x00mailer\x00secret",
+			subject:      "Kode Aktivasi",
+			to:           []string{"user@example.com", "Other User <other@example.com>"},
+		},
+		{
+			name:    "subject with line break",
+			subject: "Kode\r\nBcc: attacker@example.com",
+			to:      []string{"user@example.com"},
+		},
+		{
+			name:          "invalid recipient",
+			subject:       "Kode Aktivasi",
+			to:            []string{"user@example.com\r\nBcc: attacker@example.com"},
+			expectedError: true,
+		},
+		{
+			name:          "no recipient",
+			subject:       "Kode Aktivasi",
+			to:            []string{},
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			standIn := startSMTPStandIn(t)
+			sender := mailer.NewSMTPSender(mailer.SMTPConfig{
+				Host:     "127.0.0.1",
+				Port:     standIn.port(),
+				Username: tt.username,
+				Password: "secret",
+			}, testFrom)
+
+			_, cuserr := sender.Send(&mailer.Message{To: tt.to, Subject: tt.subject, HTML: "<p>Kode: 123456</p>"})
+
+			if tt.expectedError {
+				assert.NotNil(t, cuserr)
+				return
+			}
+			require.Nil(t, cuserr)
+
+			delivery := <-standIn.received
+			assert.Equal(t, tt.expectedAuth, delivery.auth)
+			assert.Equal(t, "MAIL FROM:<activation@example.com>", delivery.from)
+			assert.Len(t, delivery.recipients, len(tt.to))
+
+			message, body := decodeBody(t, delivery.data)
+			assert.Equal(t, `"Retail Pro" <activation@example.com>`, message.Header.Get("From"))
+			assert.Equal(t, "", message.Header.Get("Bcc"))
+			assert.Equal(t, "text/html; charset=UTF-8", message.Header.Get("Content-Type"))
+			assert.Equal(t, "<p>Kode: 123456</p>", body)
+		})
+	}
+}
+
+func TestSMTPSenderRequiresStartTLS(t *testing.T) {
+	standIn := startSMTPStandIn(t)
+	sender := mailer.NewSMTPSender(mailer.SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     standIn.port(),
+		StartTLS: true,
+	}, testFrom)
+
+	_, cuserr := sender.Send(&mailer.Message{To: []string{"user@example.com"}, Subject: "Kode Aktivasi", HTML: "<p>Kode: 123456</p>"})
+
+	require.NotNil(t, cuserr)
+	assert.Contains(t, cuserr.Original(), "STARTTLS")
+}
+
+func TestFileSender(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	sender := mailer.NewFileSender(dir, testFrom)
+
+	for i := 0; i < 2; i++ {
+		_, cuserr := sender.Send(&mailer.Message{To: []string{"user@example.com"}, Subject: "Kode Aktivasi", HTML: "<p>Kode: " + strconv.Itoa(i) + "</p>"})
+		require.Nil(t, cuserr)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+	message, body := decodeBody(t, content)
+	assert.Equal(t, "Kode Aktivasi", message.Header.Get("Subject"))
+	assert.Equal(t, "user@example.com", strings.Trim(message.Header.Get("To"), "<>"))
+	assert.Contains(t, body, "<p>Kode: ")
+}
+
+func TestFileSenderWithText(t *testing.T) {
+	dir := t.TempDir()
+	sender := mailer.NewFileSender(dir, testFrom)
+
+	_, cuserr := sender.Send(&mailer.Message{
+		To:      []string{"user@example.com"},
+		Subject: "Kode Aktivasi",
+		HTML:    "<p>Kode: 123456</p>",
+		Text:    "Kode: 123456",
+	})
+	require.Nil(t, cuserr)
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	content, err := os.ReadFile(files[0])
+	require.NoError(t, err)
+
+	message, err := mail.ReadMessage(bytes.NewReader(content))
+	require.NoError(t, err)
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/alternative", mediaType)
+
+	// The plain-text alternative comes first so clients prefer the HTML
+	parts := multipart.NewReader(message.Body, params["boundary"])
+	expected := [][2]string{
+		{"text/plain; charset=UTF-8", "Kode: 123456"},
+		{"text/html; charset=UTF-8", "<p>Kode: 123456</p>"},
+	}
+	for _, want := range expected {
+		part, err := parts.NextRawPart()
+		require.NoError(t, err)
+		assert.Equal(t, want[0], part.Header.Get("Content-Type"))
+		body, err := io.ReadAll(quotedprintable.NewReader(part))
+		require.NoError(t, err)
+		assert.Equal(t, want[1], string(body))
+	}
+	_, err = parts.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestLogSender(t *testing.T) {
+	var output bytes.Buffer
+	sender := mailer.NewLogSender(&output, testFrom)
+
+	_, cuserr := sender.Send(&mailer.Message{To: []string{"user@example.com"}, Subject: "Kode Aktivasi", HTML: "<p>Kode: 123456</p>"})
+
+	require.Nil(t, cuserr)
+	assert.Contains(t, output.String(), "To: <user@example.com>")
+	assert.Contains(t, output.String(), "Subject: Kode Aktivasi")
+	assert.Contains(t, output.String(), "<p>Kode: 123456</p>")
+
+	// The plain-text body is easier to read in a terminal
+	output.Reset()
+	_, cuserr = sender.Send(&mailer.Message{To: []string{"user@example.com"}, Subject: "Kode Aktivasi", HTML: "<p>Kode: 123456</p>", Text: "Kode: 123456"})
+
+	require.Nil(t, cuserr)
+	assert.Contains(t, output.String(), "Kode: 123456")
+	assert.NotContains(t, output.String(), "<p>")
+}
@@ -0,0 +1,108 @@
+package mailer
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+)
+
+// smtpTimeout bounds a whole delivery, from dialing to QUIT
+const smtpTimeout = 30 * time.Second
+
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username enables AUTH PLAIN, which net/smtp only sends over TLS or to localhost
+	Username string
+	Password string
+	// StartTLS refuses servers that cannot upgrade the connection before authenticating
+	StartTLS bool
+}
+
+type smtpSender struct {
+	config SMTPConfig
+	from   mail.Address
+}
+
+// NewSMTPSender creates a sender delivering through an SMTP server
+func NewSMTPSender(config SMTPConfig, from mail.Address) Sender {
+	return &smtpSender{config: config, from: from}
+}
+
+func (s *smtpSender) Send(email *Message) (string, *customerror.CustomError) {
+	recipients, cuserr := parseRecipients(email.To)
+	if cuserr != nil {
+		return "", cuserr
+	}
+
+	message, err := composeMessage(s.from, recipients, email, time.Now())
+	if err != nil {
+		return "", customerror.NewCustomError(err, "Failed to send email", http.StatusInternalServerError)
+	}
+
+	if err := s.deliver(recipients, message); err != nil {
+		return "", customerror.NewCustomError(err, "Failed to send email", http.StatusInternalServerError)
+	}
+	return "", nil
+}
+
+func (s *smtpSender) deliver(recipients []*mail.Address, message []byte) error {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port)), smtpTimeout)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(time.Now().Add(smtpTimeout)); err != nil {
+		conn.Close()
+		return err
+	}
+
+	client, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if s.config.StartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("smtp: server does not support STARTTLS")
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: s.config.Host}); err != nil {
+			return err
+		}
+	}
+
+	if s.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(s.from.Address); err != nil {
+		return err
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient.Address); err != nil {
+			return err
+		}
+	}
+
+	data, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := data.Write(message); err != nil {
+		return err
+	}
+	if err := data.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
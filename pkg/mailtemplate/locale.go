@@ -0,0 +1,75 @@
+package mailtemplate
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MatchLocale returns the first locale of the engine matching the preferences, tried in
+// order, or the default locale. Each preference is a locale such as "id" or an
+// Accept-Language header such as "id-ID,id;q=0.9,en;q=0.8". A regional tag matches the
+// locale of its language.
+func (e *Engine) MatchLocale(preferences ...string) string {
+	for _, preference := range preferences {
+		for _, tag := range parseAcceptLanguage(preference) {
+			if locale, ok := e.match(tag); ok {
+				return locale
+			}
+		}
+	}
+	return e.defaultLocale
+}
+
+func (e *Engine) match(tag string) (string, bool) {
+	language, _, _ := strings.Cut(tag, "-")
+	for _, candidate := range []string{tag, language} {
+		for _, locale := range e.locales {
+			if strings.EqualFold(locale, candidate) {
+				return locale, true
+			}
+		}
+	}
+	return "", false
+}
+
+// parseAcceptLanguage returns the language tags of the header by descending quality,
+// without wildcards and tags with q=0
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag     string
+		quality float64
+	}
+
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ReplaceAll(strings.TrimSpace(tag), "_", "-")
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+		if quality <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, quality: quality})
+	}
+
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].quality > tags[j].quality
+	})
+
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		result = append(result, tag.tag)
+	}
+	return result
+}
@@ -0,0 +1,212 @@
+package mailtemplate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+)
+
+// ErrUnknownTemplate is returned when rendering a template name the engine did not load
+var ErrUnknownTemplate = errors.New("mailtemplate: unknown template")
+
+const (
+	layoutDir  = "layouts"
+	htmlSuffix = ".html.tmpl"
+	textSuffix = ".txt.tmpl"
+)
+
+// Rendered is an email produced by a template
+type Rendered struct {
+	Subject string
+	HTML    string
+	Text    string
+}
+
+// View is the data of the layouts. Templates see Data as dot.
+type View struct {
+	Subject string
+	Locale  string
+	Data    any
+}
+
+type localized struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// Engine renders emails from a tree of template files:
+//
+//	layouts/*.html.tmpl, layouts/*.txt.tmpl   shared by every locale, define "layout"
+//	<locale>/_*.html.tmpl, <locale>/_*.txt.tmpl   partials shared by the templates of a locale
+//	<locale>/<name>.html.tmpl, <locale>/<name>.txt.tmpl   one email, define "content"
+//
+// The text file of an email also defines "subject". Every email must exist in the
+// default locale, which is used when the requested locale does not have it.
+type Engine struct {
+	defaultLocale string
+	locales       []string
+	templates     map[string]map[string]*localized
+}
+
+// New parses every template of fsys, so broken templates fail at startup instead of
+// when the first email is sent
+func New(fsys fs.FS, defaultLocale string) (*Engine, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("mailtemplate: %w", err)
+	}
+
+	htmlLayouts, err := fs.Glob(fsys, path.Join(layoutDir, "*"+htmlSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("mailtemplate: %w", err)
+	}
+	textLayouts, err := fs.Glob(fsys, path.Join(layoutDir, "*"+textSuffix))
+	if err != nil {
+		return nil, fmt.Errorf("mailtemplate: %w", err)
+	}
+
+	engine := &Engine{
+		defaultLocale: defaultLocale,
+		templates:     map[string]map[string]*localized{},
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || entry.Name() == layoutDir {
+			continue
+		}
+		if err := engine.parseLocale(fsys, entry.Name(), htmlLayouts, textLayouts); err != nil {
+			return nil, err
+		}
+		engine.locales = append(engine.locales, entry.Name())
+	}
+
+	for name, locales := range engine.templates {
+		if locales[defaultLocale] == nil {
+			return nil, fmt.Errorf("mailtemplate: %s is missing in the default locale %q", name, defaultLocale)
+		}
+	}
+	return engine, nil
+}
+
+func (e *Engine) parseLocale(fsys fs.FS, locale string, htmlLayouts, textLayouts []string) error {
+	htmlPartials, err := fs.Glob(fsys, path.Join(locale, "_*"+htmlSuffix))
+	if err != nil {
+		return fmt.Errorf("mailtemplate: %w", err)
+	}
+	textPartials, err := fs.Glob(fsys, path.Join(locale, "_*"+textSuffix))
+	if err != nil {
+		return fmt.Errorf("mailtemplate: %w", err)
+	}
+	textFiles, err := fs.Glob(fsys, path.Join(locale, "*"+textSuffix))
+	if err != nil {
+		return fmt.Errorf("mailtemplate: %w", err)
+	}
+
+	for _, textFile := range textFiles {
+		name := strings.TrimSuffix(path.Base(textFile), textSuffix)
+		if strings.HasPrefix(name, "_") {
+			continue
+		}
+		htmlFile := path.Join(locale, name+htmlSuffix)
+
+		text, err := texttemplate.New(name).Option("missingkey=error").
+			ParseFS(fsys, concat(textLayouts, textPartials, []string{textFile})...)
+		if err != nil {
+			return fmt.Errorf("mailtemplate: %s: %w", textFile, err)
+		}
+		if text.Lookup("subject") == nil {
+			return fmt.Errorf("mailtemplate: %s does not define a subject", textFile)
+		}
+
+		html, err := htmltemplate.New(name).Option("missingkey=error").
+			ParseFS(fsys, concat(htmlLayouts, htmlPartials, []string{htmlFile})...)
+		if err != nil {
+			return fmt.Errorf("mailtemplate: %s: %w", htmlFile, err)
+		}
+
+		if e.templates[name] == nil {
+			e.templates[name] = map[string]*localized{}
+		}
+		e.templates[name][locale] = &localized{html: html, text: text}
+	}
+	return nil
+}
+
+func concat(lists ...[]string) []string {
+	var all []string
+	for _, list := range lists {
+		all = append(all, list...)
+	}
+	return all
+}
+
+// Names lists the emails the engine can render
+func (e *Engine) Names() []string {
+	names := make([]string, 0, len(e.templates))
+	for name := range e.templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Locales lists the locales with at least one email
+func (e *Engine) Locales() []string {
+	return append([]string(nil), e.locales...)
+}
+
+// Translations lists the locales an email is written in
+func (e *Engine) Translations(name string) []string {
+	locales := make([]string, 0, len(e.templates[name]))
+	for locale := range e.templates[name] {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// DefaultLocale is the locale used when no preference matches
+func (e *Engine) DefaultLocale() string {
+	return e.defaultLocale
+}
+
+// Render executes the email in the locale, falling back to the default locale
+// when the email has no translation
+func (e *Engine) Render(name, locale string, data any) (*Rendered, error) {
+	locales, ok := e.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownTemplate, name)
+	}
+	template, ok := locales[locale]
+	if !ok {
+		locale = e.defaultLocale
+		template = locales[locale]
+	}
+
+	var subject bytes.Buffer
+	if err := template.text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return nil, fmt.Errorf("mailtemplate: %s subject: %w", name, err)
+	}
+	// A subject is a single header line
+	view := View{Subject: strings.Join(strings.Fields(subject.String()), " "), Locale: locale, Data: data}
+
+	var text bytes.Buffer
+	if err := template.text.ExecuteTemplate(&text, "layout", view); err != nil {
+		return nil, fmt.Errorf("mailtemplate: %s text: %w", name, err)
+	}
+	var html bytes.Buffer
+	if err := template.html.ExecuteTemplate(&html, "layout", view); err != nil {
+		return nil, fmt.Errorf("mailtemplate: %s html: %w", name, err)
+	}
+
+	return &Rendered{
+		Subject: view.Subject,
+		HTML:    html.String(),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+	}, nil
+}
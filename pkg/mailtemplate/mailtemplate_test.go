@@ -0,0 +1,227 @@
+package mailtemplate_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yantology/golang-starter-template/pkg/mailtemplate"
+)
+
+type codeData struct {
+	Name string
+	Code string
+}
+
+func file(content string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(content)}
+}
+
+// testTemplates has a "code" email in English and Indonesian and a "notice" email only in English
+func testTemplates() fstest.MapFS {
+	return fstest.MapFS{
+		"layouts/base.html.tmpl": file(`{{define "layout"}}<html lang="{{.Locale}}"><title>{{.Subject}}</title>{{template "content" .Data}}<p>{{template "footer" .}}</p></html>{{end}}`),
+		"layouts/base.txt.tmpl":  file(`{{define "layout"}}{{template "content" .Data}}` + "\n--\n" + `{{template "footer" .}}{{end}}`),
+
+		"en/_footer.html.tmpl": file(`{{define "footer"}}Do not reply{{end}}`),
+		"en/_footer.txt.tmpl":  file(`{{define "footer"}}Do not reply{{end}}`),
+		"en/code.html.tmpl":    file(`{{define "content"}}<p>Hello {{.Name}}, your code is <b>{{.Code}}</b></p>{{end}}`),
+		"en/code.txt.tmpl":     file(`{{define "subject"}}Your code{{end}}{{define "content"}}Hello {{.Name}}, your code is {{.Code}}{{end}}`),
+		"en/notice.html.tmpl":  file(`{{define "content"}}<p>Notice for {{.Name}}</p>{{end}}`),
+		"en/notice.txt.tmpl":   file("{{define \"subject\"}}\n  Notice\n  for {{.Name}}\n{{end}}{{define \"content\"}}Notice for {{.Name}}{{end}}"),
+
+		"id/_footer.html.tmpl": file(`{{define "footer"}}Jangan membalas{{end}}`),
+		"id/_footer.txt.tmpl":  file(`{{define "footer"}}Jangan membalas{{end}}`),
+		"id/code.html.tmpl":    file(`{{define "content"}}<p>Halo {{.Name}}, kode Anda <b>{{.Code}}</b></p>{{end}}`),
+		"id/code.txt.tmpl":     file(`{{define "subject"}}Kode Anda{{end}}{{define "content"}}Halo {{.Name}}, kode Anda {{.Code}}{{end}}`),
+	}
+}
+
+func TestEngineRender(t *testing.T) {
+	engine, err := mailtemplate.New(testTemplates(), "en")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		template string
+		locale   string
+		data     any
+		expected *mailtemplate.Rendered
+	}{
+		{
+			name:     "default locale",
+			template: "code",
+			locale:   "en",
+			data:     codeData{Name: "Budi", Code: "123456"},
+			expected: &mailtemplate.Rendered{
+				Subject: "Your code",
+				HTML:    `<html lang="en"><title>Your code</title><p>Hello Budi, your code is <b>123456</b></p><p>Do not reply</p></html>`,
+				Text:    "Hello Budi, your code is 123456\n--\nDo not reply\n",
+			},
+		},
+		{
+			name:     "translated locale",
+			template: "code",
+			locale:   "id",
+			data:     codeData{Name: "Budi", Code: "123456"},
+			expected: &mailtemplate.Rendered{
+				Subject: "Kode Anda",
+				HTML:    `<html lang="id"><title>Kode Anda</title><p>Halo Budi, kode Anda <b>123456</b></p><p>Jangan membalas</p></html>`,
+				Text:    "Halo Budi, kode Anda 123456\n--\nJangan membalas\n",
+			},
+		},
+		{
+			name:     "missing translation falls back to default locale",
+			template: "notice",
+			locale:   "id",
+			data:     codeData{Name: "Budi"},
+			expected: &mailtemplate.Rendered{
+				Subject: "Notice for Budi",
+				HTML:    `<html lang="en"><title>Notice for Budi</title><p>Notice for Budi</p><p>Do not reply</p></html>`,
+				Text:    "Notice for Budi\n--\nDo not reply\n",
+			},
+		},
+		{
+			name:     "html escaped only in html body",
+			template: "code",
+			locale:   "en",
+			data:     codeData{Name: "<script>", Code: "1&2"},
+			expected: &mailtemplate.Rendered{
+				Subject: "Your code",
+				HTML:    `<html lang="en"><title>Your code</title><p>Hello &lt;script&gt;, your code is <b>1&amp;2</b></p><p>Do not reply</p></html>`,
+				Text:    "Hello <script>, your code is 1&2\n--\nDo not reply\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := engine.Render(tt.template, tt.locale, tt.data)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rendered)
+		})
+	}
+}
+
+func TestEngineRenderErrors(t *testing.T) {
+	engine, err := mailtemplate.New(testTemplates(), "en")
+	require.NoError(t, err)
+
+	_, err = engine.Render("welcome", "en", codeData{})
+	assert.True(t, errors.Is(err, mailtemplate.ErrUnknownTemplate))
+
+	// A field the data does not have is an error instead of an empty string
+	_, err = engine.Render("code", "en", struct{ Name string }{Name: "Budi"})
+	assert.Error(t, err)
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name          string
+		change        func(fsys fstest.MapFS)
+		defaultLocale string
+		expectedError bool
+	}{
+		{
+			name:          "valid templates",
+			change:        func(fsys fstest.MapFS) {},
+			defaultLocale: "en",
+		},
+		{
+			name: "text without subject",
+			change: func(fsys fstest.MapFS) {
+				fsys["en/code.txt.tmpl"] = file(`{{define "content"}}{{.Code}}{{end}}`)
+			},
+			defaultLocale: "en",
+			expectedError: true,
+		},
+		{
+			name: "text without html",
+			change: func(fsys fstest.MapFS) {
+				delete(fsys, "en/code.html.tmpl")
+			},
+			defaultLocale: "en",
+			expectedError: true,
+		},
+		{
+			name: "syntax error",
+			change: func(fsys fstest.MapFS) {
+				fsys["id/code.html.tmpl"] = file(`{{define "content"}}{{.Code}{{end}}`)
+			},
+			defaultLocale: "en",
+			expectedError: true,
+		},
+		{
+			name:          "template missing in default locale",
+			change:        func(fsys fstest.MapFS) {},
+			defaultLocale: "id",
+			expectedError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := testTemplates()
+			tt.change(fsys)
+
+			engine, err := mailtemplate.New(fsys, tt.defaultLocale)
+
+			if tt.expectedError {
+				assert.Error(t, err)
+				assert.Nil(t, engine)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, []string{"code", "notice"}, engine.Names())
+			assert.Equal(t, []string{"en", "id"}, engine.Locales())
+			assert.Equal(t, []string{"en", "id"}, engine.Translations("code"))
+			assert.Equal(t, []string{"en"}, engine.Translations("notice"))
+		})
+	}
+}
+
+func TestMatchLocale(t *testing.T) {
+	engine, err := mailtemplate.New(testTemplates(), "en")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		preferences []string
+		expected    string
+	}{
+		{name: "no preference", preferences: []string{"", ""}, expected: "en"},
+		{name: "user preference wins", preferences: []string{"id", "en-US,en"}, expected: "id"},
+		{name: "unsupported user preference", preferences: []string{"fr", "id"}, expected: "id"},
+		{name: "regional tag matches language", preferences: []string{"", "id-ID"}, expected: "id"},
+		{name: "highest quality first", preferences: []string{"", "fr;q=1, en;q=0.5, id;q=0.8"}, expected: "id"},
+		{name: "zero quality ignored", preferences: []string{"", "id;q=0, fr"}, expected: "en"},
+		{name: "wildcard ignored", preferences: []string{"", "*"}, expected: "en"},
+		{name: "case insensitive", preferences: []string{"", "ID"}, expected: "id"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, engine.MatchLocale(tt.preferences...))
+		})
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	override := fstest.MapFS{
+		"en/code.txt.tmpl": file(`{{define "subject"}}Your new code{{end}}{{define "content"}}Code: {{.Code}}{{end}}`),
+	}
+
+	engine, err := mailtemplate.New(mailtemplate.Overlay(override, testTemplates()), "en")
+	require.NoError(t, err)
+
+	// The overridden text file replaces the embedded one, the html file is kept
+	rendered, err := engine.Render("code", "en", codeData{Name: "Budi", Code: "123456"})
+	require.NoError(t, err)
+	assert.Equal(t, "Your new code", rendered.Subject)
+	assert.Equal(t, "Code: 123456\n--\nDo not reply\n", rendered.Text)
+	assert.Contains(t, rendered.HTML, "Hello Budi, your code is <b>123456</b>")
+	assert.Equal(t, []string{"code", "notice"}, engine.Names())
+}
@@ -0,0 +1,58 @@
+package mailtemplate
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+)
+
+type overlayFS struct {
+	top  fs.FS
+	base fs.FS
+}
+
+// Overlay returns a file system reading files from top and falling back to base, so a
+// directory only needs the templates it changes
+func Overlay(top, base fs.FS) fs.FS {
+	return &overlayFS{top: top, base: base}
+}
+
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	file, err := o.top.Open(name)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return file, err
+	}
+	return o.base.Open(name)
+}
+
+// ReadDir merges both directories, preferring the entries of top
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	topEntries, topErr := fs.ReadDir(o.top, name)
+	if topErr != nil && !errors.Is(topErr, fs.ErrNotExist) {
+		return nil, topErr
+	}
+	baseEntries, baseErr := fs.ReadDir(o.base, name)
+	if baseErr != nil && !errors.Is(baseErr, fs.ErrNotExist) {
+		return nil, baseErr
+	}
+	if topErr != nil && baseErr != nil {
+		return nil, topErr
+	}
+
+	merged := map[string]fs.DirEntry{}
+	for _, entry := range baseEntries {
+		merged[entry.Name()] = entry
+	}
+	for _, entry := range topEntries {
+		merged[entry.Name()] = entry
+	}
+
+	entries := make([]fs.DirEntry, 0, len(merged))
+	for _, entry := range merged {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
@@ -0,0 +1,193 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	gooidc "github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// Errors returned while finishing an authorization code flow
+var (
+	ErrMissingIDToken = errors.New("oidc: token response has no id_token")
+	ErrNonceMismatch  = errors.New("oidc: nonce mismatch")
+	ErrMissingSubject = errors.New("oidc: id_token has no subject")
+)
+
+// ProviderConfig describes an OpenID Connect identity provider
+type ProviderConfig struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Identity is the verified user information returned by a provider
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// Provider runs the authorization code flow with PKCE against a single identity provider
+type Provider struct {
+	name     string
+	oauth2   oauth2.Config
+	verifier *gooidc.IDTokenVerifier
+}
+
+// NewProvider discovers the provider configuration from {issuer}/.well-known/openid-configuration
+func NewProvider(ctx context.Context, config ProviderConfig) (*Provider, error) {
+	provider, err := gooidc.NewProvider(ctx, config.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("discover %s: %w", config.Name, err)
+	}
+
+	scopes := config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{gooidc.ScopeOpenID, "email", "profile"}
+	}
+
+	return &Provider{
+		name: config.Name,
+		oauth2: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier: provider.Verifier(&gooidc.Config{ClientID: config.ClientID}),
+	}, nil
+}
+
+// Name returns the name the provider was configured with
+func (p *Provider) Name() string {
+	return p.name
+}
+
+// AuthCodeURL returns the URL the user is redirected to for authentication
+func (p *Provider) AuthCodeURL(state, nonce, codeVerifier string) string {
+	return p.oauth2.AuthCodeURL(state,
+		gooidc.Nonce(nonce),
+		oauth2.S256ChallengeOption(codeVerifier),
+	)
+}
+
+// Exchange redeems the authorization code, verifies the id_token and its nonce
+// and returns the identity it describes
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("exchange code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, ErrMissingIDToken
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("verify id_token: %w", err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+	if idToken.Subject == "" {
+		return nil, ErrMissingSubject
+	}
+
+	var claims struct {
+		Email         string   `json:"email"`
+		EmailVerified flexBool `json:"email_verified"`
+		Name          string   `json:"name"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("decode claims: %w", err)
+	}
+
+	return &Identity{
+		Subject:       idToken.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
+
+// Registry holds the configured providers by name
+type Registry struct {
+	providers map[string]*Provider
+	names     []string
+}
+
+// NewRegistry creates an empty provider registry
+func NewRegistry() *Registry {
+	return &Registry{providers: map[string]*Provider{}}
+}
+
+// Add registers a provider under its name
+func (r *Registry) Add(provider *Provider) {
+	if _, exists := r.providers[provider.name]; !exists {
+		r.names = append(r.names, provider.name)
+	}
+	r.providers[provider.name] = provider
+}
+
+// Get returns the provider with the given name
+func (r *Registry) Get(name string) (*Provider, bool) {
+	provider, ok := r.providers[name]
+	return provider, ok
+}
+
+// Names returns the registered provider names in registration order
+func (r *Registry) Names() []string {
+	return append([]string{}, r.names...)
+}
+
+// NewState returns a random value for the state or nonce parameter
+func NewState() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// NewCodeVerifier returns a random PKCE code verifier
+func NewCodeVerifier() string {
+	return oauth2.GenerateVerifier()
+}
+
+// flexBool accepts both JSON booleans and the string form some providers send
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	switch v := value.(type) {
+	case bool:
+		*b = flexBool(v)
+	case string:
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return err
+		}
+		*b = flexBool(parsed)
+	default:
+		*b = false
+	}
+	return nil
+}
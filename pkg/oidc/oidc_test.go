@@ -0,0 +1,242 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/pkg/oidc"
+)
+
+const (
+	testClientID = "retail-pro"
+	testKeyID    = "test-key"
+)
+
+// mockOIDCServer is a minimal in-process identity provider
+type mockOIDCServer struct {
+	server        *httptest.Server
+	key           *rsa.PrivateKey
+	codeChallenge string
+	nonce         string
+	claims        jwt.MapClaims
+}
+
+func newMockOIDCServer(t *testing.T) *mockOIDCServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	m := &mockOIDCServer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/jwks", m.jwks)
+	mux.HandleFunc("/token", m.token)
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockOIDCServer) discovery(w http.ResponseWriter, r *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                                m.server.URL,
+		"authorization_endpoint":                m.server.URL + "/authorize",
+		"token_endpoint":                        m.server.URL + "/token",
+		"jwks_uri":                              m.server.URL + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (m *mockOIDCServer) jwks(w http.ResponseWriter, r *http.Request) {
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": testKeyID,
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (m *mockOIDCServer) token(w http.ResponseWriter, r *http.Request) {
+	_ = r.ParseForm()
+
+	// Enforce PKCE like a real provider
+	verifierHash := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if r.PostForm.Get("code") != "valid-code" ||
+		base64.RawURLEncoding.EncodeToString(verifierHash[:]) != m.codeChallenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   m.server.URL,
+		"aud":   testClientID,
+		"sub":   "user-123",
+		"email": "user@example.com",
+		"nonce": m.nonce,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+	}
+	for k, v := range m.claims {
+		claims[k] = v
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testKeyID
+	idToken, _ := token.SignedString(m.key)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
+
+// authorize records the parameters the browser would send to the authorization endpoint
+func (m *mockOIDCServer) authorize(t *testing.T, authURL string) {
+	parsed, err := url.Parse(authURL)
+	assert.Nil(t, err)
+
+	query := parsed.Query()
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	m.codeChallenge = query.Get("code_challenge")
+	m.nonce = query.Get("nonce")
+}
+
+func newProvider(t *testing.T, m *mockOIDCServer) *oidc.Provider {
+	provider, err := oidc.NewProvider(context.Background(), oidc.ProviderConfig{
+		Name:        "mock",
+		IssuerURL:   m.server.URL,
+		ClientID:    testClientID,
+		RedirectURL: "http://localhost:3000/callback",
+	})
+	assert.Nil(t, err)
+	return provider
+}
+
+func TestExchange(t *testing.T) {
+	tests := []struct {
+		name          string
+		claims        jwt.MapClaims
+		code          string
+		wrongVerifier bool
+		wrongNonce    bool
+		expectedErr   bool
+		expected      *oidc.Identity
+	}{
+		{
+			name:     "verified email",
+			claims:   jwt.MapClaims{"email_verified": true, "name": "Test User"},
+			code:     "valid-code",
+			expected: &oidc.Identity{Subject: "user-123", Email: "user@example.com", EmailVerified: true, Name: "Test User"},
+		},
+		{
+			name:     "email verified as string",
+			claims:   jwt.MapClaims{"email_verified": "true"},
+			code:     "valid-code",
+			expected: &oidc.Identity{Subject: "user-123", Email: "user@example.com", EmailVerified: true},
+		},
+		{
+			name:     "unverified email",
+			claims:   jwt.MapClaims{"email_verified": false},
+			code:     "valid-code",
+			expected: &oidc.Identity{Subject: "user-123", Email: "user@example.com", EmailVerified: false},
+		},
+		{name: "invalid code", code: "other-code", expectedErr: true},
+		{name: "wrong code verifier", code: "valid-code", wrongVerifier: true, expectedErr: true},
+		{name: "nonce mismatch", code: "valid-code", wrongNonce: true, expectedErr: true},
+		{name: "wrong audience", claims: jwt.MapClaims{"aud": "other-client"}, code: "valid-code", expectedErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMockOIDCServer(t)
+			m.claims = tt.claims
+			provider := newProvider(t, m)
+
+			verifier := oidc.NewCodeVerifier()
+			nonce, err := oidc.NewState()
+			assert.Nil(t, err)
+			m.authorize(t, provider.AuthCodeURL("state", nonce, verifier))
+
+			if tt.wrongVerifier {
+				verifier = oidc.NewCodeVerifier()
+			}
+			if tt.wrongNonce {
+				nonce = "other-nonce"
+			}
+
+			identity, err := provider.Exchange(context.Background(), tt.code, verifier, nonce)
+
+			if tt.expectedErr {
+				assert.NotNil(t, err)
+				assert.Nil(t, identity)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, identity)
+		})
+	}
+}
+
+func TestAuthCodeURL(t *testing.T) {
+	m := newMockOIDCServer(t)
+	provider := newProvider(t, m)
+
+	parsed, err := url.Parse(provider.AuthCodeURL("state-value", "nonce-value", "verifier"))
+	assert.Nil(t, err)
+
+	query := parsed.Query()
+	assert.Equal(t, m.server.URL+"/authorize", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, testClientID, query.Get("client_id"))
+	assert.Equal(t, "state-value", query.Get("state"))
+	assert.Equal(t, "nonce-value", query.Get("nonce"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "http://localhost:3000/callback", query.Get("redirect_uri"))
+}
+
+func TestNewProviderDiscoveryFailure(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	provider, err := oidc.NewProvider(context.Background(), oidc.ProviderConfig{
+		Name:      "broken",
+		IssuerURL: server.URL,
+		ClientID:  testClientID,
+	})
+
+	assert.NotNil(t, err)
+	assert.Nil(t, provider)
+}
+
+func TestRegistry(t *testing.T) {
+	m := newMockOIDCServer(t)
+	registry := oidc.NewRegistry()
+	registry.Add(newProvider(t, m))
+
+	provider, ok := registry.Get("mock")
+	assert.Equal(t, true, ok)
+	assert.Equal(t, "mock", provider.Name())
+
+	_, ok = registry.Get("unknown")
+	assert.Equal(t, false, ok)
+	assert.Equal(t, []string{"mock"}, registry.Names())
+}
@@ -0,0 +1,85 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/yantology/golang-starter-template/pkg/mailer"
+)
+
+const (
+	StatusPending = "pending"
+	StatusSent    = "sent"
+	// StatusDead marks a message that failed every attempt and waits for an admin retry
+	StatusDead = "dead"
+)
+
+// Delivery outcomes reported by the provider after a message was sent
+const (
+	DeliveryDelivered  = "delivered"
+	DeliveryBounced    = "bounced"
+	DeliveryComplained = "complained"
+)
+
+// ErrNotFound is returned when a message does not exist or is not in the expected status
+var ErrNotFound = errors.New("outbox: message not found")
+
+// ErrContentCleared is returned when retrying a dead message whose content was cleared
+// after the retry window
+var ErrContentCleared = errors.New("outbox: message content cleared")
+
+// Email is the content of an outbox message, handed to the sender as is
+type Email = mailer.Message
+
+// Message is an email in the outbox together with its delivery state
+type Message struct {
+	ID string
+	Email
+	Status        string
+	Attempts      int
+	LastError     string
+	NextAttemptAt time.Time
+	SentAt        *time.Time
+	CreatedAt     time.Time
+
+	// ProviderMessageID is the id the mail driver gave the sent message, empty for
+	// drivers without delivery events
+	ProviderMessageID string
+	// DeliveryStatus is the last outcome reported by the provider, empty until one arrives
+	DeliveryStatus    string
+	DeliveryDetail    string
+	DeliveryUpdatedAt *time.Time
+}
+
+// Execer is implemented by both *sql.DB and *sql.Tx, so an email can be queued in the
+// transaction that saves what it is about and is only sent when that commits
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// Store keeps the outbox messages
+type Store interface {
+	// Claim leases up to limit due messages so no other worker delivers them meanwhile
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]Message, error)
+	// MarkSent records a message handed to the provider under providerMessageID and
+	// clears its content, which may carry codes and tokens
+	MarkSent(ctx context.Context, id, providerMessageID string) error
+	// MarkFailed records a failed attempt. A nil retryAt moves the message to StatusDead.
+	MarkFailed(ctx context.Context, id, lastError string, retryAt *time.Time) error
+	// List returns the messages in a status, newest first
+	List(ctx context.Context, status string, limit int) ([]Message, error)
+	// Get returns one message
+	Get(ctx context.Context, id string) (*Message, error)
+	// Retry moves a dead message back to pending with its attempts reset. It fails with
+	// ErrContentCleared once the retry window has passed.
+	Retry(ctx context.Context, id string) error
+	// RecordDelivery stores the outcome the provider reported for a sent message. A
+	// late delivered event does not replace a bounce or complaint, and events for
+	// messages not sent from the outbox are ignored.
+	RecordDelivery(ctx context.Context, providerMessageID, status, detail string) error
+	// Purge clears the content of messages dead for longer than the retry window and
+	// deletes sent and dead messages older than the retention
+	Purge(ctx context.Context, retention Retention) error
+}
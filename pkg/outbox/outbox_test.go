@@ -0,0 +1,162 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+	"github.com/yantology/golang-starter-template/pkg/mailer"
+	"github.com/yantology/golang-starter-template/pkg/outbox"
+)
+
+var testPolicy = outbox.Policy{
+	BaseDelay:   30 * time.Second,
+	MaxDelay:    10 * time.Minute,
+	MaxAttempts: 3,
+}
+
+func TestPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		expected time.Duration
+	}{
+		{name: "first failure waits base delay", failures: 1, expected: 30 * time.Second},
+		{name: "second failure doubles", failures: 2, expected: time.Minute},
+		{name: "fourth failure doubles three times", failures: 4, expected: 4 * time.Minute},
+		{name: "delay capped at max", failures: 10, expected: 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, testPolicy.Delay(tt.failures))
+		})
+	}
+}
+
+type failure struct {
+	lastError string
+	retryAt   *time.Time
+}
+
+type fakeStore struct {
+	outbox.Store
+	due      []outbox.Message
+	claimErr error
+	sent     map[string]string
+	failed   map[string]failure
+	purged   []outbox.Retention
+}
+
+func (s *fakeStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]outbox.Message, error) {
+	return s.due, s.claimErr
+}
+
+func (s *fakeStore) MarkSent(ctx context.Context, id, providerMessageID string) error {
+	s.sent[id] = providerMessageID
+	return nil
+}
+
+func (s *fakeStore) MarkFailed(ctx context.Context, id, lastError string, retryAt *time.Time) error {
+	s.failed[id] = failure{lastError: lastError, retryAt: retryAt}
+	return nil
+}
+
+func (s *fakeStore) Purge(ctx context.Context, retention outbox.Retention) error {
+	s.purged = append(s.purged, retention)
+	return nil
+}
+
+type fakeSender struct {
+	failFor    map[string]bool
+	suppressed map[string]bool
+}
+
+func (s *fakeSender) Send(message *mailer.Message) (string, *customerror.CustomError) {
+	if s.failFor[message.To[0]] {
+		return "", customerror.NewCustomError(errors.New("connection refused"), "Failed to send email", http.StatusInternalServerError)
+	}
+	if s.suppressed[message.To[0]] {
+		return "", customerror.NewCustomError(nil, "Recipient is suppressed after a bounce or spam complaint: "+message.To[0], http.StatusUnprocessableEntity)
+	}
+	return "re_" + message.To[0], nil
+}
+
+func message(id, to string, attempts int) outbox.Message {
+	return outbox.Message{
+		ID:       id,
+		Email:    outbox.Email{To: []string{to}, Subject: "Kode Aktivasi", HTML: "<p>123456</p>"},
+		Status:   outbox.StatusPending,
+		Attempts: attempts,
+	}
+}
+
+func TestWorkerDeliverDue(t *testing.T) {
+	store := &fakeStore{
+		due: []outbox.Message{
+			message("1", "ok@example.com", 0),
+			message("2", "down@example.com", 0),
+			message("3", "down@example.com", 2),
+			message("4", "bounced@example.com", 0),
+		},
+		sent:   map[string]string{},
+		failed: map[string]failure{},
+	}
+	sender := &fakeSender{
+		failFor:    map[string]bool{"down@example.com": true},
+		suppressed: map[string]bool{"bounced@example.com": true},
+	}
+	worker := outbox.NewWorker(store, sender, testPolicy, time.Second)
+
+	before := time.Now()
+	claimed, err := worker.DeliverDue(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, claimed)
+	// The provider id is kept to match delivery events later
+	assert.Equal(t, map[string]string{"1": "re_ok@example.com"}, store.sent)
+
+	// First failure is retried after the base delay
+	retried := store.failed["2"]
+	assert.Equal(t, "Failed to send email: connection refused", retried.lastError)
+	if assert.NotNil(t, retried.retryAt) {
+		assert.WithinDuration(t, before.Add(testPolicy.BaseDelay), *retried.retryAt, time.Second)
+	}
+
+	// Third failure reaches MaxAttempts and is dead-lettered
+	dead := store.failed["3"]
+	assert.Equal(t, "Failed to send email: connection refused", dead.lastError)
+	assert.Nil(t, dead.retryAt)
+
+	// A refused recipient cannot succeed later and is dead-lettered on the first failure
+	refused := store.failed["4"]
+	assert.Equal(t, "Recipient is suppressed after a bounce or spam complaint: bounced@example.com", refused.lastError)
+	assert.Nil(t, refused.retryAt)
+}
+
+func TestWorkerDeliverDueClaimError(t *testing.T) {
+	store := &fakeStore{claimErr: errors.New("connection refused"), sent: map[string]string{}, failed: map[string]failure{}}
+	worker := outbox.NewWorker(store, &fakeSender{}, testPolicy, time.Second)
+
+	claimed, err := worker.DeliverDue(context.Background())
+
+	assert.Error(t, err)
+	assert.Equal(t, 0, claimed)
+	assert.Empty(t, store.sent)
+}
+
+func TestPurgerRun(t *testing.T) {
+	store := &fakeStore{}
+	retention := outbox.Retention{RetryWindow: 24 * time.Hour, MaxAge: 30 * 24 * time.Hour}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// A cancelled context stops the purger after the first run
+	outbox.NewPurger(store, retention, time.Hour).Run(ctx)
+
+	assert.Equal(t, []outbox.Retention{retention}, store.purged)
+}
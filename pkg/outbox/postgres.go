@@ -0,0 +1,186 @@
+package outbox
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Verify interface implementation
+var _ Store = (*PostgresStore)(nil)
+
+// Enqueue stores an email for the worker to deliver
+func Enqueue(ctx context.Context, db Execer, email *Email) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO email_outbox (recipients, subject, html, text)
+		VALUES ($1, $2, $3, $4)`,
+		pq.Array(email.To), email.Subject, email.HTML, email.Text)
+	return err
+}
+
+// PostgresStore keeps messages in the email_outbox table
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// messageColumns are the email_outbox columns read by scanMessage
+const messageColumns = `id, recipients, subject, html, text, status, attempts, COALESCE(last_error, ''),
+	next_attempt_at, sent_at, created_at, COALESCE(provider_message_id, ''), COALESCE(delivery_status, ''),
+	COALESCE(delivery_detail, ''), delivery_updated_at`
+
+func scanMessage(row interface{ Scan(dest ...any) error }) (*Message, error) {
+	message := &Message{}
+	err := row.Scan(&message.ID, pq.Array(&message.To), &message.Subject, &message.HTML, &message.Text, &message.Status,
+		&message.Attempts, &message.LastError, &message.NextAttemptAt, &message.SentAt, &message.CreatedAt,
+		&message.ProviderMessageID, &message.DeliveryStatus, &message.DeliveryDetail, &message.DeliveryUpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return message, nil
+}
+
+// Claim skips rows locked by other replicas, and the lease keeps a message from being
+// claimed again while it is being sent
+func (s *PostgresStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]Message, error) {
+	return s.query(ctx, `
+		UPDATE email_outbox
+		SET locked_until = NOW() + ($2 || ' milliseconds')::interval
+		WHERE id IN (
+			SELECT id FROM email_outbox
+			WHERE status = 'pending'
+			  AND next_attempt_at <= NOW()
+			  AND (locked_until IS NULL OR locked_until <= NOW())
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+messageColumns,
+		limit, lease.Milliseconds())
+}
+
+func (s *PostgresStore) MarkSent(ctx context.Context, id, providerMessageID string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE email_outbox
+		SET status = 'sent', attempts = attempts + 1, sent_at = NOW(), locked_until = NULL,
+			provider_message_id = NULLIF($2, ''), html = '', text = ''
+		WHERE id = $1`,
+		id, providerMessageID)
+	return err
+}
+
+func (s *PostgresStore) MarkFailed(ctx context.Context, id, lastError string, retryAt *time.Time) error {
+	status := StatusPending
+	if retryAt == nil {
+		status = StatusDead
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE email_outbox
+		SET status = $2, attempts = attempts + 1, last_error = $3,
+			next_attempt_at = COALESCE($4, next_attempt_at), locked_until = NULL
+		WHERE id = $1`,
+		id, status, lastError, retryAt)
+	return err
+}
+
+func (s *PostgresStore) List(ctx context.Context, status string, limit int) ([]Message, error) {
+	return s.query(ctx, `
+		SELECT `+messageColumns+`
+		FROM email_outbox
+		WHERE status = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`,
+		status, limit)
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id string) (*Message, error) {
+	message, err := scanMessage(s.db.QueryRowContext(ctx, `SELECT `+messageColumns+` FROM email_outbox WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	return message, err
+}
+
+func (s *PostgresStore) Retry(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE email_outbox
+		SET status = 'pending', attempts = 0, next_attempt_at = NOW(), locked_until = NULL
+		WHERE id = $1 AND status = 'dead' AND (html <> '' OR text <> '')`,
+		id)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = s.db.QueryRowContext(ctx, `SELECT TRUE FROM email_outbox WHERE id = $1 AND status = 'dead'`, id).Scan(&exists)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return ErrContentCleared
+}
+
+func (s *PostgresStore) RecordDelivery(ctx context.Context, providerMessageID, status, detail string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE email_outbox
+		SET delivery_status = $2, delivery_detail = $3, delivery_updated_at = NOW()
+		WHERE provider_message_id = $1
+		  AND (delivery_status IS NULL OR delivery_status = 'delivered' OR $2 <> 'delivered')`,
+		providerMessageID, status, detail)
+	return err
+}
+
+// A dead message is not touched after its last attempt, so next_attempt_at is when it died
+func (s *PostgresStore) Purge(ctx context.Context, retention Retention) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE email_outbox
+		SET html = '', text = ''
+		WHERE status = 'dead'
+		  AND next_attempt_at <= NOW() - ($1 || ' milliseconds')::interval
+		  AND (html <> '' OR text <> '')`,
+		retention.RetryWindow.Milliseconds())
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		DELETE FROM email_outbox
+		WHERE status IN ('sent', 'dead')
+		  AND created_at <= NOW() - ($1 || ' milliseconds')::interval`,
+		retention.MaxAge.Milliseconds())
+	return err
+}
+
+func (s *PostgresStore) query(ctx context.Context, query string, args ...any) ([]Message, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	messages := []Message{}
+	for rows.Next() {
+		message, err := scanMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, *message)
+	}
+	return messages, rows.Err()
+}
@@ -0,0 +1,50 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Retention decides how long message content and messages are kept. Content is
+// cleared as soon as a message is sent.
+type Retention struct {
+	// RetryWindow is how long a dead message keeps its content for an admin retry
+	RetryWindow time.Duration
+	// MaxAge deletes sent and dead messages this long after they were queued
+	MaxAge time.Duration
+}
+
+// Purger periodically applies the retention to the outbox
+type Purger struct {
+	store     Store
+	retention Retention
+	interval  time.Duration
+}
+
+// NewPurger creates a purger applying the retention every interval
+func NewPurger(store Store, retention Retention, interval time.Duration) *Purger {
+	return &Purger{
+		store:     store,
+		retention: retention,
+		interval:  interval,
+	}
+}
+
+// Run purges the outbox until ctx is cancelled
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.store.Purge(ctx, p.retention); err != nil {
+			log.Println("Failed to purge outbox messages:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
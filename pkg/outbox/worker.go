@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/yantology/golang-starter-template/pkg/mailer"
+)
+
+// claimLease is how long a claimed message is held by one worker. It must be longer
+// than a delivery, or a slow send could be claimed and sent twice.
+const claimLease = 5 * time.Minute
+
+// Policy decides when a failed message is tried again
+type Policy struct {
+	// BaseDelay is the wait after the first failure, doubled after every further one
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// MaxAttempts moves a message to StatusDead once it has failed this many times
+	MaxAttempts int
+}
+
+// Delay returns the wait after the given number of failed attempts
+func (p Policy) Delay(failures int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// Worker delivers due outbox messages through a mail sender
+type Worker struct {
+	store     Store
+	sender    mailer.Sender
+	policy    Policy
+	interval  time.Duration
+	batchSize int
+}
+
+// NewWorker creates a worker polling the store every interval
+func NewWorker(store Store, sender mailer.Sender, policy Policy, interval time.Duration) *Worker {
+	return &Worker{
+		store:     store,
+		sender:    sender,
+		policy:    policy,
+		interval:  interval,
+		batchSize: 50,
+	}
+}
+
+// Run delivers due messages until ctx is cancelled
+func (w *Worker) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		// A full batch means more messages may be due, so the next one is claimed right away
+		for {
+			delivered, err := w.DeliverDue(ctx)
+			if err != nil {
+				log.Println("Failed to claim outbox messages:", err)
+			}
+			if err != nil || delivered < w.batchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue sends one batch of due messages and returns how many were claimed.
+// Failures are recorded on the message and retried after the policy's delay, except
+// client errors such as an invalid or suppressed recipient, which no retry can fix
+// and are dead-lettered at once.
+func (w *Worker) DeliverDue(ctx context.Context) (int, error) {
+	messages, err := w.store.Claim(ctx, w.batchSize, claimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, message := range messages {
+		providerMessageID, cuserr := w.sender.Send(&message.Email)
+		if cuserr == nil {
+			if err := w.store.MarkSent(ctx, message.ID, providerMessageID); err != nil {
+				log.Printf("Failed to mark outbox message %s as sent: %v", message.ID, err)
+			}
+			continue
+		}
+
+		lastError := cuserr.Message()
+		if cuserr.Original() != "" {
+			lastError += ": " + cuserr.Original()
+		}
+
+		failures := message.Attempts + 1
+		var retryAt *time.Time
+		switch {
+		case cuserr.Code() >= 400 && cuserr.Code() < 500:
+			log.Printf("Outbox message %s cannot be delivered and was dead-lettered: %s", message.ID, lastError)
+		case failures < w.policy.MaxAttempts:
+			next := time.Now().Add(w.policy.Delay(failures))
+			retryAt = &next
+		default:
+			log.Printf("Outbox message %s failed %d times and was dead-lettered: %s", message.ID, failures, lastError)
+		}
+
+		if err := w.store.MarkFailed(ctx, message.ID, lastError, retryAt); err != nil {
+			log.Printf("Failed to record outbox message %s failure: %v", message.ID, err)
+		}
+	}
+	return len(messages), nil
+}
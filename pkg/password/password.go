@@ -0,0 +1,62 @@
+package password
+
+import "errors"
+
+var (
+	ErrMismatch        = errors.New("password: hash does not match")
+	ErrUnknownFormat   = errors.New("password: unknown hash format")
+	ErrInvalidHash     = errors.New("password: malformed hash")
+	ErrPasswordTooLong = errors.New("password: password too long for algorithm")
+)
+
+// PasswordHasher hashes passwords into self-describing strings that carry
+// the algorithm and its parameters
+type PasswordHasher interface {
+	// Hash returns the encoded hash of password
+	Hash(password string) (string, error)
+	// Verify returns nil when password matches the encoded hash and ErrMismatch otherwise
+	Verify(encoded, password string) error
+	// NeedsRehash reports whether encoded was made with other parameters than the current ones
+	NeedsRehash(encoded string) bool
+}
+
+// Scheme is a PasswordHasher for a single algorithm that can recognise its own hashes
+type Scheme interface {
+	PasswordHasher
+	Identify(encoded string) bool
+}
+
+type multiHasher struct {
+	preferred Scheme
+	schemes   []Scheme
+}
+
+// NewMultiHasher hashes new passwords with preferred and verifies hashes made by
+// preferred or any of the legacy schemes. Hashes from a legacy scheme, or from
+// preferred with outdated parameters, need a rehash.
+func NewMultiHasher(preferred Scheme, legacy ...Scheme) PasswordHasher {
+	return &multiHasher{
+		preferred: preferred,
+		schemes:   append([]Scheme{preferred}, legacy...),
+	}
+}
+
+func (m *multiHasher) Hash(password string) (string, error) {
+	return m.preferred.Hash(password)
+}
+
+func (m *multiHasher) Verify(encoded, password string) error {
+	for _, scheme := range m.schemes {
+		if scheme.Identify(encoded) {
+			return scheme.Verify(encoded, password)
+		}
+	}
+	return ErrUnknownFormat
+}
+
+func (m *multiHasher) NeedsRehash(encoded string) bool {
+	if !m.preferred.Identify(encoded) {
+		return true
+	}
+	return m.preferred.NeedsRehash(encoded)
+}
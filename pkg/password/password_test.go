@@ -0,0 +1,92 @@
+package password_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/pkg/password"
+)
+
+// Small parameters keep the tests fast
+var testParams = password.Argon2Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestArgon2id(t *testing.T) {
+	hasher := password.NewArgon2id(testParams)
+
+	hash, err := hasher.Hash("correct horse")
+	assert.Nil(t, err)
+	assert.Equal(t, true, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+	assert.Equal(t, true, hasher.Identify(hash))
+	assert.Equal(t, false, hasher.NeedsRehash(hash))
+
+	other, _ := hasher.Hash("correct horse")
+	assert.NotEqual(t, hash, other)
+
+	tests := []struct {
+		name     string
+		encoded  string
+		password string
+		expected error
+	}{
+		{name: "matching password", encoded: hash, password: "correct horse", expected: nil},
+		{name: "wrong password", encoded: hash, password: "battery staple", expected: password.ErrMismatch},
+		{name: "malformed hash", encoded: "$argon2id$v=19$m=1024$abc", password: "correct horse", expected: password.ErrInvalidHash},
+		{name: "unsupported version", encoded: strings.Replace(hash, "v=19", "v=16", 1), password: "correct horse", expected: password.ErrInvalidHash},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, hasher.Verify(tt.encoded, tt.password))
+		})
+	}
+
+	stronger := testParams
+	stronger.Iterations = 2
+	assert.Equal(t, true, password.NewArgon2id(stronger).NeedsRehash(hash))
+}
+
+func TestBcrypt(t *testing.T) {
+	hasher := password.NewBcrypt(4)
+
+	hash, err := hasher.Hash("correct horse")
+	assert.Nil(t, err)
+	assert.Equal(t, true, hasher.Identify(hash))
+	assert.Equal(t, false, hasher.NeedsRehash(hash))
+	assert.Equal(t, true, password.NewBcrypt(5).NeedsRehash(hash))
+
+	assert.Nil(t, hasher.Verify(hash, "correct horse"))
+	assert.Equal(t, password.ErrMismatch, hasher.Verify(hash, "battery staple"))
+
+	_, err = hasher.Hash(strings.Repeat("a", 73))
+	assert.Equal(t, password.ErrPasswordTooLong, err)
+}
+
+func TestMultiHasher(t *testing.T) {
+	bcrypt := password.NewBcrypt(4)
+	argon := password.NewArgon2id(testParams)
+	hasher := password.NewMultiHasher(argon, bcrypt)
+
+	legacyHash, _ := bcrypt.Hash("correct horse")
+	currentHash, _ := hasher.Hash("correct horse")
+
+	tests := []struct {
+		name                string
+		encoded             string
+		password            string
+		expectedErr         error
+		expectedNeedsRehash bool
+	}{
+		{name: "current hash", encoded: currentHash, password: "correct horse", expectedErr: nil, expectedNeedsRehash: false},
+		{name: "legacy hash", encoded: legacyHash, password: "correct horse", expectedErr: nil, expectedNeedsRehash: true},
+		{name: "legacy hash wrong password", encoded: legacyHash, password: "battery staple", expectedErr: password.ErrMismatch, expectedNeedsRehash: true},
+		{name: "empty hash", encoded: "", password: "correct horse", expectedErr: password.ErrUnknownFormat, expectedNeedsRehash: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedErr, hasher.Verify(tt.encoded, tt.password))
+			assert.Equal(t, tt.expectedNeedsRehash, hasher.NeedsRehash(tt.encoded))
+		})
+	}
+}
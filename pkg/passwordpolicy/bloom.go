@@ -0,0 +1,135 @@
+package passwordpolicy
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+)
+
+// bloomMagic starts every filter file, followed by a version byte, the number
+// of hash functions (1 byte), the number of bits (8 bytes big endian) and the bit set
+var bloomMagic = [4]byte{'P', 'W', 'B', 'F'}
+
+const bloomVersion = 1
+
+var ErrInvalidFilter = errors.New("passwordpolicy: invalid bloom filter file")
+
+// BloomFilter is a compact set of breached passwords. Entries are SHA-1
+// digests, so a filter can be built from plain password lists or from the
+// SHA-1 dumps of Have I Been Pwned without storing any password.
+// A lookup can report a false positive but never a false negative.
+type BloomFilter struct {
+	bits   []byte
+	m      uint64
+	hashes uint8
+}
+
+// NewBloomFilter sizes a filter for n entries at the given false positive rate
+func NewBloomFilter(n int, falsePositiveRate float64) *BloomFilter {
+	if n < 1 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	if m < 8 {
+		m = 8
+	}
+	k := uint8(math.Max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+
+	return &BloomFilter{
+		bits:   make([]byte, (m+7)/8),
+		m:      m,
+		hashes: k,
+	}
+}
+
+// Add inserts a SHA-1 digest
+func (f *BloomFilter) Add(digest [sha1.Size]byte) {
+	f.each(digest, func(bit uint64) bool {
+		f.bits[bit/8] |= 1 << (bit % 8)
+		return true
+	})
+}
+
+// AddPassword inserts a plain password
+func (f *BloomFilter) AddPassword(password string) {
+	f.Add(sha1.Sum([]byte(password)))
+}
+
+// Contains reports whether the SHA-1 digest may be in the set
+func (f *BloomFilter) Contains(digest [sha1.Size]byte) bool {
+	found := true
+	f.each(digest, func(bit uint64) bool {
+		found = f.bits[bit/8]&(1<<(bit%8)) != 0
+		return found
+	})
+	return found
+}
+
+// ContainsPassword reports whether the password may be in the set
+func (f *BloomFilter) ContainsPassword(password string) bool {
+	return f.Contains(sha1.Sum([]byte(password)))
+}
+
+// each calls fn for every bit of the digest using double hashing, stopping when fn returns false
+func (f *BloomFilter) each(digest [sha1.Size]byte, fn func(bit uint64) bool) {
+	h1 := binary.BigEndian.Uint64(digest[0:8])
+	h2 := binary.BigEndian.Uint64(digest[8:16]) | 1
+	for i := uint64(0); i < uint64(f.hashes); i++ {
+		if !fn((h1 + i*h2) % f.m) {
+			return
+		}
+	}
+}
+
+// WriteTo writes the filter in its file format
+func (f *BloomFilter) WriteTo(w io.Writer) (int64, error) {
+	header := make([]byte, 0, 14)
+	header = append(header, bloomMagic[:]...)
+	header = append(header, bloomVersion, f.hashes)
+	header = binary.BigEndian.AppendUint64(header, f.m)
+
+	n, err := w.Write(header)
+	if err != nil {
+		return int64(n), err
+	}
+	written, err := w.Write(f.bits)
+	return int64(n + written), err
+}
+
+// ReadBloomFilter reads a filter written by WriteTo
+func ReadBloomFilter(r io.Reader) (*BloomFilter, error) {
+	header := make([]byte, 14)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, ErrInvalidFilter
+	}
+	if [4]byte(header[0:4]) != bloomMagic || header[4] != bloomVersion || header[5] == 0 {
+		return nil, ErrInvalidFilter
+	}
+
+	m := binary.BigEndian.Uint64(header[6:14])
+	if m == 0 || m > math.MaxInt64-7 {
+		return nil, ErrInvalidFilter
+	}
+
+	f := &BloomFilter{m: m, hashes: header[5]}
+	f.bits = make([]byte, (m+7)/8)
+	if _, err := io.ReadFull(r, f.bits); err != nil {
+		return nil, ErrInvalidFilter
+	}
+	return f, nil
+}
+
+// LoadBloomFilter reads a filter file from disk
+func LoadBloomFilter(path string) (*BloomFilter, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadBloomFilter(bufio.NewReader(file))
+}
@@ -0,0 +1,146 @@
+package passwordpolicy
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ccojocar/zxcvbn-go"
+)
+
+// Violation codes
+const (
+	TooShort             = "too_short"
+	TooLong              = "too_long"
+	MissingLowercase     = "missing_lowercase"
+	MissingUppercase     = "missing_uppercase"
+	MissingDigit         = "missing_digit"
+	MissingSymbol        = "missing_symbol"
+	TooWeak              = "too_weak"
+	ContainsPersonalInfo = "contains_personal_info"
+	Breached             = "breached"
+)
+
+// minPersonalInfoLength is the shortest email local part or name that is searched for
+const minPersonalInfoLength = 3
+
+// Violation is one rule a password breaks. Limit is the length or score the
+// rule requires, zero when the rule has none.
+type Violation struct {
+	Code  string
+	Limit int
+}
+
+// UserInfo is personal data a password must not contain
+type UserInfo struct {
+	Email    string
+	FullName string
+}
+
+// Policy describes what a password must satisfy. Zero values disable a rule.
+type Policy struct {
+	// MinLength and MaxLength count characters, not bytes
+	MinLength        int
+	MaxLength        int
+	RequireLowercase bool
+	RequireUppercase bool
+	RequireDigit     bool
+	RequireSymbol    bool
+	// MinStrength is the lowest accepted zxcvbn score, from 0 (too guessable) to 4
+	MinStrength int
+	// ForbidPersonalInfo rejects passwords containing the email, its local part or the full name
+	ForbidPersonalInfo bool
+	// Breached rejects passwords found in a breach corpus
+	Breached *BloomFilter
+}
+
+// Validate returns every rule the password breaks, or nil when it is accepted
+func (p *Policy) Validate(password string, info UserInfo) []Violation {
+	var violations []Violation
+
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		violations = append(violations, Violation{Code: TooShort, Limit: p.MinLength})
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, Violation{Code: TooLong, Limit: p.MaxLength})
+	}
+
+	var hasLower, hasUpper, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireLowercase && !hasLower {
+		violations = append(violations, Violation{Code: MissingLowercase})
+	}
+	if p.RequireUppercase && !hasUpper {
+		violations = append(violations, Violation{Code: MissingUppercase})
+	}
+	if p.RequireDigit && !hasDigit {
+		violations = append(violations, Violation{Code: MissingDigit})
+	}
+	if p.RequireSymbol && !hasSymbol {
+		violations = append(violations, Violation{Code: MissingSymbol})
+	}
+
+	if p.ForbidPersonalInfo && containsPersonalInfo(password, info) {
+		violations = append(violations, Violation{Code: ContainsPersonalInfo})
+	}
+
+	if p.MinStrength > 0 && password != "" {
+		// Personal info is passed along so zxcvbn also scores it as guessable
+		strength := zxcvbn.PasswordStrength(password, personalInfo(info))
+		if strength.Score < p.MinStrength {
+			violations = append(violations, Violation{Code: TooWeak, Limit: p.MinStrength})
+		}
+	}
+
+	if p.Breached != nil && p.Breached.ContainsPassword(password) {
+		violations = append(violations, Violation{Code: Breached})
+	}
+
+	return violations
+}
+
+func containsPersonalInfo(password string, info UserInfo) bool {
+	lowered := strings.ToLower(password)
+	for _, value := range personalInfo(info) {
+		if utf8.RuneCountInString(value) >= minPersonalInfoLength && strings.Contains(lowered, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// personalInfo returns the lower-cased values searched for: the email, its local
+// part, and the full name with and without spaces
+func personalInfo(info UserInfo) []string {
+	var values []string
+
+	email := strings.ToLower(strings.TrimSpace(info.Email))
+	if email != "" {
+		values = append(values, email)
+		if at := strings.LastIndex(email, "@"); at > 0 {
+			values = append(values, email[:at])
+		}
+	}
+
+	name := strings.ToLower(strings.Join(strings.Fields(info.FullName), " "))
+	if name != "" {
+		values = append(values, name)
+		if compact := strings.ReplaceAll(name, " ", ""); compact != name {
+			values = append(values, compact)
+		}
+	}
+
+	return values
+}
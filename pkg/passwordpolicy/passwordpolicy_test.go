@@ -0,0 +1,133 @@
+package passwordpolicy_test
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/pkg/passwordpolicy"
+)
+
+func TestValidate(t *testing.T) {
+	breached := passwordpolicy.NewBloomFilter(10, 0.001)
+	breached.AddPassword("Tr0ub4dor&3")
+
+	policy := &passwordpolicy.Policy{
+		MinLength:          8,
+		MaxLength:          20,
+		RequireLowercase:   true,
+		RequireUppercase:   true,
+		RequireDigit:       true,
+		RequireSymbol:      true,
+		MinStrength:        3,
+		ForbidPersonalInfo: true,
+		Breached:           breached,
+	}
+	info := passwordpolicy.UserInfo{Email: "budi.santoso@example.com", FullName: "Budi Santoso"}
+
+	tests := []struct {
+		name     string
+		password string
+		expected []passwordpolicy.Violation
+	}{
+		{
+			name:     "accepted",
+			password: "Kopi!Hitam7Senja",
+			expected: nil,
+		},
+		{
+			name:     "too short and weak",
+			password: "Ab1!",
+			expected: []passwordpolicy.Violation{
+				{Code: passwordpolicy.TooShort, Limit: 8},
+				{Code: passwordpolicy.TooWeak, Limit: 3},
+			},
+		},
+		{
+			name:     "too long",
+			password: "Kopi!Hitam7Senja-Pagi-Hari",
+			expected: []passwordpolicy.Violation{
+				{Code: passwordpolicy.TooLong, Limit: 20},
+			},
+		},
+		{
+			name:     "missing character classes",
+			password: "kopihitamsenjapagi",
+			expected: []passwordpolicy.Violation{
+				{Code: passwordpolicy.MissingUppercase},
+				{Code: passwordpolicy.MissingDigit},
+				{Code: passwordpolicy.MissingSymbol},
+			},
+		},
+		{
+			name:     "contains full name",
+			password: "BudiSantoso!2026",
+			expected: []passwordpolicy.Violation{
+				{Code: passwordpolicy.ContainsPersonalInfo},
+				{Code: passwordpolicy.TooWeak, Limit: 3},
+			},
+		},
+		{
+			name:     "contains email local part",
+			password: "X9!budi.santoso#Q",
+			expected: []passwordpolicy.Violation{
+				{Code: passwordpolicy.ContainsPersonalInfo},
+				{Code: passwordpolicy.TooWeak, Limit: 3},
+			},
+		},
+		{
+			name:     "breached",
+			password: "Tr0ub4dor&3",
+			expected: []passwordpolicy.Violation{
+				{Code: passwordpolicy.Breached},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, policy.Validate(tt.password, info))
+		})
+	}
+}
+
+func TestValidateDisabledRules(t *testing.T) {
+	policy := &passwordpolicy.Policy{MinLength: 4}
+
+	assert.Equal(t, []passwordpolicy.Violation(nil), policy.Validate("budi", passwordpolicy.UserInfo{FullName: "Budi"}))
+}
+
+func TestBloomFilter(t *testing.T) {
+	filter := passwordpolicy.NewBloomFilter(1000, 0.01)
+	for i := 0; i < 1000; i++ {
+		filter.AddPassword(fmt.Sprintf("password%d", i))
+	}
+	filter.Add(sha1.Sum([]byte("from-digest")))
+
+	var buf bytes.Buffer
+	_, err := filter.WriteTo(&buf)
+	assert.Nil(t, err)
+
+	loaded, err := passwordpolicy.ReadBloomFilter(&buf)
+	assert.Nil(t, err)
+
+	// No false negatives
+	for i := 0; i < 1000; i++ {
+		assert.Equal(t, true, loaded.ContainsPassword(fmt.Sprintf("password%d", i)))
+	}
+	assert.Equal(t, true, loaded.ContainsPassword("from-digest"))
+
+	// False positives stay near the configured rate
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if loaded.ContainsPassword(fmt.Sprintf("unknown%d", i)) {
+			falsePositives++
+		}
+	}
+	assert.Equal(t, true, falsePositives < 300)
+
+	_, err = passwordpolicy.ReadBloomFilter(bytes.NewReader([]byte("not a filter")))
+	assert.Equal(t, passwordpolicy.ErrInvalidFilter, err)
+}
@@ -0,0 +1,86 @@
+package personaldata
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Provider exports and erases the personal data one module keeps about a user.
+// Every repository holding user data registers one, so export and deletion
+// requests cover the whole application.
+type Provider interface {
+	// Name is the key of the provider's section in the export archive
+	Name() string
+	// Export returns the data kept about the user, encoded as JSON in the archive
+	Export(ctx context.Context, userID string) (any, error)
+	// Purge hard-deletes or anonymizes the data kept about the user
+	Purge(ctx context.Context, userID string) error
+}
+
+// Archive is the export of everything the registered providers keep about a user
+type Archive struct {
+	UserID     string         `json:"user_id"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Data       map[string]any `json:"data"`
+}
+
+// Registry holds the providers of the application
+type Registry struct {
+	mu        sync.RWMutex
+	providers []Provider
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a provider. Providers are purged in reverse order of registration,
+// so the module owning the user account registers first and deletes it last.
+func (r *Registry) Register(provider Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, registered := range r.providers {
+		if registered.Name() == provider.Name() {
+			panic(fmt.Sprintf("personaldata: provider %q registered twice", provider.Name()))
+		}
+	}
+	r.providers = append(r.providers, provider)
+}
+
+// Export collects the data of every provider into one archive
+func (r *Registry) Export(ctx context.Context, userID string) (*Archive, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	archive := &Archive{
+		UserID:     userID,
+		ExportedAt: time.Now().UTC(),
+		Data:       make(map[string]any, len(r.providers)),
+	}
+	for _, provider := range r.providers {
+		data, err := provider.Export(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("personaldata: export %s: %w", provider.Name(), err)
+		}
+		archive.Data[provider.Name()] = data
+	}
+	return archive, nil
+}
+
+// Purge erases the data of every provider, stopping at the first failure so the
+// account is kept and the purge can be retried
+func (r *Registry) Purge(ctx context.Context, userID string) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for i := len(r.providers) - 1; i >= 0; i-- {
+		if err := r.providers[i].Purge(ctx, userID); err != nil {
+			return fmt.Errorf("personaldata: purge %s: %w", r.providers[i].Name(), err)
+		}
+	}
+	return nil
+}
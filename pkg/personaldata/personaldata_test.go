@@ -0,0 +1,200 @@
+package personaldata_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/pkg/personaldata"
+)
+
+type fakeProvider struct {
+	name      string
+	data      any
+	exportErr error
+	purgeErr  error
+	purged    *[]string
+}
+
+func (p *fakeProvider) Name() string {
+	return p.name
+}
+
+func (p *fakeProvider) Export(ctx context.Context, userID string) (any, error) {
+	return p.data, p.exportErr
+}
+
+func (p *fakeProvider) Purge(ctx context.Context, userID string) error {
+	if p.purgeErr != nil {
+		return p.purgeErr
+	}
+	*p.purged = append(*p.purged, p.name+":"+userID)
+	return nil
+}
+
+type fakeSchedule struct {
+	userIDs []string
+	err     error
+}
+
+func (s *fakeSchedule) DueDeletions(ctx context.Context) ([]string, error) {
+	return s.userIDs, s.err
+}
+
+func TestRegistryExport(t *testing.T) {
+	tests := []struct {
+		name          string
+		providers     []*fakeProvider
+		expectedData  map[string]any
+		expectedError string
+	}{
+		{
+			name:         "no providers",
+			expectedData: map[string]any{},
+		},
+		{
+			name: "one section per provider",
+			providers: []*fakeProvider{
+				{name: "auth", data: map[string]string{"email": "user@example.com"}},
+				{name: "orders", data: []int{1, 2}},
+			},
+			expectedData: map[string]any{
+				"auth":   map[string]string{"email": "user@example.com"},
+				"orders": []int{1, 2},
+			},
+		},
+		{
+			name: "provider failure",
+			providers: []*fakeProvider{
+				{name: "auth", data: "ok"},
+				{name: "orders", exportErr: errors.New("connection refused")},
+			},
+			expectedError: "personaldata: export orders: connection refused",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := personaldata.NewRegistry()
+			for _, provider := range tt.providers {
+				registry.Register(provider)
+			}
+
+			archive, err := registry.Export(context.Background(), "7")
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				assert.Nil(t, archive)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "7", archive.UserID)
+			assert.False(t, archive.ExportedAt.IsZero())
+			assert.Equal(t, tt.expectedData, archive.Data)
+		})
+	}
+}
+
+func TestRegistryPurge(t *testing.T) {
+	tests := []struct {
+		name           string
+		purgeErrors    map[string]error
+		expectedPurged []string
+		expectedError  string
+	}{
+		{
+			name:           "reverse registration order",
+			expectedPurged: []string{"orders:7", "organizations:7", "auth:7"},
+		},
+		{
+			name:           "stops at the first failure",
+			purgeErrors:    map[string]error{"organizations": errors.New("deadlock")},
+			expectedPurged: []string{"orders:7"},
+			expectedError:  "personaldata: purge organizations: deadlock",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			purged := []string{}
+			registry := personaldata.NewRegistry()
+			for _, name := range []string{"auth", "organizations", "orders"} {
+				registry.Register(&fakeProvider{name: name, purgeErr: tt.purgeErrors[name], purged: &purged})
+			}
+
+			err := registry.Purge(context.Background(), "7")
+
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedPurged, purged)
+		})
+	}
+}
+
+func TestRegistryRegisterDuplicate(t *testing.T) {
+	registry := personaldata.NewRegistry()
+	registry.Register(&fakeProvider{name: "auth"})
+
+	assert.Panics(t, func() {
+		registry.Register(&fakeProvider{name: "auth"})
+	})
+}
+
+func TestPurgerPurgeDue(t *testing.T) {
+	tests := []struct {
+		name           string
+		schedule       *fakeSchedule
+		purgeErr       error
+		expectedCount  int
+		expectedPurged []string
+		expectedError  bool
+	}{
+		{
+			name:           "nothing due",
+			schedule:       &fakeSchedule{},
+			expectedPurged: []string{},
+		},
+		{
+			name:           "every due user",
+			schedule:       &fakeSchedule{userIDs: []string{"7", "9"}},
+			expectedCount:  2,
+			expectedPurged: []string{"auth:7", "auth:9"},
+		},
+		{
+			name:           "failed purge is skipped",
+			schedule:       &fakeSchedule{userIDs: []string{"7", "9"}},
+			purgeErr:       errors.New("deadlock"),
+			expectedPurged: []string{},
+		},
+		{
+			name:           "schedule failure",
+			schedule:       &fakeSchedule{err: errors.New("connection refused")},
+			expectedPurged: []string{},
+			expectedError:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			purged := []string{}
+			registry := personaldata.NewRegistry()
+			registry.Register(&fakeProvider{name: "auth", purgeErr: tt.purgeErr, purged: &purged})
+			purger := personaldata.NewPurger(registry, tt.schedule, time.Hour)
+
+			count, err := purger.PurgeDue(context.Background())
+
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedCount, count)
+			assert.Equal(t, tt.expectedPurged, purged)
+		})
+	}
+}
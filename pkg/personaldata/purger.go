@@ -0,0 +1,65 @@
+package personaldata
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Schedule lists the users whose deletion grace period has ended
+type Schedule interface {
+	DueDeletions(ctx context.Context) ([]string, error)
+}
+
+// Purger periodically erases the users whose deletion is due
+type Purger struct {
+	registry *Registry
+	schedule Schedule
+	interval time.Duration
+}
+
+// NewPurger creates a purger checking the schedule every interval
+func NewPurger(registry *Registry, schedule Schedule, interval time.Duration) *Purger {
+	return &Purger{
+		registry: registry,
+		schedule: schedule,
+		interval: interval,
+	}
+}
+
+// Run purges due users until ctx is cancelled
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := p.PurgeDue(ctx); err != nil {
+			log.Println("Failed to list due account deletions:", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeDue erases every user whose deletion is due and returns how many were erased.
+// A failing user is logged and retried on the next run without blocking the others.
+func (p *Purger) PurgeDue(ctx context.Context) (int, error) {
+	userIDs, err := p.schedule.DueDeletions(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, userID := range userIDs {
+		if err := p.registry.Purge(ctx, userID); err != nil {
+			log.Printf("Failed to purge user %s: %v", userID, err)
+			continue
+		}
+		purged++
+	}
+	return purged, nil
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// pruneThreshold is the number of stored keys above which expired keys are swept
+const pruneThreshold = 10000
+
+type bucketState struct {
+	tokens    float64
+	updatedAt time.Time
+	expiresAt time.Time
+}
+
+type windowState struct {
+	index     int64
+	current   int
+	previous  int
+	expiresAt time.Time
+}
+
+// MemoryStore keeps counters in process memory. It only limits a single API
+// instance; use RedisStore when running several replicas.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	buckets map[string]bucketState
+	windows map[string]windowState
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		now:     time.Now,
+		buckets: make(map[string]bucketState),
+		windows: make(map[string]windowState),
+	}
+}
+
+// WithClock replaces the time source, used by tests
+func (s *MemoryStore) WithClock(now func() time.Time) *MemoryStore {
+	s.now = now
+	return s
+}
+
+func (s *MemoryStore) Allow(_ context.Context, key string, rule Rule) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if len(s.buckets)+len(s.windows) >= pruneThreshold {
+		s.prune(now)
+	}
+
+	switch rule.Algorithm {
+	case TokenBucket:
+		return s.takeToken(key, rule, now), nil
+	case SlidingWindow:
+		return s.countWindow(key, rule, now), nil
+	default:
+		return Result{}, ErrUnknownAlgorithm
+	}
+}
+
+func (s *MemoryStore) takeToken(key string, rule Rule, now time.Time) Result {
+	tokens := float64(rule.Limit)
+	if state, ok := s.buckets[key]; ok {
+		tokens = refillTokens(rule, state.tokens, now.Sub(state.updatedAt))
+	}
+
+	allowed := tokens >= 1
+	if allowed {
+		tokens--
+	}
+	s.buckets[key] = bucketState{tokens: tokens, updatedAt: now, expiresAt: now.Add(rule.Period)}
+	return tokenBucketResult(rule, allowed, tokens)
+}
+
+func (s *MemoryStore) countWindow(key string, rule Rule, now time.Time) Result {
+	index, elapsed := windowStart(rule, now)
+
+	state := s.windows[key]
+	switch state.index {
+	case index:
+	case index - 1:
+		state = windowState{index: index, previous: state.current}
+	default:
+		state = windowState{index: index}
+	}
+
+	allowed := slidingEstimate(rule, state.current, state.previous, elapsed)+1 <= float64(rule.Limit)
+	if allowed {
+		state.current++
+	}
+	state.expiresAt = now.Add(2*rule.Period - elapsed)
+	s.windows[key] = state
+	return slidingWindowResult(rule, allowed, state.current, state.previous, elapsed)
+}
+
+func (s *MemoryStore) prune(now time.Time) {
+	for key, state := range s.buckets {
+		if !now.Before(state.expiresAt) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, state := range s.windows {
+		if !now.Before(state.expiresAt) {
+			delete(s.windows, key)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"math"
+	"time"
+)
+
+// Algorithm selects how requests are counted
+type Algorithm string
+
+const (
+	// TokenBucket allows bursts of Limit requests and refills Limit tokens every Period
+	TokenBucket Algorithm = "token_bucket"
+	// SlidingWindow allows Limit requests in any Period, estimated from the
+	// current and previous fixed window
+	SlidingWindow Algorithm = "sliding_window"
+)
+
+var ErrUnknownAlgorithm = errors.New("ratelimit: unknown algorithm")
+
+// Rule describes a limit of Limit requests per Period
+type Rule struct {
+	Algorithm Algorithm
+	Limit     int
+	Period    time.Duration
+}
+
+// Result is the outcome of one request against a rule
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// ResetAfter is the time until the quota is fully available again
+	ResetAfter time.Duration
+	// RetryAfter is the time until the next request is allowed, zero when allowed
+	RetryAfter time.Duration
+}
+
+// Store counts requests per key. Implementations must apply a request atomically
+// so concurrent callers, including other API replicas, cannot exceed the limit.
+type Store interface {
+	Allow(ctx context.Context, key string, rule Rule) (Result, error)
+}
+
+// refillTokens returns the tokens in a bucket after elapsed time
+func refillTokens(rule Rule, tokens float64, elapsed time.Duration) float64 {
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	tokens += float64(elapsed) * float64(rule.Limit) / float64(rule.Period)
+	return math.Min(tokens, float64(rule.Limit))
+}
+
+// tokenBucketResult builds the result from the tokens left after a request
+func tokenBucketResult(rule Rule, allowed bool, tokens float64) Result {
+	perToken := float64(rule.Period) / float64(rule.Limit)
+	result := Result{
+		Allowed:    allowed,
+		Limit:      rule.Limit,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration(math.Ceil((float64(rule.Limit) - tokens) * perToken)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration(math.Ceil((1 - tokens) * perToken))
+	}
+	return result
+}
+
+// windowStart returns the index of the fixed window containing now and how far into it now is
+func windowStart(rule Rule, now time.Time) (int64, time.Duration) {
+	period := rule.Period.Nanoseconds()
+	nanos := now.UnixNano()
+	return nanos / period, time.Duration(nanos % period)
+}
+
+// slidingEstimate weights the previous window by the part of it still inside the sliding period
+func slidingEstimate(rule Rule, current, previous int, elapsed time.Duration) float64 {
+	weight := float64(rule.Period-elapsed) / float64(rule.Period)
+	return float64(previous)*weight + float64(current)
+}
+
+// slidingWindowResult builds the result from the window counters after a request
+func slidingWindowResult(rule Rule, allowed bool, current, previous int, elapsed time.Duration) Result {
+	estimate := slidingEstimate(rule, current, previous, elapsed)
+	remaining := int(math.Floor(float64(rule.Limit) - estimate))
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	// The current window only stops counting once it is the previous one and has slid out
+	result := Result{
+		Allowed:    allowed,
+		Limit:      rule.Limit,
+		Remaining:  remaining,
+		ResetAfter: 2*rule.Period - elapsed,
+	}
+	if current == 0 {
+		result.ResetAfter = rule.Period - elapsed
+	}
+	if !allowed {
+		result.RetryAfter = slidingRetryAfter(rule, current, previous, elapsed)
+	}
+	return result
+}
+
+// slidingRetryAfter returns the wait until the estimate drops to Limit-1, so one more request fits
+func slidingRetryAfter(rule Rule, current, previous int, elapsed time.Duration) time.Duration {
+	period := float64(rule.Period)
+	room := float64(rule.Limit - 1 - current)
+
+	if room >= 0 && previous > 0 {
+		// Wait inside the current window for the previous one to slide out
+		wait := period - float64(elapsed) - room*period/float64(previous)
+		return time.Duration(math.Ceil(math.Max(wait, 0)))
+	}
+
+	// Wait for the next window, where the current count becomes the previous one
+	wait := period - float64(elapsed)
+	if current > 0 {
+		wait += math.Max(period-float64(rule.Limit-1)*period/float64(current), 0)
+	}
+	return time.Duration(math.Ceil(wait))
+}
@@ -0,0 +1,116 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/pkg/ratelimit"
+)
+
+type step struct {
+	advance           time.Duration
+	expectedAllowed   bool
+	expectedRemaining int
+	expectedRetry     time.Duration
+}
+
+// newStores returns every store implementation driven by the same clock
+func newStores(t *testing.T, now func() time.Time) map[string]ratelimit.Store {
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	return map[string]ratelimit.Store{
+		"memory": ratelimit.NewMemoryStore().WithClock(now),
+		"redis":  ratelimit.NewRedisStore(client, "test:").WithClock(now),
+	}
+}
+
+func TestStores(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  ratelimit.Rule
+		steps []step
+	}{
+		{
+			name: "token bucket",
+			rule: ratelimit.Rule{Algorithm: ratelimit.TokenBucket, Limit: 3, Period: 3 * time.Second},
+			steps: []step{
+				{expectedAllowed: true, expectedRemaining: 2},
+				{expectedAllowed: true, expectedRemaining: 1},
+				{expectedAllowed: true, expectedRemaining: 0},
+				{expectedAllowed: false, expectedRemaining: 0, expectedRetry: time.Second},
+				{advance: time.Second, expectedAllowed: true, expectedRemaining: 0},
+				{advance: 3 * time.Second, expectedAllowed: true, expectedRemaining: 2},
+			},
+		},
+		{
+			name: "sliding window",
+			rule: ratelimit.Rule{Algorithm: ratelimit.SlidingWindow, Limit: 2, Period: 10 * time.Second},
+			steps: []step{
+				{expectedAllowed: true, expectedRemaining: 1},
+				{expectedAllowed: true, expectedRemaining: 0},
+				{expectedAllowed: false, expectedRemaining: 0, expectedRetry: 15 * time.Second},
+				// Half of the previous window still counts
+				{advance: 10 * time.Second, expectedAllowed: false, expectedRemaining: 0, expectedRetry: 5 * time.Second},
+				{advance: 5 * time.Second, expectedAllowed: true, expectedRemaining: 0},
+				{advance: 20 * time.Second, expectedAllowed: true, expectedRemaining: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		now := time.Unix(1800000000, 0)
+		stores := newStores(t, func() time.Time { return now })
+
+		for storeName, store := range stores {
+			t.Run(tt.name+" "+storeName, func(t *testing.T) {
+				now = time.Unix(1800000000, 0)
+
+				for i, s := range tt.steps {
+					now = now.Add(s.advance)
+					result, err := store.Allow(context.Background(), "client", tt.rule)
+
+					assert.Nil(t, err)
+					assert.Equal(t, s.expectedAllowed, result.Allowed, "step %d", i)
+					assert.Equal(t, s.expectedRemaining, result.Remaining, "step %d", i)
+					assert.Equal(t, s.expectedRetry, result.RetryAfter, "step %d", i)
+					assert.Equal(t, tt.rule.Limit, result.Limit)
+				}
+			})
+		}
+	}
+}
+
+func TestStoresSeparateKeys(t *testing.T) {
+	now := time.Unix(1800000000, 0)
+	rule := ratelimit.Rule{Algorithm: ratelimit.SlidingWindow, Limit: 1, Period: time.Minute}
+
+	for storeName, store := range newStores(t, func() time.Time { return now }) {
+		t.Run(storeName, func(t *testing.T) {
+			first, _ := store.Allow(context.Background(), "a", rule)
+			second, _ := store.Allow(context.Background(), "a", rule)
+			other, _ := store.Allow(context.Background(), "b", rule)
+
+			assert.Equal(t, true, first.Allowed)
+			assert.Equal(t, false, second.Allowed)
+			assert.Equal(t, true, other.Allowed)
+		})
+	}
+}
+
+func TestUnknownAlgorithm(t *testing.T) {
+	rule := ratelimit.Rule{Algorithm: "leaky_bucket", Limit: 1, Period: time.Minute}
+
+	for storeName, store := range newStores(t, time.Now) {
+		t.Run(storeName, func(t *testing.T) {
+			_, err := store.Allow(context.Background(), "a", rule)
+
+			assert.Equal(t, ratelimit.ErrUnknownAlgorithm, err)
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// tokenBucketScript refills and takes one token atomically.
+// KEYS[1] bucket, ARGV[1] limit, ARGV[2] period ms, ARGV[3] now ms.
+// Returns {allowed, tokens left as string}.
+var tokenBucketScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = limit
+	ts = now
+end
+
+local elapsed = math.max(0, now - ts)
+tokens = math.min(limit, tokens + elapsed * limit / period)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HMSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], period)
+return {allowed, tostring(tokens)}
+`)
+
+// slidingWindowScript counts one request in the current window when the estimate allows it.
+// KEYS[1] current window, KEYS[2] previous window, ARGV[1] limit, ARGV[2] period ms,
+// ARGV[3] ms elapsed in the current window. Returns {allowed, current, previous}.
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+
+local current = tonumber(redis.call('GET', KEYS[1]) or '0')
+local previous = tonumber(redis.call('GET', KEYS[2]) or '0')
+
+local allowed = 0
+if previous * (period - elapsed) / period + current + 1 <= limit then
+	current = redis.call('INCR', KEYS[1])
+	redis.call('PEXPIRE', KEYS[1], period * 2)
+	allowed = 1
+end
+return {allowed, current, previous}
+`)
+
+// RedisStore keeps counters in Redis, or any server speaking the Redis protocol,
+// so all API replicas share the same limits
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisStore creates a store whose keys start with prefix
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix, now: time.Now}
+}
+
+// WithClock replaces the time source, used by tests
+func (s *RedisStore) WithClock(now func() time.Time) *RedisStore {
+	s.now = now
+	return s
+}
+
+func (s *RedisStore) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	switch rule.Algorithm {
+	case TokenBucket:
+		return s.takeToken(ctx, key, rule)
+	case SlidingWindow:
+		return s.countWindow(ctx, key, rule)
+	default:
+		return Result{}, ErrUnknownAlgorithm
+	}
+}
+
+func (s *RedisStore) takeToken(ctx context.Context, key string, rule Rule) (Result, error) {
+	reply, err := tokenBucketScript.Run(ctx, s.client,
+		[]string{s.prefix + "tb:" + key},
+		rule.Limit, rule.Period.Milliseconds(), s.now().UnixMilli(),
+	).Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(reply) != 2 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected token bucket reply %v", reply)
+	}
+
+	allowed, _ := reply[0].(int64)
+	text, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("ratelimit: invalid token count %q", text)
+	}
+	return tokenBucketResult(rule, allowed == 1, tokens), nil
+}
+
+func (s *RedisStore) countWindow(ctx context.Context, key string, rule Rule) (Result, error) {
+	index, elapsed := windowStart(rule, s.now())
+	base := s.prefix + "sw:" + key + ":"
+
+	reply, err := slidingWindowScript.Run(ctx, s.client,
+		[]string{base + strconv.FormatInt(index, 10), base + strconv.FormatInt(index-1, 10)},
+		rule.Limit, rule.Period.Milliseconds(), elapsed.Milliseconds(),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(reply) != 3 {
+		return Result{}, fmt.Errorf("ratelimit: unexpected sliding window reply %v", reply)
+	}
+	return slidingWindowResult(rule, reply[0] == 1, int(reply[1]), int(reply[2]), elapsed), nil
+}
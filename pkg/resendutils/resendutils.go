@@ -1,43 +1,71 @@
 package resendutils
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/resend/resend-go/v2"
 	"github.com/yantology/golang-starter-template/pkg/customerror"
+	"github.com/yantology/golang-starter-template/pkg/mailer"
+	"github.com/yantology/golang-starter-template/pkg/suppression"
 )
 
 type ResendUtilsInterface interface {
-	Send(html, subject string, to []string) *customerror.CustomError
+	Send(message *mailer.Message) (string, *customerror.CustomError)
 }
 
 type ResendUtils struct {
-	apiKey     string
-	fromDomain string
+	apiKey       string
+	fromDomain   string
+	fromName     string
+	suppressions suppression.Checker
 }
 
-func NewResendUtils(apiKey, fromDomain string) ResendUtilsInterface {
+// NewResendUtils creates the Resend client. Emails to an address in suppressions are
+// refused, nil sends to every address.
+func NewResendUtils(apiKey, fromDomain, fromName string, suppressions suppression.Checker) ResendUtilsInterface {
 	return &ResendUtils{
-		apiKey:     apiKey,
-		fromDomain: fromDomain,
+		apiKey:       apiKey,
+		fromDomain:   fromDomain,
+		fromName:     fromName,
+		suppressions: suppressions,
 	}
 }
 
-func (r *ResendUtils) Send(html, subject string, to []string) *customerror.CustomError {
+// Send returns the Resend id of the email, which its webhook events refer to
+func (r *ResendUtils) Send(message *mailer.Message) (string, *customerror.CustomError) {
+	if len(message.To) == 0 {
+		return "", customerror.NewCustomError(nil, "No email recipient", http.StatusBadRequest)
+	}
+
+	if r.suppressions != nil {
+		suppressed, err := r.suppressions.Suppressed(context.Background(), message.To...)
+		if err != nil {
+			return "", customerror.NewCustomError(err, "Failed to check suppressed recipients", http.StatusInternalServerError)
+		}
+		if len(suppressed) > 0 {
+			return "", customerror.NewCustomError(nil,
+				"Recipient is suppressed after a bounce or spam complaint: "+strings.Join(suppressed, ", "),
+				http.StatusUnprocessableEntity)
+		}
+	}
+
 	client := resend.NewClient(r.apiKey)
 
 	params := &resend.SendEmailRequest{
-		From:    fmt.Sprintf("Yantology <activation@%s>", r.fromDomain),
-		To:      to,
-		Subject: subject,
-		Html:    html,
+		From:    fmt.Sprintf("%s <activation@%s>", r.fromName, r.fromDomain),
+		To:      message.To,
+		Subject: message.Subject,
+		Html:    message.HTML,
+		Text:    message.Text,
 	}
 
-	_, err := client.Emails.Send(params)
+	sent, err := client.Emails.Send(params)
 	if err != nil {
-		return customerror.NewCustomError(err, "Failed to send email", http.StatusInternalServerError)
+		return "", customerror.NewCustomError(err, "Failed to send email", http.StatusInternalServerError)
 	}
 
-	return nil
+	return sent.Id, nil
 }
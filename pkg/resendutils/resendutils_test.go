@@ -0,0 +1,266 @@
+package resendutils_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yantology/golang-starter-template/pkg/mailer"
+	"github.com/yantology/golang-starter-template/pkg/resendutils"
+)
+
+type fakeChecker struct {
+	suppressed map[string]bool
+	err        error
+}
+
+func (c *fakeChecker) Suppressed(ctx context.Context, emails ...string) ([]string, error) {
+	var suppressed []string
+	for _, email := range emails {
+		if c.suppressed[email] {
+			suppressed = append(suppressed, email)
+		}
+	}
+	return suppressed, c.err
+}
+
+func TestNewResendUtils(t *testing.T) {
+	client := resendutils.NewResendUtils("test-api-key", "test.com", "Test Sender", nil)
+	assert.NotNil(t, client)
+}
+
+// The cases are refused before Resend is called, so they need no API key
+func TestResendUtilsSendRefused(t *testing.T) {
+	tests := []struct {
+		name         string
+		to           []string
+		checker      *fakeChecker
+		expectedCode int
+	}{
+		{
+			name:         "empty recipient",
+			to:           []string{},
+			checker:      &fakeChecker{},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "suppressed recipient",
+			to:           []string{"ok@example.com", "bounced@example.com"},
+			checker:      &fakeChecker{suppressed: map[string]bool{"bounced@example.com": true}},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "suppression check failed",
+			to:           []string{"ok@example.com"},
+			checker:      &fakeChecker{err: errors.New("connection refused")},
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := resendutils.NewResendUtils("test-api-key", "test.com", "Test Sender", tt.checker)
+
+			id, cuserr := client.Send(&mailer.Message{To: tt.to, Subject: "Test Subject", HTML: "<h1>Test Email</h1>"})
+
+			require.NotNil(t, cuserr)
+			assert.Equal(t, tt.expectedCode, cuserr.Code())
+			assert.Empty(t, id)
+		})
+	}
+}
+
+// Fixtures are recorded webhook payloads, signed with testSecret at fixtureTime
+const testSecret = "whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw"
+
+var fixtureTime = time.Date(2026, time.October, 18, 8, 0, 3, 0, time.UTC)
+
+type fixture struct {
+	file      string
+	id        string
+	signature string
+}
+
+var (
+	deliveredFixture = fixture{
+		file:      "email_delivered.json",
+		id:        "msg_2mXgpBqJmMCH0zSyrmWPL7uG4yo",
+		signature: "v1,bfa7Y1+ZpNmR243oKv39I5aflpq0sLAQXZ6EDz1yMVw=",
+	}
+	permanentBounceFixture = fixture{
+		file:      "email_bounced_permanent.json",
+		id:        "msg_2mXgpEZ9kX3o6Z1Hh0FzDqf7NZb",
+		signature: "v1,wwICJlBwcGUYo4OaggcQW9R+mBVRd6xNPbuVZj1ue5o=",
+	}
+	transientBounceFixture = fixture{
+		file:      "email_bounced_transient.json",
+		id:        "msg_2mXgpHfG2w6YxQJ4hH1SQb9vEoA",
+		signature: "v1,R13fjM6wqEP/SYWBy20JK5q+3Kv7Mzti+ioyoFBmYaI=",
+	}
+	complaintFixture = fixture{
+		file:      "email_complained.json",
+		id:        "msg_2mXh3aA0V2FkQ1uYwL5hQ0mM8cT",
+		signature: "v1,Ylih+HV8mfaEWJaSFwVHA28+LZh2FEPra5DPXIThV64=",
+	}
+)
+
+func (f fixture) request(t *testing.T) (http.Header, []byte) {
+	body, err := os.ReadFile(filepath.Join("testdata", f.file))
+	require.NoError(t, err)
+
+	header := http.Header{}
+	header.Set("svix-id", f.id)
+	header.Set("svix-timestamp", "1792310403")
+	header.Set("svix-signature", f.signature)
+	return header, body
+}
+
+func newTestVerifier(t *testing.T, now time.Time) *resendutils.WebhookVerifier {
+	verifier, err := resendutils.NewWebhookVerifier(testSecret)
+	require.NoError(t, err)
+	return verifier.WithClock(func() time.Time { return now })
+}
+
+func TestWebhookVerifierEvents(t *testing.T) {
+	tests := []struct {
+		name               string
+		fixture            fixture
+		expectedType       string
+		expectedEmailID    string
+		expectedTo         []string
+		expectedHardBounce bool
+	}{
+		{
+			name:            "delivered",
+			fixture:         deliveredFixture,
+			expectedType:    resendutils.EventDelivered,
+			expectedEmailID: "4ef9a417-02e9-4d39-ad75-9611e0fcc33c",
+			expectedTo:      []string{"user@example.com"},
+		},
+		{
+			name:               "permanent bounce",
+			fixture:            permanentBounceFixture,
+			expectedType:       resendutils.EventBounced,
+			expectedEmailID:    "56761188-7520-42d8-8898-ff6fc54ce618",
+			expectedTo:         []string{"missing@example.com"},
+			expectedHardBounce: true,
+		},
+		{
+			name:            "transient bounce",
+			fixture:         transientBounceFixture,
+			expectedType:    resendutils.EventBounced,
+			expectedEmailID: "0c2ab3b4-3b8e-4a52-9c3a-2d5e5b9c6d71",
+			expectedTo:      []string{"full@example.com"},
+		},
+		{
+			name:            "complaint",
+			fixture:         complaintFixture,
+			expectedType:    resendutils.EventComplained,
+			expectedEmailID: "9a1f6c2e-7d3b-4f0a-8e5c-1b2d3e4f5a6b",
+			expectedTo:      []string{"angry@example.com"},
+		},
+	}
+
+	verifier := newTestVerifier(t, fixtureTime)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, body := tt.fixture.request(t)
+
+			event, err := verifier.Verify(header, body)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedType, event.Type)
+			assert.Equal(t, tt.expectedEmailID, event.Data.EmailID)
+			assert.Equal(t, tt.expectedTo, event.Data.To)
+			assert.Equal(t, tt.expectedHardBounce, event.HardBounce())
+		})
+	}
+}
+
+func TestWebhookVerifierRejects(t *testing.T) {
+	tests := []struct {
+		name          string
+		now           time.Time
+		change        func(header http.Header, body []byte) []byte
+		expectedError error
+	}{
+		{
+			name: "tampered body",
+			now:  fixtureTime,
+			change: func(header http.Header, body []byte) []byte {
+				return append(body, ' ')
+			},
+			expectedError: resendutils.ErrInvalidSignature,
+		},
+		{
+			name: "signature of another message",
+			now:  fixtureTime,
+			change: func(header http.Header, body []byte) []byte {
+				header.Set("svix-signature", deliveredFixture.signature)
+				return body
+			},
+			expectedError: resendutils.ErrInvalidSignature,
+		},
+		{
+			name: "missing signature",
+			now:  fixtureTime,
+			change: func(header http.Header, body []byte) []byte {
+				header.Del("svix-signature")
+				return body
+			},
+			expectedError: resendutils.ErrInvalidSignature,
+		},
+		{
+			name: "unknown signature version",
+			now:  fixtureTime,
+			change: func(header http.Header, body []byte) []byte {
+				header.Set("svix-signature", "v2,"+permanentBounceFixture.signature[3:])
+				return body
+			},
+			expectedError: resendutils.ErrInvalidSignature,
+		},
+		{
+			name: "replayed later",
+			now:  fixtureTime.Add(10 * time.Minute),
+			change: func(header http.Header, body []byte) []byte {
+				return body
+			},
+			expectedError: resendutils.ErrExpiredWebhook,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, body := permanentBounceFixture.request(t)
+			body = tt.change(header, body)
+
+			event, err := newTestVerifier(t, tt.now).Verify(header, body)
+
+			assert.True(t, errors.Is(err, tt.expectedError), "unexpected error %v", err)
+			assert.Nil(t, event)
+		})
+	}
+}
+
+func TestWebhookVerifierRotatedSecret(t *testing.T) {
+	header, body := complaintFixture.request(t)
+	// During a rotation the signature of the old secret is sent too
+	header.Set("svix-signature", "v1,c3RhbGUgc2lnbmF0dXJl "+complaintFixture.signature)
+
+	event, err := newTestVerifier(t, fixtureTime).Verify(header, body)
+
+	require.NoError(t, err)
+	assert.Equal(t, resendutils.EventComplained, event.Type)
+}
+
+func TestNewWebhookVerifierInvalidSecret(t *testing.T) {
+	verifier, err := resendutils.NewWebhookVerifier("whsec_not base64!")
+	assert.Error(t, err)
+	assert.Nil(t, verifier)
+}
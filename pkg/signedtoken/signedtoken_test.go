@@ -0,0 +1,50 @@
+package signedtoken_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/pkg/signedtoken"
+)
+
+type payload struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+func TestSignAndVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	signer := signedtoken.New([]byte("secret")).WithClock(func() time.Time { return now })
+
+	token, err := signer.Sign("login", payload{Email: "user@example.com", Code: "123456"}, time.Minute)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name        string
+		signer      *signedtoken.Signer
+		purpose     string
+		token       string
+		expectedErr error
+	}{
+		{name: "valid token", signer: signer, purpose: "login", token: token},
+		{name: "wrong purpose", signer: signer, purpose: "invitation", token: token, expectedErr: signedtoken.ErrInvalidToken},
+		{name: "wrong secret", signer: signedtoken.New([]byte("other")).WithClock(func() time.Time { return now }), purpose: "login", token: token, expectedErr: signedtoken.ErrInvalidToken},
+		{name: "tampered payload", signer: signer, purpose: "login", token: "x" + token, expectedErr: signedtoken.ErrInvalidToken},
+		{name: "missing signature", signer: signer, purpose: "login", token: strings.Split(token, ".")[0], expectedErr: signedtoken.ErrInvalidToken},
+		{name: "expired token", signer: signer.WithClock(func() time.Time { return now.Add(time.Minute) }), purpose: "login", token: token, expectedErr: signedtoken.ErrExpiredToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out payload
+			err := tt.signer.Verify(tt.purpose, tt.token, &out)
+
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedErr == nil {
+				assert.Equal(t, payload{Email: "user@example.com", Code: "123456"}, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package suppression
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+// Verify interface implementation
+var _ Store = (*PostgresStore)(nil)
+
+// PostgresStore keeps suppressed addresses in the email_suppressions table
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Suppressed(ctx context.Context, emails ...string) ([]string, error) {
+	normalized := make([]string, 0, len(emails))
+	for _, email := range emails {
+		normalized = append(normalized, Normalize(email))
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT email FROM email_suppressions WHERE email = ANY($1)`, pq.Array(normalized))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	suppressed := []string{}
+	for rows.Next() {
+		var email string
+		if err := rows.Scan(&email); err != nil {
+			return nil, err
+		}
+		suppressed = append(suppressed, email)
+	}
+	return suppressed, rows.Err()
+}
+
+func (s *PostgresStore) Suppress(ctx context.Context, email, reason, detail string) error {
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO email_suppressions (email, reason, detail)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (email) DO NOTHING`,
+		Normalize(email), reason, detail)
+	return err
+}
+
+func (s *PostgresStore) List(ctx context.Context, limit int) ([]Entry, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT email, reason, detail, created_at
+		FROM email_suppressions
+		ORDER BY created_at DESC, email
+		LIMIT $1`,
+		limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		var entry Entry
+		if err := rows.Scan(&entry.Email, &entry.Reason, &entry.Detail, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+func (s *PostgresStore) Remove(ctx context.Context, email string) error {
+	result, err := s.db.ExecContext(ctx, `DELETE FROM email_suppressions WHERE email = $1`, Normalize(email))
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package suppression
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Reasons an address is suppressed
+const (
+	// ReasonBounce marks an address the provider reported as permanently undeliverable
+	ReasonBounce = "bounce"
+	// ReasonComplaint marks an address whose owner reported an email as spam
+	ReasonComplaint = "complaint"
+)
+
+// ErrNotFound is returned when removing an address that is not suppressed
+var ErrNotFound = errors.New("suppression: address not found")
+
+// Entry is a suppressed address
+type Entry struct {
+	Email  string
+	Reason string
+	// Detail is what the provider reported, such as the bounce message
+	Detail    string
+	CreatedAt time.Time
+}
+
+// Checker tells which addresses must not be sent to. Sending to addresses that
+// bounced or complained hurts the reputation of the sending domain.
+type Checker interface {
+	// Suppressed returns the suppressed addresses among emails, normalized
+	Suppressed(ctx context.Context, emails ...string) ([]string, error)
+}
+
+// Store keeps the suppressed addresses
+type Store interface {
+	Checker
+	// Suppress adds an address, keeping the first reason when it is already suppressed
+	Suppress(ctx context.Context, email, reason, detail string) error
+	// List returns the suppressed addresses, newest first
+	List(ctx context.Context, limit int) ([]Entry, error)
+	// Remove lets an address receive email again
+	Remove(ctx context.Context, email string) error
+}
+
+// Normalize returns the bare, lower-cased address of a recipient, so "User
+// <User@Example.com>" and "user@example.com" are the same entry
+func Normalize(email string) string {
+	if address, err := mail.ParseAddress(email); err == nil {
+		email = address.Address
+	}
+	return strings.ToLower(strings.TrimSpace(email))
+}
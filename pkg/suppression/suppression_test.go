@@ -0,0 +1,27 @@
+package suppression_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/pkg/suppression"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		email    string
+		expected string
+	}{
+		{name: "bare address", email: "user@example.com", expected: "user@example.com"},
+		{name: "mixed case", email: "User@Example.COM", expected: "user@example.com"},
+		{name: "surrounding spaces", email: "  user@example.com ", expected: "user@example.com"},
+		{name: "display name", email: "John Doe <John@Example.com>", expected: "john@example.com"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, suppression.Normalize(tt.email))
+		})
+	}
+}
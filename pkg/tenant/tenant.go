@@ -0,0 +1,34 @@
+// Package tenant carries the organization a request acts for in a context.Context,
+// so repositories can scope their queries without depending on the HTTP layer.
+package tenant
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNoTenant is returned by Require when the context carries no organization
+var ErrNoTenant = errors.New("tenant: no organization in context")
+
+type contextKey struct{}
+
+// WithID returns a copy of ctx carrying the organization ID
+func WithID(ctx context.Context, organizationID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, organizationID)
+}
+
+// ID returns the organization ID carried by ctx, if any
+func ID(ctx context.Context) (string, bool) {
+	organizationID, ok := ctx.Value(contextKey{}).(string)
+	return organizationID, ok && organizationID != ""
+}
+
+// Require returns the organization ID carried by ctx, or ErrNoTenant so a
+// repository never runs a query unscoped by mistake
+func Require(ctx context.Context) (string, error) {
+	organizationID, ok := ID(ctx)
+	if !ok {
+		return "", ErrNoTenant
+	}
+	return organizationID, nil
+}
@@ -0,0 +1,30 @@
+package tenant_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/pkg/tenant"
+)
+
+func TestRequire(t *testing.T) {
+	tests := []struct {
+		name        string
+		ctx         context.Context
+		expectedID  string
+		expectedErr error
+	}{
+		{name: "organization in context", ctx: tenant.WithID(context.Background(), "42"), expectedID: "42"},
+		{name: "no organization", ctx: context.Background(), expectedErr: tenant.ErrNoTenant},
+		{name: "empty organization", ctx: tenant.WithID(context.Background(), ""), expectedErr: tenant.ErrNoTenant},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			organizationID, err := tenant.Require(tt.ctx)
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expectedID, organizationID)
+		})
+	}
+}
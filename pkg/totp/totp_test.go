@@ -0,0 +1,93 @@
+package totp_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/pkg/totp"
+)
+
+// rfcSecret is the base32 form of the RFC 6238 SHA1 test key "12345678901234567890"
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestGenerateCode(t *testing.T) {
+	// Expected values are the last six digits of the RFC 6238 appendix B vectors
+	tests := []struct {
+		name     string
+		unixTime int64
+		expected string
+	}{
+		{name: "time 59", unixTime: 59, expected: "287082"},
+		{name: "time 1111111109", unixTime: 1111111109, expected: "081804"},
+		{name: "time 1111111111", unixTime: 1111111111, expected: "050471"},
+		{name: "time 1234567890", unixTime: 1234567890, expected: "005924"},
+		{name: "time 2000000000", unixTime: 2000000000, expected: "279037"},
+		{name: "time 20000000000", unixTime: 20000000000, expected: "353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, err := totp.GenerateCode(rfcSecret, totp.TimeStep(time.Unix(tt.unixTime, 0)))
+
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, code)
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	currentCode, _ := totp.GenerateCode(rfcSecret, totp.TimeStep(now))
+	previousCode, _ := totp.GenerateCode(rfcSecret, totp.TimeStep(now)-1)
+	oldCode, _ := totp.GenerateCode(rfcSecret, totp.TimeStep(now)-3)
+
+	tests := []struct {
+		name         string
+		code         string
+		skew         int
+		expectedOK   bool
+		expectedStep int64
+	}{
+		{name: "current code", code: currentCode, skew: 1, expectedOK: true, expectedStep: totp.TimeStep(now)},
+		{name: "previous code within skew", code: previousCode, skew: 1, expectedOK: true, expectedStep: totp.TimeStep(now) - 1},
+		{name: "previous code without skew", code: previousCode, skew: 0, expectedOK: false},
+		{name: "code outside skew", code: oldCode, skew: 1, expectedOK: false},
+		{name: "wrong length", code: "12345", skew: 1, expectedOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := totp.Validate(rfcSecret, tt.code, now, tt.skew)
+
+			assert.Equal(t, tt.expectedOK, ok)
+			if tt.expectedOK {
+				assert.Equal(t, tt.expectedStep, step)
+			}
+		})
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	secret, err := totp.GenerateSecret()
+	assert.Nil(t, err)
+	assert.Equal(t, 32, len(secret))
+
+	other, err := totp.GenerateSecret()
+	assert.Nil(t, err)
+	assert.NotEqual(t, secret, other)
+
+	_, err = totp.GenerateCode(secret, 1)
+	assert.Nil(t, err)
+}
+
+func TestURI(t *testing.T) {
+	uri := totp.URI("Retail Pro", "user@example.com", rfcSecret)
+
+	assert.Equal(t, true, strings.HasPrefix(uri, "otpauth://totp/Retail%20Pro:user@example.com?"))
+	assert.Contains(t, uri, "secret="+rfcSecret)
+	assert.Contains(t, uri, "issuer=Retail+Pro")
+	assert.Contains(t, uri, "digits=6")
+	assert.Contains(t, uri, "period=30")
+}
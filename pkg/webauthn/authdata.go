@@ -0,0 +1,82 @@
+package webauthn
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Authenticator data flags
+const (
+	flagUserPresent  = 0x01
+	flagUserVerified = 0x04
+	flagAttestedData = 0x40
+)
+
+// authenticatorData is the parsed binary authenticator data
+type authenticatorData struct {
+	rpIDHash  []byte
+	flags     byte
+	signCount uint32
+	attested  *attestedCredentialData
+}
+
+// attestedCredentialData is present during registration
+type attestedCredentialData struct {
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+func (a *authenticatorData) userPresent() bool {
+	return a.flags&flagUserPresent != 0
+}
+
+func (a *authenticatorData) userVerified() bool {
+	return a.flags&flagUserVerified != 0
+}
+
+// parseAuthenticatorData parses the layout described in the WebAuthn spec section 6.1
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, ErrInvalidAuthData
+	}
+
+	authData := &authenticatorData{
+		rpIDHash:  data[:32],
+		flags:     data[32],
+		signCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	if authData.flags&flagAttestedData == 0 {
+		return authData, nil
+	}
+
+	rest := data[37:]
+	if len(rest) < 18 {
+		return nil, ErrInvalidAuthData
+	}
+
+	aaguid := rest[:16]
+	idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+	rest = rest[18:]
+	if len(rest) < idLength {
+		return nil, ErrInvalidAuthData
+	}
+	credentialID := rest[:idLength]
+	rest = rest[idLength:]
+
+	// The COSE key is followed by optional extensions, so decode exactly one CBOR item
+	var publicKey cbor.RawMessage
+	if err := cbor.NewDecoder(bytes.NewReader(rest)).Decode(&publicKey); err != nil {
+		return nil, ErrInvalidAuthData
+	}
+
+	authData.attested = &attestedCredentialData{
+		aaguid:       aaguid,
+		credentialID: credentialID,
+		publicKey:    publicKey,
+	}
+	return authData, nil
+}
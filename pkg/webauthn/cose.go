@@ -0,0 +1,158 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// COSE algorithm identifiers
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// COSE key types and curves
+const (
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+// COSE key map labels
+const (
+	coseLabelKeyType   = 1
+	coseLabelAlgorithm = 3
+	coseLabelCurve     = -1
+	coseLabelX         = -2
+	coseLabelY         = -3
+	coseLabelRSAN      = -1
+	coseLabelRSAE      = -2
+)
+
+// publicKey verifies signatures with a credential public key
+type publicKey struct {
+	algorithm int64
+	ecdsa     *ecdsa.PublicKey
+	ed25519   ed25519.PublicKey
+	rsa       *rsa.PublicKey
+}
+
+// parsePublicKey decodes a COSE_Key as stored in the attested credential data
+func parsePublicKey(raw []byte) (*publicKey, error) {
+	var fields map[int]cbor.RawMessage
+	if err := cbor.Unmarshal(raw, &fields); err != nil {
+		return nil, ErrInvalidPublicKey
+	}
+
+	var keyType, algorithm int64
+	if err := decodeField(fields, coseLabelKeyType, &keyType); err != nil {
+		return nil, err
+	}
+	if err := decodeField(fields, coseLabelAlgorithm, &algorithm); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case keyType == coseKeyTypeEC2 && algorithm == AlgES256:
+		var curve int64
+		var x, y []byte
+		if err := decodeField(fields, coseLabelCurve, &curve); err != nil {
+			return nil, err
+		}
+		if err := decodeField(fields, coseLabelX, &x); err != nil {
+			return nil, err
+		}
+		if err := decodeField(fields, coseLabelY, &y); err != nil {
+			return nil, err
+		}
+		if curve != coseCurveP256 || len(x) != 32 || len(y) != 32 {
+			return nil, ErrInvalidPublicKey
+		}
+
+		key := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, ErrInvalidPublicKey
+		}
+		return &publicKey{algorithm: algorithm, ecdsa: key}, nil
+
+	case keyType == coseKeyTypeOKP && algorithm == AlgEdDSA:
+		var curve int64
+		var x []byte
+		if err := decodeField(fields, coseLabelCurve, &curve); err != nil {
+			return nil, err
+		}
+		if err := decodeField(fields, coseLabelX, &x); err != nil {
+			return nil, err
+		}
+		if curve != coseCurveEd25519 || len(x) != ed25519.PublicKeySize {
+			return nil, ErrInvalidPublicKey
+		}
+		return &publicKey{algorithm: algorithm, ed25519: ed25519.PublicKey(x)}, nil
+
+	case keyType == coseKeyTypeRSA && algorithm == AlgRS256:
+		var n, e []byte
+		if err := decodeField(fields, coseLabelRSAN, &n); err != nil {
+			return nil, err
+		}
+		if err := decodeField(fields, coseLabelRSAE, &e); err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if len(n) < 256 || !exponent.IsInt64() {
+			return nil, ErrInvalidPublicKey
+		}
+		return &publicKey{algorithm: algorithm, rsa: &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(exponent.Int64()),
+		}}, nil
+	}
+
+	return nil, ErrUnsupportedAlgorithm
+}
+
+// verify checks a signature over data
+func (k *publicKey) verify(data, signature []byte) error {
+	switch k.algorithm {
+	case AlgES256:
+		digest := sha256.Sum256(data)
+		if ecdsa.VerifyASN1(k.ecdsa, digest[:], signature) {
+			return nil
+		}
+	case AlgEdDSA:
+		if ed25519.Verify(k.ed25519, data, signature) {
+			return nil
+		}
+	case AlgRS256:
+		digest := sha256.Sum256(data)
+		if rsa.VerifyPKCS1v15(k.rsa, crypto.SHA256, digest[:], signature) == nil {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+func decodeField(fields map[int]cbor.RawMessage, label int, target interface{}) error {
+	raw, ok := fields[label]
+	if !ok {
+		return ErrInvalidPublicKey
+	}
+	if err := cbor.Unmarshal(raw, target); err != nil {
+		return ErrInvalidPublicKey
+	}
+	return nil
+}
@@ -0,0 +1,407 @@
+package webauthn
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Ceremony types as they appear in the client data
+const (
+	CeremonyCreate = "webauthn.create"
+	CeremonyGet    = "webauthn.get"
+)
+
+// User verification requirements
+const (
+	UserVerificationRequired    = "required"
+	UserVerificationPreferred   = "preferred"
+	UserVerificationDiscouraged = "discouraged"
+)
+
+// Errors returned by the registration and login ceremonies
+var (
+	ErrInvalidClientData    = errors.New("webauthn: invalid client data")
+	ErrCeremonyMismatch     = errors.New("webauthn: unexpected ceremony type")
+	ErrChallengeMismatch    = errors.New("webauthn: challenge mismatch")
+	ErrOriginMismatch       = errors.New("webauthn: origin not allowed")
+	ErrInvalidAttestation   = errors.New("webauthn: invalid attestation object")
+	ErrInvalidAuthData      = errors.New("webauthn: invalid authenticator data")
+	ErrRPIDMismatch         = errors.New("webauthn: relying party ID mismatch")
+	ErrUserNotPresent       = errors.New("webauthn: user presence flag not set")
+	ErrUserNotVerified      = errors.New("webauthn: user verification required")
+	ErrInvalidSignature     = errors.New("webauthn: invalid signature")
+	ErrSignCountRegression  = errors.New("webauthn: signature counter did not increase, authenticator may be cloned")
+	ErrUnsupportedAlgorithm = errors.New("webauthn: unsupported public key algorithm")
+	ErrInvalidPublicKey     = errors.New("webauthn: invalid public key")
+)
+
+var base64URL = base64.RawURLEncoding
+
+// Config describes the relying party
+type Config struct {
+	RPID             string
+	RPName           string
+	RPOrigins        []string
+	Timeout          time.Duration
+	UserVerification string
+}
+
+// WebAuthn runs the server side of the registration and login ceremonies
+type WebAuthn struct {
+	config Config
+}
+
+// New creates a new WebAuthn relying party
+func New(config Config) *WebAuthn {
+	if config.Timeout == 0 {
+		config.Timeout = 5 * time.Minute
+	}
+	if config.UserVerification == "" {
+		config.UserVerification = UserVerificationPreferred
+	}
+	return &WebAuthn{config: config}
+}
+
+// Timeout returns how long a ceremony challenge stays valid
+func (w *WebAuthn) Timeout() time.Duration {
+	return w.config.Timeout
+}
+
+// User is the account a credential is registered for
+type User struct {
+	ID          []byte
+	Name        string
+	DisplayName string
+}
+
+// RelyingParty identifies the server in creation options
+type RelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity identifies the account in creation options
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CredentialParameter is an accepted public key algorithm
+type CredentialParameter struct {
+	Type      string `json:"type"`
+	Algorithm int64  `json:"alg"`
+}
+
+// CredentialDescriptor references an existing credential
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// AuthenticatorSelection describes the authenticator requirements
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions are passed to navigator.credentials.create
+type CreationOptions struct {
+	Challenge              string                 `json:"challenge"`
+	RP                     RelyingParty           `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are passed to navigator.credentials.get
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// RegistrationCredential is the JSON form of the credential returned by navigator.credentials.create
+type RegistrationCredential struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports"`
+	} `json:"response"`
+}
+
+// AssertionCredential is the JSON form of the credential returned by navigator.credentials.get
+type AssertionCredential struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle"`
+	} `json:"response"`
+}
+
+// Credential is a verified public key credential ready to be stored
+type Credential struct {
+	ID           []byte
+	PublicKey    []byte
+	SignCount    uint32
+	AAGUID       []byte
+	Transports   []string
+	UserVerified bool
+}
+
+// Assertion is the result of a verified login ceremony
+type Assertion struct {
+	SignCount    uint32
+	UserVerified bool
+}
+
+// clientData is the parsed clientDataJSON
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// attestationObject is the CBOR structure returned during registration
+type attestationObject struct {
+	Format       string          `cbor:"fmt"`
+	AttStatement cbor.RawMessage `cbor:"attStmt"`
+	AuthData     []byte          `cbor:"authData"`
+}
+
+// NewChallenge returns a random base64url encoded challenge
+func NewChallenge() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64URL.EncodeToString(b), nil
+}
+
+// EncodeID encodes binary identifiers the same way browsers do in the JSON credential form
+func EncodeID(id []byte) string {
+	return base64URL.EncodeToString(id)
+}
+
+// DecodeID decodes a base64url identifier from the JSON credential form
+func DecodeID(id string) ([]byte, error) {
+	return base64URL.DecodeString(id)
+}
+
+// ClientDataChallenge extracts the challenge from a base64url encoded clientDataJSON
+// so the server can look up the ceremony it belongs to
+func ClientDataChallenge(encodedClientData string) (string, error) {
+	data, _, err := parseClientData(encodedClientData)
+	if err != nil {
+		return "", err
+	}
+	return data.Challenge, nil
+}
+
+// BeginRegistration builds the options for creating a new credential
+func (w *WebAuthn) BeginRegistration(user User, challenge string, exclude []CredentialDescriptor) *CreationOptions {
+	if exclude == nil {
+		exclude = []CredentialDescriptor{}
+	}
+	return &CreationOptions{
+		Challenge: challenge,
+		RP: RelyingParty{
+			ID:   w.config.RPID,
+			Name: w.config.RPName,
+		},
+		User: UserEntity{
+			ID:          base64URL.EncodeToString(user.ID),
+			Name:        user.Name,
+			DisplayName: user.DisplayName,
+		},
+		PubKeyCredParams: []CredentialParameter{
+			{Type: "public-key", Algorithm: AlgES256},
+			{Type: "public-key", Algorithm: AlgEdDSA},
+			{Type: "public-key", Algorithm: AlgRS256},
+		},
+		Timeout:            w.config.Timeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: w.config.UserVerification,
+		},
+		Attestation: "none",
+	}
+}
+
+// BeginLogin builds the options for a discoverable credential login
+func (w *WebAuthn) BeginLogin(challenge string) *RequestOptions {
+	return &RequestOptions{
+		Challenge:        challenge,
+		Timeout:          w.config.Timeout.Milliseconds(),
+		RPID:             w.config.RPID,
+		AllowCredentials: []CredentialDescriptor{},
+		UserVerification: w.config.UserVerification,
+	}
+}
+
+// FinishRegistration verifies a registration response against the expected challenge.
+// Attestation statements are not verified because the options request "none" conveyance.
+func (w *WebAuthn) FinishRegistration(credential *RegistrationCredential, challenge string) (*Credential, error) {
+	if _, err := w.verifyClientData(credential.Response.ClientDataJSON, CeremonyCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	rawAttestation, err := base64URL.DecodeString(credential.Response.AttestationObject)
+	if err != nil {
+		return nil, ErrInvalidAttestation
+	}
+
+	var attestation attestationObject
+	if err := cbor.Unmarshal(rawAttestation, &attestation); err != nil {
+		return nil, ErrInvalidAttestation
+	}
+
+	authData, err := parseAuthenticatorData(attestation.AuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+	if authData.attested == nil {
+		return nil, ErrInvalidAuthData
+	}
+
+	// Reject keys we would not be able to verify at login
+	if _, err := parsePublicKey(authData.attested.publicKey); err != nil {
+		return nil, err
+	}
+
+	rawID, err := base64URL.DecodeString(credential.RawID)
+	if err != nil || string(rawID) != string(authData.attested.credentialID) {
+		return nil, ErrInvalidAttestation
+	}
+
+	return &Credential{
+		ID:           authData.attested.credentialID,
+		PublicKey:    authData.attested.publicKey,
+		SignCount:    authData.signCount,
+		AAGUID:       authData.attested.aaguid,
+		Transports:   credential.Response.Transports,
+		UserVerified: authData.userVerified(),
+	}, nil
+}
+
+// FinishLogin verifies an assertion against the expected challenge and the stored credential.
+// storedSignCount is the last counter seen for the credential; a counter that does not
+// increase is rejected as a possible cloned authenticator.
+func (w *WebAuthn) FinishLogin(credential *AssertionCredential, challenge string, publicKey []byte, storedSignCount uint32) (*Assertion, error) {
+	rawClientData, err := w.verifyClientData(credential.Response.ClientDataJSON, CeremonyGet, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	rawAuthData, err := base64URL.DecodeString(credential.Response.AuthenticatorData)
+	if err != nil {
+		return nil, ErrInvalidAuthData
+	}
+
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := w.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+
+	signature, err := base64URL.DecodeString(credential.Response.Signature)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// The signature covers authenticatorData || SHA-256(clientDataJSON)
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if err := key.verify(signed, signature); err != nil {
+		return nil, err
+	}
+
+	// Authenticators that do not implement a counter always report zero
+	if (authData.signCount != 0 || storedSignCount != 0) && authData.signCount <= storedSignCount {
+		return nil, ErrSignCountRegression
+	}
+
+	return &Assertion{
+		SignCount:    authData.signCount,
+		UserVerified: authData.userVerified(),
+	}, nil
+}
+
+// verifyClientData checks the ceremony type, challenge and origin and returns the raw JSON
+func (w *WebAuthn) verifyClientData(encoded, ceremony, challenge string) ([]byte, error) {
+	data, raw, err := parseClientData(encoded)
+	if err != nil {
+		return nil, err
+	}
+	if data.Type != ceremony {
+		return nil, ErrCeremonyMismatch
+	}
+	if data.Challenge != challenge {
+		return nil, ErrChallengeMismatch
+	}
+
+	for _, origin := range w.config.RPOrigins {
+		if data.Origin == origin {
+			return raw, nil
+		}
+	}
+	return nil, ErrOriginMismatch
+}
+
+// verifyAuthenticatorData checks the relying party hash and user flags
+func (w *WebAuthn) verifyAuthenticatorData(authData *authenticatorData) error {
+	rpIDHash := sha256.Sum256([]byte(w.config.RPID))
+	if string(authData.rpIDHash) != string(rpIDHash[:]) {
+		return ErrRPIDMismatch
+	}
+	if !authData.userPresent() {
+		return ErrUserNotPresent
+	}
+	if w.config.UserVerification == UserVerificationRequired && !authData.userVerified() {
+		return ErrUserNotVerified
+	}
+	return nil
+}
+
+func parseClientData(encoded string) (*clientData, []byte, error) {
+	raw, err := base64URL.DecodeString(encoded)
+	if err != nil {
+		return nil, nil, ErrInvalidClientData
+	}
+
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, nil, ErrInvalidClientData
+	}
+	return &data, raw, nil
+}
@@ -0,0 +1,288 @@
+package webauthn_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/yantology/golang-starter-template/pkg/webauthn"
+)
+
+const (
+	testRPID   = "localhost"
+	testOrigin = "http://localhost:3000"
+)
+
+var b64 = base64.RawURLEncoding
+
+// softAuthenticator is a minimal in-memory authenticator producing real signatures
+type softAuthenticator struct {
+	credentialID []byte
+	ecdsaKey     *ecdsa.PrivateKey
+	ed25519Key   ed25519.PrivateKey
+	publicKey    []byte
+	signCount    uint32
+	noCounter    bool
+	flags        byte
+}
+
+func newES256Authenticator(t *testing.T) *softAuthenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	return &softAuthenticator{credentialID: randomBytes(16), ecdsaKey: key, flags: 0x05}
+}
+
+func newEdDSAAuthenticator(t *testing.T) *softAuthenticator {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+	return &softAuthenticator{credentialID: randomBytes(16), ed25519Key: key, flags: 0x05}
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return b
+}
+
+func (a *softAuthenticator) coseKey() []byte {
+	if a.publicKey != nil {
+		return a.publicKey
+	}
+
+	var key map[int]interface{}
+	if a.ecdsaKey != nil {
+		x := make([]byte, 32)
+		y := make([]byte, 32)
+		a.ecdsaKey.X.FillBytes(x)
+		a.ecdsaKey.Y.FillBytes(y)
+		key = map[int]interface{}{1: 2, 3: -7, -1: 1, -2: x, -3: y}
+	} else {
+		key = map[int]interface{}{1: 1, 3: -8, -1: 6, -2: []byte(a.ed25519Key.Public().(ed25519.PublicKey))}
+	}
+	// Encode once, map ordering is not deterministic
+	a.publicKey, _ = cbor.Marshal(key)
+	return a.publicKey
+}
+
+func (a *softAuthenticator) authData(rpID string, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	data := append([]byte{}, rpIDHash[:]...)
+
+	flags := a.flags
+	if attested {
+		flags |= 0x40
+	}
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+
+	if attested {
+		data = append(data, make([]byte, 16)...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+func clientDataJSON(ceremony, challenge, origin string) []byte {
+	data, _ := json.Marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": challenge,
+		"origin":    origin,
+	})
+	return data
+}
+
+func (a *softAuthenticator) register(challenge, origin, rpID string) *webauthn.RegistrationCredential {
+	attestation, _ := cbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(rpID, true),
+	})
+
+	credential := &webauthn.RegistrationCredential{
+		ID:    b64.EncodeToString(a.credentialID),
+		RawID: b64.EncodeToString(a.credentialID),
+		Type:  "public-key",
+	}
+	credential.Response.ClientDataJSON = b64.EncodeToString(clientDataJSON(webauthn.CeremonyCreate, challenge, origin))
+	credential.Response.AttestationObject = b64.EncodeToString(attestation)
+	credential.Response.Transports = []string{"internal"}
+	return credential
+}
+
+func (a *softAuthenticator) login(t *testing.T, challenge, origin string) *webauthn.AssertionCredential {
+	if !a.noCounter {
+		a.signCount++
+	}
+	authData := a.authData(testRPID, false)
+	rawClientData := clientDataJSON(webauthn.CeremonyGet, challenge, origin)
+	clientDataHash := sha256.Sum256(rawClientData)
+	signed := append(append([]byte{}, authData...), clientDataHash[:]...)
+
+	var signature []byte
+	var err error
+	if a.ecdsaKey != nil {
+		digest := sha256.Sum256(signed)
+		signature, err = ecdsa.SignASN1(rand.Reader, a.ecdsaKey, digest[:])
+		assert.Nil(t, err)
+	} else {
+		signature = ed25519.Sign(a.ed25519Key, signed)
+	}
+
+	credential := &webauthn.AssertionCredential{
+		ID:    b64.EncodeToString(a.credentialID),
+		RawID: b64.EncodeToString(a.credentialID),
+		Type:  "public-key",
+	}
+	credential.Response.ClientDataJSON = b64.EncodeToString(rawClientData)
+	credential.Response.AuthenticatorData = b64.EncodeToString(authData)
+	credential.Response.Signature = b64.EncodeToString(signature)
+	return credential
+}
+
+func newRelyingParty(userVerification string) *webauthn.WebAuthn {
+	return webauthn.New(webauthn.Config{
+		RPID:             testRPID,
+		RPName:           "Test",
+		RPOrigins:        []string{testOrigin},
+		Timeout:          time.Minute,
+		UserVerification: userVerification,
+	})
+}
+
+func TestFinishRegistration(t *testing.T) {
+	tests := []struct {
+		name          string
+		authenticator func(t *testing.T) *softAuthenticator
+		challenge     string
+		origin        string
+		rpID          string
+		flags         byte
+		verification  string
+		expectedErr   error
+	}{
+		{name: "valid ES256 credential", authenticator: newES256Authenticator, challenge: "challenge", origin: testOrigin, rpID: testRPID, flags: 0x05},
+		{name: "valid EdDSA credential", authenticator: newEdDSAAuthenticator, challenge: "challenge", origin: testOrigin, rpID: testRPID, flags: 0x05},
+		{name: "challenge mismatch", authenticator: newES256Authenticator, challenge: "other", origin: testOrigin, rpID: testRPID, flags: 0x05, expectedErr: webauthn.ErrChallengeMismatch},
+		{name: "origin mismatch", authenticator: newES256Authenticator, challenge: "challenge", origin: "https://evil.example", rpID: testRPID, flags: 0x05, expectedErr: webauthn.ErrOriginMismatch},
+		{name: "rp id mismatch", authenticator: newES256Authenticator, challenge: "challenge", origin: testOrigin, rpID: "evil.example", flags: 0x05, expectedErr: webauthn.ErrRPIDMismatch},
+		{name: "user not present", authenticator: newES256Authenticator, challenge: "challenge", origin: testOrigin, rpID: testRPID, flags: 0x00, expectedErr: webauthn.ErrUserNotPresent},
+		{name: "user verification required", authenticator: newES256Authenticator, challenge: "challenge", origin: testOrigin, rpID: testRPID, flags: 0x01, verification: webauthn.UserVerificationRequired, expectedErr: webauthn.ErrUserNotVerified},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newRelyingParty(tt.verification)
+			authenticator := tt.authenticator(t)
+			authenticator.flags = tt.flags
+
+			credential, err := rp.FinishRegistration(authenticator.register(tt.challenge, tt.origin, tt.rpID), "challenge")
+
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedErr == nil {
+				assert.Equal(t, authenticator.credentialID, credential.ID)
+				assert.Equal(t, authenticator.coseKey(), credential.PublicKey)
+				assert.Equal(t, []string{"internal"}, credential.Transports)
+				assert.Equal(t, true, credential.UserVerified)
+			}
+		})
+	}
+}
+
+func TestFinishLogin(t *testing.T) {
+	tests := []struct {
+		name          string
+		authenticator func(t *testing.T) *softAuthenticator
+		ceremony      func(a *softAuthenticator, c *webauthn.AssertionCredential)
+		storedCount   uint32
+		expectedErr   error
+	}{
+		{name: "valid ES256 assertion", authenticator: newES256Authenticator},
+		{name: "valid EdDSA assertion", authenticator: newEdDSAAuthenticator},
+		{name: "sign count regression", authenticator: newES256Authenticator, storedCount: 5, expectedErr: webauthn.ErrSignCountRegression},
+		{
+			name:          "tampered signature",
+			authenticator: newES256Authenticator,
+			ceremony: func(a *softAuthenticator, c *webauthn.AssertionCredential) {
+				c.Response.Signature = b64.EncodeToString([]byte("invalid"))
+			},
+			expectedErr: webauthn.ErrInvalidSignature,
+		},
+		{
+			name:          "registration client data",
+			authenticator: newEdDSAAuthenticator,
+			ceremony: func(a *softAuthenticator, c *webauthn.AssertionCredential) {
+				c.Response.ClientDataJSON = b64.EncodeToString(clientDataJSON(webauthn.CeremonyCreate, "challenge", testOrigin))
+			},
+			expectedErr: webauthn.ErrCeremonyMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newRelyingParty("")
+			authenticator := tt.authenticator(t)
+			registered, err := rp.FinishRegistration(authenticator.register("challenge", testOrigin, testRPID), "challenge")
+			assert.Nil(t, err)
+
+			assertion := authenticator.login(t, "challenge", testOrigin)
+			if tt.ceremony != nil {
+				tt.ceremony(authenticator, assertion)
+			}
+
+			result, err := rp.FinishLogin(assertion, "challenge", registered.PublicKey, tt.storedCount)
+
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedErr == nil {
+				assert.Equal(t, uint32(1), result.SignCount)
+			}
+		})
+	}
+}
+
+func TestFinishLoginZeroCounter(t *testing.T) {
+	// Authenticators without a counter always report zero and must keep working
+	rp := newRelyingParty("")
+	authenticator := newEdDSAAuthenticator(t)
+	authenticator.noCounter = true
+	registered, err := rp.FinishRegistration(authenticator.register("challenge", testOrigin, testRPID), "challenge")
+	assert.Nil(t, err)
+
+	for i := 0; i < 2; i++ {
+		result, err := rp.FinishLogin(authenticator.login(t, "challenge", testOrigin), "challenge", registered.PublicKey, 0)
+
+		assert.Nil(t, err)
+		assert.Equal(t, uint32(0), result.SignCount)
+	}
+}
+
+func TestClientDataChallenge(t *testing.T) {
+	challenge, err := webauthn.ClientDataChallenge(b64.EncodeToString(clientDataJSON(webauthn.CeremonyGet, "abc", testOrigin)))
+	assert.Nil(t, err)
+	assert.Equal(t, "abc", challenge)
+
+	_, err = webauthn.ClientDataChallenge("!!")
+	assert.Equal(t, webauthn.ErrInvalidClientData, err)
+}
+
+func TestBeginRegistration(t *testing.T) {
+	rp := newRelyingParty("")
+	options := rp.BeginRegistration(webauthn.User{ID: []byte{1}, Name: "user@example.com", DisplayName: "User"}, "challenge", nil)
+
+	assert.Equal(t, "challenge", options.Challenge)
+	assert.Equal(t, testRPID, options.RP.ID)
+	assert.Equal(t, "AQ", options.User.ID)
+	assert.Equal(t, int64(60000), options.Timeout)
+	assert.Equal(t, webauthn.UserVerificationPreferred, options.AuthenticatorSelection.UserVerification)
+	assert.Equal(t, []webauthn.CredentialDescriptor{}, options.ExcludeCredentials)
+}
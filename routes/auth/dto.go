@@ -1,5 +1,11 @@
 package auth
 
+import (
+	"time"
+
+	"github.com/yantology/golang-starter-template/pkg/webauthn"
+)
+
 // Request DTOs
 
 // TokenRequest represents the request for generating activation tokens
@@ -34,12 +40,143 @@ type ForgetPasswordRequest struct {
 	NewPasswordConfirmation string `json:"new_password_confirmation" binding:"required" example:"newSecurePassword123"`
 }
 
+// LoginMFARequest represents the second step of a login with two-factor authentication
+// @Description MFA login request model
+type LoginMFARequest struct {
+	Email    string `json:"email" binding:"required,email" example:"user@example.com"`
+	MFAToken string `json:"mfa_token" binding:"required" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	Code     string `json:"code" binding:"required" example:"123456"`
+}
+
+// TOTPCodeRequest represents a request carrying a TOTP or recovery code
+// @Description TOTP code request model
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// PasskeyRegistrationRequest represents the credential created by the browser during passkey registration
+// @Description Passkey registration request model
+type PasskeyRegistrationRequest struct {
+	Name       string                          `json:"name" binding:"required,max=255" example:"POS Kasir 1"`
+	Credential webauthn.RegistrationCredential `json:"credential"`
+}
+
+// LoginCodeRequest represents a passwordless login with an emailed code or a signed login link token
+// @Description Passwordless login request model
+type LoginCodeRequest struct {
+	Email string `json:"email" example:"user@example.com"`
+	Code  string `json:"code" example:"123456"`
+	Token string `json:"token" example:"eyJwdXJwb3NlIjoibG9naW4tbGluayJ9.c2lnbmF0dXJl"`
+}
+
+// UnlockAccountRequest represents an admin request to clear a login lockout
+// @Description Unlock account request model
+type UnlockAccountRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+// APIKeyCreateRequest represents a request to create an API key
+// @Description API key creation request model
+type APIKeyCreateRequest struct {
+	Name string `json:"name" binding:"required,max=255" example:"Sinkronisasi Tokopedia"`
+	// Scopes must be permissions the user holds; an API key never gets more access than its owner
+	Scopes []string `json:"scopes" example:"inventory:read,inventory:write"`
+	// ExpiresInDays of 0 uses the longest allowed lifetime
+	ExpiresInDays int `json:"expires_in_days" binding:"min=0" example:"90"`
+}
+
+// APIKeyUpdateRequest represents a request to rename an API key or change its scopes
+// @Description API key update request model
+type APIKeyUpdateRequest struct {
+	Name   *string   `json:"name" binding:"omitempty,min=1,max=255" example:"Ekspor Akuntansi"`
+	Scopes *[]string `json:"scopes" example:"sales:read"`
+}
+
+// OrganizationCreateRequest represents a request to create an organization
+// @Description Organization creation request model
+type OrganizationCreateRequest struct {
+	Name string `json:"name" binding:"required,max=255" example:"Toko Maju Jaya"`
+}
+
+// OrganizationInviteRequest represents a request to invite someone to an organization
+// @Description Organization invitation request model
+type OrganizationInviteRequest struct {
+	Email string `json:"email" binding:"required,email" example:"cashier@example.com"`
+	Role  string `json:"role" binding:"required" example:"cashier"`
+}
+
+// OrganizationAcceptRequest represents a request to join an organization with an invitation token.
+// The name and password are only needed when the invited email has no account yet.
+// @Description Organization invitation acceptance request model
+type OrganizationAcceptRequest struct {
+	Token                string `json:"token" binding:"required" example:"eyJwdXJwb3NlIjoib3JnYW5pemF0aW9uLWludml0YXRpb24i..."`
+	Fullname             string `json:"fullname" example:"Siti Aminah"`
+	Password             string `json:"password" example:"securePassword123"`
+	PasswordConfirmation string `json:"password_confirmation" example:"securePassword123"`
+}
+
+// OrganizationRoleRequest represents a request to change the role of a member
+// @Description Organization member role request model
+type OrganizationRoleRequest struct {
+	Role string `json:"role" binding:"required" example:"store_manager"`
+}
+
+// ProfileUpdateRequest represents a request to change the profile of the current user
+// @Description Profile update request model
+type ProfileUpdateRequest struct {
+	Fullname string `json:"fullname" binding:"required,max=30" example:"John Doe"`
+	// Locale is the language of emails, an empty string follows the Accept-Language header and
+	// omitting it keeps the current one
+	Locale *string `json:"locale" binding:"omitempty,max=35" example:"id"`
+}
+
+// ChangePasswordRequest represents a request to change the password of the current user
+// @Description Change password request model
+type ChangePasswordRequest struct {
+	CurrentPassword         string `json:"current_password" binding:"required" example:"securePassword123"`
+	NewPassword             string `json:"new_password" binding:"required" example:"newSecurePassword123"`
+	NewPasswordConfirmation string `json:"new_password_confirmation" binding:"required" example:"newSecurePassword123"`
+}
+
+// EmailChangeRequest represents a request to send a verification code to a new email address
+// @Description Email change request model
+type EmailChangeRequest struct {
+	NewEmail        string `json:"new_email" binding:"required,email" example:"new@example.com"`
+	CurrentPassword string `json:"current_password" binding:"required" example:"securePassword123"`
+}
+
+// EmailChangeConfirmRequest represents a request to confirm a new email address with its code
+// @Description Email change confirmation request model
+type EmailChangeConfirmRequest struct {
+	NewEmail       string `json:"new_email" binding:"required,email" example:"new@example.com"`
+	ActivationCode string `json:"activation_code" binding:"required" example:"123456"`
+}
+
+// AccountDeletionRequest represents a request to delete the account of the current user
+// @Description Account deletion request model
+type AccountDeletionRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required" example:"securePassword123"`
+}
+
+// AccountDeletionResponse tells when a scheduled account deletion takes place
+// @Description Account deletion response model
+type AccountDeletionResponse struct {
+	ScheduledAt time.Time `json:"scheduled_at" example:"2026-11-01T08:00:00Z"`
+}
+
 // RefreshTokenRequest represents the refresh token request
 // @Description Refresh token request model
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required" example:"eyJhbGciOiJIUzI1NiIs..."`
 }
 
+// PasswordViolation describes one password policy rule the password breaks
+// @Description Password policy violation model
+type PasswordViolation struct {
+	Code    string `json:"code" example:"too_short"`
+	Message string `json:"message" example:"Password minimal 8 karakter"`
+}
+
 // JWTResponseData represents the JWT token response data
 // @Description JWT token response data model
 type JWTResponseData struct {
@@ -48,3 +185,181 @@ type JWTResponseData struct {
 	ExpiresIn    int    `json:"expires_in" example:"3600"`
 	RefreshToken string `json:"refresh_token" example:"eyJhbGciOiJIUzI1NiIs..."`
 }
+
+// ProfileResponse represents the account of the current user
+// @Description Profile response model
+type ProfileResponse struct {
+	ID          string   `json:"id" example:"12"`
+	Email       string   `json:"email" example:"user@example.com"`
+	Fullname    string   `json:"fullname" example:"John Doe"`
+	Roles       []string `json:"roles" example:"cashier"`
+	Permissions []string `json:"permissions" example:"sales:read,sales:write"`
+	// OrganizationID is the active organization, empty for none
+	OrganizationID string `json:"organization_id,omitempty" example:"7"`
+	// OrganizationRole and OrganizationPermissions are the access in the active organization
+	OrganizationRole        string     `json:"organization_role,omitempty" example:"store_manager"`
+	OrganizationPermissions []string   `json:"organization_permissions" example:"inventory:read,inventory:write"`
+	CreatedAt               *time.Time `json:"created_at" example:"2026-10-18T08:00:00Z"`
+	UpdatedAt               *time.Time `json:"updated_at" example:"2026-10-18T08:00:00Z"`
+	// DeletionScheduledAt is when the account will be deleted, omitted unless deletion was requested
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at,omitempty" example:"2026-11-01T08:00:00Z"`
+	// Locale is the language of emails, empty when it follows the Accept-Language header
+	Locale string `json:"locale" example:"id"`
+}
+
+// SessionResponse represents an active login session of the current user
+// @Description Active session response model
+type SessionResponse struct {
+	ID         string    `json:"id" example:"4f3c2b1a9e8d7c6b5a4f3e2d1c0b9a8f"`
+	UserAgent  string    `json:"user_agent" example:"Mozilla/5.0 (Windows NT 10.0; Win64; x64)"`
+	IPAddress  string    `json:"ip_address" example:"203.0.113.10"`
+	CreatedAt  time.Time `json:"created_at" example:"2026-10-18T08:00:00Z"`
+	LastUsedAt time.Time `json:"last_used_at" example:"2026-10-18T09:30:00Z"`
+	Current    bool      `json:"current" example:"true"`
+}
+
+// MFAChallengeResponse represents the challenge returned when a login needs a second factor
+// @Description MFA challenge response model
+type MFAChallengeResponse struct {
+	MFARequired bool   `json:"mfa_required" example:"true"`
+	MFAToken    string `json:"mfa_token" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+	ExpiresIn   int    `json:"expires_in" example:"300"`
+}
+
+// TOTPEnrollmentResponse represents the data needed to add the account to an authenticator app
+// @Description TOTP enrollment response model
+type TOTPEnrollmentResponse struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	OTPAuthURI string `json:"otpauth_uri" example:"otpauth://totp/Retail%20Pro:user@example.com?secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP&issuer=Retail+Pro"`
+}
+
+// RecoveryCodesResponse represents the one-time recovery codes shown after enabling 2FA
+// @Description Recovery codes response model
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k7xq2-m4pzt,r9wfa-3hd6e"`
+}
+
+// PasskeyResponse represents a passkey registered by the current user
+// @Description Passkey response model
+type PasskeyResponse struct {
+	ID         string     `json:"id" example:"1"`
+	Name       string     `json:"name" example:"POS Kasir 1"`
+	Transports []string   `json:"transports" example:"internal,hybrid"`
+	CreatedAt  *time.Time `json:"created_at" example:"2026-10-18T08:00:00Z"`
+	LastUsedAt *time.Time `json:"last_used_at" example:"2026-10-18T09:30:00Z"`
+}
+
+// RoleResponse represents a role with its permissions
+// @Description Role response model
+type RoleResponse struct {
+	Name        string   `json:"name" example:"cashier"`
+	Description string   `json:"description" example:"Records sales at the point of sale"`
+	Permissions []string `json:"permissions" example:"sales:read,sales:write"`
+}
+
+// UserAccessResponse represents the roles and resulting permissions of a user
+// @Description User access response model
+type UserAccessResponse struct {
+	Roles       []string `json:"roles" example:"store_manager"`
+	Permissions []string `json:"permissions" example:"inventory:read,inventory:write"`
+}
+
+// APIKeyResponse represents an API key without its secret
+// @Description API key response model
+type APIKeyResponse struct {
+	ID         string     `json:"id" example:"rp_3f9a1c2b7d4e5f60"`
+	Name       string     `json:"name" example:"Sinkronisasi Tokopedia"`
+	Scopes     []string   `json:"scopes" example:"inventory:read,inventory:write"`
+	ExpiresAt  *time.Time `json:"expires_at" example:"2027-01-16T08:00:00Z"`
+	LastUsedAt *time.Time `json:"last_used_at" example:"2026-10-18T09:30:00Z"`
+	CreatedAt  *time.Time `json:"created_at" example:"2026-10-18T08:00:00Z"`
+
+	// OrganizationID is the organization the key acts for, empty for keys created without one
+	OrganizationID string `json:"organization_id,omitempty" example:"7"`
+}
+
+// CreatedAPIKeyResponse represents a new API key together with the full key, which is only shown once
+// @Description Created API key response model
+type CreatedAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key" example:"rp_3f9a1c2b7d4e5f60_9b2c..."`
+}
+
+// OrganizationResponse represents an organization the user belongs to
+// @Description Organization response model
+type OrganizationResponse struct {
+	ID   string `json:"id" example:"7"`
+	Name string `json:"name" example:"Toko Maju Jaya"`
+	Role string `json:"role" example:"owner"`
+	// Active is true for the organization of the current access token
+	Active bool `json:"active" example:"true"`
+}
+
+// OrganizationInvitationResponse represents a pending invitation to an organization
+// @Description Organization invitation response model
+type OrganizationInvitationResponse struct {
+	ID        string     `json:"id" example:"3"`
+	Email     string     `json:"email" example:"cashier@example.com"`
+	Role      string     `json:"role" example:"cashier"`
+	InvitedBy string     `json:"invited_by" example:"owner@example.com"`
+	ExpiresAt time.Time  `json:"expires_at" example:"2026-10-21T08:00:00Z"`
+	SentAt    time.Time  `json:"sent_at" example:"2026-10-18T08:00:00Z"`
+	CreatedAt *time.Time `json:"created_at" example:"2026-10-18T08:00:00Z"`
+}
+
+// OrganizationMemberResponse represents a member of an organization
+// @Description Organization member response model
+type OrganizationMemberResponse struct {
+	UserID   string     `json:"user_id" example:"12"`
+	Email    string     `json:"email" example:"cashier@example.com"`
+	Fullname string     `json:"fullname" example:"Siti Aminah"`
+	Role     string     `json:"role" example:"cashier"`
+	JoinedAt *time.Time `json:"joined_at" example:"2026-10-18T08:00:00Z"`
+}
+
+// EmailOutboxResponse represents an email of the outbox with its delivery state
+// @Description Email outbox response model
+type EmailOutboxResponse struct {
+	ID            string     `json:"id" example:"42"`
+	To            []string   `json:"to" example:"user@example.com"`
+	Subject       string     `json:"subject" example:"Kode Aktivasi Pendaftaran"`
+	Status        string     `json:"status" example:"dead"`
+	Attempts      int        `json:"attempts" example:"8"`
+	LastError     string     `json:"last_error,omitempty" example:"dial tcp: connection refused"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" example:"2026-10-18T08:00:00Z"`
+	SentAt        *time.Time `json:"sent_at,omitempty" example:"2026-10-18T08:00:00Z"`
+	CreatedAt     time.Time  `json:"created_at" example:"2026-10-18T08:00:00Z"`
+
+	ProviderMessageID string     `json:"provider_message_id,omitempty" example:"4ef9a417-02e9-4d39-ad75-9611e0fcc33c"`
+	DeliveryStatus    string     `json:"delivery_status,omitempty" example:"bounced"`
+	DeliveryDetail    string     `json:"delivery_detail,omitempty" example:"The recipient's email address doesn't exist."`
+	DeliveryUpdatedAt *time.Time `json:"delivery_updated_at,omitempty" example:"2026-10-18T08:00:03Z"`
+
+	// ContentAvailable tells whether the content, never returned because it may carry codes,
+	// is still kept so a dead email can be retried
+	ContentAvailable bool `json:"content_available" example:"true"`
+}
+
+// EmailTemplateResponse represents an email template of the developer preview
+// @Description Email template response model
+type EmailTemplateResponse struct {
+	Name       string   `json:"name" example:"registration"`
+	Locales    []string `json:"locales" example:"en,id"`
+	PreviewURL string   `json:"preview_url" example:"/api/v1/dev/emails/registration"`
+}
+
+// TestEmailRequest represents a request to send a template with sample data
+// @Description Test email request model
+type TestEmailRequest struct {
+	To     string `json:"to" binding:"required,email" example:"designer@example.com"`
+	Locale string `json:"locale" example:"id"`
+}
+
+// EmailSuppressionResponse represents an address that no email is sent to
+// @Description Email suppression response model
+type EmailSuppressionResponse struct {
+	Email     string    `json:"email" example:"user@example.com"`
+	Reason    string    `json:"reason" example:"bounce"`
+	Detail    string    `json:"detail,omitempty" example:"The recipient's email address doesn't exist."`
+	CreatedAt time.Time `json:"created_at" example:"2026-10-18T08:00:03Z"`
+}
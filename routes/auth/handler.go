@@ -1,35 +1,90 @@
 package auth
 
 import (
+	"log"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/yantology/golang-starter-template/config"
+	"github.com/yantology/golang-starter-template/middleware"
+	"github.com/yantology/golang-starter-template/pkg/customerror"
 	"github.com/yantology/golang-starter-template/pkg/dto"
-	"github.com/yantology/golang-starter-template/pkg/resendutils"
+	"github.com/yantology/golang-starter-template/pkg/mailtemplate"
+	"github.com/yantology/golang-starter-template/pkg/oidc"
+	"github.com/yantology/golang-starter-template/pkg/outbox"
+	"github.com/yantology/golang-starter-template/pkg/personaldata"
+	"github.com/yantology/golang-starter-template/pkg/suppression"
+	"github.com/yantology/golang-starter-template/pkg/webauthn"
 )
 
 type authHandler struct {
 	authService    AuthService
 	authRepository *AuthRepository
-	emailSender    resendutils.ResendUtilsInterface
-	emailTemplate  EmailTemplateInterface
+	emailTemplates *mailtemplate.Engine
 	tokenRequest   *config.TokenConfig
+	mfaConfig      *config.MFAConfig
+	webAuthn       *webauthn.WebAuthn
+	oidcProviders  *oidc.Registry
+	oidcConfig     *config.OIDCConfig
+	passwordless   *config.PasswordlessConfig
+	activation     *config.ActivationConfig
+	loginLockout   *LoginLockout
+	lockoutConfig  *config.LockoutConfig
+	apiKeyConfig   *config.APIKeyConfig
+	rbacConfig     *config.RBACConfig
+
+	organizationConfig *config.OrganizationConfig
+	personalData       *personaldata.Registry
+	accountDeletion    *config.AccountDeletionConfig
+	emailOutbox        outbox.Store
+	emailSuppressions  suppression.Store
 }
 
 func NewAuthHandler(
 	authService AuthService,
 	authRepository *AuthRepository,
-	emailSender resendutils.ResendUtilsInterface,
-	emailTemplate EmailTemplateInterface,
+	emailTemplates *mailtemplate.Engine,
 	tokenRequest *config.TokenConfig,
+	mfaConfig *config.MFAConfig,
+	webAuthn *webauthn.WebAuthn,
+	oidcProviders *oidc.Registry,
+	oidcConfig *config.OIDCConfig,
+	passwordless *config.PasswordlessConfig,
+	activation *config.ActivationConfig,
+	loginLockout *LoginLockout,
+	lockoutConfig *config.LockoutConfig,
+	apiKeyConfig *config.APIKeyConfig,
+	rbacConfig *config.RBACConfig,
+	organizationConfig *config.OrganizationConfig,
+	personalData *personaldata.Registry,
+	accountDeletion *config.AccountDeletionConfig,
+	emailOutbox outbox.Store,
+	emailSuppressions suppression.Store,
 ) *authHandler {
 	return &authHandler{
 		authService:    authService,
 		authRepository: authRepository,
-		emailSender:    emailSender,
-		emailTemplate:  emailTemplate,
+		emailTemplates: emailTemplates,
 		tokenRequest:   tokenRequest,
+		mfaConfig:      mfaConfig,
+		webAuthn:       webAuthn,
+		oidcProviders:  oidcProviders,
+		oidcConfig:     oidcConfig,
+		passwordless:   passwordless,
+		activation:     activation,
+		loginLockout:   loginLockout,
+		lockoutConfig:  lockoutConfig,
+		apiKeyConfig:   apiKeyConfig,
+		rbacConfig:     rbacConfig,
+
+		organizationConfig: organizationConfig,
+		personalData:       personalData,
+		accountDeletion:    accountDeletion,
+		emailOutbox:        emailOutbox,
+		emailSuppressions:  emailSuppressions,
 	}
 }
 
@@ -38,18 +93,20 @@ func NewAuthHandler(
 // @Tags auth
 // @Accept json
 // @Produce json
-// @Param type path string true "Token type (registration or forget-password)"
+// @Param type path string true "Token type (registration, forget-password or login)"
 // @Param request body TokenRequest true "Token request parameters"
 // @Success 200 {object} dto.MessageResponse
 // @Failure 400 {object} dto.MessageResponse
 // @Failure 404 {object} dto.MessageResponse
 // @Failure 409 {object} dto.MessageResponse
+// @Failure 422 {object} dto.MessageResponse
+// @Failure 429 {object} dto.MessageResponse
 // @Router /auth/token/{type} [post]
 func (h *authHandler) RequestToken(c *gin.Context) {
 	tokenType := c.Param("type")
 
 	// Validate token type
-	if tokenType != "registration" && tokenType != "forget-password" {
+	if tokenType != "registration" && tokenType != "forget-password" && tokenType != loginTokenType {
 		c.JSON(http.StatusBadRequest, dto.MessageResponse{
 
 			Message: "Tipe token tidak valid",
@@ -75,7 +132,25 @@ func (h *authHandler) RequestToken(c *gin.Context) {
 		return
 	}
 
-	// Check if email exists based on token type
+	// Sending to an address that bounced or complained hurts the reputation of the sending domain
+	suppressed, err := h.emailSuppressions.Suppressed(c.Request.Context(), req.Email)
+	if err != nil {
+		log.Println("Error checking suppressed email:", err)
+		c.JSON(http.StatusInternalServerError, dto.MessageResponse{
+			Message: "Gagal memeriksa alamat email",
+		})
+		return
+	}
+	if len(suppressed) > 0 {
+		c.JSON(http.StatusUnprocessableEntity, dto.MessageResponse{
+			Message: "Email tidak dapat dikirim ke alamat ini karena sebelumnya ditolak atau dilaporkan sebagai spam, gunakan alamat lain atau hubungi dukungan",
+		})
+		return
+	}
+
+	// Check if email exists based on token type. The account of an existing email
+	// picks the language of the email.
+	var recipient *User
 	if tokenType == "registration" {
 		if cuserr := h.authRepository.CheckIsNotExistingEmail(req.Email); cuserr != nil {
 			c.JSON(http.StatusConflict, dto.MessageResponse{
@@ -84,8 +159,9 @@ func (h *authHandler) RequestToken(c *gin.Context) {
 			})
 			return
 		}
-	} else if tokenType == "forget-password" {
-		if cuserr := h.authRepository.CheckIsExistingEmail(req.Email); cuserr != nil {
+	} else if tokenType == "forget-password" || tokenType == loginTokenType {
+		var cuserr *customerror.CustomError
+		if recipient, cuserr = h.authRepository.GetUserByEmail(req.Email); cuserr != nil {
 			c.JSON(http.StatusNotFound, dto.MessageResponse{
 
 				Message: cuserr.Message(),
@@ -114,37 +190,53 @@ func (h *authHandler) RequestToken(c *gin.Context) {
 		return
 	}
 
-	// Save token to database
-	tokenReq := &ActivationTokenRequest{
-		Email:          req.Email,
-		ActivationCode: hashedToken,
-		TokenType:      tokenType,
-		ExpiryMinutes:  15, // 15 minutes expiry as per docs
+	// Generate email content based on token type
+	templateName := emailRegistration
+	var templateData any = codeEmailData{Email: req.Email, Code: token, ExpiryMinutes: h.activation.ExpiryMinutes}
+	if tokenType == "forget-password" {
+		templateName = emailPasswordReset
+	}
+	if tokenType == loginTokenType {
+		link, cuserr := h.authService.GenerateLoginLink(req.Email, token, time.Duration(h.activation.ExpiryMinutes)*time.Minute)
+		if cuserr != nil {
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: cuserr.Message(),
+			})
+			return
+		}
+		templateName = emailLogin
+		templateData = loginEmailData{Email: req.Email, Code: token, Link: link, ExpiryMinutes: h.activation.ExpiryMinutes}
 	}
 
-	if cuserr := h.authRepository.SaveActivationToken(tokenReq); cuserr != nil {
-		c.JSON(cuserr.Code(), dto.MessageResponse{
-			Message: "Gagal menyimpan token",
+	email, err := h.composeEmail(templateName, h.emailLocale(c, recipient), req.Email, templateData)
+	if err != nil {
+		log.Println("Error rendering email:", err)
+		c.JSON(http.StatusInternalServerError, dto.MessageResponse{
+			Message: "Gagal membuat email",
 		})
 		return
 	}
 
-	// Generate email content based on token type
-	var emailHTML, emailSubject string
-	if tokenType == "registration" {
-		emailHTML = h.emailTemplate.GenerateRegistrationEmail(req.Email, token)
-		emailSubject = "Kode Aktivasi Pendaftaran"
-	}
-	if tokenType == "forget-password" {
-		emailHTML = h.emailTemplate.GeneratePasswordResetEmail(req.Email, token)
-		emailSubject = "Kode Reset Password"
+	// Save token to database, queueing the email in the same transaction
+	tokenReq := &ActivationTokenRequest{
+		Email:                 req.Email,
+		ActivationCode:        hashedToken,
+		TokenType:             tokenType,
+		ExpiryMinutes:         h.activation.ExpiryMinutes,
+		ResendCooldownSeconds: h.activation.ResendCooldownSeconds,
+		Message:               email,
 	}
 
-	// Send email
-	if cuserr := h.emailSender.Send(emailHTML, emailSubject, []string{req.Email}); cuserr != nil {
+	if cuserr := h.authRepository.SaveActivationToken(tokenReq); cuserr != nil {
+		if cuserr.Code() == http.StatusTooManyRequests {
+			c.Header("Retry-After", strconv.Itoa(h.activation.ResendCooldownSeconds))
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: "Kode baru sudah dikirim, silakan tunggu sebelum meminta lagi",
+			})
+			return
+		}
 		c.JSON(cuserr.Code(), dto.MessageResponse{
-
-			Message: cuserr.Message(),
+			Message: "Gagal menyimpan token",
 		})
 		return
 	}
@@ -162,7 +254,7 @@ func (h *authHandler) RequestToken(c *gin.Context) {
 // @Produce json
 // @Param request body RegisterRequest true "Registration details"
 // @Success 201 {object} dto.MessageResponse
-// @Failure 400 {object} dto.MessageResponse
+// @Failure 400 {object} dto.DataResponse[[]PasswordViolation]
 // @Failure 401 {object} dto.MessageResponse
 // @Router /auth/register [post]
 func (h *authHandler) Register(c *gin.Context) {
@@ -190,22 +282,17 @@ func (h *authHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// Validate activation token
+	if !h.checkPasswordPolicy(c, req.Password, req.Email, req.Fullname) {
+		return
+	}
+
+	// Validate activation token, which can only be used once
 	tokenReq := &GetActivationTokenRequest{
 		Email:     req.Email,
 		TokenType: "registration",
 	}
 
-	token, cuserr := h.authRepository.GetActivationToken(tokenReq)
-	if cuserr != nil {
-		c.JSON(cuserr.Code(), dto.MessageResponse{
-			Message: cuserr.Message(),
-		})
-		return
-	}
-
-	// Verify token
-	if cuserr := h.authService.VerifyHash(token, req.ActivationCode); cuserr != nil {
+	if cuserr := h.consumeActivationCode(tokenReq, req.ActivationCode); cuserr != nil {
 		c.JSON(cuserr.Code(), dto.MessageResponse{
 			Message: cuserr.Message(),
 		})
@@ -242,14 +329,16 @@ func (h *authHandler) Register(c *gin.Context) {
 }
 
 // @Summary User login
-// @Description Authenticate user and return JWT tokens
+// @Description Authenticate user and return JWT tokens, or an MFA challenge when two-factor authentication is enabled
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Param request body LoginRequest true "Login credentials"
 // @Success 200 {object} dto.MessageResponse
+// @Success 202 {object} dto.DataResponse[MFAChallengeResponse]
 // @Failure 400 {object} dto.MessageResponse
 // @Failure 401 {object} dto.MessageResponse
+// @Failure 429 {object} dto.MessageResponse
 // @Router /auth/login [post]
 func (h *authHandler) Login(c *gin.Context) {
 	var req LoginRequest
@@ -261,9 +350,16 @@ func (h *authHandler) Login(c *gin.Context) {
 		return
 	}
 
+	if !h.checkLoginLockout(c, req.Email) {
+		return
+	}
+
 	// Get user by email
 	user, cuserr := h.authRepository.GetUserByEmail(req.Email)
 	if cuserr != nil {
+		if cuserr.Code() == http.StatusNotFound {
+			h.recordLoginFailure(c, req.Email, nil)
+		}
 		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
 
 			Message: cuserr.Message(),
@@ -273,6 +369,7 @@ func (h *authHandler) Login(c *gin.Context) {
 
 	// Verify password
 	if cuserr := h.authService.VerifyHash(user.PasswordHash, req.Password); cuserr != nil {
+		h.recordLoginFailure(c, req.Email, user)
 		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
 
 			Message: "hash tidak valid",
@@ -280,24 +377,47 @@ func (h *authHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Generate token pair
-	tokenPairReq := TokenPairRequest{
-		UserID: user.ID,
-		Email:  user.Email,
+	h.resetLoginLockout(c, req.Email)
+
+	// Upgrade hashes made with an outdated algorithm or cost while the password is known
+	if h.authService.NeedsRehash(user.PasswordHash) {
+		h.rehashPassword(user, req.Password)
 	}
 
-	cuserr = h.authService.GenerateTokenPairCookies(c.Writer, tokenPairReq)
-	if cuserr != nil {
-		c.JSON(cuserr.Code(), dto.MessageResponse{
+	h.completeLogin(c, user)
+}
 
-			Message: cuserr.Message(),
-		})
-		return
+// checkPasswordPolicy responds with 400 and the list of broken rules when the
+// password does not satisfy the password policy
+func (h *authHandler) checkPasswordPolicy(c *gin.Context, password, email, fullname string) bool {
+	violations := h.authService.ValidatePasswordPolicy(password, email, fullname)
+	if len(violations) == 0 {
+		return true
 	}
 
-	c.JSON(http.StatusOK, dto.MessageResponse{
-		Message: "Login berhasil",
+	c.JSON(http.StatusBadRequest, dto.DataResponse[[]PasswordViolation]{
+		Data:    violations,
+		Message: "Password tidak memenuhi kebijakan password",
 	})
+	return false
+}
+
+// rehashPassword stores a new hash of password. Failures are only logged since
+// the old hash still works.
+func (h *authHandler) rehashPassword(user *User, password string) {
+	hashedPassword, cuserr := h.authService.HashString(password)
+	if cuserr != nil {
+		log.Println("Error rehashing password:", cuserr.Original())
+		return
+	}
+
+	updateReq := &UpdatePasswordRequest{
+		Email:           user.Email,
+		NewPasswordHash: hashedPassword,
+	}
+	if cuserr := h.authRepository.UpdateUserPassword(updateReq); cuserr != nil {
+		log.Println("Error storing rehashed password:", cuserr.Original())
+	}
 }
 
 // @Summary Reset password
@@ -307,7 +427,7 @@ func (h *authHandler) Login(c *gin.Context) {
 // @Produce json
 // @Param request body ForgetPasswordRequest true "Password reset details"
 // @Success 200 {object} dto.MessageResponse "Success response with message"
-// @Failure 400 {object} dto.MessageResponse "Bad request response"
+// @Failure 400 {object} dto.DataResponse[[]PasswordViolation] "Password policy violations"
 // @Failure 401 {object} dto.MessageResponse "Unauthorized response"
 // @Router /auth/forget-password [post]
 func (h *authHandler) ForgetPassword(c *gin.Context) {
@@ -329,22 +449,22 @@ func (h *authHandler) ForgetPassword(c *gin.Context) {
 		return
 	}
 
-	// Validate activation token
+	// The full name is only known when the account exists
+	fullname := ""
+	if user, cuserr := h.authRepository.GetUserByEmail(req.Email); cuserr == nil {
+		fullname = user.Fullname
+	}
+	if !h.checkPasswordPolicy(c, req.NewPassword, req.Email, fullname) {
+		return
+	}
+
+	// Validate activation token, which can only be used once
 	tokenReq := &GetActivationTokenRequest{
 		Email:     req.Email,
 		TokenType: "forget-password",
 	}
 
-	token, cuserr := h.authRepository.GetActivationToken(tokenReq)
-	if cuserr != nil {
-		c.JSON(cuserr.Code(), dto.MessageResponse{
-			Message: cuserr.Message(),
-		})
-		return
-	}
-
-	// Verify token
-	if cuserr := h.authService.VerifyHash(token, req.ActivationCode); cuserr != nil {
+	if cuserr := h.consumeActivationCode(tokenReq, req.ActivationCode); cuserr != nil {
 		c.JSON(cuserr.Code(), dto.MessageResponse{
 			Message: cuserr.Message(),
 		})
@@ -375,9 +495,24 @@ func (h *authHandler) ForgetPassword(c *gin.Context) {
 		return
 	}
 
+	// A reset often follows a takeover, so every session is ended, including the caller's
+	user, cuserr := h.authRepository.GetUserByEmail(req.Email)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+	if cuserr := h.authRepository.RevokeOtherSessions(user.ID, ""); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
 	c.JSON(http.StatusOK, dto.MessageResponse{
 
-		Message: "Password berhasil diubah",
+		Message: "Password berhasil diubah, semua sesi telah dicabut",
 	})
 }
 
@@ -410,13 +545,65 @@ func (h *authHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	tokenPair := TokenPairRequest{
-		UserID: claims.UserID,
-		Email:  claims.Email,
+	// Look up the stored session of this refresh token
+	session, cuserr := h.authRepository.GetSessionByTokenHash(h.authService.HashToken(refreshToken))
+	if cuserr != nil {
+		if cuserr.Code() == http.StatusNotFound {
+			c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+				Message: "Sesi tidak ditemukan",
+			})
+			return
+		}
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if session.RevokedAt != nil {
+		h.authService.GenerateLogoutCookies(c.Writer)
+		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+			Message: "Sesi telah dicabut",
+		})
+		return
+	}
+
+	// A refresh token that was already rotated is being replayed
+	if session.RotatedAt != nil {
+		h.handleRefreshTokenReuse(c, session)
+		return
+	}
+
+	// The email is read again since it may have changed after the refresh token was issued
+	user, cuserr := h.authRepository.GetUserByID(claims.UserID)
+	if cuserr != nil {
+		if cuserr.Code() == http.StatusNotFound {
+			h.authService.GenerateLogoutCookies(c.Writer)
+			c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+				Message: "Akun tidak ditemukan",
+			})
+			return
+		}
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	tokenPairReq, cuserr := h.withUserAccess(TokenPairRequest{
+		UserID:         user.ID,
+		Email:          user.Email,
+		OrganizationID: claims.OrganizationID,
+	})
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
 	}
+	tokenPairReq.SessionID = session.FamilyID
 
-	// Generate new access token
-	cuserr = h.authService.GenerateTokenPairCookies(c.Writer, tokenPair)
+	tokenPair, cuserr := h.authService.GenerateTokenPair(tokenPairReq)
 	if cuserr != nil {
 		c.JSON(cuserr.Code(), dto.MessageResponse{
 
@@ -425,35 +612,359 @@ func (h *authHandler) RefreshToken(c *gin.Context) {
 		return
 	}
 
+	// Rotate the refresh token within the same family
+	rotateReq := &RotateSessionRequest{
+		SessionID:    session.ID,
+		FamilyID:     session.FamilyID,
+		UserID:       session.UserID,
+		NewTokenHash: h.authService.HashToken(tokenPair.RefreshToken),
+		UserAgent:    c.Request.UserAgent(),
+		IPAddress:    c.ClientIP(),
+		ExpiresAt:    time.Now().Add(h.tokenRequest.RefreshTokenExpiry),
+	}
+
+	if cuserr := h.authRepository.RotateSession(rotateReq); cuserr != nil {
+		// Another request rotated this token first
+		if cuserr.Code() == http.StatusConflict {
+			h.handleRefreshTokenReuse(c, session)
+			return
+		}
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	h.authService.SetTokenPairCookies(c.Writer, tokenPair)
+
 	c.JSON(http.StatusOK, dto.MessageResponse{
 		Message: "Token berhasil diperbarui",
 	})
 }
 
 // @Summary User logout
-// @Description Clear user authentication cookies
+// @Description Revoke the current session and access token and clear user authentication cookies
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Success 200 {object} dto.MessageResponse "Success response with message"
-// @Router /auth/logout [post]
+// @Router /auth/logout [delete]
 func (h *authHandler) Logout(c *gin.Context) {
 	h.authService.GenerateLogoutCookies(c.Writer)
 
+	// The access token would stay valid until it expires, so it is put on the denylist
+	if accessToken := h.accessTokenFromRequest(c); accessToken != "" {
+		if cuserr := h.authService.RevokeAccessToken(accessToken); cuserr != nil {
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: cuserr.Message(),
+			})
+			return
+		}
+	}
+
+	// Revoke the server-side session when a refresh token is present
+	if refreshToken, err := c.Cookie(h.tokenRequest.RefreshTokenName); err == nil && refreshToken != "" {
+		session, cuserr := h.authRepository.GetSessionByTokenHash(h.authService.HashToken(refreshToken))
+		if cuserr != nil && cuserr.Code() != http.StatusNotFound {
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: cuserr.Message(),
+			})
+			return
+		}
+
+		if session != nil {
+			if cuserr := h.authRepository.RevokeSessionFamily(session.FamilyID); cuserr != nil {
+				c.JSON(cuserr.Code(), dto.MessageResponse{
+					Message: cuserr.Message(),
+				})
+				return
+			}
+		}
+	}
+
 	c.JSON(http.StatusOK, dto.MessageResponse{
 		Message: "Logout berhasil",
 	})
 }
 
+// accessTokenFromRequest returns the access token of the cookie or the bearer header, empty when there is none
+func (h *authHandler) accessTokenFromRequest(c *gin.Context) string {
+	if accessToken, err := c.Cookie(h.tokenRequest.AccessTokenName); err == nil && accessToken != "" {
+		return accessToken
+	}
+	if parts := strings.Split(c.GetHeader("Authorization"), " "); len(parts) == 2 && strings.EqualFold(parts[0], "Bearer") {
+		return parts[1]
+	}
+	return ""
+}
+
+// JWKS serves the public keys verifying access tokens at /.well-known/jwks.json,
+// outside the API base path. The set is empty while access tokens are HMAC signed.
+func (h *authHandler) JWKS(c *gin.Context) {
+	// Verifiers cache the set; rotated keys stay listed during their grace period
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, h.authService.JWKS())
+}
+
+// RateLimits holds the rate limiting middleware of the auth routes
+type RateLimits struct {
+	// Group applies to every auth route
+	Group gin.HandlerFunc
+	// TokenRequest applies to the routes that send activation emails
+	TokenRequest gin.HandlerFunc
+}
+
 // RegisterRoutes registers all auth routes
-func (h *authHandler) RegisterRoutes(router *gin.RouterGroup) {
-	authGroup := router.Group("/auth")
+func (h *authHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware, rateLimits RateLimits) {
+	authGroup := router.Group("/auth", rateLimits.Group)
 	{
-		authGroup.POST("/token/:type", h.RequestToken)
+		authGroup.POST("/token/:type", rateLimits.TokenRequest, h.RequestToken)
 		authGroup.POST("/register", h.Register)
 		authGroup.POST("/login", h.Login)
 		authGroup.POST("/forget-password", h.ForgetPassword)
 		authGroup.GET("/refresh-token", h.RefreshToken)
 		authGroup.DELETE("/logout", h.Logout)
+
+		authGroup.POST("/login/mfa", h.LoginMFA)
+		authGroup.POST("/login/code", h.LoginWithCode)
+		authGroup.POST("/login/passkey/begin", h.BeginPasskeyLogin)
+		authGroup.POST("/login/passkey/finish", h.FinishPasskeyLogin)
+		authGroup.GET("/oidc/providers", h.ListOIDCProviders)
+		authGroup.GET("/oidc/:provider/login", h.OIDCLogin)
+		authGroup.GET("/oidc/:provider/callback", h.OIDCCallback)
+		authGroup.POST("/invitations/accept", h.AcceptOrganizationInvitation)
+
+		mfaGroup := authGroup.Group("/mfa/totp", authMiddleware.AuthRequired(), authMiddleware.RejectAPIKeys())
+		{
+			mfaGroup.POST("/enroll", h.EnrollTOTP)
+			mfaGroup.POST("/confirm", h.ConfirmTOTP)
+			mfaGroup.POST("/disable", h.DisableTOTP)
+		}
+
+		profileGroup := authGroup.Group("", authMiddleware.AuthRequired(), authMiddleware.RejectAPIKeys())
+		{
+			profileGroup.GET("/me", h.GetProfile)
+			profileGroup.PATCH("/me", h.UpdateProfile)
+			profileGroup.POST("/me/email", h.RequestEmailChange)
+			profileGroup.POST("/me/email/confirm", h.ConfirmEmailChange)
+			profileGroup.POST("/change-password", h.ChangePassword)
+			profileGroup.GET("/me/export", h.ExportPersonalData)
+			profileGroup.POST("/me/deletion", h.RequestAccountDeletion)
+			profileGroup.DELETE("/me/deletion", h.CancelAccountDeletion)
+		}
+
+		sessionGroup := authGroup.Group("/sessions", authMiddleware.AuthRequired(), authMiddleware.RejectAPIKeys())
+		{
+			sessionGroup.GET("", h.ListSessions)
+			sessionGroup.DELETE("", h.RevokeOtherSessions)
+			sessionGroup.DELETE("/:id", h.RevokeSession)
+		}
+
+		passkeyGroup := authGroup.Group("/passkeys", authMiddleware.AuthRequired(), authMiddleware.RejectAPIKeys())
+		{
+			passkeyGroup.GET("", h.ListPasskeys)
+			passkeyGroup.POST("/register/begin", h.BeginPasskeyRegistration)
+			passkeyGroup.POST("/register/finish", h.FinishPasskeyRegistration)
+			passkeyGroup.DELETE("/:id", h.DeletePasskey)
+		}
+
+		apiKeyGroup := authGroup.Group("/api-keys", authMiddleware.AuthRequired(), authMiddleware.RejectAPIKeys())
+		{
+			apiKeyGroup.GET("", h.ListAPIKeys)
+			apiKeyGroup.POST("", h.CreateAPIKey)
+			apiKeyGroup.GET("/:id", h.GetAPIKey)
+			apiKeyGroup.PATCH("/:id", h.UpdateAPIKey)
+			apiKeyGroup.DELETE("/:id", h.DeleteAPIKey)
+		}
+
+		organizationGroup := authGroup.Group("/organizations", authMiddleware.AuthRequired(), authMiddleware.RejectAPIKeys())
+		{
+			organizationGroup.GET("", h.ListOrganizations)
+			organizationGroup.POST("", h.CreateOrganization)
+			organizationGroup.POST("/:id/switch", h.SwitchOrganization)
+			organizationGroup.GET("/:id/members", h.ListOrganizationMembers)
+			organizationGroup.PUT("/:id/members/:user", h.UpdateOrganizationMemberRole)
+			organizationGroup.DELETE("/:id/members/:user", h.RemoveOrganizationMember)
+			organizationGroup.GET("/:id/invitations", h.ListOrganizationInvitations)
+			organizationGroup.POST("/:id/invitations", h.InviteOrganizationMember)
+			organizationGroup.POST("/:id/invitations/:invitation/resend", h.ResendOrganizationInvitation)
+			organizationGroup.DELETE("/:id/invitations/:invitation", h.RevokeOrganizationInvitation)
+		}
+
+		adminGroup := authGroup.Group("/admin", authMiddleware.AuthRequired())
+		{
+			adminGroup.POST("/unlock", authMiddleware.RequirePermission(PermissionUsersUnlock), h.UnlockAccount)
+			adminGroup.GET("/emails", authMiddleware.RequirePermission(PermissionEmailsManage), h.ListOutboxEmails)
+			adminGroup.GET("/emails/:id", authMiddleware.RequirePermission(PermissionEmailsManage), h.GetOutboxEmail)
+			adminGroup.POST("/emails/:id/retry", authMiddleware.RequirePermission(PermissionEmailsManage), h.RetryOutboxEmail)
+			adminGroup.GET("/email-suppressions", authMiddleware.RequirePermission(PermissionEmailsManage), h.ListEmailSuppressions)
+			adminGroup.DELETE("/email-suppressions/:email", authMiddleware.RequirePermission(PermissionEmailsManage), h.RemoveEmailSuppression)
+		}
+
+		roleGroup := authGroup.Group("", authMiddleware.AuthRequired(), authMiddleware.RequirePermission(PermissionRolesManage))
+		{
+			roleGroup.GET("/roles", h.ListRoles)
+			roleGroup.GET("/users/:id/roles", h.GetUserRoles)
+			roleGroup.PUT("/users/:id/roles/:role", h.AssignUserRole)
+			roleGroup.DELETE("/users/:id/roles/:role", h.RemoveUserRole)
+		}
+	}
+}
+
+// completeLogin finishes a first-factor login, either by starting a session or by
+// issuing an MFA challenge when the user has two-factor authentication enabled
+func (h *authHandler) completeLogin(c *gin.Context, user *User) {
+	userTOTP, cuserr := h.authRepository.GetUserTOTP(user.ID)
+	if cuserr != nil && cuserr.Code() != http.StatusNotFound {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if userTOTP != nil && userTOTP.ConfirmedAt != nil {
+		h.issueMFAChallenge(c, user)
+		return
+	}
+
+	tokenPairReq := TokenPairRequest{
+		UserID: user.ID,
+		Email:  user.Email,
 	}
+
+	if cuserr := h.startSession(c, tokenPairReq); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Login berhasil",
+	})
+}
+
+// consumeActivationCode removes the token before checking the code, so concurrent requests
+// cannot both use it. A wrong guess puts the token back with the attempt counted.
+func (h *authHandler) consumeActivationCode(req *GetActivationTokenRequest, code string) *customerror.CustomError {
+	token, cuserr := h.authRepository.ConsumeActivationToken(req)
+	if cuserr != nil {
+		return cuserr
+	}
+
+	if cuserr := h.authService.VerifyHash(token.TokenHash, code); cuserr != nil {
+		if cuserr := h.authRepository.RestoreActivationToken(token, h.activation.MaxAttempts); cuserr != nil {
+			return cuserr
+		}
+		return cuserr
+	}
+	return nil
+}
+
+// startSession creates a new session family for the user and sets the token cookies
+func (h *authHandler) startSession(c *gin.Context, req TokenPairRequest) *customerror.CustomError {
+	familyID, cuserr := h.authService.GenerateSessionFamilyID()
+	if cuserr != nil {
+		return cuserr
+	}
+
+	req, cuserr = h.withUserAccess(req)
+	if cuserr != nil {
+		return cuserr
+	}
+	req.SessionID = familyID
+
+	tokenPair, cuserr := h.authService.GenerateTokenPair(req)
+	if cuserr != nil {
+		return cuserr
+	}
+
+	sessionReq := &CreateSessionRequest{
+		FamilyID:  familyID,
+		UserID:    req.UserID,
+		TokenHash: h.authService.HashToken(tokenPair.RefreshToken),
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+		ExpiresAt: time.Now().Add(h.tokenRequest.RefreshTokenExpiry),
+	}
+
+	if cuserr := h.authRepository.CreateSession(sessionReq); cuserr != nil {
+		return cuserr
+	}
+
+	h.authService.SetTokenPairCookies(c.Writer, tokenPair)
+	return nil
+}
+
+// withUserAccess adds the user's current roles and permissions to the token
+// request, so role changes reach the access token at the next refresh. The requested
+// organization stays active while the user is a member, otherwise the first
+// organization the user joined becomes active and its role is added next to the global ones.
+func (h *authHandler) withUserAccess(req TokenPairRequest) (TokenPairRequest, *customerror.CustomError) {
+	access, cuserr := h.authRepository.GetUserAccess(req.UserID)
+	if cuserr != nil {
+		return req, cuserr
+	}
+
+	access, cuserr = h.withConfiguredAdmin(access, req.UserID, req.Email)
+	if cuserr != nil {
+		return req, cuserr
+	}
+
+	memberships, cuserr := h.authRepository.ListUserOrganizations(req.UserID)
+	if cuserr != nil {
+		return req, cuserr
+	}
+
+	var active *OrganizationMembership
+	for i := range memberships {
+		if memberships[i].OrganizationID == req.OrganizationID {
+			active = &memberships[i]
+			break
+		}
+	}
+	if active == nil && len(memberships) > 0 {
+		active = &memberships[0]
+	}
+
+	req.OrganizationID = ""
+	if active != nil {
+		req.OrganizationID = active.OrganizationID
+		access = withMembership(access, active)
+	}
+	req.Roles = access.Roles
+	req.Permissions = access.Permissions
+	req.OrganizationRole = access.OrganizationRole
+	req.OrganizationPermissions = access.OrganizationPermissions
+	return req, nil
+}
+
+// withConfiguredAdmin grants the admin role to a user listed in ADMIN_EMAILS who does not
+// have it yet, so an account registered after startup becomes admin on its first login
+func (h *authHandler) withConfiguredAdmin(access *UserAccess, userID, email string) (*UserAccess, *customerror.CustomError) {
+	if containsString(access.Roles, adminRole) || !containsString(h.rbacConfig.AdminEmails, strings.ToLower(email)) {
+		return access, nil
+	}
+
+	if cuserr := h.authRepository.AssignUserRole(userID, adminRole); cuserr != nil {
+		return access, cuserr
+	}
+	return h.authRepository.GetUserAccess(userID)
+}
+
+// handleRefreshTokenReuse revokes the whole session family after a rotated refresh token is replayed
+func (h *authHandler) handleRefreshTokenReuse(c *gin.Context, session *Session) {
+	log.Printf("Refresh token reuse detected for user %s, revoking session family %s", session.UserID, session.FamilyID)
+
+	if cuserr := h.authRepository.RevokeSessionFamily(session.FamilyID); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	h.authService.GenerateLogoutCookies(c.Writer)
+	c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+		Message: "Refresh token sudah pernah digunakan, sesi telah dicabut",
+	})
 }
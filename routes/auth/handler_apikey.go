@@ -0,0 +1,380 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/middleware"
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+)
+
+// @Summary Create an API key
+// @Description Create an API key for an integration. The full key is only returned in this response.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body APIKeyCreateRequest true "API key parameters"
+// @Success 201 {object} dto.DataResponse[CreatedAPIKeyResponse]
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Router /auth/api-keys [post]
+func (h *authHandler) CreateAPIKey(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	var req APIKeyCreateRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	// Keys belong to the active organization and act only within it
+	scopes, cuserr := h.checkAPIKeyScopes(claims.UserID, claims.OrganizationID, req.Scopes)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	expiresInDays := req.ExpiresInDays
+	if expiresInDays == 0 {
+		expiresInDays = h.apiKeyConfig.MaxExpiryDays
+	}
+	if h.apiKeyConfig.MaxExpiryDays > 0 && expiresInDays > h.apiKeyConfig.MaxExpiryDays {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Masa berlaku API key maksimal " + strconv.Itoa(h.apiKeyConfig.MaxExpiryDays) + " hari",
+		})
+		return
+	}
+	var expiresAt *time.Time
+	if expiresInDays > 0 {
+		expiry := time.Now().AddDate(0, 0, expiresInDays)
+		expiresAt = &expiry
+	}
+
+	keyID, key, cuserr := h.authService.GenerateAPIKey(h.apiKeyConfig.Prefix)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+	_, secret, _ := h.authService.ParseAPIKey(key)
+
+	apiKey, cuserr := h.authRepository.CreateAPIKey(&CreateAPIKeyRequest{
+		KeyID:      keyID,
+		UserID:     claims.UserID,
+		Name:       req.Name,
+		SecretHash: h.authService.HashToken(secret),
+		Scopes:     scopes,
+		ExpiresAt:  expiresAt,
+
+		OrganizationID: claims.OrganizationID,
+	})
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.DataResponse[CreatedAPIKeyResponse]{
+		Data: CreatedAPIKeyResponse{
+			APIKeyResponse: newAPIKeyResponse(apiKey),
+			Key:            key,
+		},
+		Message: "API key berhasil dibuat, simpan key ini karena tidak akan ditampilkan lagi",
+	})
+}
+
+// @Summary List API keys
+// @Description List the API keys of the current user
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.DataResponse[[]APIKeyResponse]
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Router /auth/api-keys [get]
+func (h *authHandler) ListAPIKeys(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	apiKeys, cuserr := h.authRepository.ListAPIKeys(claims.UserID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	response := make([]APIKeyResponse, 0, len(apiKeys))
+	for i := range apiKeys {
+		response = append(response, newAPIKeyResponse(&apiKeys[i]))
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[[]APIKeyResponse]{
+		Data:    response,
+		Message: "Daftar API key berhasil diambil",
+	})
+}
+
+// @Summary Get an API key
+// @Description Get an API key of the current user
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "API key ID"
+// @Success 200 {object} dto.DataResponse[APIKeyResponse]
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Router /auth/api-keys/{id} [get]
+func (h *authHandler) GetAPIKey(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	apiKey, cuserr := h.authRepository.GetAPIKey(c.Param("id"))
+	if cuserr == nil && apiKey.UserID != claims.UserID {
+		cuserr = customerror.NewCustomError(nil, "api key not found", http.StatusNotFound)
+	}
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[APIKeyResponse]{
+		Data:    newAPIKeyResponse(apiKey),
+		Message: "API key berhasil diambil",
+	})
+}
+
+// @Summary Update an API key
+// @Description Rename an API key of the current user or replace its scopes
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "API key ID"
+// @Param request body APIKeyUpdateRequest true "Fields to change"
+// @Success 200 {object} dto.DataResponse[APIKeyResponse]
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Router /auth/api-keys/{id} [patch]
+func (h *authHandler) UpdateAPIKey(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	var req APIKeyUpdateRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	update := &UpdateAPIKeyRequest{
+		KeyID:  c.Param("id"),
+		UserID: claims.UserID,
+		Name:   req.Name,
+	}
+	if req.Scopes != nil {
+		apiKey, cuserr := h.authRepository.GetAPIKey(update.KeyID)
+		if cuserr == nil && apiKey.UserID != claims.UserID {
+			cuserr = customerror.NewCustomError(nil, "api key not found", http.StatusNotFound)
+		}
+		if cuserr != nil {
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: cuserr.Message(),
+			})
+			return
+		}
+
+		scopes, cuserr := h.checkAPIKeyScopes(claims.UserID, apiKey.OrganizationID, *req.Scopes)
+		if cuserr != nil {
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: cuserr.Message(),
+			})
+			return
+		}
+		update.Scopes = scopes
+	}
+
+	apiKey, cuserr := h.authRepository.UpdateAPIKey(update)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[APIKeyResponse]{
+		Data:    newAPIKeyResponse(apiKey),
+		Message: "API key berhasil diperbarui",
+	})
+}
+
+// @Summary Delete an API key
+// @Description Revoke an API key of the current user. Integrations using it are rejected immediately.
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "API key ID"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Router /auth/api-keys/{id} [delete]
+func (h *authHandler) DeleteAPIKey(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	if cuserr := h.authRepository.DeleteAPIKey(claims.UserID, c.Param("id")); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "API key berhasil dihapus",
+	})
+}
+
+// checkAPIKeyScopes removes duplicate scopes and rejects scopes the user does not hold,
+// counting the permissions of their role in the organization of the key
+func (h *authHandler) checkAPIKeyScopes(userID, organizationID string, scopes []string) ([]string, *customerror.CustomError) {
+	access, cuserr := userAccess(h.authRepository, userID, organizationID)
+	if cuserr != nil {
+		return nil, cuserr
+	}
+
+	unique := []string{}
+	for _, scope := range scopes {
+		if !containsString(access.Permissions, scope) && !containsString(access.OrganizationPermissions, scope) {
+			return nil, customerror.NewCustomError(nil, "Scope tidak diizinkan: "+scope, http.StatusBadRequest)
+		}
+		if !containsString(unique, scope) {
+			unique = append(unique, scope)
+		}
+	}
+	sort.Strings(unique)
+	return unique, nil
+}
+
+func newAPIKeyResponse(apiKey *APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         apiKey.ID,
+		Name:       apiKey.Name,
+		Scopes:     apiKey.Scopes,
+		ExpiresAt:  apiKey.ExpiresAt,
+		LastUsedAt: apiKey.LastUsedAt,
+		CreatedAt:  apiKey.CreatedAt,
+
+		OrganizationID: apiKey.OrganizationID,
+	}
+}
+
+// Verify interface implementation
+var _ middleware.APIKeyAuthenticator = (*APIKeyAuthenticator)(nil)
+
+// APIKeyAuthenticator lets AuthMiddleware accept the API keys stored by this module
+type APIKeyAuthenticator struct {
+	authService    AuthService
+	authRepository *AuthRepository
+}
+
+// NewAPIKeyAuthenticator creates the authenticator passed to middleware.NewAuthMiddleware
+func NewAPIKeyAuthenticator(authService AuthService, authRepository *AuthRepository) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{
+		authService:    authService,
+		authRepository: authRepository,
+	}
+}
+
+// AuthenticateAPIKey verifies an API key and returns the claims of its owner. The
+// permissions are the key's scopes the owner still holds, so taking a role away
+// also narrows the keys of that user. Keys of an organization stop working when
+// the owner leaves it.
+func (a *APIKeyAuthenticator) AuthenticateAPIKey(key string) (*middleware.UserClaims, *customerror.CustomError) {
+	invalid := customerror.NewCustomError(nil, "API key tidak valid", http.StatusUnauthorized)
+
+	keyID, secret, ok := a.authService.ParseAPIKey(key)
+	if !ok {
+		return nil, invalid
+	}
+
+	apiKey, cuserr := a.authRepository.GetAPIKey(keyID)
+	if cuserr != nil {
+		if cuserr.Code() == http.StatusNotFound {
+			return nil, invalid
+		}
+		return nil, cuserr
+	}
+
+	secretHash := a.authService.HashToken(secret)
+	if subtle.ConstantTimeCompare([]byte(secretHash), []byte(apiKey.SecretHash)) != 1 {
+		return nil, invalid
+	}
+	if apiKey.ExpiresAt != nil && !time.Now().Before(*apiKey.ExpiresAt) {
+		return nil, customerror.NewCustomError(nil, "API key sudah kadaluarsa", http.StatusUnauthorized)
+	}
+
+	user, cuserr := a.authRepository.GetUserByID(apiKey.UserID)
+	if cuserr != nil {
+		return nil, cuserr
+	}
+	access, cuserr := userAccess(a.authRepository, apiKey.UserID, apiKey.OrganizationID)
+	if cuserr != nil {
+		if cuserr.Code() == http.StatusForbidden {
+			return nil, invalid
+		}
+		return nil, cuserr
+	}
+
+	// Scopes keep the side they were granted by, so an organization permission never
+	// reaches global routes
+	permissions, organizationPermissions := []string{}, []string{}
+	for _, scope := range apiKey.Scopes {
+		if containsString(access.Permissions, scope) {
+			permissions = append(permissions, scope)
+		}
+		if containsString(access.OrganizationPermissions, scope) {
+			organizationPermissions = append(organizationPermissions, scope)
+		}
+	}
+
+	if cuserr := a.authRepository.TouchAPIKey(apiKey.ID); cuserr != nil {
+		log.Println("Failed to record API key usage:", cuserr.Original())
+	}
+
+	return &middleware.UserClaims{
+		UserID:         user.ID,
+		Email:          user.Email,
+		Permissions:    permissions,
+		OrganizationID: apiKey.OrganizationID,
+		APIKeyID:       apiKey.ID,
+
+		OrganizationRole:        access.OrganizationRole,
+		OrganizationPermissions: organizationPermissions,
+	}, nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
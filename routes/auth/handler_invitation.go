@@ -0,0 +1,359 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/middleware"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+)
+
+// @Summary Invite to an organization
+// @Description Email a signed invitation link for an organization role. Accepting it creates the account when the email has none.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Organization ID"
+// @Param request body OrganizationInviteRequest true "Invitation details"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Failure 409 {object} dto.MessageResponse
+// @Failure 429 {object} dto.MessageResponse
+// @Router /auth/organizations/{id}/invitations [post]
+func (h *authHandler) InviteOrganizationMember(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	var req OrganizationInviteRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	membership, cuserr := h.checkOrganizationPermission(c.Param("id"), claims.UserID, PermissionMembersManage)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if !h.sendOrganizationInvitation(c, membership, claims.UserID, req.Email, req.Role) {
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Undangan telah dikirim ke email",
+	})
+}
+
+// @Summary List pending invitations
+// @Description List the invitations of an organization that were not accepted or revoked yet
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} dto.DataResponse[[]OrganizationInvitationResponse]
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Router /auth/organizations/{id}/invitations [get]
+func (h *authHandler) ListOrganizationInvitations(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	membership, cuserr := h.checkOrganizationPermission(c.Param("id"), claims.UserID, PermissionMembersManage)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	invitations, cuserr := h.authRepository.ListOrganizationInvitations(membership.OrganizationID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	response := make([]OrganizationInvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		response = append(response, OrganizationInvitationResponse{
+			ID:        invitation.ID,
+			Email:     invitation.Email,
+			Role:      invitation.Role,
+			InvitedBy: invitation.InvitedBy,
+			ExpiresAt: invitation.ExpiresAt,
+			SentAt:    invitation.SentAt,
+			CreatedAt: invitation.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[[]OrganizationInvitationResponse]{
+		Data:    response,
+		Message: "Daftar undangan berhasil diambil",
+	})
+}
+
+// @Summary Resend an invitation
+// @Description Email a new invitation link with a new expiry. Links sent before stop working.
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Organization ID"
+// @Param invitation path string true "Invitation ID"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Failure 429 {object} dto.MessageResponse
+// @Router /auth/organizations/{id}/invitations/{invitation}/resend [post]
+func (h *authHandler) ResendOrganizationInvitation(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	membership, cuserr := h.checkOrganizationPermission(c.Param("id"), claims.UserID, PermissionMembersManage)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	invitation, cuserr := h.authRepository.GetOrganizationInvitation(membership.OrganizationID, c.Param("invitation"))
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if !h.sendOrganizationInvitation(c, membership, claims.UserID, invitation.Email, invitation.Role) {
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Undangan telah dikirim ulang ke email",
+	})
+}
+
+// @Summary Revoke an invitation
+// @Description Delete a pending invitation so its link can no longer be accepted
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Organization ID"
+// @Param invitation path string true "Invitation ID"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Router /auth/organizations/{id}/invitations/{invitation} [delete]
+func (h *authHandler) RevokeOrganizationInvitation(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	membership, cuserr := h.checkOrganizationPermission(c.Param("id"), claims.UserID, PermissionMembersManage)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if cuserr := h.authRepository.DeleteOrganizationInvitation(membership.OrganizationID, c.Param("invitation")); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Undangan berhasil dicabut",
+	})
+}
+
+// @Summary Accept an organization invitation
+// @Description Join an organization with the token of an invitation link. An account is created for an invited email that has none, which needs the full name and password, without a separate registration code. Existing users log in afterwards as usual.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body OrganizationAcceptRequest true "Invitation token and, for new accounts, the account details"
+// @Success 200 {object} dto.MessageResponse
+// @Success 201 {object} dto.MessageResponse
+// @Failure 400 {object} dto.DataResponse[[]PasswordViolation]
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Router /auth/invitations/accept [post]
+func (h *authHandler) AcceptOrganizationInvitation(c *gin.Context) {
+	var req OrganizationAcceptRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	invitation, cuserr := h.authService.ParseInvitationToken(req.Token)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	acceptReq := &AcceptOrganizationInvitationRequest{
+		OrganizationID: invitation.OrganizationID,
+		Email:          invitation.Email,
+		TokenHash:      h.authService.HashToken(req.Token),
+	}
+
+	// The signed link proves the email address, so a new account needs no registration code
+	user, cuserr := h.authRepository.GetUserByEmail(invitation.Email)
+	switch {
+	case cuserr == nil:
+		acceptReq.UserID = user.ID
+	case cuserr.Code() == http.StatusNotFound:
+		if req.Fullname == "" {
+			c.JSON(http.StatusBadRequest, dto.MessageResponse{
+				Message: "Nama lengkap dan password diperlukan untuk membuat akun",
+			})
+			return
+		}
+
+		regReq := RegistrationRequest{
+			Email:                invitation.Email,
+			Username:             req.Fullname,
+			Password:             req.Password,
+			PasswordConfirmation: req.PasswordConfirmation,
+		}
+		if cuserr := h.authService.ValidateRegistrationInput(regReq); cuserr != nil {
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: cuserr.Message(),
+			})
+			return
+		}
+
+		if !h.checkPasswordPolicy(c, req.Password, invitation.Email, req.Fullname) {
+			return
+		}
+
+		hashedPassword, cuserr := h.authService.HashString(req.Password)
+		if cuserr != nil {
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: cuserr.Message(),
+			})
+			return
+		}
+
+		acceptReq.NewUser = &CreateUserRequest{
+			Email:        invitation.Email,
+			Fullname:     req.Fullname,
+			PasswordHash: hashedPassword,
+		}
+	default:
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if cuserr := h.authRepository.AcceptOrganizationInvitation(acceptReq); cuserr != nil {
+		if cuserr.Code() == http.StatusNotFound {
+			c.JSON(http.StatusNotFound, dto.MessageResponse{
+				Message: "Undangan tidak ditemukan atau sudah tidak berlaku",
+			})
+			return
+		}
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if acceptReq.NewUser != nil {
+		c.JSON(http.StatusCreated, dto.MessageResponse{
+			Message: "Akun berhasil dibuat dan bergabung dengan organisasi, silakan login",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Berhasil bergabung dengan organisasi",
+	})
+}
+
+// sendOrganizationInvitation stores a new invitation token for the email and queues it,
+// responding with the error and returning false when that fails
+func (h *authHandler) sendOrganizationInvitation(c *gin.Context, membership *OrganizationMembership, inviterID, email, role string) bool {
+	inviter, cuserr := h.authRepository.GetUserByID(inviterID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return false
+	}
+
+	expiry := time.Duration(h.organizationConfig.InvitationExpiryHours) * time.Hour
+	token, link, cuserr := h.authService.GenerateInvitationToken(membership.OrganizationID, email, expiry)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return false
+	}
+
+	// The invitee may have no account yet, so the language follows the inviter's request
+	data := organizationInvitationEmailData{
+		Email:            email,
+		OrganizationName: membership.OrganizationName,
+		InviterName:      inviter.Fullname,
+		Token:            token,
+		Link:             link,
+		ExpiryHours:      h.organizationConfig.InvitationExpiryHours,
+	}
+	message, err := h.composeEmail(emailOrganizationInvitation, h.emailLocale(c, nil), email, data)
+	if err != nil {
+		log.Println("Error rendering invitation email:", err)
+		c.JSON(http.StatusInternalServerError, dto.MessageResponse{
+			Message: "Gagal membuat email",
+		})
+		return false
+	}
+
+	saveReq := &SaveOrganizationInvitationRequest{
+		OrganizationID:        membership.OrganizationID,
+		Email:                 email,
+		Role:                  role,
+		InvitedBy:             inviterID,
+		TokenHash:             h.authService.HashToken(token),
+		ExpiresAt:             time.Now().Add(expiry),
+		ResendCooldownSeconds: h.activation.ResendCooldownSeconds,
+		Message:               message,
+	}
+	if cuserr := h.authRepository.SaveOrganizationInvitation(saveReq); cuserr != nil {
+		switch cuserr.Code() {
+		case http.StatusTooManyRequests:
+			c.Header("Retry-After", strconv.Itoa(h.activation.ResendCooldownSeconds))
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: "Undangan baru sudah dikirim, silakan tunggu sebelum mengirim lagi",
+			})
+		case http.StatusConflict:
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: "Email sudah menjadi anggota organisasi",
+			})
+		default:
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: cuserr.Message(),
+			})
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+	"github.com/yantology/golang-starter-template/pkg/lockout"
+)
+
+// LoginLockout holds the trackers that slow down and lock password login,
+// one keyed by email and one keyed by client IP. MFA is keyed by user ID and
+// counts wrong second factor codes, which a new MFA challenge does not reset.
+type LoginLockout struct {
+	Email lockout.Tracker
+	IP    lockout.Tracker
+	MFA   lockout.Tracker
+}
+
+func lockoutEmailKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// checkLoginLockout responds with 429 and returns false when either the email
+// or the client IP has to wait. Tracker errors are logged and do not block login.
+func (h *authHandler) checkLoginLockout(c *gin.Context, email string) bool {
+	checks := []struct {
+		tracker lockout.Tracker
+		key     string
+	}{
+		{tracker: h.loginLockout.Email, key: lockoutEmailKey(email)},
+		{tracker: h.loginLockout.IP, key: c.ClientIP()},
+	}
+
+	for _, check := range checks {
+		status, err := check.tracker.Check(c.Request.Context(), check.key)
+		if err != nil {
+			log.Println("Failed to check login attempts:", err)
+			continue
+		}
+		if status.Allowed {
+			continue
+		}
+
+		c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(status.RetryAfter)))
+		message := "Terlalu banyak percobaan login, silakan coba lagi nanti"
+		if status.Locked {
+			message = "Akun dikunci sementara karena terlalu banyak percobaan login"
+		}
+		c.JSON(http.StatusTooManyRequests, dto.MessageResponse{
+			Message: message,
+		})
+		return false
+	}
+	return true
+}
+
+// recordLoginFailure counts a failed password login. user is nil when the
+// email is unknown; the attempt is still counted so unknown emails behave the same.
+func (h *authHandler) recordLoginFailure(c *gin.Context, email string, user *User) {
+	if _, err := h.loginLockout.IP.RecordFailure(c.Request.Context(), c.ClientIP()); err != nil {
+		log.Println("Failed to record login attempt:", err)
+	}
+
+	status, err := h.loginLockout.Email.RecordFailure(c.Request.Context(), lockoutEmailKey(email))
+	if err != nil {
+		log.Println("Failed to record login attempt:", err)
+		return
+	}
+
+	if status.JustLocked && user != nil && h.lockoutConfig.NotifyEmail {
+		data := accountLockedEmailData{Email: user.Email, LockedMinutes: h.lockoutConfig.DurationMinutes}
+		h.queueNotification(c, emailAccountLocked, user, data)
+	}
+}
+
+// resetLoginLockout clears the email counter after a successful login. The IP
+// counter is left alone so one valid account cannot hide guesses on others.
+func (h *authHandler) resetLoginLockout(c *gin.Context, email string) {
+	if err := h.loginLockout.Email.Reset(c.Request.Context(), lockoutEmailKey(email)); err != nil {
+		log.Println("Failed to reset login attempts:", err)
+	}
+}
+
+// checkMFALockout responds with 429 and returns false when the user has to wait
+// before trying another second factor code. Tracker errors are logged and do not block.
+func (h *authHandler) checkMFALockout(c *gin.Context, userID string) bool {
+	status, err := h.loginLockout.MFA.Check(c.Request.Context(), userID)
+	if err != nil {
+		log.Println("Failed to check MFA attempts:", err)
+		return true
+	}
+	if status.Allowed {
+		return true
+	}
+
+	c.Header("Retry-After", strconv.Itoa(retryAfterSeconds(status.RetryAfter)))
+	message := "Terlalu banyak percobaan kode 2FA, silakan coba lagi nanti"
+	if status.Locked {
+		message = "Akun dikunci sementara karena terlalu banyak kode 2FA yang salah"
+	}
+	c.JSON(http.StatusTooManyRequests, dto.MessageResponse{
+		Message: message,
+	})
+	return false
+}
+
+// recordMFAFailure counts a wrong TOTP or recovery code of a user
+func (h *authHandler) recordMFAFailure(c *gin.Context, userID string) {
+	if _, err := h.loginLockout.MFA.RecordFailure(c.Request.Context(), userID); err != nil {
+		log.Println("Failed to record MFA attempt:", err)
+	}
+}
+
+// resetMFALockout clears the MFA counter after a correct second factor
+func (h *authHandler) resetMFALockout(c *gin.Context, userID string) {
+	if err := h.loginLockout.MFA.Reset(c.Request.Context(), userID); err != nil {
+		log.Println("Failed to reset MFA attempts:", err)
+	}
+}
+
+func retryAfterSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// @Summary Unlock account
+// @Description Clear failed login attempts and lockout of an email
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body UnlockAccountRequest true "Email to unlock"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Router /auth/admin/unlock [post]
+func (h *authHandler) UnlockAccount(c *gin.Context) {
+	var req UnlockAccountRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	if err := h.loginLockout.Email.Reset(c.Request.Context(), lockoutEmailKey(req.Email)); err != nil {
+		log.Println("Failed to unlock account:", err)
+		c.JSON(http.StatusInternalServerError, dto.MessageResponse{
+			Message: "Gagal membuka kunci akun",
+		})
+		return
+	}
+
+	// The MFA counter is keyed by user ID, an unknown email has none
+	if user, cuserr := h.authRepository.GetUserByEmail(req.Email); cuserr == nil {
+		if err := h.loginLockout.MFA.Reset(c.Request.Context(), user.ID); err != nil {
+			log.Println("Failed to unlock account:", err)
+			c.JSON(http.StatusInternalServerError, dto.MessageResponse{
+				Message: "Gagal membuka kunci akun",
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Kunci akun berhasil dibuka",
+	})
+}
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/middleware"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+	"github.com/yantology/golang-starter-template/pkg/mailer"
+	"github.com/yantology/golang-starter-template/pkg/mailtemplate"
+)
+
+// sampleEmailData is the data the preview renders each template with
+var sampleEmailData = map[string]any{
+	emailRegistration:  codeEmailData{Email: "user@example.com", Code: "123456", ExpiryMinutes: 5},
+	emailPasswordReset: codeEmailData{Email: "user@example.com", Code: "123456", ExpiryMinutes: 5},
+	emailLogin: loginEmailData{
+		Email:         "user@example.com",
+		Code:          "123456",
+		Link:          "https://example.com/login?token=sample",
+		ExpiryMinutes: 5,
+	},
+	emailAccountLocked: accountLockedEmailData{Email: "user@example.com", LockedMinutes: 15},
+	emailEmailChange:   codeEmailData{Email: "new@example.com", Code: "123456", ExpiryMinutes: 5},
+	emailEmailChanged:  emailChangedEmailData{Email: "user@example.com", NewEmail: "new@example.com"},
+	emailAccountDeletion: accountDeletionEmailData{
+		Email:       "user@example.com",
+		ScheduledAt: time.Date(2026, time.January, 15, 9, 0, 0, 0, time.UTC),
+	},
+	emailOrganizationInvitation: organizationInvitationEmailData{
+		Email:            "user@example.com",
+		OrganizationName: "Toko Maju",
+		InviterName:      "John Doe",
+		Token:            "sample-invitation-token",
+		Link:             "https://example.com/invitations/accept?token=sample-invitation-token",
+		ExpiryHours:      72,
+	},
+}
+
+// MailPreviewHandler lets developers and designers look at the email templates with
+// sample data and send them to themselves. It is meant for development only: the
+// previews are not authenticated, sending needs the emails:manage permission.
+type MailPreviewHandler struct {
+	templateDir   string
+	defaultLocale string
+	emailSender   mailer.Sender
+}
+
+func NewMailPreviewHandler(templateDir, defaultLocale string, emailSender mailer.Sender) *MailPreviewHandler {
+	return &MailPreviewHandler{
+		templateDir:   templateDir,
+		defaultLocale: defaultLocale,
+		emailSender:   emailSender,
+	}
+}
+
+// RegisterRoutes serves the preview under /dev/emails of the router. rateLimits.Group
+// applies to every route and rateLimits.TokenRequest to sending, like the auth routes.
+func (h *MailPreviewHandler) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middleware.AuthMiddleware, rateLimits RateLimits) {
+	previewGroup := router.Group("/dev/emails", rateLimits.Group)
+	{
+		previewGroup.GET("", h.ListEmailTemplates)
+		previewGroup.GET("/:name", h.PreviewEmail)
+		previewGroup.POST("/:name/send",
+			authMiddleware.AuthRequired(),
+			authMiddleware.RequirePermission(PermissionEmailsManage),
+			rateLimits.TokenRequest,
+			h.SendTestEmail,
+		)
+	}
+}
+
+// loadTemplates parses the templates on every request, so an edit in the template
+// directory shows on the next reload of the page. A broken template is reported
+// to the developer instead of stopping the server.
+func (h *MailPreviewHandler) loadTemplates(c *gin.Context) (*mailtemplate.Engine, bool) {
+	engine, err := NewEmailTemplates(h.templateDir, h.defaultLocale)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, dto.MessageResponse{
+			Message: "Gagal memuat template email: " + err.Error(),
+		})
+		return nil, false
+	}
+	return engine, true
+}
+
+// @Summary List email templates
+// @Description List the email templates with their translations and preview links. Only served when MAIL_PREVIEW_ENABLED is set.
+// @Tags dev
+// @Produce json
+// @Success 200 {object} dto.DataResponse[[]EmailTemplateResponse]
+// @Failure 500 {object} dto.MessageResponse
+// @Router /dev/emails [get]
+func (h *MailPreviewHandler) ListEmailTemplates(c *gin.Context) {
+	engine, ok := h.loadTemplates(c)
+	if !ok {
+		return
+	}
+
+	names := engine.Names()
+	templates := make([]EmailTemplateResponse, 0, len(names))
+	for _, name := range names {
+		templates = append(templates, EmailTemplateResponse{
+			Name:       name,
+			Locales:    engine.Translations(name),
+			PreviewURL: path.Join(c.FullPath(), name),
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[[]EmailTemplateResponse]{
+		Data:    templates,
+		Message: "Daftar template email berhasil diambil",
+	})
+}
+
+// @Summary Preview email template
+// @Description Render an email template with sample data, as HTML for the browser or as plain text with its subject on the first line
+// @Tags dev
+// @Produce html
+// @Produce plain
+// @Param name path string true "Template name"
+// @Param locale query string false "Locale, the default locale when empty or missing"
+// @Param format query string false "html or text" default(html)
+// @Success 200 {string} string
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Failure 500 {object} dto.MessageResponse
+// @Router /dev/emails/{name} [get]
+func (h *MailPreviewHandler) PreviewEmail(c *gin.Context) {
+	format := c.DefaultQuery("format", "html")
+	if format != "html" && format != "text" {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format harus html atau text",
+		})
+		return
+	}
+
+	rendered, ok := h.renderSample(c, c.Query("locale"))
+	if !ok {
+		return
+	}
+
+	if format == "text" {
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte("Subject: "+rendered.Subject+"\n\n"+rendered.Text))
+		return
+	}
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(rendered.HTML))
+}
+
+// @Summary Send test email
+// @Description Send an email template with sample data straight through the configured mail driver, skipping the outbox
+// @Tags dev
+// @Accept json
+// @Produce json
+// @Param name path string true "Template name"
+// @Security ApiKeyAuth
+// @Param request body TestEmailRequest true "Recipient and locale"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Failure 429 {object} dto.MessageResponse
+// @Failure 500 {object} dto.MessageResponse
+// @Router /dev/emails/{name}/send [post]
+func (h *MailPreviewHandler) SendTestEmail(c *gin.Context) {
+	var req TestEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	rendered, ok := h.renderSample(c, req.Locale)
+	if !ok {
+		return
+	}
+
+	if _, cuserr := h.emailSender.Send(&mailer.Message{
+		To:      []string{req.To},
+		Subject: "[Test] " + rendered.Subject,
+		HTML:    rendered.HTML,
+		Text:    rendered.Text,
+	}); cuserr != nil {
+		log.Println("Error sending test email:", cuserr.Original())
+		c.JSON(http.StatusInternalServerError, dto.MessageResponse{
+			Message: "Gagal mengirim email uji: " + cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Email uji berhasil dikirim",
+	})
+}
+
+// renderSample renders the template named in the path with its sample data, responding
+// and returning false when it cannot
+func (h *MailPreviewHandler) renderSample(c *gin.Context, locale string) (*mailtemplate.Rendered, bool) {
+	engine, ok := h.loadTemplates(c)
+	if !ok {
+		return nil, false
+	}
+
+	name := c.Param("name")
+	data, ok := sampleEmailData[name]
+	if !ok {
+		c.JSON(http.StatusNotFound, dto.MessageResponse{
+			Message: "Template email tidak ditemukan",
+		})
+		return nil, false
+	}
+	if locale == "" {
+		locale = engine.DefaultLocale()
+	}
+
+	rendered, err := engine.Render(name, locale, data)
+	if err != nil {
+		if errors.Is(err, mailtemplate.ErrUnknownTemplate) {
+			c.JSON(http.StatusNotFound, dto.MessageResponse{
+				Message: "Template email tidak ditemukan",
+			})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, dto.MessageResponse{
+			Message: "Gagal membuat email: " + err.Error(),
+		})
+		return nil, false
+	}
+	return rendered, true
+}
@@ -0,0 +1,368 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/middleware"
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+	"github.com/yantology/golang-starter-template/pkg/totp"
+)
+
+// mfaLoginTokenType is the activation token type holding pending MFA login challenges
+const mfaLoginTokenType = "mfa-login"
+
+// @Summary Complete MFA login
+// @Description Finish a login by exchanging the MFA challenge and a TOTP or recovery code for JWT tokens
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body LoginMFARequest true "MFA challenge and code"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 429 {object} dto.MessageResponse
+// @Router /auth/login/mfa [post]
+func (h *authHandler) LoginMFA(c *gin.Context) {
+	var req LoginMFARequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	challengeReq := &GetActivationTokenRequest{
+		Email:     req.Email,
+		TokenType: mfaLoginTokenType,
+	}
+
+	// The challenge is removed before it is checked, so concurrent requests cannot both
+	// redeem it. It is only put back after a wrong code.
+	challenge, cuserr := h.authRepository.ConsumeActivationToken(challengeReq)
+	if cuserr != nil {
+		if cuserr.Code() == http.StatusNotFound {
+			c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+				Message: "Sesi verifikasi 2FA tidak ditemukan atau kadaluarsa",
+			})
+			return
+		}
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if cuserr := h.authService.VerifyHash(challenge.TokenHash, req.MFAToken); cuserr != nil {
+		h.rejectMFAAttempt(c, challenge, "Sesi verifikasi 2FA tidak valid")
+		return
+	}
+
+	user, cuserr := h.authRepository.GetUserByEmail(req.Email)
+	if cuserr != nil {
+		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	userTOTP, cuserr := h.authRepository.GetUserTOTP(user.ID)
+	if cuserr != nil || userTOTP.ConfirmedAt == nil {
+		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+			Message: "2FA tidak aktif untuk akun ini",
+		})
+		return
+	}
+
+	// Failures are counted per user as well, every password login issues a fresh challenge.
+	// A locked user's challenge stays consumed and the login starts over.
+	if !h.checkMFALockout(c, user.ID) {
+		return
+	}
+
+	// Verify the second factor
+	if cuserr := h.verifySecondFactor(userTOTP, req.Code); cuserr != nil {
+		h.recordMFAFailure(c, user.ID)
+		h.rejectMFAAttempt(c, challenge, cuserr.Message())
+		return
+	}
+	h.resetMFALockout(c, user.ID)
+
+	tokenPairReq := TokenPairRequest{
+		UserID: user.ID,
+		Email:  user.Email,
+	}
+
+	if cuserr := h.startSession(c, tokenPairReq); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Login berhasil",
+	})
+}
+
+// @Summary Start TOTP enrollment
+// @Description Generate a new TOTP secret and otpauth URI for the current user
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.DataResponse[TOTPEnrollmentResponse]
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 409 {object} dto.MessageResponse
+// @Router /auth/mfa/totp/enroll [post]
+func (h *authHandler) EnrollTOTP(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	secret, cuserr := h.authService.GenerateTOTPSecret()
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if cuserr := h.authRepository.SaveTOTPSecret(claims.UserID, secret); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[TOTPEnrollmentResponse]{
+		Data: TOTPEnrollmentResponse{
+			Secret:     secret,
+			OTPAuthURI: h.authService.BuildTOTPURI(claims.Email, secret),
+		},
+		Message: "Pindai kode QR lalu konfirmasi dengan kode pertama",
+	})
+}
+
+// @Summary Confirm TOTP enrollment
+// @Description Enable two-factor authentication with the first TOTP code and return recovery codes
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body TOTPCodeRequest true "First TOTP code"
+// @Success 200 {object} dto.DataResponse[RecoveryCodesResponse]
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 409 {object} dto.MessageResponse
+// @Router /auth/mfa/totp/confirm [post]
+func (h *authHandler) ConfirmTOTP(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	var req TOTPCodeRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	userTOTP, cuserr := h.authRepository.GetUserTOTP(claims.UserID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if userTOTP.ConfirmedAt != nil {
+		c.JSON(http.StatusConflict, dto.MessageResponse{
+			Message: "2FA sudah aktif",
+		})
+		return
+	}
+
+	step, cuserr := h.authService.VerifyTOTPCode(userTOTP.Secret, req.Code)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	recoveryCodes, cuserr := h.authService.GenerateRecoveryCodes()
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	recoveryCodeHashes := make([]string, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		recoveryCodeHashes = append(recoveryCodeHashes, h.authService.HashRecoveryCode(code))
+	}
+
+	confirmReq := &ConfirmTOTPRequest{
+		UserID:             claims.UserID,
+		Step:               step,
+		RecoveryCodeHashes: recoveryCodeHashes,
+	}
+
+	if cuserr := h.authRepository.ConfirmTOTP(confirmReq); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[RecoveryCodesResponse]{
+		Data: RecoveryCodesResponse{
+			RecoveryCodes: recoveryCodes,
+		},
+		Message: "2FA berhasil diaktifkan, simpan kode pemulihan di tempat yang aman",
+	})
+}
+
+// @Summary Disable TOTP
+// @Description Disable two-factor authentication using a TOTP or recovery code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body TOTPCodeRequest true "TOTP or recovery code"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Failure 429 {object} dto.MessageResponse
+// @Router /auth/mfa/totp/disable [post]
+func (h *authHandler) DisableTOTP(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	var req TOTPCodeRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	userTOTP, cuserr := h.authRepository.GetUserTOTP(claims.UserID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	// A pending enrollment can be cancelled without a code
+	if userTOTP.ConfirmedAt != nil {
+		if !h.checkMFALockout(c, claims.UserID) {
+			return
+		}
+		if cuserr := h.verifySecondFactor(userTOTP, req.Code); cuserr != nil {
+			h.recordMFAFailure(c, claims.UserID)
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: cuserr.Message(),
+			})
+			return
+		}
+		h.resetMFALockout(c, claims.UserID)
+	}
+
+	if cuserr := h.authRepository.DisableTOTP(claims.UserID); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "2FA berhasil dinonaktifkan",
+	})
+}
+
+// issueMFAChallenge stores a short-lived challenge and asks the client for the second factor
+func (h *authHandler) issueMFAChallenge(c *gin.Context, user *User) {
+	challenge, cuserr := h.authService.GenerateMFAChallengeToken()
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	hashedChallenge, cuserr := h.authService.HashString(challenge)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	challengeReq := &ActivationTokenRequest{
+		Email:          user.Email,
+		TokenType:      mfaLoginTokenType,
+		ActivationCode: hashedChallenge,
+		ExpiryMinutes:  h.mfaConfig.ChallengeExpiryMinutes,
+	}
+
+	if cuserr := h.authRepository.SaveActivationToken(challengeReq); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, dto.DataResponse[MFAChallengeResponse]{
+		Data: MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    challenge,
+			ExpiresIn:   h.mfaConfig.ChallengeExpiryMinutes * 60,
+		},
+		Message: "Verifikasi dua langkah diperlukan",
+	})
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code
+func (h *authHandler) verifySecondFactor(userTOTP *UserTOTP, code string) *customerror.CustomError {
+	code = strings.TrimSpace(code)
+
+	if len(code) == totp.Digits {
+		step, cuserr := h.authService.VerifyTOTPCode(userTOTP.Secret, code)
+		if cuserr != nil {
+			return cuserr
+		}
+		// Each code can only be used once
+		if cuserr := h.authRepository.UseTOTPStep(userTOTP.UserID, step); cuserr != nil {
+			if cuserr.Code() == http.StatusUnauthorized {
+				return customerror.NewCustomError(nil, "Kode 2FA sudah digunakan", http.StatusUnauthorized)
+			}
+			return cuserr
+		}
+		return nil
+	}
+
+	if cuserr := h.authRepository.UseRecoveryCode(userTOTP.UserID, h.authService.HashRecoveryCode(code)); cuserr != nil {
+		if cuserr.Code() == http.StatusUnauthorized {
+			return customerror.NewCustomError(nil, "Kode pemulihan tidak valid", http.StatusUnauthorized)
+		}
+		return cuserr
+	}
+	return nil
+}
+
+// rejectMFAAttempt puts the consumed challenge back with the failed attempt counted and
+// responds with 401. The challenge stays deleted once the attempt limit is reached.
+func (h *authHandler) rejectMFAAttempt(c *gin.Context, challenge *ActivationToken, message string) {
+	if cuserr := h.authRepository.RestoreActivationToken(challenge, h.mfaConfig.MaxAttempts); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+		Message: message,
+	})
+}
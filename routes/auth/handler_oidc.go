@@ -0,0 +1,188 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+	"github.com/yantology/golang-starter-template/pkg/oidc"
+)
+
+// @Summary List login providers
+// @Description List the configured OpenID Connect providers
+// @Tags auth
+// @Produce json
+// @Success 200 {object} dto.DataResponse[[]string]
+// @Router /auth/oidc/providers [get]
+func (h *authHandler) ListOIDCProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, dto.DataResponse[[]string]{
+		Data:    h.oidcProviders.Names(),
+		Message: "Daftar penyedia login berhasil diambil",
+	})
+}
+
+// @Summary Start provider login
+// @Description Redirect to the OpenID Connect provider using the authorization code flow with PKCE
+// @Tags auth
+// @Param provider path string true "Provider name"
+// @Success 302
+// @Failure 404 {object} dto.MessageResponse
+// @Router /auth/oidc/{provider}/login [get]
+func (h *authHandler) OIDCLogin(c *gin.Context) {
+	provider, ok := h.oidcProviders.Get(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, dto.MessageResponse{
+			Message: "Penyedia login tidak ditemukan",
+		})
+		return
+	}
+
+	expiry := time.Duration(h.oidcConfig.StateExpiryMinutes) * time.Minute
+	flow, cuserr := h.authService.NewOIDCFlow(provider.Name(), expiry)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if cuserr := h.authService.SetOIDCFlowCookie(c.Writer, flow); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+	c.Redirect(http.StatusFound, provider.AuthCodeURL(flow.State, flow.Nonce, flow.CodeVerifier))
+}
+
+// @Summary Finish provider login
+// @Description Exchange the authorization code, link or create the account and set JWT tokens as cookies
+// @Tags auth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State returned by the provider"
+// @Success 200 {object} dto.MessageResponse
+// @Success 202 {object} dto.DataResponse[MFAChallengeResponse]
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Router /auth/oidc/{provider}/callback [get]
+func (h *authHandler) OIDCCallback(c *gin.Context) {
+	provider, ok := h.oidcProviders.Get(c.Param("provider"))
+	if !ok {
+		c.JSON(http.StatusNotFound, dto.MessageResponse{
+			Message: "Penyedia login tidak ditemukan",
+		})
+		return
+	}
+
+	cookie, err := c.Cookie(oidcFlowCookieName)
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+			Message: "Sesi login tidak ditemukan",
+		})
+		return
+	}
+
+	// The flow can only be used once
+	h.authService.ClearOIDCFlowCookie(c.Writer)
+
+	flow, cuserr := h.authService.ReadOIDCFlow(cookie)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	state := c.Query("state")
+	if flow.Provider != provider.Name() || subtle.ConstantTimeCompare([]byte(flow.State), []byte(state)) != 1 {
+		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+			Message: "Parameter state tidak valid",
+		})
+		return
+	}
+
+	if c.Query("error") != "" {
+		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+			Message: "Login dengan penyedia dibatalkan",
+		})
+		return
+	}
+
+	identity, err := provider.Exchange(c.Request.Context(), c.Query("code"), flow.CodeVerifier, flow.Nonce)
+	if err != nil {
+		log.Printf("OIDC login with %s failed: %v", provider.Name(), err)
+		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+			Message: "Gagal memverifikasi login dengan penyedia",
+		})
+		return
+	}
+
+	user, cuserr := h.resolveOIDCUser(provider.Name(), identity)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	h.completeLogin(c, user)
+}
+
+// resolveOIDCUser finds the user linked to the identity, links an existing account with the
+// same verified email, or creates a new account without the activation code step
+func (h *authHandler) resolveOIDCUser(provider string, identity *oidc.Identity) (*User, *customerror.CustomError) {
+	user, cuserr := h.authRepository.GetUserByIdentity(provider, identity.Subject)
+	if cuserr == nil {
+		return user, nil
+	}
+	if cuserr.Code() != http.StatusNotFound {
+		return nil, cuserr
+	}
+
+	// Only an address the provider verified may be linked to, or create, an account
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, customerror.NewCustomError(nil, "Email dari penyedia login belum terverifikasi", http.StatusForbidden)
+	}
+
+	if cuserr := h.authService.ValidateEmail(identity.Email); cuserr != nil {
+		return nil, cuserr
+	}
+
+	user, cuserr = h.authRepository.GetUserByEmail(identity.Email)
+	if cuserr != nil && cuserr.Code() != http.StatusNotFound {
+		return nil, cuserr
+	}
+
+	if user != nil {
+		linkReq := &CreateUserIdentityRequest{
+			UserID:   user.ID,
+			Provider: provider,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+		}
+		if cuserr := h.authRepository.CreateUserIdentity(linkReq); cuserr != nil {
+			return nil, cuserr
+		}
+		return user, nil
+	}
+
+	fullname := identity.Name
+	if fullname == "" {
+		fullname = strings.Split(identity.Email, "@")[0]
+	}
+
+	return h.authRepository.CreateUserWithIdentity(&CreateUserWithIdentityRequest{
+		Email:    identity.Email,
+		Fullname: fullname,
+		Provider: provider,
+		Subject:  identity.Subject,
+	})
+}
@@ -0,0 +1,425 @@
+package auth
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/middleware"
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+)
+
+// OrganizationRoleOwner is given to the creator of an organization. An organization always keeps one.
+const OrganizationRoleOwner = "owner"
+
+// PermissionMembersManage allows inviting, removing and changing the role of organization members
+const PermissionMembersManage = "members:manage"
+
+// @Summary Create an organization
+// @Description Create an organization with the current user as its owner
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body OrganizationCreateRequest true "Organization details"
+// @Success 201 {object} dto.DataResponse[OrganizationResponse]
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Router /auth/organizations [post]
+func (h *authHandler) CreateOrganization(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	var req OrganizationCreateRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	organization, cuserr := h.authRepository.CreateOrganization(&CreateOrganizationRequest{
+		Name:    req.Name,
+		OwnerID: claims.UserID,
+	})
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.DataResponse[OrganizationResponse]{
+		Data: OrganizationResponse{
+			ID:   organization.ID,
+			Name: organization.Name,
+			Role: OrganizationRoleOwner,
+		},
+		Message: "Organisasi berhasil dibuat",
+	})
+}
+
+// @Summary List organizations
+// @Description List the organizations of the current user and mark the active one
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.DataResponse[[]OrganizationResponse]
+// @Failure 401 {object} dto.MessageResponse
+// @Router /auth/organizations [get]
+func (h *authHandler) ListOrganizations(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	memberships, cuserr := h.authRepository.ListUserOrganizations(claims.UserID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	response := make([]OrganizationResponse, 0, len(memberships))
+	for _, membership := range memberships {
+		response = append(response, OrganizationResponse{
+			ID:     membership.OrganizationID,
+			Name:   membership.OrganizationName,
+			Role:   membership.Role,
+			Active: membership.OrganizationID == claims.OrganizationID,
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[[]OrganizationResponse]{
+		Data:    response,
+		Message: "Daftar organisasi berhasil diambil",
+	})
+}
+
+// @Summary Switch organization
+// @Description Make another organization of the user active. The session is rotated to a new token pair carrying the organization and its role, and the current access token is revoked.
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} dto.DataResponse[OrganizationResponse]
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Router /auth/organizations/{id}/switch [post]
+func (h *authHandler) SwitchOrganization(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	membership, cuserr := h.organizationMembership(c.Param("id"), claims.UserID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	// The session is rotated so the refresh token keeps the new organization as well
+	refreshToken, err := c.Cookie(h.tokenRequest.RefreshTokenName)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Refresh token tidak ditemukan dalam cookies",
+		})
+		return
+	}
+
+	session, cuserr := h.authRepository.GetSessionByTokenHash(h.authService.HashToken(refreshToken))
+	if cuserr == nil && session.UserID != claims.UserID {
+		cuserr = customerror.NewCustomError(nil, "session not found", http.StatusNotFound)
+	}
+	if cuserr != nil {
+		if cuserr.Code() == http.StatusNotFound {
+			c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+				Message: "Sesi tidak ditemukan",
+			})
+			return
+		}
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if session.RevokedAt != nil {
+		h.authService.GenerateLogoutCookies(c.Writer)
+		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+			Message: "Sesi telah dicabut",
+		})
+		return
+	}
+	if session.RotatedAt != nil {
+		h.handleRefreshTokenReuse(c, session)
+		return
+	}
+
+	tokenPairReq, cuserr := h.withUserAccess(TokenPairRequest{
+		UserID:         claims.UserID,
+		Email:          claims.Email,
+		OrganizationID: membership.OrganizationID,
+	})
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+	tokenPairReq.SessionID = session.FamilyID
+
+	tokenPair, cuserr := h.authService.GenerateTokenPair(tokenPairReq)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	rotateReq := &RotateSessionRequest{
+		SessionID:    session.ID,
+		FamilyID:     session.FamilyID,
+		UserID:       session.UserID,
+		NewTokenHash: h.authService.HashToken(tokenPair.RefreshToken),
+		UserAgent:    c.Request.UserAgent(),
+		IPAddress:    c.ClientIP(),
+		ExpiresAt:    time.Now().Add(h.tokenRequest.RefreshTokenExpiry),
+	}
+	if cuserr := h.authRepository.RotateSession(rotateReq); cuserr != nil {
+		if cuserr.Code() == http.StatusConflict {
+			h.handleRefreshTokenReuse(c, session)
+			return
+		}
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	// The previous access token still carries the old organization and its role
+	if accessToken := h.accessTokenFromRequest(c); accessToken != "" {
+		if cuserr := h.authService.RevokeAccessToken(accessToken); cuserr != nil {
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: cuserr.Message(),
+			})
+			return
+		}
+	}
+
+	h.authService.SetTokenPairCookies(c.Writer, tokenPair)
+
+	c.JSON(http.StatusOK, dto.DataResponse[OrganizationResponse]{
+		Data: OrganizationResponse{
+			ID:     membership.OrganizationID,
+			Name:   membership.OrganizationName,
+			Role:   membership.Role,
+			Active: true,
+		},
+		Message: "Organisasi aktif berhasil diganti",
+	})
+}
+
+// @Summary List organization members
+// @Description List the members of an organization the user belongs to
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Organization ID"
+// @Success 200 {object} dto.DataResponse[[]OrganizationMemberResponse]
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Router /auth/organizations/{id}/members [get]
+func (h *authHandler) ListOrganizationMembers(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	membership, cuserr := h.organizationMembership(c.Param("id"), claims.UserID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	members, cuserr := h.authRepository.ListOrganizationMembers(membership.OrganizationID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	response := make([]OrganizationMemberResponse, 0, len(members))
+	for _, member := range members {
+		response = append(response, OrganizationMemberResponse{
+			UserID:   member.UserID,
+			Email:    member.Email,
+			Fullname: member.Fullname,
+			Role:     member.Role,
+			JoinedAt: member.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[[]OrganizationMemberResponse]{
+		Data:    response,
+		Message: "Daftar anggota organisasi berhasil diambil",
+	})
+}
+
+// @Summary Change a member's role
+// @Description Give a member another organization role. Takes effect at the member's next token refresh.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Organization ID"
+// @Param user path string true "User ID"
+// @Param request body OrganizationRoleRequest true "New role"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Failure 409 {object} dto.MessageResponse
+// @Router /auth/organizations/{id}/members/{user} [put]
+func (h *authHandler) UpdateOrganizationMemberRole(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	var req OrganizationRoleRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	membership, cuserr := h.checkOrganizationPermission(c.Param("id"), claims.UserID, PermissionMembersManage)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if cuserr := h.authRepository.UpdateOrganizationMemberRole(membership.OrganizationID, c.Param("user"), req.Role); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Role anggota berhasil diubah",
+	})
+}
+
+// @Summary Remove a member
+// @Description Remove a user from an organization. The last owner cannot be removed.
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Organization ID"
+// @Param user path string true "User ID"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Failure 409 {object} dto.MessageResponse
+// @Router /auth/organizations/{id}/members/{user} [delete]
+func (h *authHandler) RemoveOrganizationMember(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	membership, cuserr := h.checkOrganizationPermission(c.Param("id"), claims.UserID, PermissionMembersManage)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if cuserr := h.authRepository.RemoveOrganizationMember(membership.OrganizationID, c.Param("user")); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Anggota berhasil dihapus dari organisasi",
+	})
+}
+
+// organizationMembership returns the membership of the user, or 403 when the user
+// does not belong to the organization
+func (h *authHandler) organizationMembership(organizationID, userID string) (*OrganizationMembership, *customerror.CustomError) {
+	membership, cuserr := h.authRepository.GetOrganizationMembership(organizationID, userID)
+	if cuserr != nil && cuserr.Code() == http.StatusNotFound {
+		return nil, customerror.NewCustomError(nil, "Anda bukan anggota organisasi ini", http.StatusForbidden)
+	}
+	return membership, cuserr
+}
+
+// checkOrganizationPermission returns the membership of the user when their role in the
+// organization or one of their global roles grants the permission
+func (h *authHandler) checkOrganizationPermission(organizationID, userID, permission string) (*OrganizationMembership, *customerror.CustomError) {
+	membership, cuserr := h.organizationMembership(organizationID, userID)
+	if cuserr != nil {
+		return nil, cuserr
+	}
+	if containsString(membership.Permissions, permission) {
+		return membership, nil
+	}
+
+	access, cuserr := h.authRepository.GetUserAccess(userID)
+	if cuserr != nil {
+		return nil, cuserr
+	}
+	if !containsString(access.Permissions, permission) {
+		return nil, customerror.NewCustomError(nil, "Akses ditolak", http.StatusForbidden)
+	}
+	return membership, nil
+}
+
+// userAccess returns the global roles and permissions of a user together with those of
+// their role in the organization, or 403 when the user left the organization
+func userAccess(authRepository *AuthRepository, userID, organizationID string) (*UserAccess, *customerror.CustomError) {
+	access, cuserr := authRepository.GetUserAccess(userID)
+	if cuserr != nil || organizationID == "" {
+		return access, cuserr
+	}
+
+	membership, cuserr := authRepository.GetOrganizationMembership(organizationID, userID)
+	if cuserr != nil {
+		if cuserr.Code() == http.StatusNotFound {
+			return nil, customerror.NewCustomError(nil, "Anda bukan anggota organisasi ini", http.StatusForbidden)
+		}
+		return nil, cuserr
+	}
+	return withMembership(access, membership), nil
+}
+
+// withMembership adds the role and permissions of a membership to the access of a user.
+// They are kept apart from the global ones, so an organization role never passes
+// RequirePermission on global routes.
+func withMembership(access *UserAccess, membership *OrganizationMembership) *UserAccess {
+	return &UserAccess{
+		Roles:       access.Roles,
+		Permissions: access.Permissions,
+
+		OrganizationRole:        membership.Role,
+		OrganizationPermissions: membership.Permissions,
+	}
+}
+
+// mergeStrings returns the sorted union of both lists without duplicates
+func mergeStrings(a, b []string) []string {
+	merged := []string{}
+	for _, values := range [][]string{a, b} {
+		for _, value := range values {
+			if !containsString(merged, value) {
+				merged = append(merged, value)
+			}
+		}
+	}
+	sort.Strings(merged)
+	return merged
+}
@@ -0,0 +1,176 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+	"github.com/yantology/golang-starter-template/pkg/outbox"
+)
+
+const (
+	defaultEmailListLimit = 50
+	maxEmailListLimit     = 200
+)
+
+// @Summary List outbox emails
+// @Description List the emails of the outbox in a status, newest first. Dead emails failed every attempt and wait for a retry.
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param status query string false "pending, sent or dead" default(dead)
+// @Param limit query int false "Maximum number of emails, at most 200" default(50)
+// @Success 200 {object} dto.DataResponse[[]EmailOutboxResponse]
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Router /auth/admin/emails [get]
+func (h *authHandler) ListOutboxEmails(c *gin.Context) {
+	status := c.DefaultQuery("status", outbox.StatusDead)
+	if status != outbox.StatusPending && status != outbox.StatusSent && status != outbox.StatusDead {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Status email tidak valid",
+		})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultEmailListLimit)))
+	if err != nil || limit <= 0 || limit > maxEmailListLimit {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Limit tidak valid",
+		})
+		return
+	}
+
+	messages, err := h.emailOutbox.List(c.Request.Context(), status, limit)
+	if err != nil {
+		log.Println("Error listing outbox emails:", err)
+		c.JSON(http.StatusInternalServerError, dto.MessageResponse{
+			Message: "Gagal mengambil daftar email",
+		})
+		return
+	}
+
+	emails := make([]EmailOutboxResponse, 0, len(messages))
+	for i := range messages {
+		emails = append(emails, toEmailOutboxResponse(&messages[i]))
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[[]EmailOutboxResponse]{
+		Data:    emails,
+		Message: "Daftar email berhasil diambil",
+	})
+}
+
+// @Summary Get outbox email
+// @Description Get one email of the outbox with its delivery state and the outcome reported by the provider. The content is never returned.
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Email ID"
+// @Success 200 {object} dto.DataResponse[EmailOutboxResponse]
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Router /auth/admin/emails/{id} [get]
+func (h *authHandler) GetOutboxEmail(c *gin.Context) {
+	id, ok := outboxEmailID(c)
+	if !ok {
+		return
+	}
+
+	message, err := h.emailOutbox.Get(c.Request.Context(), id)
+	if err != nil {
+		respondOutboxError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[EmailOutboxResponse]{
+		Data:    toEmailOutboxResponse(message),
+		Message: "Email berhasil diambil",
+	})
+}
+
+// @Summary Retry outbox email
+// @Description Queue a dead email again with its attempts reset, while it is within the retry window
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Email ID"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Failure 409 {object} dto.MessageResponse
+// @Router /auth/admin/emails/{id}/retry [post]
+func (h *authHandler) RetryOutboxEmail(c *gin.Context) {
+	id, ok := outboxEmailID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.emailOutbox.Retry(c.Request.Context(), id); err != nil {
+		respondOutboxError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Email dijadwalkan untuk dikirim ulang",
+	})
+}
+
+// outboxEmailID returns the id path parameter, responding with 404 and returning
+// false when it cannot be an outbox id
+func outboxEmailID(c *gin.Context) (string, bool) {
+	id := c.Param("id")
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		c.JSON(http.StatusNotFound, dto.MessageResponse{
+			Message: "Email tidak ditemukan",
+		})
+		return "", false
+	}
+	return id, true
+}
+
+func respondOutboxError(c *gin.Context, err error) {
+	if errors.Is(err, outbox.ErrNotFound) {
+		c.JSON(http.StatusNotFound, dto.MessageResponse{
+			Message: "Email tidak ditemukan",
+		})
+		return
+	}
+	if errors.Is(err, outbox.ErrContentCleared) {
+		c.JSON(http.StatusConflict, dto.MessageResponse{
+			Message: "Isi email sudah dihapus, email tidak dapat dikirim ulang",
+		})
+		return
+	}
+	log.Println("Error accessing outbox email:", err)
+	c.JSON(http.StatusInternalServerError, dto.MessageResponse{
+		Message: "Gagal mengakses email",
+	})
+}
+
+func toEmailOutboxResponse(message *outbox.Message) EmailOutboxResponse {
+	return EmailOutboxResponse{
+		ID:            message.ID,
+		To:            message.To,
+		Subject:       message.Subject,
+		Status:        message.Status,
+		Attempts:      message.Attempts,
+		LastError:     message.LastError,
+		NextAttemptAt: message.NextAttemptAt,
+		SentAt:        message.SentAt,
+		CreatedAt:     message.CreatedAt,
+
+		ProviderMessageID: message.ProviderMessageID,
+		DeliveryStatus:    message.DeliveryStatus,
+		DeliveryDetail:    message.DeliveryDetail,
+		DeliveryUpdatedAt: message.DeliveryUpdatedAt,
+
+		ContentAvailable: message.HTML != "" || message.Text != "",
+	}
+}
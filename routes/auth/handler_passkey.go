@@ -0,0 +1,407 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/middleware"
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+	"github.com/yantology/golang-starter-template/pkg/webauthn"
+)
+
+// Challenge types stored in webauthn_challenges
+const (
+	passkeyRegistrationChallenge = "registration"
+	passkeyLoginChallenge        = "login"
+)
+
+// @Summary Start passkey registration
+// @Description Generate the options for navigator.credentials.create for the current user
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.DataResponse[webauthn.CreationOptions]
+// @Failure 401 {object} dto.MessageResponse
+// @Router /auth/passkeys/register/begin [post]
+func (h *authHandler) BeginPasskeyRegistration(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	user, cuserr := h.authRepository.GetUserByID(claims.UserID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	credentials, cuserr := h.authRepository.ListWebAuthnCredentials(user.ID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	// Keep the authenticator from registering the same passkey twice
+	exclude := make([]webauthn.CredentialDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		exclude = append(exclude, webauthn.CredentialDescriptor{
+			Type:       "public-key",
+			ID:         credential.CredentialID,
+			Transports: credential.Transports,
+		})
+	}
+
+	challenge, cuserr := h.newPasskeyChallenge(user.ID, passkeyRegistrationChallenge)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	options := h.webAuthn.BeginRegistration(webauthn.User{
+		ID:          []byte(user.ID),
+		Name:        user.Email,
+		DisplayName: user.Fullname,
+	}, challenge, exclude)
+
+	c.JSON(http.StatusOK, dto.DataResponse[*webauthn.CreationOptions]{
+		Data:    options,
+		Message: "Opsi registrasi passkey berhasil dibuat",
+	})
+}
+
+// @Summary Finish passkey registration
+// @Description Verify the credential created by the browser and store it for the current user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body PasskeyRegistrationRequest true "Passkey name and credential"
+// @Success 201 {object} dto.DataResponse[PasskeyResponse]
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 409 {object} dto.MessageResponse
+// @Router /auth/passkeys/register/finish [post]
+func (h *authHandler) FinishPasskeyRegistration(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	var req PasskeyRegistrationRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	challenge, cuserr := h.consumePasskeyChallenge(req.Credential.Response.ClientDataJSON, passkeyRegistrationChallenge)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	// The challenge must have been issued to the same user
+	if challenge.UserID != claims.UserID {
+		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+			Message: "Challenge passkey tidak valid",
+		})
+		return
+	}
+
+	credential, err := h.webAuthn.FinishRegistration(&req.Credential, challenge.value)
+	if err != nil {
+		cuserr := passkeyError(err)
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	createReq := &CreateWebAuthnCredentialRequest{
+		UserID:       claims.UserID,
+		CredentialID: webauthn.EncodeID(credential.ID),
+		PublicKey:    credential.PublicKey,
+		SignCount:    credential.SignCount,
+		AAGUID:       credential.AAGUID,
+		Transports:   credential.Transports,
+		Name:         req.Name,
+	}
+
+	if cuserr := h.authRepository.CreateWebAuthnCredential(createReq); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	stored, cuserr := h.authRepository.GetWebAuthnCredential(createReq.CredentialID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.DataResponse[PasskeyResponse]{
+		Data:    newPasskeyResponse(stored),
+		Message: "Passkey berhasil didaftarkan",
+	})
+}
+
+// @Summary List passkeys
+// @Description List the passkeys registered by the current user
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.DataResponse[[]PasskeyResponse]
+// @Failure 401 {object} dto.MessageResponse
+// @Router /auth/passkeys [get]
+func (h *authHandler) ListPasskeys(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	credentials, cuserr := h.authRepository.ListWebAuthnCredentials(claims.UserID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	response := make([]PasskeyResponse, 0, len(credentials))
+	for i := range credentials {
+		response = append(response, newPasskeyResponse(&credentials[i]))
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[[]PasskeyResponse]{
+		Data:    response,
+		Message: "Daftar passkey berhasil diambil",
+	})
+}
+
+// @Summary Delete a passkey
+// @Description Remove a passkey registered by the current user
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Passkey ID"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Router /auth/passkeys/{id} [delete]
+func (h *authHandler) DeletePasskey(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	if cuserr := h.authRepository.DeleteWebAuthnCredential(claims.UserID, c.Param("id")); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Passkey berhasil dihapus",
+	})
+}
+
+// @Summary Start passkey login
+// @Description Generate the options for navigator.credentials.get
+// @Tags auth
+// @Produce json
+// @Success 200 {object} dto.DataResponse[webauthn.RequestOptions]
+// @Router /auth/login/passkey/begin [post]
+func (h *authHandler) BeginPasskeyLogin(c *gin.Context) {
+	// Discoverable credentials let the authenticator pick the account, so no user is bound yet
+	challenge, cuserr := h.newPasskeyChallenge("", passkeyLoginChallenge)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[*webauthn.RequestOptions]{
+		Data:    h.webAuthn.BeginLogin(challenge),
+		Message: "Opsi login passkey berhasil dibuat",
+	})
+}
+
+// @Summary Finish passkey login
+// @Description Verify the passkey assertion and set JWT tokens as cookies
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body webauthn.AssertionCredential true "Assertion returned by the browser"
+// @Success 200 {object} dto.MessageResponse
+// @Success 202 {object} dto.DataResponse[MFAChallengeResponse]
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Router /auth/login/passkey/finish [post]
+func (h *authHandler) FinishPasskeyLogin(c *gin.Context) {
+	var req webauthn.AssertionCredential
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	challenge, cuserr := h.consumePasskeyChallenge(req.Response.ClientDataJSON, passkeyLoginChallenge)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	credential, cuserr := h.authRepository.GetWebAuthnCredential(req.RawID)
+	if cuserr != nil {
+		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+			Message: "Passkey tidak terdaftar",
+		})
+		return
+	}
+
+	// The user handle, when sent, must belong to the owner of the credential
+	if req.Response.UserHandle != "" {
+		userHandle, err := webauthn.DecodeID(req.Response.UserHandle)
+		if err != nil || string(userHandle) != credential.UserID {
+			c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+				Message: "Passkey tidak valid",
+			})
+			return
+		}
+	}
+
+	assertion, err := h.webAuthn.FinishLogin(&req, challenge.value, credential.PublicKey, credential.SignCount)
+	if err != nil {
+		if errors.Is(err, webauthn.ErrSignCountRegression) {
+			log.Printf("Passkey sign count regression for user %s, credential %s", credential.UserID, credential.ID)
+		}
+		cuserr := passkeyError(err)
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if cuserr := h.authRepository.UpdateWebAuthnSignCount(credential.CredentialID, assertion.SignCount); cuserr != nil {
+		if cuserr.Code() == http.StatusConflict {
+			log.Printf("Passkey sign count regression for user %s, credential %s", credential.UserID, credential.ID)
+			cuserr = passkeyError(webauthn.ErrSignCountRegression)
+		}
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	user, cuserr := h.authRepository.GetUserByID(credential.UserID)
+	if cuserr != nil {
+		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	// A user-verified passkey already combines possession and a PIN or biometric,
+	// otherwise it only replaces the password and 2FA still applies
+	if !assertion.UserVerified {
+		h.completeLogin(c, user)
+		return
+	}
+
+	tokenPairReq := TokenPairRequest{
+		UserID: user.ID,
+		Email:  user.Email,
+	}
+
+	if cuserr := h.startSession(c, tokenPairReq); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Login berhasil",
+	})
+}
+
+// pendingPasskeyChallenge is a consumed challenge together with its plain value
+type pendingPasskeyChallenge struct {
+	*WebAuthnChallenge
+	value string
+}
+
+// newPasskeyChallenge generates a challenge and stores its hash until the ceremony times out
+func (h *authHandler) newPasskeyChallenge(userID, challengeType string) (string, *customerror.CustomError) {
+	challenge, cuserr := h.authService.GenerateWebAuthnChallenge()
+	if cuserr != nil {
+		return "", cuserr
+	}
+
+	saveReq := &SaveWebAuthnChallengeRequest{
+		ChallengeHash: h.authService.HashToken(challenge),
+		UserID:        userID,
+		Type:          challengeType,
+		ExpiresAt:     time.Now().Add(h.webAuthn.Timeout()),
+	}
+
+	if cuserr := h.authRepository.SaveWebAuthnChallenge(saveReq); cuserr != nil {
+		return "", cuserr
+	}
+	return challenge, nil
+}
+
+// consumePasskeyChallenge looks up the challenge signed in clientDataJSON and deletes it
+func (h *authHandler) consumePasskeyChallenge(clientDataJSON, challengeType string) (*pendingPasskeyChallenge, *customerror.CustomError) {
+	value, err := webauthn.ClientDataChallenge(clientDataJSON)
+	if err != nil {
+		return nil, passkeyError(err)
+	}
+
+	challenge, cuserr := h.authRepository.ConsumeWebAuthnChallenge(h.authService.HashToken(value), challengeType)
+	if cuserr != nil {
+		if cuserr.Code() == http.StatusNotFound {
+			return nil, customerror.NewCustomError(nil, "Challenge passkey tidak ditemukan atau kadaluarsa", http.StatusUnauthorized)
+		}
+		return nil, cuserr
+	}
+
+	return &pendingPasskeyChallenge{WebAuthnChallenge: challenge, value: value}, nil
+}
+
+// passkeyError maps ceremony verification errors to API errors
+func passkeyError(err error) *customerror.CustomError {
+	switch {
+	case errors.Is(err, webauthn.ErrInvalidClientData),
+		errors.Is(err, webauthn.ErrInvalidAttestation),
+		errors.Is(err, webauthn.ErrInvalidAuthData),
+		errors.Is(err, webauthn.ErrInvalidPublicKey):
+		return customerror.NewCustomError(err, "Data passkey tidak valid", http.StatusBadRequest)
+	case errors.Is(err, webauthn.ErrUnsupportedAlgorithm):
+		return customerror.NewCustomError(err, "Algoritma passkey tidak didukung", http.StatusBadRequest)
+	case errors.Is(err, webauthn.ErrUserNotVerified):
+		return customerror.NewCustomError(err, "Verifikasi PIN atau biometrik diperlukan", http.StatusUnauthorized)
+	case errors.Is(err, webauthn.ErrSignCountRegression):
+		return customerror.NewCustomError(err, "Passkey terdeteksi sebagai salinan, silakan hubungi admin", http.StatusUnauthorized)
+	}
+	return customerror.NewCustomError(err, "Verifikasi passkey gagal", http.StatusUnauthorized)
+}
+
+func newPasskeyResponse(credential *WebAuthnCredential) PasskeyResponse {
+	return PasskeyResponse{
+		ID:         credential.ID,
+		Name:       credential.Name,
+		Transports: credential.Transports,
+		CreatedAt:  credential.CreatedAt,
+		LastUsedAt: credential.LastUsedAt,
+	}
+}
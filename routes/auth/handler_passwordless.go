@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+)
+
+// loginTokenType is the activation token type used for passwordless login codes
+const loginTokenType = "login"
+
+// @Summary Login with an emailed code
+// @Description Exchange a login code, or the token of a signed login link, for JWT tokens set as cookies.
+// @Description Request the code with /auth/token/login first.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body LoginCodeRequest true "Email and code, or login link token"
+// @Success 200 {object} dto.MessageResponse
+// @Success 202 {object} dto.DataResponse[MFAChallengeResponse]
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Router /auth/login/code [post]
+func (h *authHandler) LoginWithCode(c *gin.Context) {
+	var req LoginCodeRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	// A login link carries the same email and code, signed so it cannot be altered
+	if req.Token != "" {
+		claims, cuserr := h.authService.ParseLoginLink(req.Token)
+		if cuserr != nil {
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: cuserr.Message(),
+			})
+			return
+		}
+		req.Email = claims.Email
+		req.Code = claims.Code
+	}
+
+	if req.Email == "" || req.Code == "" {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Email dan kode wajib diisi",
+		})
+		return
+	}
+
+	tokenReq := &GetActivationTokenRequest{
+		Email:     req.Email,
+		TokenType: loginTokenType,
+	}
+
+	// The code can only be used once
+	if cuserr := h.consumeActivationCode(tokenReq, req.Code); cuserr != nil {
+		switch cuserr.Code() {
+		case http.StatusNotFound:
+			c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+				Message: "Kode login tidak ditemukan atau kadaluarsa",
+			})
+		case http.StatusUnauthorized:
+			c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+				Message: "Kode login tidak valid",
+			})
+		default:
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: cuserr.Message(),
+			})
+		}
+		return
+	}
+
+	user, cuserr := h.authRepository.GetUserByEmail(req.Email)
+	if cuserr != nil {
+		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	// The code replaces the password only, a confirmed second factor is still required
+	h.completeLogin(c, user)
+}
@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/middleware"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+)
+
+// @Summary Export personal data
+// @Description Download a JSON archive of everything kept about the current user, one section per registered data provider
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} personaldata.Archive
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 500 {object} dto.MessageResponse
+// @Router /auth/me/export [get]
+func (h *authHandler) ExportPersonalData(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	archive, err := h.personalData.Export(c.Request.Context(), claims.UserID)
+	if err != nil {
+		log.Println("Failed to export personal data:", err)
+		c.JSON(http.StatusInternalServerError, dto.MessageResponse{
+			Message: "Gagal mengekspor data pribadi",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="personal-data-`+claims.UserID+`.json"`)
+	c.JSON(http.StatusOK, archive)
+}
+
+// @Summary Request account deletion
+// @Description Schedule the deletion of the current user after the grace period. Requires the current password, revokes every other session and can be cancelled until the account is purged.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body AccountDeletionRequest true "Current password"
+// @Success 200 {object} dto.DataResponse[AccountDeletionResponse]
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 409 {object} dto.MessageResponse
+// @Failure 429 {object} dto.MessageResponse
+// @Router /auth/me/deletion [post]
+func (h *authHandler) RequestAccountDeletion(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	currentFamilyID, ok := h.requireCurrentSession(c)
+	if !ok {
+		return
+	}
+
+	var req AccountDeletionRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	user, cuserr := h.authRepository.GetUserByID(claims.UserID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if !h.verifyCurrentPassword(c, user, req.CurrentPassword) {
+		return
+	}
+
+	scheduledAt, cuserr := h.authRepository.ScheduleUserDeletion(user.ID, h.accountDeletion.GraceDays)
+	if cuserr != nil {
+		if cuserr.Code() == http.StatusConflict {
+			c.JSON(http.StatusConflict, dto.MessageResponse{
+				Message: "Serahkan kepemilikan organisasi kepada anggota lain sebelum menghapus akun",
+			})
+			return
+		}
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	// Only the session that asked keeps access, so it can still cancel
+	if cuserr := h.authRepository.RevokeOtherSessions(user.ID, currentFamilyID); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	// The deletion is scheduled, so a failed confirmation is only logged
+	h.queueNotification(c, emailAccountDeletion, user, accountDeletionEmailData{Email: user.Email, ScheduledAt: scheduledAt})
+
+	c.JSON(http.StatusOK, dto.DataResponse[AccountDeletionResponse]{
+		Data:    AccountDeletionResponse{ScheduledAt: scheduledAt},
+		Message: "Penghapusan akun dijadwalkan",
+	})
+}
+
+// @Summary Cancel account deletion
+// @Description Keep the account of the current user whose deletion was scheduled
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Router /auth/me/deletion [delete]
+func (h *authHandler) CancelAccountDeletion(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	if cuserr := h.authRepository.CancelUserDeletion(claims.UserID); cuserr != nil {
+		if cuserr.Code() == http.StatusNotFound {
+			c.JSON(http.StatusNotFound, dto.MessageResponse{
+				Message: "Tidak ada penghapusan akun yang dijadwalkan",
+			})
+			return
+		}
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Penghapusan akun dibatalkan",
+	})
+}
@@ -0,0 +1,398 @@
+package auth
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/middleware"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+)
+
+// emailChangeTokenType is the activation token type of codes confirming a new email
+// address, one per user so the code only changes the account that requested it
+func emailChangeTokenType(userID string) string {
+	return "email-change:" + userID
+}
+
+// @Summary Get profile
+// @Description Get the account of the current user with the roles and permissions of the access token
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.DataResponse[ProfileResponse]
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Router /auth/me [get]
+func (h *authHandler) GetProfile(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	user, cuserr := h.authRepository.GetUserByID(claims.UserID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[ProfileResponse]{
+		Data:    newProfileResponse(user, claims),
+		Message: "Profil berhasil diambil",
+	})
+}
+
+// @Summary Update profile
+// @Description Change the full name and email language of the current user
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body ProfileUpdateRequest true "Profile fields"
+// @Success 200 {object} dto.DataResponse[ProfileResponse]
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Router /auth/me [patch]
+func (h *authHandler) UpdateProfile(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	var req ProfileUpdateRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	if req.Locale != nil && *req.Locale != "" && !containsString(h.emailTemplates.Locales(), *req.Locale) {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Bahasa tidak didukung, pilih salah satu dari: " + strings.Join(h.emailTemplates.Locales(), ", "),
+		})
+		return
+	}
+
+	updateReq := &UpdateProfileRequest{
+		UserID:   claims.UserID,
+		Fullname: strings.TrimSpace(req.Fullname),
+		Locale:   req.Locale,
+	}
+	user, cuserr := h.authRepository.UpdateUserProfile(updateReq)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[ProfileResponse]{
+		Data:    newProfileResponse(user, claims),
+		Message: "Profil berhasil diperbarui",
+	})
+}
+
+// @Summary Change password
+// @Description Change the password of the current user. Requires the current password and revokes every other session.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body ChangePasswordRequest true "Current and new password"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.DataResponse[[]PasswordViolation]
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 429 {object} dto.MessageResponse
+// @Router /auth/change-password [post]
+func (h *authHandler) ChangePassword(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	currentFamilyID, ok := h.requireCurrentSession(c)
+	if !ok {
+		return
+	}
+
+	var req ChangePasswordRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	if cuserr := h.authService.ValidatePasswordInput(req.NewPassword, req.NewPasswordConfirmation); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	user, cuserr := h.authRepository.GetUserByID(claims.UserID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if !h.verifyCurrentPassword(c, user, req.CurrentPassword) {
+		return
+	}
+
+	if !h.checkPasswordPolicy(c, req.NewPassword, user.Email, user.Fullname) {
+		return
+	}
+
+	hashedPassword, cuserr := h.authService.HashString(req.NewPassword)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	updateReq := &UpdatePasswordRequest{
+		Email:           user.Email,
+		NewPasswordHash: hashedPassword,
+	}
+	if cuserr := h.authRepository.UpdateUserPassword(updateReq); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	// Sessions that might have been opened with the old password are ended
+	if cuserr := h.authRepository.RevokeOtherSessions(user.ID, currentFamilyID); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Password berhasil diubah, sesi lain telah dicabut",
+	})
+}
+
+// @Summary Request an email change
+// @Description Send a code to the new email address. The address is only changed once the code is confirmed.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body EmailChangeRequest true "New email and current password"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 409 {object} dto.MessageResponse
+// @Failure 429 {object} dto.MessageResponse
+// @Router /auth/me/email [post]
+func (h *authHandler) RequestEmailChange(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	var req EmailChangeRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	if cuserr := h.authService.ValidateEmail(req.NewEmail); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	user, cuserr := h.authRepository.GetUserByID(claims.UserID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if strings.EqualFold(req.NewEmail, user.Email) {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Email baru sama dengan email saat ini",
+		})
+		return
+	}
+
+	if !h.verifyCurrentPassword(c, user, req.CurrentPassword) {
+		return
+	}
+
+	if cuserr := h.authRepository.CheckIsNotExistingEmail(req.NewEmail); cuserr != nil {
+		c.JSON(http.StatusConflict, dto.MessageResponse{
+			Message: "Email sudah digunakan",
+		})
+		return
+	}
+
+	token, cuserr := h.authService.GenerateActivationToken()
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	hashedToken, cuserr := h.authService.HashString(token)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	data := codeEmailData{Email: req.NewEmail, Code: token, ExpiryMinutes: h.activation.ExpiryMinutes}
+	message, err := h.composeEmail(emailEmailChange, h.emailLocale(c, user), req.NewEmail, data)
+	if err != nil {
+		log.Println("Error rendering email change email:", err)
+		c.JSON(http.StatusInternalServerError, dto.MessageResponse{
+			Message: "Gagal membuat email",
+		})
+		return
+	}
+
+	tokenReq := &ActivationTokenRequest{
+		Email:                 req.NewEmail,
+		ActivationCode:        hashedToken,
+		TokenType:             emailChangeTokenType(user.ID),
+		ExpiryMinutes:         h.activation.ExpiryMinutes,
+		ResendCooldownSeconds: h.activation.ResendCooldownSeconds,
+		Message:               message,
+	}
+	if cuserr := h.authRepository.SaveActivationToken(tokenReq); cuserr != nil {
+		if cuserr.Code() == http.StatusTooManyRequests {
+			c.Header("Retry-After", strconv.Itoa(h.activation.ResendCooldownSeconds))
+			c.JSON(cuserr.Code(), dto.MessageResponse{
+				Message: "Kode baru sudah dikirim, silakan tunggu sebelum meminta lagi",
+			})
+			return
+		}
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: "Gagal menyimpan token",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Kode konfirmasi telah dikirim ke email baru",
+	})
+}
+
+// @Summary Confirm an email change
+// @Description Replace the email address of the current user with the confirmed new address and notify the previous address. Access tokens carry the new address from the next refresh.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param request body EmailChangeConfirmRequest true "New email and its code"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Failure 409 {object} dto.MessageResponse
+// @Router /auth/me/email/confirm [post]
+func (h *authHandler) ConfirmEmailChange(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	var req EmailChangeConfirmRequest
+	if cuserr := c.ShouldBindJSON(&req); cuserr != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	user, cuserr := h.authRepository.GetUserByID(claims.UserID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	tokenReq := &GetActivationTokenRequest{
+		Email:     req.NewEmail,
+		TokenType: emailChangeTokenType(user.ID),
+	}
+	// The code can only be used once
+	if cuserr := h.consumeActivationCode(tokenReq, req.ActivationCode); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	updateReq := &UpdateEmailRequest{
+		UserID:   user.ID,
+		NewEmail: req.NewEmail,
+	}
+	if cuserr := h.authRepository.UpdateUserEmail(updateReq); cuserr != nil {
+		if cuserr.Code() == http.StatusConflict {
+			c.JSON(http.StatusConflict, dto.MessageResponse{
+				Message: "Email sudah digunakan",
+			})
+			return
+		}
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	// The change is done, so a failed notification is only logged
+	h.queueNotification(c, emailEmailChanged, user, emailChangedEmailData{Email: user.Email, NewEmail: req.NewEmail})
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Email berhasil diubah",
+	})
+}
+
+// verifyCurrentPassword checks the password of the signed-in user. Wrong passwords count
+// as failed logins, so a stolen session cannot be used to guess the password.
+func (h *authHandler) verifyCurrentPassword(c *gin.Context, user *User, password string) bool {
+	if !h.checkLoginLockout(c, user.Email) {
+		return false
+	}
+
+	if cuserr := h.authService.VerifyHash(user.PasswordHash, password); cuserr != nil {
+		h.recordLoginFailure(c, user.Email, user)
+		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+			Message: "Password saat ini salah",
+		})
+		return false
+	}
+
+	h.resetLoginLockout(c, user.Email)
+	return true
+}
+
+// newProfileResponse combines the stored account with the access of the current token.
+// Empty role and permission lists are returned as [] instead of null.
+func newProfileResponse(user *User, claims *middleware.UserClaims) ProfileResponse {
+	return ProfileResponse{
+		ID:             user.ID,
+		Email:          user.Email,
+		Fullname:       user.Fullname,
+		Roles:          mergeStrings(claims.Roles, nil),
+		Permissions:    mergeStrings(claims.Permissions, nil),
+		OrganizationID: claims.OrganizationID,
+		CreatedAt:      user.CreatedAt,
+		UpdatedAt:      user.UpdatedAt,
+
+		OrganizationRole:        claims.OrganizationRole,
+		OrganizationPermissions: mergeStrings(claims.OrganizationPermissions, nil),
+
+		DeletionScheduledAt: user.DeletionScheduledAt,
+		Locale:              user.Locale,
+	}
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+)
+
+// Permissions checked by the auth routes. The full list is seeded by the RBAC migration.
+const (
+	PermissionUsersUnlock  = "users:unlock"
+	PermissionRolesManage  = "roles:manage"
+	PermissionEmailsManage = "emails:manage"
+)
+
+// adminRole is granted to the users listed in ADMIN_EMAILS
+const adminRole = "admin"
+
+// @Summary List roles
+// @Description List the global roles with their permissions
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.DataResponse[[]RoleResponse]
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Router /auth/roles [get]
+func (h *authHandler) ListRoles(c *gin.Context) {
+	roles, cuserr := h.authRepository.ListRoles()
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	response := make([]RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		response = append(response, RoleResponse{
+			Name:        role.Name,
+			Description: role.Description,
+			Permissions: role.Permissions,
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[[]RoleResponse]{
+		Data:    response,
+		Message: "Daftar role berhasil diambil",
+	})
+}
+
+// @Summary Get user roles
+// @Description Get the roles and resulting permissions of a user
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} dto.DataResponse[UserAccessResponse]
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Router /auth/users/{id}/roles [get]
+func (h *authHandler) GetUserRoles(c *gin.Context) {
+	user, cuserr := h.authRepository.GetUserByID(c.Param("id"))
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	access, cuserr := h.authRepository.GetUserAccess(user.ID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[UserAccessResponse]{
+		Data: UserAccessResponse{
+			Roles:       access.Roles,
+			Permissions: access.Permissions,
+		},
+		Message: "Role pengguna berhasil diambil",
+	})
+}
+
+// @Summary Assign role
+// @Description Give a role to a user. Takes effect at the user's next token refresh.
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "User ID"
+// @Param role path string true "Role name"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Router /auth/users/{id}/roles/{role} [put]
+func (h *authHandler) AssignUserRole(c *gin.Context) {
+	user, cuserr := h.authRepository.GetUserByID(c.Param("id"))
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	if cuserr := h.authRepository.AssignUserRole(user.ID, c.Param("role")); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Role berhasil diberikan",
+	})
+}
+
+// @Summary Remove role
+// @Description Take a role away from a user. Takes effect at the user's next token refresh.
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "User ID"
+// @Param role path string true "Role name"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Router /auth/users/{id}/roles/{role} [delete]
+func (h *authHandler) RemoveUserRole(c *gin.Context) {
+	if cuserr := h.authRepository.RemoveUserRole(c.Param("id"), c.Param("role")); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Role berhasil dicabut",
+	})
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/middleware"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+)
+
+// @Summary List active sessions
+// @Description List the active login sessions of the current user
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.DataResponse[[]SessionResponse]
+// @Failure 401 {object} dto.MessageResponse
+// @Router /auth/sessions [get]
+func (h *authHandler) ListSessions(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	sessions, cuserr := h.authRepository.GetActiveSessions(claims.UserID)
+	if cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	currentFamilyID := h.currentSessionFamilyID(c)
+
+	response := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, SessionResponse{
+			ID:         session.FamilyID,
+			UserAgent:  session.UserAgent,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			Current:    session.FamilyID == currentFamilyID,
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[[]SessionResponse]{
+		Data:    response,
+		Message: "Daftar sesi berhasil diambil",
+	})
+}
+
+// @Summary Revoke a session
+// @Description Revoke one login session of the current user
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Router /auth/sessions/{id} [delete]
+func (h *authHandler) RevokeSession(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+	familyID := c.Param("id")
+
+	if cuserr := h.authRepository.RevokeUserSession(claims.UserID, familyID); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	// Revoking the current session is the same as logging out
+	if familyID == h.currentSessionFamilyID(c) {
+		h.authService.GenerateLogoutCookies(c.Writer)
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Sesi berhasil dicabut",
+	})
+}
+
+// @Summary Revoke other sessions
+// @Description Revoke every login session of the current user except the current one
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Router /auth/sessions [delete]
+func (h *authHandler) RevokeOtherSessions(c *gin.Context) {
+	claims := middleware.ExtractUserClaims(c)
+
+	currentFamilyID, ok := h.requireCurrentSession(c)
+	if !ok {
+		return
+	}
+
+	if cuserr := h.authRepository.RevokeOtherSessions(claims.UserID, currentFamilyID); cuserr != nil {
+		c.JSON(cuserr.Code(), dto.MessageResponse{
+			Message: cuserr.Message(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Semua sesi lain berhasil dicabut",
+	})
+}
+
+// currentSessionFamilyID returns the session family of the request, from the sid claim
+// of the access token or else from the refresh cookie, or an empty string when neither
+// identifies one
+func (h *authHandler) currentSessionFamilyID(c *gin.Context) string {
+	if claims := middleware.ExtractUserClaims(c); claims != nil && claims.SessionID != "" {
+		return claims.SessionID
+	}
+
+	refreshToken, err := c.Cookie(h.tokenRequest.RefreshTokenName)
+	if err != nil || refreshToken == "" {
+		return ""
+	}
+
+	session, cuserr := h.authRepository.GetSessionByTokenHash(h.authService.HashToken(refreshToken))
+	if cuserr != nil {
+		return ""
+	}
+	return session.FamilyID
+}
+
+// requireCurrentSession returns the session family of the request, responding with 400
+// and returning false when it cannot be identified. Revoking "every other session"
+// with an unknown current one would end the caller's session too.
+func (h *authHandler) requireCurrentSession(c *gin.Context) (string, bool) {
+	familyID := h.currentSessionFamilyID(c)
+	if familyID == "" {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Sesi saat ini tidak dikenali, silakan login ulang",
+		})
+		return "", false
+	}
+	return familyID, true
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+	"github.com/yantology/golang-starter-template/pkg/suppression"
+)
+
+// @Summary List email suppressions
+// @Description List the addresses no email is sent to after a hard bounce or a spam complaint, newest first
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param limit query int false "Maximum number of addresses, at most 200" default(50)
+// @Success 200 {object} dto.DataResponse[[]EmailSuppressionResponse]
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Router /auth/admin/email-suppressions [get]
+func (h *authHandler) ListEmailSuppressions(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", strconv.Itoa(defaultEmailListLimit)))
+	if err != nil || limit <= 0 || limit > maxEmailListLimit {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Limit tidak valid",
+		})
+		return
+	}
+
+	entries, err := h.emailSuppressions.List(c.Request.Context(), limit)
+	if err != nil {
+		log.Println("Error listing email suppressions:", err)
+		c.JSON(http.StatusInternalServerError, dto.MessageResponse{
+			Message: "Gagal mengambil daftar alamat yang diblokir",
+		})
+		return
+	}
+
+	suppressions := make([]EmailSuppressionResponse, 0, len(entries))
+	for _, entry := range entries {
+		suppressions = append(suppressions, EmailSuppressionResponse{
+			Email:     entry.Email,
+			Reason:    entry.Reason,
+			Detail:    entry.Detail,
+			CreatedAt: entry.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, dto.DataResponse[[]EmailSuppressionResponse]{
+		Data:    suppressions,
+		Message: "Daftar alamat yang diblokir berhasil diambil",
+	})
+}
+
+// @Summary Remove email suppression
+// @Description Let an address receive email again, for example once its owner fixed the mailbox that bounced
+// @Tags auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Param email path string true "Email address"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 403 {object} dto.MessageResponse
+// @Failure 404 {object} dto.MessageResponse
+// @Router /auth/admin/email-suppressions/{email} [delete]
+func (h *authHandler) RemoveEmailSuppression(c *gin.Context) {
+	if err := h.emailSuppressions.Remove(c.Request.Context(), c.Param("email")); err != nil {
+		if errors.Is(err, suppression.ErrNotFound) {
+			c.JSON(http.StatusNotFound, dto.MessageResponse{
+				Message: "Alamat email tidak diblokir",
+			})
+			return
+		}
+		log.Println("Error removing email suppression:", err)
+		c.JSON(http.StatusInternalServerError, dto.MessageResponse{
+			Message: "Gagal membuka blokir alamat email",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Blokir alamat email berhasil dibuka",
+	})
+}
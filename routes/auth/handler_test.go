@@ -0,0 +1,988 @@
+package auth_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/yantology/golang-starter-template/config"
+	"github.com/yantology/golang-starter-template/middleware"
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+	jwtPkg "github.com/yantology/golang-starter-template/pkg/jwt"
+	"github.com/yantology/golang-starter-template/pkg/lockout"
+	"github.com/yantology/golang-starter-template/pkg/outbox"
+	"github.com/yantology/golang-starter-template/pkg/password"
+	"github.com/yantology/golang-starter-template/pkg/passwordpolicy"
+	"github.com/yantology/golang-starter-template/routes/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// fakeDB implements the database calls of the tested handlers; any other call panics
+type fakeDB struct {
+	auth.AuthDBInterface
+
+	mu     sync.Mutex
+	tokens map[string]*auth.ActivationToken
+	users  map[string]*auth.User
+	totp   map[string]*auth.UserTOTP
+
+	sessions map[string]*auth.Session
+
+	consumedTokens       int
+	recoveryCodeAttempts int
+	revokedFamilies      []string
+
+	savedTokens     []*auth.ActivationTokenRequest
+	saveTokenErr    *customerror.CustomError
+	passwordUpdates []*auth.UpdatePasswordRequest
+	keptFamilies    []string
+	emailUpdates    []*auth.UpdateEmailRequest
+	updateEmailErr  *customerror.CustomError
+	queuedEmails    []*outbox.Email
+
+	scheduleDeletionErr *customerror.CustomError
+
+	accepted  *auth.AcceptOrganizationInvitationRequest
+	acceptErr *customerror.CustomError
+}
+
+func tokenKey(email, tokenType string) string {
+	return tokenType + ":" + email
+}
+
+// addToken stores a token for code, hashed the way the handlers store it
+func (f *fakeDB) addToken(t *testing.T, service auth.AuthService, email, tokenType, code string) {
+	hash, cuserr := service.HashString(code)
+	require.Nil(t, cuserr)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.tokens == nil {
+		f.tokens = map[string]*auth.ActivationToken{}
+	}
+	f.tokens[tokenKey(email, tokenType)] = &auth.ActivationToken{
+		Email:     email,
+		TokenType: tokenType,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+}
+
+func (f *fakeDB) token(email, tokenType string) *auth.ActivationToken {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.tokens[tokenKey(email, tokenType)]
+}
+
+func (f *fakeDB) ConsumeActivationToken(req *auth.GetActivationTokenRequest) (*auth.ActivationToken, *customerror.CustomError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := tokenKey(req.Email, req.TokenType)
+	token, ok := f.tokens[key]
+	if !ok {
+		return nil, customerror.NewCustomError(nil, "token not found or expired", http.StatusNotFound)
+	}
+	delete(f.tokens, key)
+	f.consumedTokens++
+	consumed := *token
+	return &consumed, nil
+}
+
+func (f *fakeDB) RestoreActivationToken(token *auth.ActivationToken, maxAttempts int) *customerror.CustomError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if token.Attempts+1 >= maxAttempts {
+		return nil
+	}
+	key := tokenKey(token.Email, token.TokenType)
+	if _, ok := f.tokens[key]; ok {
+		return nil
+	}
+	restored := *token
+	restored.Attempts++
+	f.tokens[key] = &restored
+	return nil
+}
+
+func (f *fakeDB) GetUserByEmail(email string) (*auth.User, *customerror.CustomError) {
+	user, ok := f.users[email]
+	if !ok {
+		return nil, customerror.NewCustomError(nil, "User tidak ditemukan", http.StatusNotFound)
+	}
+	return user, nil
+}
+
+func (f *fakeDB) GetUserByID(userID string) (*auth.User, *customerror.CustomError) {
+	for _, user := range f.users {
+		if user.ID == userID {
+			return user, nil
+		}
+	}
+	return nil, customerror.NewCustomError(nil, "User tidak ditemukan", http.StatusNotFound)
+}
+
+func (f *fakeDB) CheckIsNotExistingEmail(email string) *customerror.CustomError {
+	if _, ok := f.users[email]; ok {
+		return customerror.NewCustomError(nil, "Email sudah terdaftar", http.StatusConflict)
+	}
+	return nil
+}
+
+func (f *fakeDB) SaveActivationToken(req *auth.ActivationTokenRequest) *customerror.CustomError {
+	if f.saveTokenErr != nil {
+		return f.saveTokenErr
+	}
+	f.savedTokens = append(f.savedTokens, req)
+	return nil
+}
+
+func (f *fakeDB) UpdateUserPassword(req *auth.UpdatePasswordRequest) *customerror.CustomError {
+	f.passwordUpdates = append(f.passwordUpdates, req)
+	return nil
+}
+
+func (f *fakeDB) UpdateUserEmail(req *auth.UpdateEmailRequest) *customerror.CustomError {
+	if f.updateEmailErr != nil {
+		return f.updateEmailErr
+	}
+	f.emailUpdates = append(f.emailUpdates, req)
+	return nil
+}
+
+func (f *fakeDB) ScheduleUserDeletion(userID string, graceDays int) (time.Time, *customerror.CustomError) {
+	if f.scheduleDeletionErr != nil {
+		return time.Time{}, f.scheduleDeletionErr
+	}
+	user, cuserr := f.GetUserByID(userID)
+	if cuserr != nil {
+		return time.Time{}, cuserr
+	}
+	scheduledAt := time.Now().AddDate(0, 0, graceDays)
+	user.DeletionScheduledAt = &scheduledAt
+	return scheduledAt, nil
+}
+
+func (f *fakeDB) CancelUserDeletion(userID string) *customerror.CustomError {
+	user, cuserr := f.GetUserByID(userID)
+	if cuserr != nil {
+		return cuserr
+	}
+	if user.DeletionScheduledAt == nil {
+		return customerror.NewCustomError(nil, "no deletion scheduled", http.StatusNotFound)
+	}
+	user.DeletionScheduledAt = nil
+	return nil
+}
+
+func (f *fakeDB) EnqueueEmail(email *outbox.Email) *customerror.CustomError {
+	f.queuedEmails = append(f.queuedEmails, email)
+	return nil
+}
+
+func (f *fakeDB) GetUserTOTP(userID string) (*auth.UserTOTP, *customerror.CustomError) {
+	userTOTP, ok := f.totp[userID]
+	if !ok {
+		return nil, customerror.NewCustomError(nil, "2FA tidak ditemukan", http.StatusNotFound)
+	}
+	return userTOTP, nil
+}
+
+func (f *fakeDB) UseRecoveryCode(userID, codeHash string) *customerror.CustomError {
+	f.recoveryCodeAttempts++
+	return customerror.NewCustomError(nil, "Kode pemulihan tidak ditemukan", http.StatusUnauthorized)
+}
+
+func (f *fakeDB) GetSessionByTokenHash(tokenHash string) (*auth.Session, *customerror.CustomError) {
+	session, ok := f.sessions[tokenHash]
+	if !ok {
+		return nil, customerror.NewCustomError(nil, "Sesi tidak ditemukan", http.StatusNotFound)
+	}
+	return session, nil
+}
+
+func (f *fakeDB) RevokeSessionFamily(familyID string) *customerror.CustomError {
+	f.revokedFamilies = append(f.revokedFamilies, familyID)
+	return nil
+}
+
+func (f *fakeDB) RevokeOtherSessions(userID, keepFamilyID string) *customerror.CustomError {
+	f.keptFamilies = append(f.keptFamilies, keepFamilyID)
+	return nil
+}
+
+func (f *fakeDB) AcceptOrganizationInvitation(req *auth.AcceptOrganizationInvitationRequest) *customerror.CustomError {
+	if f.acceptErr != nil {
+		return f.acceptErr
+	}
+	f.accepted = req
+	return nil
+}
+
+var tokenConfig = &config.TokenConfig{
+	AccessTokenName:    "access_token",
+	RefreshTokenName:   "refresh_token",
+	CookiePath:         "/",
+	AccessTokenExpiry:  15 * time.Minute,
+	RefreshTokenExpiry: 24 * time.Hour,
+}
+
+// handlerFuncs are the tested handler methods
+type handlerFuncs interface {
+	Register(c *gin.Context)
+	ForgetPassword(c *gin.Context)
+	RefreshToken(c *gin.Context)
+	LoginMFA(c *gin.Context)
+	ChangePassword(c *gin.Context)
+	RequestEmailChange(c *gin.Context)
+	ConfirmEmailChange(c *gin.Context)
+	AcceptOrganizationInvitation(c *gin.Context)
+	RequestAccountDeletion(c *gin.Context)
+	CancelAccountDeletion(c *gin.Context)
+}
+
+type testHandler struct {
+	handler    handlerFuncs
+	service    auth.AuthService
+	jwtService jwtPkg.Service[middleware.UserClaims]
+}
+
+func newTestHandler(t *testing.T, db *fakeDB) *testHandler {
+	gin.SetMode(gin.TestMode)
+
+	emailTemplates, err := auth.NewEmailTemplates("", "en")
+	require.NoError(t, err)
+
+	jwtService := jwtPkg.NewService[middleware.UserClaims]("test-access", "test-refresh", 0, 0, "test")
+	mfaConfig := &config.MFAConfig{MaxAttempts: 10}
+	activationConfig := &config.ActivationConfig{MaxAttempts: 3}
+	organizationConfig := &config.OrganizationConfig{InvitationSecret: "test-invitation-secret", InvitationExpiryHours: 72}
+	service := auth.NewAuthService(
+		jwtService,
+		tokenConfig,
+		mfaConfig,
+		&config.PasswordlessConfig{LinkSecret: "test-link-secret"},
+		activationConfig,
+		password.NewMultiHasher(password.NewBcrypt(bcrypt.MinCost)),
+		&passwordpolicy.Policy{MinLength: 8},
+		organizationConfig,
+		&config.OIDCConfig{FlowSecret: "test-flow-secret"},
+	)
+
+	// Three wrong second factor codes lock the user, without a delay between the attempts
+	loginLockout := &auth.LoginLockout{
+		Email: lockout.NewMemoryTracker(lockout.Policy{}),
+		IP:    lockout.NewMemoryTracker(lockout.Policy{}),
+		MFA:   lockout.NewMemoryTracker(lockout.Policy{Threshold: 3, LockoutDuration: 15 * time.Minute, Window: time.Hour}),
+	}
+
+	handler := auth.NewAuthHandler(
+		service, auth.NewAuthRepository(db), emailTemplates, tokenConfig, mfaConfig, nil, nil,
+		&config.OIDCConfig{}, &config.PasswordlessConfig{}, activationConfig,
+		loginLockout, &config.LockoutConfig{}, &config.APIKeyConfig{}, &config.RBACConfig{},
+		organizationConfig, nil, &config.AccountDeletionConfig{GraceDays: 30}, nil, nil,
+	)
+
+	return &testHandler{
+		handler:    handler,
+		service:    service,
+		jwtService: jwtService,
+	}
+}
+
+// serve runs the request through the handlers
+func (th *testHandler) serve(req *http.Request, handlers ...gin.HandlerFunc) *httptest.ResponseRecorder {
+	router := gin.New()
+	router.Handle(req.Method, req.URL.Path, handlers...)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// authenticated puts the claims AuthRequired would set for a token of the user
+func authenticated(userID, email string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("email", email)
+		c.Set("session_id", "family-1")
+		c.Next()
+	}
+}
+
+func jsonRequest(t *testing.T, method, path string, body any) *http.Request {
+	encoded, err := json.Marshal(body)
+	require.NoError(t, err)
+	req := httptest.NewRequest(method, path, bytes.NewReader(encoded))
+	req.Header.Set("Content-Type", "application/json")
+	return req
+}
+
+func decodeMessage(t *testing.T, w *httptest.ResponseRecorder) string {
+	var body struct {
+		Message string `json:"message"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	return body.Message
+}
+
+func TestRefreshTokenReuse(t *testing.T) {
+	rotatedAt := time.Now().Add(-time.Minute)
+	revokedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name                    string
+		session                 auth.Session
+		expectedMessage         string
+		expectedRevokedFamilies []string
+	}{
+		{
+			name:                    "rotated token replayed",
+			session:                 auth.Session{ID: "session-1", FamilyID: "family-1", UserID: "user-1", RotatedAt: &rotatedAt},
+			expectedMessage:         "Refresh token sudah pernah digunakan, sesi telah dicabut",
+			expectedRevokedFamilies: []string{"family-1"},
+		},
+		{
+			name:            "revoked session",
+			session:         auth.Session{ID: "session-1", FamilyID: "family-1", UserID: "user-1", RevokedAt: &revokedAt},
+			expectedMessage: "Sesi telah dicabut",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{}
+			th := newTestHandler(t, db)
+
+			refreshToken, err := th.jwtService.GenerateRefreshToken(middleware.UserClaims{UserID: "user-1", Email: "user@example.com"})
+			require.NoError(t, err)
+			session := tt.session
+			db.sessions = map[string]*auth.Session{th.service.HashToken(refreshToken): &session}
+
+			req := httptest.NewRequest(http.MethodGet, "/auth/refresh-token", nil)
+			req.AddCookie(&http.Cookie{Name: tokenConfig.RefreshTokenName, Value: refreshToken})
+
+			w := th.serve(req, th.handler.RefreshToken)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, tt.expectedMessage, decodeMessage(t, w))
+			assert.Equal(t, tt.expectedRevokedFamilies, db.revokedFamilies)
+
+			// The cookies are cleared so the client stops replaying the token
+			cleared := map[string]bool{}
+			for _, cookie := range w.Result().Cookies() {
+				cleared[cookie.Name] = cookie.Value == "" && cookie.Expires.Before(time.Now())
+			}
+			assert.True(t, cleared[tokenConfig.AccessTokenName])
+			assert.True(t, cleared[tokenConfig.RefreshTokenName])
+		})
+	}
+}
+
+func TestLoginMFAAttemptLimit(t *testing.T) {
+	confirmedAt := time.Now()
+	db := &fakeDB{
+		users: map[string]*auth.User{
+			"user@example.com": {ID: "user-1", Email: "user@example.com"},
+		},
+		totp: map[string]*auth.UserTOTP{
+			"user-1": {UserID: "user-1", Secret: "JBSWY3DPEHPK3PXP", ConfirmedAt: &confirmedAt},
+		},
+	}
+	th := newTestHandler(t, db)
+	db.addToken(t, th.service, "user@example.com", "mfa-login", "challenge")
+
+	// A code that is not six digits is checked as a recovery code
+	body := auth.LoginMFARequest{Email: "user@example.com", MFAToken: "challenge", Code: "wrong-recovery-code"}
+
+	for i := 1; i <= 3; i++ {
+		w := th.serve(jsonRequest(t, http.MethodPost, "/auth/login/mfa", body), th.handler.LoginMFA)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, "Kode pemulihan tidak valid", decodeMessage(t, w))
+		// The challenge is put back with the wrong code counted
+		require.NotNil(t, db.token("user@example.com", "mfa-login"))
+		assert.Equal(t, i, db.token("user@example.com", "mfa-login").Attempts)
+	}
+
+	// The challenge allows more attempts, but the user is locked before the code is checked
+	w := th.serve(jsonRequest(t, http.MethodPost, "/auth/login/mfa", body), th.handler.LoginMFA)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "Akun dikunci sementara karena terlalu banyak kode 2FA yang salah", decodeMessage(t, w))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+	assert.Equal(t, 3, db.recoveryCodeAttempts, "the code must not be checked while locked")
+	assert.Nil(t, db.token("user@example.com", "mfa-login"), "a locked user has to log in again")
+}
+
+func TestLoginMFAChallenge(t *testing.T) {
+	tests := []struct {
+		name            string
+		stored          bool
+		mfaToken        string
+		expectedMessage string
+	}{
+		{
+			name:            "wrong challenge",
+			stored:          true,
+			mfaToken:        "wrong-challenge",
+			expectedMessage: "Sesi verifikasi 2FA tidak valid",
+		},
+		{
+			name:            "unknown challenge",
+			mfaToken:        "challenge",
+			expectedMessage: "Sesi verifikasi 2FA tidak ditemukan atau kadaluarsa",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{}
+			th := newTestHandler(t, db)
+			if tt.stored {
+				db.addToken(t, th.service, "user@example.com", "mfa-login", "challenge")
+			}
+			body := auth.LoginMFARequest{Email: "user@example.com", MFAToken: tt.mfaToken, Code: "123456"}
+
+			w := th.serve(jsonRequest(t, http.MethodPost, "/auth/login/mfa", body), th.handler.LoginMFA)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.Equal(t, tt.expectedMessage, decodeMessage(t, w))
+			// A wrong challenge is put back for the next attempt
+			assert.Equal(t, tt.stored, db.token("user@example.com", "mfa-login") != nil)
+		})
+	}
+}
+
+// Wrong codes sent in parallel must not get more guesses than the attempt limit allows
+func TestActivationCodeConcurrentGuesses(t *testing.T) {
+	tests := []struct {
+		name      string
+		email     string
+		tokenType string
+		request   func(th *testHandler) (string, any, []gin.HandlerFunc)
+	}{
+		{
+			name:      "registration",
+			email:     "new@example.com",
+			tokenType: "registration",
+			request: func(th *testHandler) (string, any, []gin.HandlerFunc) {
+				return "/auth/register", auth.RegisterRequest{
+					Email:                "new@example.com",
+					Fullname:             "Siti Aminah",
+					Password:             "correct-horse-battery",
+					PasswordConfirmation: "correct-horse-battery",
+					ActivationCode:       "000000",
+				}, []gin.HandlerFunc{th.handler.Register}
+			},
+		},
+		{
+			name:      "password reset",
+			email:     "user@example.com",
+			tokenType: "forget-password",
+			request: func(th *testHandler) (string, any, []gin.HandlerFunc) {
+				return "/auth/forget-password", auth.ForgetPasswordRequest{
+					Email:                   "user@example.com",
+					ActivationCode:          "000000",
+					NewPassword:             "correct-horse-battery",
+					NewPasswordConfirmation: "correct-horse-battery",
+				}, []gin.HandlerFunc{th.handler.ForgetPassword}
+			},
+		},
+		{
+			name:      "email change",
+			email:     "new@example.com",
+			tokenType: "email-change:user-1",
+			request: func(th *testHandler) (string, any, []gin.HandlerFunc) {
+				return "/auth/me/email/confirm", auth.EmailChangeConfirmRequest{
+					NewEmail:       "new@example.com",
+					ActivationCode: "000000",
+				}, []gin.HandlerFunc{authenticated("user-1", "user@example.com"), th.handler.ConfirmEmailChange}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{
+				users: map[string]*auth.User{
+					"user@example.com": {ID: "user-1", Email: "user@example.com", Fullname: "Budi"},
+				},
+			}
+			th := newTestHandler(t, db)
+			db.addToken(t, th.service, tt.email, tt.tokenType, "123456")
+			path, body, handlers := tt.request(th)
+
+			// Guesses that arrive while another one holds the token are refused without
+			// counting, so waves are sent until the limit deleted the token
+			for wave := 0; wave < 10 && db.token(tt.email, tt.tokenType) != nil; wave++ {
+				var wg sync.WaitGroup
+				for i := 0; i < 10; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						w := th.serve(jsonRequest(t, http.MethodPost, path, body), handlers...)
+						assert.Contains(t, []int{http.StatusUnauthorized, http.StatusNotFound}, w.Code)
+					}()
+				}
+				wg.Wait()
+			}
+
+			assert.Nil(t, db.token(tt.email, tt.tokenType), "the token must be deleted at the attempt limit")
+			assert.Equal(t, 3, db.consumedTokens, "only the allowed number of guesses may be checked")
+		})
+	}
+}
+
+func TestAcceptOrganizationInvitation(t *testing.T) {
+	tests := []struct {
+		name             string
+		email            string
+		request          func(token string) auth.OrganizationAcceptRequest
+		acceptErr        *customerror.CustomError
+		expectedCode     int
+		expectedMessage  string
+		expectedUserID   string
+		expectedNewUser  bool
+		expectedAccepted bool
+	}{
+		{
+			name:  "existing user",
+			email: "member@example.com",
+			request: func(token string) auth.OrganizationAcceptRequest {
+				return auth.OrganizationAcceptRequest{Token: token}
+			},
+			expectedCode:     http.StatusOK,
+			expectedMessage:  "Berhasil bergabung dengan organisasi",
+			expectedUserID:   "user-1",
+			expectedAccepted: true,
+		},
+		{
+			name:  "new user",
+			email: "new@example.com",
+			request: func(token string) auth.OrganizationAcceptRequest {
+				return auth.OrganizationAcceptRequest{
+					Token:                token,
+					Fullname:             "Siti Aminah",
+					Password:             "correct-horse-battery",
+					PasswordConfirmation: "correct-horse-battery",
+				}
+			},
+			expectedCode:     http.StatusCreated,
+			expectedMessage:  "Akun berhasil dibuat dan bergabung dengan organisasi, silakan login",
+			expectedNewUser:  true,
+			expectedAccepted: true,
+		},
+		{
+			name:  "new user without account details",
+			email: "new@example.com",
+			request: func(token string) auth.OrganizationAcceptRequest {
+				return auth.OrganizationAcceptRequest{Token: token}
+			},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Nama lengkap dan password diperlukan untuk membuat akun",
+		},
+		{
+			name:  "tampered token",
+			email: "member@example.com",
+			request: func(token string) auth.OrganizationAcceptRequest {
+				return auth.OrganizationAcceptRequest{Token: token + "x"}
+			},
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "Undangan tidak valid",
+		},
+		{
+			// The stored hash no longer matches after the invitation was resent, revoked or used
+			name:  "invitation no longer pending",
+			email: "member@example.com",
+			request: func(token string) auth.OrganizationAcceptRequest {
+				return auth.OrganizationAcceptRequest{Token: token}
+			},
+			acceptErr:       customerror.NewCustomError(nil, "Undangan tidak ditemukan", http.StatusNotFound),
+			expectedCode:    http.StatusNotFound,
+			expectedMessage: "Undangan tidak ditemukan atau sudah tidak berlaku",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{
+				users: map[string]*auth.User{
+					"member@example.com": {ID: "user-1", Email: "member@example.com"},
+				},
+				acceptErr: tt.acceptErr,
+			}
+			th := newTestHandler(t, db)
+
+			token, _, cuserr := th.service.GenerateInvitationToken("org-1", tt.email, time.Hour)
+			require.Nil(t, cuserr)
+
+			w := th.serve(jsonRequest(t, http.MethodPost, "/auth/invitations/accept", tt.request(token)), th.handler.AcceptOrganizationInvitation)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedMessage, decodeMessage(t, w))
+			if !tt.expectedAccepted {
+				assert.Nil(t, db.accepted)
+				return
+			}
+
+			require.NotNil(t, db.accepted)
+			assert.Equal(t, "org-1", db.accepted.OrganizationID)
+			assert.Equal(t, tt.email, db.accepted.Email)
+			assert.Equal(t, th.service.HashToken(token), db.accepted.TokenHash)
+			assert.Equal(t, tt.expectedUserID, db.accepted.UserID)
+			if tt.expectedNewUser {
+				require.NotNil(t, db.accepted.NewUser)
+				assert.Equal(t, "Siti Aminah", db.accepted.NewUser.Fullname)
+				assert.True(t, strings.HasPrefix(db.accepted.NewUser.PasswordHash, "$2"), "the password must be stored hashed")
+			} else {
+				assert.Nil(t, db.accepted.NewUser)
+			}
+		})
+	}
+}
+
+// setPassword stores the hash of password for the user with the email
+func (f *fakeDB) setPassword(t *testing.T, service auth.AuthService, email, password string) {
+	hash, cuserr := service.HashString(password)
+	require.Nil(t, cuserr)
+	f.users[email].PasswordHash = hash
+}
+
+func TestChangePassword(t *testing.T) {
+	tests := []struct {
+		name            string
+		request         auth.ChangePasswordRequest
+		expectedCode    int
+		expectedMessage string
+		expectedChange  bool
+	}{
+		{
+			name: "password changed",
+			request: auth.ChangePasswordRequest{
+				CurrentPassword:         "current-password",
+				NewPassword:             "correct-horse-battery",
+				NewPasswordConfirmation: "correct-horse-battery",
+			},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "Password berhasil diubah, sesi lain telah dicabut",
+			expectedChange:  true,
+		},
+		{
+			name: "wrong current password",
+			request: auth.ChangePasswordRequest{
+				CurrentPassword:         "wrong-password",
+				NewPassword:             "correct-horse-battery",
+				NewPasswordConfirmation: "correct-horse-battery",
+			},
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "Password saat ini salah",
+		},
+		{
+			name: "confirmation mismatch",
+			request: auth.ChangePasswordRequest{
+				CurrentPassword:         "current-password",
+				NewPassword:             "correct-horse-battery",
+				NewPasswordConfirmation: "correct-horse-staple",
+			},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Password baru tidak cocok dengan konfirmasi",
+		},
+		{
+			name: "new password against policy",
+			request: auth.ChangePasswordRequest{
+				CurrentPassword:         "current-password",
+				NewPassword:             "short",
+				NewPasswordConfirmation: "short",
+			},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Password tidak memenuhi kebijakan password",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{
+				users: map[string]*auth.User{
+					"user@example.com": {ID: "user-1", Email: "user@example.com", Fullname: "Budi"},
+				},
+			}
+			th := newTestHandler(t, db)
+			db.setPassword(t, th.service, "user@example.com", "current-password")
+
+			w := th.serve(jsonRequest(t, http.MethodPost, "/auth/change-password", tt.request),
+				authenticated("user-1", "user@example.com"), th.handler.ChangePassword)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedMessage, decodeMessage(t, w))
+			if !tt.expectedChange {
+				assert.Empty(t, db.passwordUpdates)
+				assert.Empty(t, db.keptFamilies)
+				return
+			}
+
+			require.Len(t, db.passwordUpdates, 1)
+			assert.Equal(t, "user@example.com", db.passwordUpdates[0].Email)
+			assert.Nil(t, th.service.VerifyHash(db.passwordUpdates[0].NewPasswordHash, tt.request.NewPassword))
+			// Every session but the current one is revoked
+			assert.Equal(t, []string{"family-1"}, db.keptFamilies)
+		})
+	}
+}
+
+func TestRequestEmailChange(t *testing.T) {
+	tests := []struct {
+		name            string
+		request         auth.EmailChangeRequest
+		saveTokenErr    *customerror.CustomError
+		expectedCode    int
+		expectedMessage string
+		expectedSaved   bool
+	}{
+		{
+			name:            "code sent",
+			request:         auth.EmailChangeRequest{NewEmail: "new@example.com", CurrentPassword: "current-password"},
+			expectedCode:    http.StatusOK,
+			expectedMessage: "Kode konfirmasi telah dikirim ke email baru",
+			expectedSaved:   true,
+		},
+		{
+			name:            "same email",
+			request:         auth.EmailChangeRequest{NewEmail: "USER@example.com", CurrentPassword: "current-password"},
+			expectedCode:    http.StatusBadRequest,
+			expectedMessage: "Email baru sama dengan email saat ini",
+		},
+		{
+			name:            "wrong current password",
+			request:         auth.EmailChangeRequest{NewEmail: "new@example.com", CurrentPassword: "wrong-password"},
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "Password saat ini salah",
+		},
+		{
+			name:            "email taken",
+			request:         auth.EmailChangeRequest{NewEmail: "other@example.com", CurrentPassword: "current-password"},
+			expectedCode:    http.StatusConflict,
+			expectedMessage: "Email sudah digunakan",
+		},
+		{
+			name:            "code sent too recently",
+			request:         auth.EmailChangeRequest{NewEmail: "new@example.com", CurrentPassword: "current-password"},
+			saveTokenErr:    customerror.NewCustomError(nil, "token was sent too recently", http.StatusTooManyRequests),
+			expectedCode:    http.StatusTooManyRequests,
+			expectedMessage: "Kode baru sudah dikirim, silakan tunggu sebelum meminta lagi",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{
+				users: map[string]*auth.User{
+					"user@example.com":  {ID: "user-1", Email: "user@example.com", Fullname: "Budi"},
+					"other@example.com": {ID: "user-2", Email: "other@example.com", Fullname: "Siti"},
+				},
+				saveTokenErr: tt.saveTokenErr,
+			}
+			th := newTestHandler(t, db)
+			db.setPassword(t, th.service, "user@example.com", "current-password")
+
+			w := th.serve(jsonRequest(t, http.MethodPost, "/auth/me/email", tt.request),
+				authenticated("user-1", "user@example.com"), th.handler.RequestEmailChange)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedMessage, decodeMessage(t, w))
+			if !tt.expectedSaved {
+				assert.Empty(t, db.savedTokens)
+				return
+			}
+
+			// The code goes to the new address and only confirms a change of this user
+			require.Len(t, db.savedTokens, 1)
+			saved := db.savedTokens[0]
+			assert.Equal(t, "new@example.com", saved.Email)
+			assert.Equal(t, "email-change:user-1", saved.TokenType)
+			require.NotNil(t, saved.Message)
+			assert.Equal(t, []string{"new@example.com"}, saved.Message.To)
+			assert.Empty(t, db.emailUpdates, "the email must not change before it is confirmed")
+		})
+	}
+}
+
+func TestConfirmEmailChange(t *testing.T) {
+	tests := []struct {
+		name            string
+		code            string
+		updateEmailErr  *customerror.CustomError
+		expectedCode    int
+		expectedMessage string
+		expectedChange  bool
+	}{
+		{
+			name:            "email changed",
+			code:            "123456",
+			expectedCode:    http.StatusOK,
+			expectedMessage: "Email berhasil diubah",
+			expectedChange:  true,
+		},
+		{
+			name:            "wrong code",
+			code:            "654321",
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "Hash tidak cocok",
+		},
+		{
+			// Another account took the address after the code was sent
+			name:            "email taken",
+			code:            "123456",
+			updateEmailErr:  customerror.NewCustomError(nil, "email already exists", http.StatusConflict),
+			expectedCode:    http.StatusConflict,
+			expectedMessage: "Email sudah digunakan",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{
+				users: map[string]*auth.User{
+					"user@example.com": {ID: "user-1", Email: "user@example.com", Fullname: "Budi"},
+				},
+				updateEmailErr: tt.updateEmailErr,
+			}
+			th := newTestHandler(t, db)
+			db.addToken(t, th.service, "new@example.com", "email-change:user-1", "123456")
+			body := auth.EmailChangeConfirmRequest{NewEmail: "new@example.com", ActivationCode: tt.code}
+
+			w := th.serve(jsonRequest(t, http.MethodPost, "/auth/me/email/confirm", body),
+				authenticated("user-1", "user@example.com"), th.handler.ConfirmEmailChange)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedMessage, decodeMessage(t, w))
+			if !tt.expectedChange {
+				assert.Empty(t, db.emailUpdates)
+				assert.Empty(t, db.queuedEmails)
+				return
+			}
+
+			assert.Equal(t, []*auth.UpdateEmailRequest{{UserID: "user-1", NewEmail: "new@example.com"}}, db.emailUpdates)
+			assert.Nil(t, db.token("new@example.com", "email-change:user-1"), "the code can only be used once")
+			// The previous address is told about the change
+			require.Len(t, db.queuedEmails, 1)
+			assert.Equal(t, []string{"user@example.com"}, db.queuedEmails[0].To)
+		})
+	}
+}
+
+func TestRequestAccountDeletion(t *testing.T) {
+	tests := []struct {
+		name                string
+		currentPassword     string
+		scheduleDeletionErr *customerror.CustomError
+		expectedCode        int
+		expectedMessage     string
+		expectedScheduled   bool
+	}{
+		{
+			name:              "deletion scheduled",
+			currentPassword:   "current-password",
+			expectedCode:      http.StatusOK,
+			expectedMessage:   "Penghapusan akun dijadwalkan",
+			expectedScheduled: true,
+		},
+		{
+			name:            "wrong current password",
+			currentPassword: "wrong-password",
+			expectedCode:    http.StatusUnauthorized,
+			expectedMessage: "Password saat ini salah",
+		},
+		{
+			name:                "only owner of an organization",
+			currentPassword:     "current-password",
+			scheduleDeletionErr: customerror.NewCustomError(nil, "user is the only owner of an organization", http.StatusConflict),
+			expectedCode:        http.StatusConflict,
+			expectedMessage:     "Serahkan kepemilikan organisasi kepada anggota lain sebelum menghapus akun",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{
+				users: map[string]*auth.User{
+					"user@example.com": {ID: "user-1", Email: "user@example.com", Fullname: "Budi"},
+				},
+				scheduleDeletionErr: tt.scheduleDeletionErr,
+			}
+			th := newTestHandler(t, db)
+			db.setPassword(t, th.service, "user@example.com", "current-password")
+			body := auth.AccountDeletionRequest{CurrentPassword: tt.currentPassword}
+
+			w := th.serve(jsonRequest(t, http.MethodPost, "/auth/me/deletion", body),
+				authenticated("user-1", "user@example.com"), th.handler.RequestAccountDeletion)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedMessage, decodeMessage(t, w))
+			if !tt.expectedScheduled {
+				assert.Nil(t, db.users["user@example.com"].DeletionScheduledAt)
+				assert.Empty(t, db.keptFamilies)
+				assert.Empty(t, db.queuedEmails)
+				return
+			}
+
+			var response struct {
+				Data auth.AccountDeletionResponse `json:"data"`
+			}
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.WithinDuration(t, time.Now().AddDate(0, 0, 30), response.Data.ScheduledAt, time.Minute)
+			// Only the current session is kept, so it can still cancel
+			assert.Equal(t, []string{"family-1"}, db.keptFamilies)
+			require.Len(t, db.queuedEmails, 1)
+			assert.Equal(t, []string{"user@example.com"}, db.queuedEmails[0].To)
+		})
+	}
+}
+
+func TestCancelAccountDeletion(t *testing.T) {
+	scheduledAt := time.Now().AddDate(0, 0, 30)
+
+	tests := []struct {
+		name            string
+		scheduledAt     *time.Time
+		expectedCode    int
+		expectedMessage string
+	}{
+		{
+			name:            "deletion cancelled",
+			scheduledAt:     &scheduledAt,
+			expectedCode:    http.StatusOK,
+			expectedMessage: "Penghapusan akun dibatalkan",
+		},
+		{
+			name:            "no deletion scheduled",
+			expectedCode:    http.StatusNotFound,
+			expectedMessage: "Tidak ada penghapusan akun yang dijadwalkan",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &fakeDB{
+				users: map[string]*auth.User{
+					"user@example.com": {ID: "user-1", Email: "user@example.com", DeletionScheduledAt: tt.scheduledAt},
+				},
+			}
+			th := newTestHandler(t, db)
+
+			req := httptest.NewRequest(http.MethodDelete, "/auth/me/deletion", nil)
+			w := th.serve(req, authenticated("user-1", "user@example.com"), th.handler.CancelAccountDeletion)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedMessage, decodeMessage(t, w))
+			assert.Nil(t, db.users["user@example.com"].DeletionScheduledAt)
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/pkg/dto"
+	"github.com/yantology/golang-starter-template/pkg/outbox"
+	"github.com/yantology/golang-starter-template/pkg/resendutils"
+	"github.com/yantology/golang-starter-template/pkg/suppression"
+)
+
+// maxWebhookBodyBytes bounds the body read before the signature is checked
+const maxWebhookBodyBytes = 1 << 20
+
+// EmailWebhookHandler receives the delivery events of Resend. It records the outcome
+// on the outbox email and suppresses addresses that hard bounced or complained, so
+// they are not sent to again.
+type EmailWebhookHandler struct {
+	verifier          *resendutils.WebhookVerifier
+	emailOutbox       outbox.Store
+	emailSuppressions suppression.Store
+}
+
+func NewEmailWebhookHandler(verifier *resendutils.WebhookVerifier, emailOutbox outbox.Store, emailSuppressions suppression.Store) *EmailWebhookHandler {
+	return &EmailWebhookHandler{
+		verifier:          verifier,
+		emailOutbox:       emailOutbox,
+		emailSuppressions: emailSuppressions,
+	}
+}
+
+// RegisterRoutes serves the webhook under /webhooks/resend of the router. It is
+// authenticated by the signature, not by a token.
+func (h *EmailWebhookHandler) RegisterRoutes(router *gin.RouterGroup) {
+	router.POST("/webhooks/resend", h.ReceiveResendEvent)
+}
+
+// @Summary Receive Resend delivery event
+// @Description Webhook for the email.delivered, email.bounced and email.complained events of Resend, signed with RESEND_WEBHOOK_SECRET. Hard bounces and complaints suppress the address. Other event types are accepted and ignored.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param svix-id header string true "Message ID"
+// @Param svix-timestamp header string true "Unix timestamp of the request"
+// @Param svix-signature header string true "Signatures of the request"
+// @Success 200 {object} dto.MessageResponse
+// @Failure 400 {object} dto.MessageResponse
+// @Failure 401 {object} dto.MessageResponse
+// @Failure 500 {object} dto.MessageResponse
+// @Router /webhooks/resend [post]
+func (h *EmailWebhookHandler) ReceiveResendEvent(c *gin.Context) {
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBodyBytes))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, dto.MessageResponse{
+			Message: "Format request tidak valid",
+		})
+		return
+	}
+
+	event, err := h.verifier.Verify(c.Request.Header, body)
+	if err != nil {
+		if errors.Is(err, resendutils.ErrInvalidEvent) {
+			c.JSON(http.StatusBadRequest, dto.MessageResponse{
+				Message: "Format request tidak valid",
+			})
+			return
+		}
+		log.Println("Rejected Resend webhook:", err)
+		c.JSON(http.StatusUnauthorized, dto.MessageResponse{
+			Message: "Tanda tangan webhook tidak valid",
+		})
+		return
+	}
+
+	// A failure responds 500, so Resend delivers the event again later
+	if err := h.handleEvent(c, event); err != nil {
+		log.Printf("Failed to handle Resend %s event for %s: %v", event.Type, event.Data.EmailID, err)
+		c.JSON(http.StatusInternalServerError, dto.MessageResponse{
+			Message: "Gagal memproses webhook",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.MessageResponse{
+		Message: "Webhook diterima",
+	})
+}
+
+func (h *EmailWebhookHandler) handleEvent(c *gin.Context, event *resendutils.WebhookEvent) error {
+	ctx := c.Request.Context()
+
+	var status, detail, reason string
+	switch event.Type {
+	case resendutils.EventDelivered:
+		status = outbox.DeliveryDelivered
+	case resendutils.EventBounced:
+		status = outbox.DeliveryBounced
+		if event.Data.Bounce != nil {
+			detail = event.Data.Bounce.Message
+		}
+		// Soft bounces such as a full mailbox may succeed later
+		if event.HardBounce() {
+			reason = suppression.ReasonBounce
+		}
+	case resendutils.EventComplained:
+		status = outbox.DeliveryComplained
+		reason = suppression.ReasonComplaint
+	default:
+		return nil
+	}
+
+	if err := h.emailOutbox.RecordDelivery(ctx, event.Data.EmailID, status, detail); err != nil {
+		return err
+	}
+
+	if reason == "" {
+		return nil
+	}
+	for _, email := range event.Data.To {
+		if err := h.emailSuppressions.Suppress(ctx, email, reason, detail); err != nil {
+			return err
+		}
+		log.Printf("Suppressed %s after a %s", suppression.Normalize(email), reason)
+	}
+	return nil
+}
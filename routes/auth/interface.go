@@ -1,6 +1,11 @@
 package auth
 
-import "github.com/yantology/golang-starter-template/pkg/customerror"
+import (
+	"time"
+
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+	"github.com/yantology/golang-starter-template/pkg/outbox"
+)
 
 // AuthDBInterface defines the interface for authentication database operations
 type AuthDBInterface interface {
@@ -15,12 +20,185 @@ type AuthDBInterface interface {
 	// ValidateActivationToken validates if a token exists and is not expired
 	GetActivationToken(req *GetActivationTokenRequest) (string, *customerror.CustomError)
 
+	// ConsumeActivationToken deletes an unexpired token and returns it, so only one request can use it
+	ConsumeActivationToken(req *GetActivationTokenRequest) (*ActivationToken, *customerror.CustomError)
+
+	// RestoreActivationToken puts back a consumed token after a wrong guess with the attempt counted,
+	// unless maxAttempts is reached or a newer token was saved meanwhile
+	RestoreActivationToken(token *ActivationToken, maxAttempts int) *customerror.CustomError
+
 	// CreateUser creates a new user in the database
 	CreateUser(req *CreateUserRequest) *customerror.CustomError
 
 	// GetUserByEmail retrieves a user by their email
 	GetUserByEmail(email string) (*User, *customerror.CustomError)
 
+	// GetUserByID retrieves a user by their ID
+	GetUserByID(userID string) (*User, *customerror.CustomError)
+
 	// UpdateUserPassword updates a user's password
 	UpdateUserPassword(req *UpdatePasswordRequest) *customerror.CustomError
+
+	// UpdateUserProfile changes the full name and email locale of a user and returns the updated user
+	UpdateUserProfile(req *UpdateProfileRequest) (*User, *customerror.CustomError)
+
+	// UpdateUserEmail replaces the email address of a user
+	UpdateUserEmail(req *UpdateEmailRequest) *customerror.CustomError
+
+	// CreateSession stores the first refresh token of a new session family
+	CreateSession(req *CreateSessionRequest) *customerror.CustomError
+
+	// GetSessionByTokenHash retrieves the session row of a refresh token
+	GetSessionByTokenHash(tokenHash string) (*Session, *customerror.CustomError)
+
+	// RotateSession marks a refresh token as used and stores its replacement
+	RotateSession(req *RotateSessionRequest) *customerror.CustomError
+
+	// RevokeSessionFamily revokes every refresh token in a session family
+	RevokeSessionFamily(familyID string) *customerror.CustomError
+
+	// GetActiveSessions lists the sessions of a user that can still be refreshed
+	GetActiveSessions(userID string) ([]ActiveSession, *customerror.CustomError)
+
+	// RevokeUserSession revokes one session family owned by the user
+	RevokeUserSession(userID, familyID string) *customerror.CustomError
+
+	// RevokeOtherSessions revokes every session family of the user except the given one,
+	// an empty keepFamilyID revokes all of them
+	RevokeOtherSessions(userID, keepFamilyID string) *customerror.CustomError
+
+	// SaveTOTPSecret stores a new, unconfirmed TOTP secret for a user
+	SaveTOTPSecret(userID, secret string) *customerror.CustomError
+
+	// GetUserTOTP retrieves the TOTP enrollment of a user
+	GetUserTOTP(userID string) (*UserTOTP, *customerror.CustomError)
+
+	// ConfirmTOTP enables TOTP and replaces the user's recovery codes
+	ConfirmTOTP(req *ConfirmTOTPRequest) *customerror.CustomError
+
+	// UseTOTPStep records a used time step so a code cannot be replayed
+	UseTOTPStep(userID string, step int64) *customerror.CustomError
+
+	// UseRecoveryCode consumes a one-time recovery code
+	UseRecoveryCode(userID, codeHash string) *customerror.CustomError
+
+	// DisableTOTP removes the TOTP enrollment and recovery codes of a user
+	DisableTOTP(userID string) *customerror.CustomError
+
+	// SaveWebAuthnChallenge stores a pending passkey ceremony challenge
+	SaveWebAuthnChallenge(req *SaveWebAuthnChallengeRequest) *customerror.CustomError
+
+	// ConsumeWebAuthnChallenge deletes a challenge and returns it if it was still valid
+	ConsumeWebAuthnChallenge(challengeHash, challengeType string) (*WebAuthnChallenge, *customerror.CustomError)
+
+	// CreateWebAuthnCredential stores a verified passkey
+	CreateWebAuthnCredential(req *CreateWebAuthnCredentialRequest) *customerror.CustomError
+
+	// GetWebAuthnCredential retrieves a passkey by its credential ID
+	GetWebAuthnCredential(credentialID string) (*WebAuthnCredential, *customerror.CustomError)
+
+	// ListWebAuthnCredentials lists the passkeys of a user
+	ListWebAuthnCredentials(userID string) ([]WebAuthnCredential, *customerror.CustomError)
+
+	// UpdateWebAuthnSignCount stores a new signature counter, rejecting counters that did not increase
+	UpdateWebAuthnSignCount(credentialID string, signCount uint32) *customerror.CustomError
+
+	// DeleteWebAuthnCredential removes a passkey owned by the user
+	DeleteWebAuthnCredential(userID, id string) *customerror.CustomError
+
+	// GetUserByIdentity retrieves the user linked to an external identity
+	GetUserByIdentity(provider, subject string) (*User, *customerror.CustomError)
+
+	// CreateUserIdentity links an external identity to an existing user
+	CreateUserIdentity(req *CreateUserIdentityRequest) *customerror.CustomError
+
+	// CreateUserWithIdentity creates a user without password together with its external identity
+	CreateUserWithIdentity(req *CreateUserWithIdentityRequest) (*User, *customerror.CustomError)
+
+	// GetUserAccess retrieves the role and permission names of a user
+	GetUserAccess(userID string) (*UserAccess, *customerror.CustomError)
+
+	// ListRoles lists the global roles with their permissions
+	ListRoles() ([]Role, *customerror.CustomError)
+
+	// AssignUserRole gives a global role to a user, doing nothing when the user already has it
+	AssignUserRole(userID, role string) *customerror.CustomError
+
+	// RemoveUserRole takes a global role away from a user
+	RemoveUserRole(userID, role string) *customerror.CustomError
+
+	// CreateAPIKey stores a new API key and returns it
+	CreateAPIKey(req *CreateAPIKeyRequest) (*APIKey, *customerror.CustomError)
+
+	// GetAPIKey retrieves an API key by its displayable ID
+	GetAPIKey(keyID string) (*APIKey, *customerror.CustomError)
+
+	// ListAPIKeys lists the API keys of a user
+	ListAPIKeys(userID string) ([]APIKey, *customerror.CustomError)
+
+	// UpdateAPIKey changes the name or scopes of an API key owned by the user
+	UpdateAPIKey(req *UpdateAPIKeyRequest) (*APIKey, *customerror.CustomError)
+
+	// DeleteAPIKey removes an API key owned by the user
+	DeleteAPIKey(userID, keyID string) *customerror.CustomError
+
+	// TouchAPIKey records that an API key was used
+	TouchAPIKey(keyID string) *customerror.CustomError
+
+	// CreateOrganization creates an organization with the user as its owner
+	CreateOrganization(req *CreateOrganizationRequest) (*Organization, *customerror.CustomError)
+
+	// GetOrganization retrieves an organization by its ID
+	GetOrganization(organizationID string) (*Organization, *customerror.CustomError)
+
+	// ListUserOrganizations lists the memberships of a user, oldest first
+	ListUserOrganizations(userID string) ([]OrganizationMembership, *customerror.CustomError)
+
+	// GetOrganizationMembership retrieves the role and permissions of a user in an organization
+	GetOrganizationMembership(organizationID, userID string) (*OrganizationMembership, *customerror.CustomError)
+
+	// ListOrganizationMembers lists the members of an organization
+	ListOrganizationMembers(organizationID string) ([]OrganizationMember, *customerror.CustomError)
+
+	// UpdateOrganizationMemberRole gives a member another organization role
+	UpdateOrganizationMemberRole(organizationID, userID, role string) *customerror.CustomError
+
+	// RemoveOrganizationMember removes a user from an organization
+	RemoveOrganizationMember(organizationID, userID string) *customerror.CustomError
+
+	// SaveOrganizationInvitation stores or replaces the pending invitation of an email address
+	SaveOrganizationInvitation(req *SaveOrganizationInvitationRequest) *customerror.CustomError
+
+	// ListOrganizationInvitations lists the pending invitations of an organization, newest first
+	ListOrganizationInvitations(organizationID string) ([]OrganizationInvitation, *customerror.CustomError)
+
+	// GetOrganizationInvitation retrieves a pending invitation of an organization
+	GetOrganizationInvitation(organizationID, invitationID string) (*OrganizationInvitation, *customerror.CustomError)
+
+	// DeleteOrganizationInvitation revokes a pending invitation
+	DeleteOrganizationInvitation(organizationID, invitationID string) *customerror.CustomError
+
+	// AcceptOrganizationInvitation adds the user, or creates the new user, with the invited role
+	// and removes the invitation. The token hash must match the latest invitation sent.
+	AcceptOrganizationInvitation(req *AcceptOrganizationInvitationRequest) *customerror.CustomError
+
+	// ExportUserData collects everything the auth module keeps about a user
+	ExportUserData(userID string) (*UserDataExport, *customerror.CustomError)
+
+	// ScheduleUserDeletion marks the account for purging once the grace period has passed and
+	// returns the purge time. It is rejected while the user is the only owner of an organization with other members.
+	ScheduleUserDeletion(userID string, graceDays int) (time.Time, *customerror.CustomError)
+
+	// CancelUserDeletion keeps an account whose deletion was scheduled
+	CancelUserDeletion(userID string) *customerror.CustomError
+
+	// ListDueUserDeletions lists the users whose grace period has ended
+	ListDueUserDeletions() ([]string, *customerror.CustomError)
+
+	// PurgeUser deletes a user whose deletion is due with the rows referencing them. Organizations
+	// the user solely owns go to their oldest other member, or are deleted when nobody else is left.
+	PurgeUser(userID string) *customerror.CustomError
+
+	// EnqueueEmail queues a notification for the outbox worker to deliver
+	EnqueueEmail(email *outbox.Email) *customerror.CustomError
 }
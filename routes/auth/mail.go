@@ -1,72 +1,122 @@
 package auth
 
-// EmailTemplateInterface defines methods for generating email content
-type EmailTemplateInterface interface {
-	GenerateRegistrationEmail(email, token string) string
-	GeneratePasswordResetEmail(email, token string) string
+import (
+	"embed"
+	"io/fs"
+	"log"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yantology/golang-starter-template/pkg/mailtemplate"
+	"github.com/yantology/golang-starter-template/pkg/outbox"
+)
+
+// Email templates sent by the auth routes
+const (
+	emailRegistration           = "registration"
+	emailPasswordReset          = "password-reset"
+	emailLogin                  = "login"
+	emailAccountLocked          = "account-locked"
+	emailEmailChange            = "email-change"
+	emailEmailChanged           = "email-changed"
+	emailAccountDeletion        = "account-deletion"
+	emailOrganizationInvitation = "organization-invitation"
+)
+
+// embeddedTemplates are the default email templates. all: keeps the partials, whose
+// names start with an underscore.
+//
+//go:embed all:templates
+var embeddedTemplates embed.FS
+
+// NewEmailTemplates loads the embedded email templates. Files in dir replace the
+// embedded file with the same path, so a directory only needs the templates it changes.
+func NewEmailTemplates(dir, defaultLocale string) (*mailtemplate.Engine, error) {
+	templates, err := fs.Sub(embeddedTemplates, "templates")
+	if err != nil {
+		return nil, err
+	}
+	if dir != "" {
+		templates = mailtemplate.Overlay(os.DirFS(dir), templates)
+	}
+	return mailtemplate.New(templates, defaultLocale)
+}
+
+// codeEmailData is the data of the registration, password-reset and email-change templates
+type codeEmailData struct {
+	Email         string
+	Code          string
+	ExpiryMinutes int
+}
+
+// loginEmailData is the data of the login template. Link is empty when no login page is configured.
+type loginEmailData struct {
+	Email         string
+	Code          string
+	Link          string
+	ExpiryMinutes int
 }
 
-type emailTemplate struct{}
+type accountLockedEmailData struct {
+	Email         string
+	LockedMinutes int
+}
+
+type emailChangedEmailData struct {
+	Email    string
+	NewEmail string
+}
+
+type accountDeletionEmailData struct {
+	Email       string
+	ScheduledAt time.Time
+}
+
+// organizationInvitationEmailData is the data of the organization-invitation template.
+// The token is shown for pasting when Link is empty.
+type organizationInvitationEmailData struct {
+	Email            string
+	OrganizationName string
+	InviterName      string
+	Token            string
+	Link             string
+	ExpiryHours      int
+}
 
-// NewEmailTemplate creates a new email template generator
-func NewEmailTemplate() EmailTemplateInterface {
-	return &emailTemplate{}
+// emailLocale picks the locale of an email from the preference of the user, when the
+// recipient has an account, and then from the Accept-Language header of the request
+func (h *authHandler) emailLocale(c *gin.Context, user *User) string {
+	preference := ""
+	if user != nil {
+		preference = user.Locale
+	}
+	return h.emailTemplates.MatchLocale(preference, c.GetHeader("Accept-Language"))
 }
 
-// GenerateRegistrationEmail creates a simple email template for registration
-func (e *emailTemplate) GenerateRegistrationEmail(email, token string) string {
-	return `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <title>Registration Code</title>
-</head>
-<body style="font-family: Arial, sans-serif; line-height: 1.6;">
-    <div style="max-width: 600px; margin: 0 auto; padding: 20px;">
-        <h2>Registration Activation Code</h2>
-        <p>Hello,</p>
-        <p>Here is your registration activation code:</p>
-        <div style="font-size: 24px; font-weight: bold; text-align: center; 
-                    letter-spacing: 5px; margin: 20px 0; color: #0066cc;">
-            ` + token + `
-        </div>
-        <p>This code will expire in 15 minutes.</p>
-        <p>If you did not request this code, please ignore this email.</p>
-        <hr>
-        <p style="font-size: 12px; color: #666;">
-            This is an automated email, please do not reply.
-        </p>
-    </div>
-</body>
-</html>`
+// composeEmail renders a template into an outbox email to one recipient
+func (h *authHandler) composeEmail(name, locale, to string, data any) (*outbox.Email, error) {
+	rendered, err := h.emailTemplates.Render(name, locale, data)
+	if err != nil {
+		return nil, err
+	}
+	return &outbox.Email{
+		To:      []string{to},
+		Subject: rendered.Subject,
+		HTML:    rendered.HTML,
+		Text:    rendered.Text,
+	}, nil
 }
 
-// GeneratePasswordResetEmail creates a simple email template for password reset
-func (e *emailTemplate) GeneratePasswordResetEmail(email, token string) string {
-	return `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <title>Password Reset Code</title>
-</head>
-<body style="font-family: Arial, sans-serif; line-height: 1.6;">
-    <div style="max-width: 600px; margin: 0 auto; padding: 20px;">
-        <h2>Password Reset Code</h2>
-        <p>Hello,</p>
-        <p>Here is your password reset code:</p>
-        <div style="font-size: 24px; font-weight: bold; text-align: center; 
-                    letter-spacing: 5px; margin: 20px 0; color: #0066cc;">
-            ` + token + `
-        </div>
-        <p>This code will expire in 15 minutes.</p>
-        <p>If you did not request this code, please ignore this email.</p>
-        <hr>
-        <p style="font-size: 12px; color: #666;">
-            This is an automated email, please do not reply.
-        </p>
-    </div>
-</body>
-</html>`
+// queueNotification sends an email about something already done to a user, so a
+// failure is only logged
+func (h *authHandler) queueNotification(c *gin.Context, name string, user *User, data any) {
+	email, err := h.composeEmail(name, h.emailLocale(c, user), user.Email, data)
+	if err != nil {
+		log.Printf("Failed to render %s email: %v", name, err)
+		return
+	}
+	if cuserr := h.authRepository.EnqueueEmail(email); cuserr != nil {
+		log.Printf("Failed to queue %s email: %s", name, cuserr.Original())
+	}
 }
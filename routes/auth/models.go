@@ -1,11 +1,26 @@
 package auth
 
-import "time"
+import (
+	"time"
+
+	"github.com/yantology/golang-starter-template/pkg/outbox"
+)
 
 // TokenPairRequest represents the input parameters for generating token pairs
 type TokenPairRequest struct {
-	UserID string
-	Email  string
+	UserID      string
+	Email       string
+	Roles       []string
+	Permissions []string
+	// OrganizationID is the active organization, empty for none
+	OrganizationID string
+	// OrganizationRole and OrganizationPermissions are the access of the membership in the
+	// active organization, kept apart from the global Roles and Permissions
+	OrganizationRole        string
+	OrganizationPermissions []string
+	// SessionID is the session family the tokens belong to, set by startSession and
+	// kept on rotation
+	SessionID string
 }
 
 // RegistrationRequest represents the input parameters for user registration
@@ -24,6 +39,11 @@ type User struct {
 	PasswordHash string
 	CreatedAt    *time.Time
 	UpdatedAt    *time.Time
+
+	// DeletionScheduledAt is when the account will be purged, nil unless deletion was requested
+	DeletionScheduledAt *time.Time
+	// Locale is the language of emails to the user, empty follows the Accept-Language header
+	Locale string
 }
 
 // ActivationTokenRequest represents input for token activation operations
@@ -32,6 +52,10 @@ type ActivationTokenRequest struct {
 	TokenType      string
 	ActivationCode string
 	ExpiryMinutes  int
+	// ResendCooldownSeconds rejects a new token while the previous one is younger than this
+	ResendCooldownSeconds int
+	// Message carrying the token is queued in the same transaction, nil queues nothing
+	Message *outbox.Email
 }
 
 type GetActivationTokenRequest struct {
@@ -39,6 +63,16 @@ type GetActivationTokenRequest struct {
 	TokenType string
 }
 
+// ActivationToken is a token removed by ConsumeActivationToken
+type ActivationToken struct {
+	Email     string
+	TokenType string
+	TokenHash string
+	Attempts  int
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
 // CreateUserRequest represents input for creating a new user
 type CreateUserRequest struct {
 	Email        string
@@ -51,3 +85,359 @@ type UpdatePasswordRequest struct {
 	Email           string
 	NewPasswordHash string
 }
+
+// UpdateProfileRequest represents input for changing the profile of a user
+type UpdateProfileRequest struct {
+	UserID   string
+	Fullname string
+	// Locale replaces the email locale when set, an empty string clears it
+	Locale *string
+}
+
+// UpdateEmailRequest represents input for replacing the email address of a user
+type UpdateEmailRequest struct {
+	UserID   string
+	NewEmail string
+}
+
+// TokenPair holds a freshly signed access and refresh token
+type TokenPair struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// Session represents a single refresh token within a session family
+type Session struct {
+	ID        string
+	FamilyID  string
+	UserID    string
+	TokenHash string
+	ExpiresAt time.Time
+	RotatedAt *time.Time
+	RevokedAt *time.Time
+	CreatedAt *time.Time
+}
+
+// CreateSessionRequest represents input for storing the first refresh token of a session
+type CreateSessionRequest struct {
+	FamilyID  string
+	UserID    string
+	TokenHash string
+	UserAgent string
+	IPAddress string
+	ExpiresAt time.Time
+}
+
+// RotateSessionRequest represents input for replacing a refresh token within its family
+type RotateSessionRequest struct {
+	SessionID    string
+	FamilyID     string
+	UserID       string
+	NewTokenHash string
+	UserAgent    string
+	IPAddress    string
+	ExpiresAt    time.Time
+}
+
+// ActiveSession represents a session family that still holds a usable refresh token
+type ActiveSession struct {
+	FamilyID   string
+	UserAgent  string
+	IPAddress  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
+// UserTOTP represents the TOTP enrollment of a user
+type UserTOTP struct {
+	UserID       string
+	Secret       string
+	ConfirmedAt  *time.Time
+	LastUsedStep int64
+}
+
+// ConfirmTOTPRequest represents input for enabling TOTP after the first valid code
+type ConfirmTOTPRequest struct {
+	UserID             string
+	Step               int64
+	RecoveryCodeHashes []string
+}
+
+// WebAuthnCredential represents a passkey registered by a user
+type WebAuthnCredential struct {
+	ID           string
+	UserID       string
+	CredentialID string
+	PublicKey    []byte
+	SignCount    uint32
+	AAGUID       []byte
+	Transports   []string
+	Name         string
+	LastUsedAt   *time.Time
+	CreatedAt    *time.Time
+}
+
+// CreateWebAuthnCredentialRequest represents input for storing a verified passkey
+type CreateWebAuthnCredentialRequest struct {
+	UserID       string
+	CredentialID string
+	PublicKey    []byte
+	SignCount    uint32
+	AAGUID       []byte
+	Transports   []string
+	Name         string
+}
+
+// SaveWebAuthnChallengeRequest represents input for storing a pending ceremony challenge
+type SaveWebAuthnChallengeRequest struct {
+	ChallengeHash string
+	UserID        string
+	Type          string
+	ExpiresAt     time.Time
+}
+
+// WebAuthnChallenge represents a consumed ceremony challenge
+type WebAuthnChallenge struct {
+	UserID string
+	Type   string
+}
+
+// OIDCFlow holds the values of an authorization code flow between login and callback
+type OIDCFlow struct {
+	Provider     string    `json:"provider"`
+	State        string    `json:"state"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"code_verifier"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// CreateUserIdentityRequest represents input for linking an external identity to a user
+type CreateUserIdentityRequest struct {
+	UserID   string
+	Provider string
+	Subject  string
+	Email    string
+}
+
+// CreateUserWithIdentityRequest represents input for creating a user on first external login
+type CreateUserWithIdentityRequest struct {
+	Email    string
+	Fullname string
+	Provider string
+	Subject  string
+}
+
+// LoginLinkClaims is the payload of a signed login link
+type LoginLinkClaims struct {
+	Email string `json:"email"`
+	Code  string `json:"code"`
+}
+
+// UserAccess holds the role and permission names granted to a user
+type UserAccess struct {
+	Roles       []string
+	Permissions []string
+	// OrganizationRole and OrganizationPermissions are set by withMembership, empty
+	// without an organization
+	OrganizationRole        string
+	OrganizationPermissions []string
+}
+
+// Role represents a role together with the names of its permissions
+type Role struct {
+	Name        string
+	Description string
+	Permissions []string
+}
+
+// APIKey represents an API key without its secret
+type APIKey struct {
+	// ID is the displayable key ID, the part of the key before the secret
+	ID         string
+	UserID     string
+	Name       string
+	SecretHash string
+	Scopes     []string
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	CreatedAt  *time.Time
+
+	// OrganizationID is the organization the key acts for, empty for none
+	OrganizationID string
+}
+
+// CreateAPIKeyRequest represents input for storing a new API key
+type CreateAPIKeyRequest struct {
+	KeyID      string
+	UserID     string
+	Name       string
+	SecretHash string
+	Scopes     []string
+	ExpiresAt  *time.Time
+
+	OrganizationID string
+}
+
+// UpdateAPIKeyRequest represents input for changing an API key, nil fields are kept
+type UpdateAPIKeyRequest struct {
+	KeyID  string
+	UserID string
+	Name   *string
+	Scopes []string
+}
+
+// Organization represents a tenant of the application
+type Organization struct {
+	ID        string
+	Name      string
+	CreatedAt *time.Time
+}
+
+// OrganizationMembership represents the role of a user in an organization
+type OrganizationMembership struct {
+	OrganizationID   string
+	OrganizationName string
+	UserID           string
+	Role             string
+	Permissions      []string
+	CreatedAt        *time.Time
+}
+
+// OrganizationMember represents a member of an organization with their account details
+type OrganizationMember struct {
+	UserID    string
+	Email     string
+	Fullname  string
+	Role      string
+	CreatedAt *time.Time
+}
+
+// CreateOrganizationRequest represents input for creating an organization owned by a user
+type CreateOrganizationRequest struct {
+	Name    string
+	OwnerID string
+}
+
+// SaveOrganizationInvitationRequest represents input for inviting an email address to an organization
+type SaveOrganizationInvitationRequest struct {
+	OrganizationID string
+	Email          string
+	Role           string
+	InvitedBy      string
+	// TokenHash is the SHA-256 hash of the signed invitation token
+	TokenHash string
+	ExpiresAt time.Time
+	// ResendCooldownSeconds is the minimum time before the same email can be invited again
+	ResendCooldownSeconds int
+	// Message carrying the invitation is queued in the same transaction
+	Message *outbox.Email
+}
+
+// OrganizationInvitation represents a pending invitation to an organization
+type OrganizationInvitation struct {
+	ID             string
+	OrganizationID string
+	Email          string
+	Role           string
+	// InvitedBy is the email of the inviting member, empty once that account is deleted
+	InvitedBy string
+	ExpiresAt time.Time
+	SentAt    time.Time
+	CreatedAt *time.Time
+}
+
+// AcceptOrganizationInvitationRequest represents input for joining an organization with an invitation
+type AcceptOrganizationInvitationRequest struct {
+	OrganizationID string
+	Email          string
+	TokenHash      string
+	// UserID is the existing account joining the organization
+	UserID string
+	// NewUser creates the account of an email address that has none, replacing UserID
+	NewUser *CreateUserRequest
+}
+
+// InvitationClaims is the payload of a signed organization invitation
+type InvitationClaims struct {
+	OrganizationID string `json:"organization_id"`
+	Email          string `json:"email"`
+	// Nonce makes every token unique, so a resent invitation invalidates the previous link
+	Nonce string `json:"nonce"`
+}
+
+// UserDataExport is everything the auth module keeps about a user, as written to the
+// personal data archive. Password hashes, key secrets and TOTP secrets are left out.
+type UserDataExport struct {
+	Account       UserDataAccount      `json:"account"`
+	Sessions      []UserDataSession    `json:"sessions"`
+	Roles         []string             `json:"roles"`
+	Organizations []UserDataMembership `json:"organizations"`
+	Identities    []UserDataIdentity   `json:"identities"`
+	Passkeys      []UserDataPasskey    `json:"passkeys"`
+	APIKeys       []UserDataAPIKey     `json:"api_keys"`
+	TwoFactor     UserDataTwoFactor    `json:"two_factor"`
+}
+
+// UserDataAccount is the users row of an export
+type UserDataAccount struct {
+	ID                  string     `json:"id"`
+	Email               string     `json:"email"`
+	Fullname            string     `json:"fullname"`
+	HasPassword         bool       `json:"has_password"`
+	CreatedAt           *time.Time `json:"created_at"`
+	UpdatedAt           *time.Time `json:"updated_at"`
+	DeletionScheduledAt *time.Time `json:"deletion_scheduled_at"`
+	Locale              string     `json:"locale"`
+}
+
+// UserDataSession is one refresh token of an export, including rotated and revoked ones
+type UserDataSession struct {
+	FamilyID  string     `json:"family_id"`
+	UserAgent string     `json:"user_agent"`
+	IPAddress string     `json:"ip_address"`
+	CreatedAt *time.Time `json:"created_at"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	RotatedAt *time.Time `json:"rotated_at"`
+	RevokedAt *time.Time `json:"revoked_at"`
+}
+
+// UserDataMembership is an organization membership of an export
+type UserDataMembership struct {
+	OrganizationID   string     `json:"organization_id"`
+	OrganizationName string     `json:"organization_name"`
+	Role             string     `json:"role"`
+	JoinedAt         *time.Time `json:"joined_at"`
+}
+
+// UserDataIdentity is an external login of an export
+type UserDataIdentity struct {
+	Provider  string     `json:"provider"`
+	Subject   string     `json:"subject"`
+	Email     string     `json:"email"`
+	CreatedAt *time.Time `json:"created_at"`
+}
+
+// UserDataPasskey is a passkey of an export
+type UserDataPasskey struct {
+	Name       string     `json:"name"`
+	Transports []string   `json:"transports"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+// UserDataAPIKey is an API key of an export
+type UserDataAPIKey struct {
+	ID             string     `json:"id"`
+	Name           string     `json:"name"`
+	Scopes         []string   `json:"scopes"`
+	OrganizationID string     `json:"organization_id,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at"`
+	LastUsedAt     *time.Time `json:"last_used_at"`
+	CreatedAt      *time.Time `json:"created_at"`
+}
+
+// UserDataTwoFactor is the TOTP enrollment of an export
+type UserDataTwoFactor struct {
+	Enabled     bool       `json:"enabled"`
+	ConfirmedAt *time.Time `json:"confirmed_at"`
+}
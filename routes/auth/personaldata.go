@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+	"github.com/yantology/golang-starter-template/pkg/personaldata"
+)
+
+// Verify interface implementation
+var (
+	_ personaldata.Provider = (*PersonalDataProvider)(nil)
+	_ personaldata.Schedule = (*PersonalDataProvider)(nil)
+)
+
+// PersonalDataProvider exports and purges the accounts, sessions, credentials and memberships
+// kept by the auth module. The deletion schedule lives on the users row, so it also tells
+// the purger which accounts are due.
+type PersonalDataProvider struct {
+	authRepository *AuthRepository
+	loginLockout   *LoginLockout
+}
+
+func NewPersonalDataProvider(authRepository *AuthRepository, loginLockout *LoginLockout) *PersonalDataProvider {
+	return &PersonalDataProvider{
+		authRepository: authRepository,
+		loginLockout:   loginLockout,
+	}
+}
+
+func (p *PersonalDataProvider) Name() string {
+	return "auth"
+}
+
+func (p *PersonalDataProvider) Export(ctx context.Context, userID string) (any, error) {
+	export, cuserr := p.authRepository.ExportUserData(userID)
+	if cuserr != nil {
+		return nil, personalDataError(cuserr)
+	}
+	return export, nil
+}
+
+// Purge deletes the account together with everything referencing it. It must run last,
+// which the registry does as long as the auth provider is registered first.
+func (p *PersonalDataProvider) Purge(ctx context.Context, userID string) error {
+	user, cuserr := p.authRepository.GetUserByID(userID)
+	if cuserr != nil {
+		// A purge that failed after deleting the account is retried with nothing left to do
+		if cuserr.Code() == http.StatusNotFound {
+			return nil
+		}
+		return personalDataError(cuserr)
+	}
+
+	if cuserr := p.authRepository.PurgeUser(userID); cuserr != nil {
+		return personalDataError(cuserr)
+	}
+
+	// Lockout counters are keyed by email and user ID and may be kept in memory, so they are
+	// reset through the tracker. They expire on their own, so a failure is only logged.
+	if err := p.loginLockout.Email.Reset(ctx, lockoutEmailKey(user.Email)); err != nil {
+		log.Println("Failed to reset login lockout of purged user:", err)
+	}
+	if err := p.loginLockout.MFA.Reset(ctx, user.ID); err != nil {
+		log.Println("Failed to reset MFA lockout of purged user:", err)
+	}
+	return nil
+}
+
+func (p *PersonalDataProvider) DueDeletions(ctx context.Context) ([]string, error) {
+	userIDs, cuserr := p.authRepository.ListDueUserDeletions()
+	if cuserr != nil {
+		return nil, personalDataError(cuserr)
+	}
+	return userIDs, nil
+}
+
+// personalDataError converts a repository error for the personaldata package
+func personalDataError(cuserr *customerror.CustomError) error {
+	if cuserr.Original() != "" {
+		return fmt.Errorf("%s: %s", cuserr.Message(), cuserr.Original())
+	}
+	return errors.New(cuserr.Message())
+}
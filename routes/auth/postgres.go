@@ -1,12 +1,17 @@
 package auth
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/yantology/golang-starter-template/pkg/customerror"
+	"github.com/yantology/golang-starter-template/pkg/outbox"
 )
 
 // Verify interface implementation
@@ -58,17 +63,46 @@ func (ap *authPostgres) CheckIsExistingEmail(email string) *customerror.CustomEr
 }
 
 func (ap *authPostgres) SaveActivationToken(req *ActivationTokenRequest) *customerror.CustomError {
+	tx, err := ap.db.Begin()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	defer tx.Rollback()
+
+	// The cooldown is checked in the upsert itself so concurrent requests cannot both send a code
 	query := `INSERT INTO activation_tokens (email, token_hash, type, expires_at) 
 			  VALUES ($1, $2, $3, NOW() + ($4 || ' minutes')::interval)
 			  ON CONFLICT (email, type) DO UPDATE
 			  SET token_hash = $2,
-				  expires_at = NOW() + ($4 || ' minutes')::interval`
+				  expires_at = NOW() + ($4 || ' minutes')::interval,
+				  attempts = 0,
+				  created_at = NOW()
+			  WHERE activation_tokens.created_at IS NULL
+			     OR activation_tokens.created_at <= NOW() - ($5 || ' seconds')::interval`
 
-	_, err := ap.db.Exec(query, req.Email, req.ActivationCode, req.TokenType, req.ExpiryMinutes)
+	result, err := tx.Exec(query, req.Email, req.ActivationCode, req.TokenType, req.ExpiryMinutes, req.ResendCooldownSeconds)
 	if err != nil {
 		log.Println("Error saving activation token:", err)
 		return customerror.NewPostgresError(err)
 	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "token was requested too recently", http.StatusTooManyRequests)
+	}
+
+	if req.Message != nil {
+		if err := outbox.Enqueue(context.Background(), tx, req.Message); err != nil {
+			return customerror.NewPostgresError(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return customerror.NewPostgresError(err)
+	}
 	return nil
 }
 
@@ -88,6 +122,39 @@ func (ap *authPostgres) GetActivationToken(req *GetActivationTokenRequest) (stri
 	return storedHash, nil
 }
 
+func (ap *authPostgres) ConsumeActivationToken(req *GetActivationTokenRequest) (*ActivationToken, *customerror.CustomError) {
+	token := ActivationToken{Email: req.Email, TokenType: req.TokenType}
+	err := ap.db.QueryRow(`
+		DELETE FROM activation_tokens
+		WHERE email = $1 AND type = $2 AND expires_at > NOW()
+		RETURNING token_hash, attempts, expires_at, created_at`,
+		req.Email, req.TokenType).Scan(&token.TokenHash, &token.Attempts, &token.ExpiresAt, &token.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, customerror.NewCustomError(err, "token not found or expired", http.StatusNotFound)
+	}
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return &token, nil
+}
+
+func (ap *authPostgres) RestoreActivationToken(token *ActivationToken, maxAttempts int) *customerror.CustomError {
+	// Too many wrong guesses leave the token deleted
+	if token.Attempts+1 >= maxAttempts {
+		return nil
+	}
+
+	_, err := ap.db.Exec(`
+		INSERT INTO activation_tokens (email, token_hash, type, expires_at, attempts, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (email, type) DO NOTHING`,
+		token.Email, token.TokenHash, token.TokenType, token.ExpiresAt, token.Attempts+1, token.CreatedAt)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
 func (ap *authPostgres) CreateUser(req *CreateUserRequest) *customerror.CustomError {
 	tx, err := ap.db.Begin()
 	if err != nil {
@@ -117,10 +184,10 @@ func (ap *authPostgres) CreateUser(req *CreateUserRequest) *customerror.CustomEr
 func (ap *authPostgres) GetUserByEmail(email string) (*User, *customerror.CustomError) {
 	user := &User{}
 	err := ap.db.QueryRow(`
-		SELECT id, email, fullname, password_hash, created_at, updated_at 
+		SELECT id, email, fullname, password_hash, created_at, updated_at, deletion_scheduled_at, locale
 		FROM users WHERE email = $1`,
 		email).Scan(&user.ID, &user.Email, &user.Fullname, &user.PasswordHash,
-		&user.CreatedAt, &user.UpdatedAt)
+		&user.CreatedAt, &user.UpdatedAt, &user.DeletionScheduledAt, &user.Locale)
 
 	if err == sql.ErrNoRows {
 		fmt.Println("User not found:", email)
@@ -133,6 +200,23 @@ func (ap *authPostgres) GetUserByEmail(email string) (*User, *customerror.Custom
 	return user, nil
 }
 
+func (ap *authPostgres) GetUserByID(userID string) (*User, *customerror.CustomError) {
+	user := &User{}
+	err := ap.db.QueryRow(`
+		SELECT id, email, fullname, password_hash, created_at, updated_at, deletion_scheduled_at, locale
+		FROM users WHERE id = $1`,
+		userID).Scan(&user.ID, &user.Email, &user.Fullname, &user.PasswordHash,
+		&user.CreatedAt, &user.UpdatedAt, &user.DeletionScheduledAt, &user.Locale)
+
+	if err == sql.ErrNoRows {
+		return nil, customerror.NewCustomError(err, "user not found", http.StatusNotFound)
+	}
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return user, nil
+}
+
 func (ap *authPostgres) UpdateUserPassword(req *UpdatePasswordRequest) *customerror.CustomError {
 	tx, err := ap.db.Begin()
 	if err != nil {
@@ -170,3 +254,1518 @@ func (ap *authPostgres) UpdateUserPassword(req *UpdatePasswordRequest) *customer
 	}
 	return nil
 }
+
+func (ap *authPostgres) UpdateUserProfile(req *UpdateProfileRequest) (*User, *customerror.CustomError) {
+	user := &User{}
+	err := ap.db.QueryRow(`
+		UPDATE users
+		SET fullname = $1, locale = COALESCE($3, locale), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2
+		RETURNING id, email, fullname, password_hash, created_at, updated_at, deletion_scheduled_at, locale`,
+		req.Fullname, req.UserID, req.Locale).Scan(&user.ID, &user.Email, &user.Fullname, &user.PasswordHash,
+		&user.CreatedAt, &user.UpdatedAt, &user.DeletionScheduledAt, &user.Locale)
+	if err == sql.ErrNoRows {
+		return nil, customerror.NewCustomError(err, "user not found", http.StatusNotFound)
+	}
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return user, nil
+}
+
+func (ap *authPostgres) UpdateUserEmail(req *UpdateEmailRequest) *customerror.CustomError {
+	// The unique index on email rejects addresses taken since the code was sent
+	result, err := ap.db.Exec(`
+		UPDATE users
+		SET email = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2`,
+		req.NewEmail, req.UserID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "user not found", http.StatusNotFound)
+	}
+	return nil
+}
+
+func (ap *authPostgres) CreateSession(req *CreateSessionRequest) *customerror.CustomError {
+	_, err := ap.db.Exec(`
+		INSERT INTO sessions (family_id, user_id, token_hash, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		req.FamilyID, req.UserID, req.TokenHash, req.UserAgent, req.IPAddress, req.ExpiresAt)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+func (ap *authPostgres) GetSessionByTokenHash(tokenHash string) (*Session, *customerror.CustomError) {
+	session := &Session{}
+	err := ap.db.QueryRow(`
+		SELECT id, family_id, user_id, token_hash, expires_at, rotated_at, revoked_at, created_at
+		FROM sessions WHERE token_hash = $1`,
+		tokenHash).Scan(&session.ID, &session.FamilyID, &session.UserID, &session.TokenHash,
+		&session.ExpiresAt, &session.RotatedAt, &session.RevokedAt, &session.CreatedAt)
+
+	if err == sql.ErrNoRows {
+		return nil, customerror.NewCustomError(err, "session not found", http.StatusNotFound)
+	}
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return session, nil
+}
+
+func (ap *authPostgres) RotateSession(req *RotateSessionRequest) *customerror.CustomError {
+	tx, err := ap.db.Begin()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	defer tx.Rollback()
+
+	// Only an unused, unrevoked token may be rotated
+	result, err := tx.Exec(`
+		UPDATE sessions
+		SET rotated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND rotated_at IS NULL AND revoked_at IS NULL`,
+		req.SessionID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "session already rotated", http.StatusConflict)
+	}
+
+	// Store the replacement token in the same family
+	_, err = tx.Exec(`
+		INSERT INTO sessions (family_id, user_id, token_hash, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		req.FamilyID, req.UserID, req.NewTokenHash, req.UserAgent, req.IPAddress, req.ExpiresAt)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+func (ap *authPostgres) RevokeSessionFamily(familyID string) *customerror.CustomError {
+	_, err := ap.db.Exec(`
+		UPDATE sessions
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE family_id = $1 AND revoked_at IS NULL`,
+		familyID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+func (ap *authPostgres) GetActiveSessions(userID string) ([]ActiveSession, *customerror.CustomError) {
+	// The newest token of a family carries the latest client info and last refresh time,
+	// while the oldest token marks when the user logged in
+	rows, err := ap.db.Query(`
+		SELECT s.family_id, COALESCE(s.user_agent, ''), COALESCE(s.ip_address, ''), f.started_at, s.created_at
+		FROM sessions s
+		JOIN (
+			SELECT family_id, MIN(created_at) AS started_at
+			FROM sessions WHERE user_id = $1
+			GROUP BY family_id
+		) f ON f.family_id = s.family_id
+		WHERE s.user_id = $1
+		  AND s.rotated_at IS NULL
+		  AND s.revoked_at IS NULL
+		  AND s.expires_at > NOW()
+		ORDER BY s.created_at DESC`,
+		userID)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	defer rows.Close()
+
+	sessions := []ActiveSession{}
+	for rows.Next() {
+		var session ActiveSession
+		if err := rows.Scan(&session.FamilyID, &session.UserAgent, &session.IPAddress,
+			&session.CreatedAt, &session.LastUsedAt); err != nil {
+			return nil, customerror.NewPostgresError(err)
+		}
+		sessions = append(sessions, session)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return sessions, nil
+}
+
+func (ap *authPostgres) RevokeUserSession(userID, familyID string) *customerror.CustomError {
+	result, err := ap.db.Exec(`
+		UPDATE sessions
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND family_id = $2 AND revoked_at IS NULL`,
+		userID, familyID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "session not found", http.StatusNotFound)
+	}
+	return nil
+}
+
+func (ap *authPostgres) RevokeOtherSessions(userID, keepFamilyID string) *customerror.CustomError {
+	_, err := ap.db.Exec(`
+		UPDATE sessions
+		SET revoked_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND family_id <> $2 AND revoked_at IS NULL`,
+		userID, keepFamilyID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+func (ap *authPostgres) SaveTOTPSecret(userID, secret string) *customerror.CustomError {
+	// A pending enrollment may be restarted, a confirmed one must be disabled first
+	result, err := ap.db.Exec(`
+		INSERT INTO user_totp (user_id, secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret = $2, last_used_step = 0, created_at = CURRENT_TIMESTAMP
+		WHERE user_totp.confirmed_at IS NULL`,
+		userID, secret)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "two-factor authentication already enabled", http.StatusConflict)
+	}
+	return nil
+}
+
+func (ap *authPostgres) GetUserTOTP(userID string) (*UserTOTP, *customerror.CustomError) {
+	userTOTP := &UserTOTP{}
+	err := ap.db.QueryRow(`
+		SELECT user_id, secret, confirmed_at, last_used_step
+		FROM user_totp WHERE user_id = $1`,
+		userID).Scan(&userTOTP.UserID, &userTOTP.Secret, &userTOTP.ConfirmedAt, &userTOTP.LastUsedStep)
+
+	if err == sql.ErrNoRows {
+		return nil, customerror.NewCustomError(err, "two-factor authentication not enrolled", http.StatusNotFound)
+	}
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return userTOTP, nil
+}
+
+func (ap *authPostgres) ConfirmTOTP(req *ConfirmTOTPRequest) *customerror.CustomError {
+	tx, err := ap.db.Begin()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE user_totp
+		SET confirmed_at = CURRENT_TIMESTAMP, last_used_step = $2
+		WHERE user_id = $1 AND confirmed_at IS NULL`,
+		req.UserID, req.Step)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "no pending two-factor enrollment", http.StatusConflict)
+	}
+
+	// Replace any previous recovery codes
+	_, err = tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, req.UserID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	for _, codeHash := range req.RecoveryCodeHashes {
+		_, err = tx.Exec(`INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)`,
+			req.UserID, codeHash)
+		if err != nil {
+			return customerror.NewPostgresError(err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+func (ap *authPostgres) UseTOTPStep(userID string, step int64) *customerror.CustomError {
+	// Only a step newer than the last accepted one may be used
+	result, err := ap.db.Exec(`
+		UPDATE user_totp
+		SET last_used_step = $2
+		WHERE user_id = $1 AND last_used_step < $2`,
+		userID, step)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "code already used", http.StatusUnauthorized)
+	}
+	return nil
+}
+
+func (ap *authPostgres) UseRecoveryCode(userID, codeHash string) *customerror.CustomError {
+	result, err := ap.db.Exec(`
+		UPDATE mfa_recovery_codes
+		SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`,
+		userID, codeHash)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "invalid recovery code", http.StatusUnauthorized)
+	}
+	return nil
+}
+
+func (ap *authPostgres) DisableTOTP(userID string) *customerror.CustomError {
+	tx, err := ap.db.Begin()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	_, err = tx.Exec(`DELETE FROM user_totp WHERE user_id = $1`, userID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+func (ap *authPostgres) SaveWebAuthnChallenge(req *SaveWebAuthnChallengeRequest) *customerror.CustomError {
+	// Abandoned ceremonies are cleaned up whenever a new one starts
+	_, err := ap.db.Exec(`DELETE FROM webauthn_challenges WHERE expires_at <= NOW()`)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	_, err = ap.db.Exec(`
+		INSERT INTO webauthn_challenges (challenge_hash, user_id, type, expires_at)
+		VALUES ($1, NULLIF($2, '')::int, $3, $4)`,
+		req.ChallengeHash, req.UserID, req.Type, req.ExpiresAt)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+func (ap *authPostgres) ConsumeWebAuthnChallenge(challengeHash, challengeType string) (*WebAuthnChallenge, *customerror.CustomError) {
+	// Deleting while reading guarantees a challenge is used at most once
+	challenge := &WebAuthnChallenge{Type: challengeType}
+	var valid bool
+	err := ap.db.QueryRow(`
+		DELETE FROM webauthn_challenges
+		WHERE challenge_hash = $1 AND type = $2
+		RETURNING COALESCE(user_id::text, ''), expires_at > NOW()`,
+		challengeHash, challengeType).Scan(&challenge.UserID, &valid)
+	if err == sql.ErrNoRows || (err == nil && !valid) {
+		return nil, customerror.NewCustomError(err, "challenge not found or expired", http.StatusNotFound)
+	}
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return challenge, nil
+}
+
+func (ap *authPostgres) CreateWebAuthnCredential(req *CreateWebAuthnCredentialRequest) *customerror.CustomError {
+	_, err := ap.db.Exec(`
+		INSERT INTO webauthn_credentials (user_id, credential_id, public_key, sign_count, aaguid, transports, name)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		req.UserID, req.CredentialID, req.PublicKey, int64(req.SignCount), req.AAGUID,
+		strings.Join(req.Transports, ","), req.Name)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+func (ap *authPostgres) GetWebAuthnCredential(credentialID string) (*WebAuthnCredential, *customerror.CustomError) {
+	credential, err := scanWebAuthnCredential(ap.db.QueryRow(`
+		SELECT id, user_id, credential_id, public_key, sign_count, aaguid, transports, name, last_used_at, created_at
+		FROM webauthn_credentials WHERE credential_id = $1`,
+		credentialID))
+	if err == sql.ErrNoRows {
+		return nil, customerror.NewCustomError(err, "credential not found", http.StatusNotFound)
+	}
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return credential, nil
+}
+
+func (ap *authPostgres) ListWebAuthnCredentials(userID string) ([]WebAuthnCredential, *customerror.CustomError) {
+	rows, err := ap.db.Query(`
+		SELECT id, user_id, credential_id, public_key, sign_count, aaguid, transports, name, last_used_at, created_at
+		FROM webauthn_credentials WHERE user_id = $1
+		ORDER BY created_at DESC`,
+		userID)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	defer rows.Close()
+
+	credentials := []WebAuthnCredential{}
+	for rows.Next() {
+		credential, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return nil, customerror.NewPostgresError(err)
+		}
+		credentials = append(credentials, *credential)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return credentials, nil
+}
+
+func (ap *authPostgres) UpdateWebAuthnSignCount(credentialID string, signCount uint32) *customerror.CustomError {
+	// The counter check is repeated here so concurrent logins with a cloned key cannot both pass
+	result, err := ap.db.Exec(`
+		UPDATE webauthn_credentials
+		SET sign_count = $2, last_used_at = CURRENT_TIMESTAMP
+		WHERE credential_id = $1 AND (sign_count < $2 OR (sign_count = 0 AND $2 = 0))`,
+		credentialID, int64(signCount))
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "signature counter did not increase", http.StatusConflict)
+	}
+	return nil
+}
+
+func (ap *authPostgres) DeleteWebAuthnCredential(userID, id string) *customerror.CustomError {
+	result, err := ap.db.Exec(`DELETE FROM webauthn_credentials WHERE user_id = $1 AND id = $2`,
+		userID, id)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "credential not found", http.StatusNotFound)
+	}
+	return nil
+}
+
+func (ap *authPostgres) GetUserByIdentity(provider, subject string) (*User, *customerror.CustomError) {
+	user := &User{}
+	err := ap.db.QueryRow(`
+		SELECT u.id, u.email, u.fullname, u.password_hash, u.created_at, u.updated_at, u.deletion_scheduled_at, u.locale
+		FROM user_identities i
+		JOIN users u ON u.id = i.user_id
+		WHERE i.provider = $1 AND i.subject = $2`,
+		provider, subject).Scan(&user.ID, &user.Email, &user.Fullname, &user.PasswordHash,
+		&user.CreatedAt, &user.UpdatedAt, &user.DeletionScheduledAt, &user.Locale)
+
+	if err == sql.ErrNoRows {
+		return nil, customerror.NewCustomError(err, "identity not found", http.StatusNotFound)
+	}
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return user, nil
+}
+
+func (ap *authPostgres) CreateUserIdentity(req *CreateUserIdentityRequest) *customerror.CustomError {
+	_, err := ap.db.Exec(`
+		INSERT INTO user_identities (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, $4)`,
+		req.UserID, req.Provider, req.Subject, req.Email)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+func (ap *authPostgres) CreateUserWithIdentity(req *CreateUserWithIdentityRequest) (*User, *customerror.CustomError) {
+	tx, err := ap.db.Begin()
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	defer tx.Rollback()
+
+	// An empty password hash never matches, so the account can only log in through the provider
+	// until a password is set with forget-password
+	user := &User{}
+	err = tx.QueryRow(`
+		INSERT INTO users (email, fullname, password_hash)
+		VALUES ($1, $2, '')
+		RETURNING id, email, fullname, password_hash, created_at, updated_at, deletion_scheduled_at, locale`,
+		req.Email, req.Fullname).Scan(&user.ID, &user.Email, &user.Fullname, &user.PasswordHash,
+		&user.CreatedAt, &user.UpdatedAt, &user.DeletionScheduledAt, &user.Locale)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO user_identities (user_id, provider, subject, email)
+		VALUES ($1, $2, $3, $4)`,
+		user.ID, req.Provider, req.Subject, req.Email)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return user, nil
+}
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanWebAuthnCredential(row rowScanner) (*WebAuthnCredential, error) {
+	credential := &WebAuthnCredential{}
+	var signCount int64
+	var transports string
+	err := row.Scan(&credential.ID, &credential.UserID, &credential.CredentialID, &credential.PublicKey,
+		&signCount, &credential.AAGUID, &transports, &credential.Name, &credential.LastUsedAt, &credential.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	credential.SignCount = uint32(signCount)
+	credential.Transports = []string{}
+	if transports != "" {
+		credential.Transports = strings.Split(transports, ",")
+	}
+	return credential, nil
+}
+
+func (ap *authPostgres) GetUserAccess(userID string) (*UserAccess, *customerror.CustomError) {
+	access := &UserAccess{}
+
+	roles, err := queryStrings(ap.db, `
+		SELECT r.name
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id
+		WHERE ur.user_id = $1
+		ORDER BY r.name`,
+		userID)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	access.Roles = roles
+
+	permissions, err := queryStrings(ap.db, `
+		SELECT DISTINCT p.name
+		FROM user_roles ur
+		JOIN role_permissions rp ON rp.role_id = ur.role_id
+		JOIN permissions p ON p.id = rp.permission_id
+		WHERE ur.user_id = $1
+		ORDER BY p.name`,
+		userID)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	access.Permissions = permissions
+
+	return access, nil
+}
+
+func (ap *authPostgres) ListRoles() ([]Role, *customerror.CustomError) {
+	rows, err := ap.db.Query(`
+		SELECT r.name, r.description,
+			COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}')
+		FROM roles r
+		LEFT JOIN role_permissions rp ON rp.role_id = r.id
+		LEFT JOIN permissions p ON p.id = rp.permission_id
+		WHERE NOT r.organization_role
+		GROUP BY r.id
+		ORDER BY r.name`)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	defer rows.Close()
+
+	roles := []Role{}
+	for rows.Next() {
+		var role Role
+		if err := rows.Scan(&role.Name, &role.Description, pq.Array(&role.Permissions)); err != nil {
+			return nil, customerror.NewPostgresError(err)
+		}
+		roles = append(roles, role)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return roles, nil
+}
+
+func (ap *authPostgres) AssignUserRole(userID, role string) *customerror.CustomError {
+	var roleID int
+	err := ap.db.QueryRow(`SELECT id FROM roles WHERE name = $1 AND NOT organization_role`, role).Scan(&roleID)
+	if err == sql.ErrNoRows {
+		return customerror.NewCustomError(err, "role not found", http.StatusNotFound)
+	}
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	_, err = ap.db.Exec(`
+		INSERT INTO user_roles (user_id, role_id)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id, role_id) DO NOTHING`,
+		userID, roleID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+func (ap *authPostgres) RemoveUserRole(userID, role string) *customerror.CustomError {
+	result, err := ap.db.Exec(`
+		DELETE FROM user_roles
+		WHERE user_id = $1 AND role_id = (SELECT id FROM roles WHERE name = $2 AND NOT organization_role)`,
+		userID, role)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "role assignment not found", http.StatusNotFound)
+	}
+	return nil
+}
+
+func (ap *authPostgres) CreateAPIKey(req *CreateAPIKeyRequest) (*APIKey, *customerror.CustomError) {
+	row := ap.db.QueryRow(`
+		INSERT INTO api_keys (key_id, user_id, name, secret_hash, scopes, expires_at, organization_id)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::int)
+		RETURNING `+apiKeyColumns,
+		req.KeyID, req.UserID, req.Name, req.SecretHash, pq.Array(req.Scopes), req.ExpiresAt, req.OrganizationID)
+
+	apiKey, err := scanAPIKey(row)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return apiKey, nil
+}
+
+func (ap *authPostgres) GetAPIKey(keyID string) (*APIKey, *customerror.CustomError) {
+	row := ap.db.QueryRow(`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_id = $1`, keyID)
+
+	apiKey, err := scanAPIKey(row)
+	if err == sql.ErrNoRows {
+		return nil, customerror.NewCustomError(err, "api key not found", http.StatusNotFound)
+	}
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return apiKey, nil
+}
+
+func (ap *authPostgres) ListAPIKeys(userID string) ([]APIKey, *customerror.CustomError) {
+	rows, err := ap.db.Query(`
+		SELECT `+apiKeyColumns+`
+		FROM api_keys WHERE user_id = $1
+		ORDER BY created_at DESC`,
+		userID)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	defer rows.Close()
+
+	apiKeys := []APIKey{}
+	for rows.Next() {
+		apiKey, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, customerror.NewPostgresError(err)
+		}
+		apiKeys = append(apiKeys, *apiKey)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return apiKeys, nil
+}
+
+func (ap *authPostgres) UpdateAPIKey(req *UpdateAPIKeyRequest) (*APIKey, *customerror.CustomError) {
+	var scopes interface{}
+	if req.Scopes != nil {
+		scopes = pq.Array(req.Scopes)
+	}
+
+	row := ap.db.QueryRow(`
+		UPDATE api_keys
+		SET name = COALESCE($3, name), scopes = COALESCE($4, scopes)
+		WHERE key_id = $1 AND user_id = $2
+		RETURNING `+apiKeyColumns,
+		req.KeyID, req.UserID, req.Name, scopes)
+
+	apiKey, err := scanAPIKey(row)
+	if err == sql.ErrNoRows {
+		return nil, customerror.NewCustomError(err, "api key not found", http.StatusNotFound)
+	}
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return apiKey, nil
+}
+
+func (ap *authPostgres) DeleteAPIKey(userID, keyID string) *customerror.CustomError {
+	result, err := ap.db.Exec(`DELETE FROM api_keys WHERE user_id = $1 AND key_id = $2`,
+		userID, keyID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "api key not found", http.StatusNotFound)
+	}
+	return nil
+}
+
+func (ap *authPostgres) TouchAPIKey(keyID string) *customerror.CustomError {
+	// Writes are limited to one per minute so a busy integration does not update the row on every request
+	_, err := ap.db.Exec(`
+		UPDATE api_keys SET last_used_at = CURRENT_TIMESTAMP
+		WHERE key_id = $1 AND (last_used_at IS NULL OR last_used_at < CURRENT_TIMESTAMP - INTERVAL '1 minute')`,
+		keyID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+func (ap *authPostgres) CreateOrganization(req *CreateOrganizationRequest) (*Organization, *customerror.CustomError) {
+	tx, err := ap.db.Begin()
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	defer tx.Rollback()
+
+	organization := &Organization{}
+	err = tx.QueryRow(`
+		INSERT INTO organizations (name) VALUES ($1)
+		RETURNING id, name, created_at`,
+		req.Name).Scan(&organization.ID, &organization.Name, &organization.CreatedAt)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+
+	roleID, cuserr := organizationRoleID(tx, OrganizationRoleOwner)
+	if cuserr != nil {
+		return nil, cuserr
+	}
+	_, err = tx.Exec(`
+		INSERT INTO organization_members (organization_id, user_id, role_id)
+		VALUES ($1, $2, $3)`,
+		organization.ID, req.OwnerID, roleID)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return organization, nil
+}
+
+func (ap *authPostgres) GetOrganization(organizationID string) (*Organization, *customerror.CustomError) {
+	organization := &Organization{}
+	err := ap.db.QueryRow(`SELECT id, name, created_at FROM organizations WHERE id = $1`,
+		organizationID).Scan(&organization.ID, &organization.Name, &organization.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, customerror.NewCustomError(err, "organization not found", http.StatusNotFound)
+	}
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return organization, nil
+}
+
+// organizationMembershipQuery selects the columns read by scanOrganizationMembership
+// and is completed with a WHERE clause and organizationMembershipGroup
+const organizationMembershipQuery = `
+	SELECT o.id, o.name, om.user_id, r.name,
+		COALESCE(array_agg(p.name ORDER BY p.name) FILTER (WHERE p.name IS NOT NULL), '{}'),
+		om.created_at
+	FROM organization_members om
+	JOIN organizations o ON o.id = om.organization_id
+	JOIN roles r ON r.id = om.role_id
+	LEFT JOIN role_permissions rp ON rp.role_id = r.id
+	LEFT JOIN permissions p ON p.id = rp.permission_id`
+
+const organizationMembershipGroup = `
+	GROUP BY o.id, om.user_id, r.name, om.created_at
+	ORDER BY om.created_at, o.id`
+
+func (ap *authPostgres) ListUserOrganizations(userID string) ([]OrganizationMembership, *customerror.CustomError) {
+	rows, err := ap.db.Query(organizationMembershipQuery+`
+		WHERE om.user_id = $1`+organizationMembershipGroup,
+		userID)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	defer rows.Close()
+
+	memberships := []OrganizationMembership{}
+	for rows.Next() {
+		membership, err := scanOrganizationMembership(rows)
+		if err != nil {
+			return nil, customerror.NewPostgresError(err)
+		}
+		memberships = append(memberships, *membership)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return memberships, nil
+}
+
+func (ap *authPostgres) GetOrganizationMembership(organizationID, userID string) (*OrganizationMembership, *customerror.CustomError) {
+	row := ap.db.QueryRow(organizationMembershipQuery+`
+		WHERE om.organization_id = $1 AND om.user_id = $2`+organizationMembershipGroup,
+		organizationID, userID)
+
+	membership, err := scanOrganizationMembership(row)
+	if err == sql.ErrNoRows {
+		return nil, customerror.NewCustomError(err, "organization member not found", http.StatusNotFound)
+	}
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return membership, nil
+}
+
+func scanOrganizationMembership(row rowScanner) (*OrganizationMembership, error) {
+	membership := &OrganizationMembership{}
+	err := row.Scan(&membership.OrganizationID, &membership.OrganizationName, &membership.UserID,
+		&membership.Role, pq.Array(&membership.Permissions), &membership.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if membership.Permissions == nil {
+		membership.Permissions = []string{}
+	}
+	return membership, nil
+}
+
+func (ap *authPostgres) ListOrganizationMembers(organizationID string) ([]OrganizationMember, *customerror.CustomError) {
+	rows, err := ap.db.Query(`
+		SELECT u.id, u.email, u.fullname, r.name, om.created_at
+		FROM organization_members om
+		JOIN users u ON u.id = om.user_id
+		JOIN roles r ON r.id = om.role_id
+		WHERE om.organization_id = $1
+		ORDER BY om.created_at, u.id`,
+		organizationID)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	defer rows.Close()
+
+	members := []OrganizationMember{}
+	for rows.Next() {
+		var member OrganizationMember
+		if err := rows.Scan(&member.UserID, &member.Email, &member.Fullname, &member.Role, &member.CreatedAt); err != nil {
+			return nil, customerror.NewPostgresError(err)
+		}
+		members = append(members, member)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return members, nil
+}
+
+func (ap *authPostgres) UpdateOrganizationMemberRole(organizationID, userID, role string) *customerror.CustomError {
+	tx, err := ap.db.Begin()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	defer tx.Rollback()
+
+	roleID, cuserr := organizationRoleID(tx, role)
+	if cuserr != nil {
+		return cuserr
+	}
+	if role != OrganizationRoleOwner {
+		if cuserr := ensureOtherOwner(tx, organizationID, userID); cuserr != nil {
+			return cuserr
+		}
+	}
+
+	result, err := tx.Exec(`
+		UPDATE organization_members SET role_id = $3
+		WHERE organization_id = $1 AND user_id = $2`,
+		organizationID, userID, roleID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "organization member not found", http.StatusNotFound)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+func (ap *authPostgres) RemoveOrganizationMember(organizationID, userID string) *customerror.CustomError {
+	tx, err := ap.db.Begin()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	defer tx.Rollback()
+
+	if cuserr := ensureOtherOwner(tx, organizationID, userID); cuserr != nil {
+		return cuserr
+	}
+
+	result, err := tx.Exec(`DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2`,
+		organizationID, userID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "organization member not found", http.StatusNotFound)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+func (ap *authPostgres) SaveOrganizationInvitation(req *SaveOrganizationInvitationRequest) *customerror.CustomError {
+	roleID, cuserr := organizationRoleID(ap.db, req.Role)
+	if cuserr != nil {
+		return cuserr
+	}
+
+	var isMember bool
+	err := ap.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM organization_members om
+			JOIN users u ON u.id = om.user_id
+			WHERE om.organization_id = $1 AND u.email = $2
+		)`,
+		req.OrganizationID, req.Email).Scan(&isMember)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if isMember {
+		return customerror.NewCustomError(nil, "email is already a member", http.StatusConflict)
+	}
+
+	tx, err := ap.db.Begin()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	defer tx.Rollback()
+
+	// The cooldown is checked in the upsert itself so concurrent requests cannot both send an invitation
+	result, err := tx.Exec(`
+		INSERT INTO organization_invitations (organization_id, email, role_id, invited_by, token_hash, expires_at)
+		VALUES ($1, $2, $3, NULLIF($4, '')::int, $5, $6)
+		ON CONFLICT (organization_id, email) DO UPDATE
+		SET role_id = $3,
+			invited_by = NULLIF($4, '')::int,
+			token_hash = $5,
+			expires_at = $6,
+			sent_at = NOW()
+		WHERE organization_invitations.sent_at <= NOW() - ($7 || ' seconds')::interval`,
+		req.OrganizationID, req.Email, roleID, req.InvitedBy, req.TokenHash, req.ExpiresAt, req.ResendCooldownSeconds)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "invitation was sent too recently", http.StatusTooManyRequests)
+	}
+
+	if err := outbox.Enqueue(context.Background(), tx, req.Message); err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+// organizationInvitationQuery selects the columns read by scanOrganizationInvitation
+const organizationInvitationQuery = `
+	SELECT i.id, i.organization_id, i.email, r.name, COALESCE(u.email, ''), i.expires_at, i.sent_at, i.created_at
+	FROM organization_invitations i
+	JOIN roles r ON r.id = i.role_id
+	LEFT JOIN users u ON u.id = i.invited_by`
+
+func scanOrganizationInvitation(row rowScanner) (*OrganizationInvitation, error) {
+	invitation := &OrganizationInvitation{}
+	err := row.Scan(&invitation.ID, &invitation.OrganizationID, &invitation.Email, &invitation.Role,
+		&invitation.InvitedBy, &invitation.ExpiresAt, &invitation.SentAt, &invitation.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (ap *authPostgres) ListOrganizationInvitations(organizationID string) ([]OrganizationInvitation, *customerror.CustomError) {
+	rows, err := ap.db.Query(organizationInvitationQuery+`
+		WHERE i.organization_id = $1
+		ORDER BY i.sent_at DESC, i.id DESC`,
+		organizationID)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	defer rows.Close()
+
+	invitations := []OrganizationInvitation{}
+	for rows.Next() {
+		invitation, err := scanOrganizationInvitation(rows)
+		if err != nil {
+			return nil, customerror.NewPostgresError(err)
+		}
+		invitations = append(invitations, *invitation)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return invitations, nil
+}
+
+func (ap *authPostgres) GetOrganizationInvitation(organizationID, invitationID string) (*OrganizationInvitation, *customerror.CustomError) {
+	invitation, err := scanOrganizationInvitation(ap.db.QueryRow(organizationInvitationQuery+`
+		WHERE i.organization_id = $1 AND i.id = $2`,
+		organizationID, invitationID))
+	if err == sql.ErrNoRows {
+		return nil, customerror.NewCustomError(err, "invitation not found", http.StatusNotFound)
+	}
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return invitation, nil
+}
+
+func (ap *authPostgres) DeleteOrganizationInvitation(organizationID, invitationID string) *customerror.CustomError {
+	result, err := ap.db.Exec(`
+		DELETE FROM organization_invitations
+		WHERE organization_id = $1 AND id = $2`,
+		organizationID, invitationID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "invitation not found", http.StatusNotFound)
+	}
+	return nil
+}
+
+func (ap *authPostgres) AcceptOrganizationInvitation(req *AcceptOrganizationInvitationRequest) *customerror.CustomError {
+	tx, err := ap.db.Begin()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	defer tx.Rollback()
+
+	// Revoked, resent and expired invitations no longer match
+	var roleID int
+	err = tx.QueryRow(`
+		DELETE FROM organization_invitations
+		WHERE organization_id = $1 AND email = $2 AND token_hash = $3 AND expires_at > NOW()
+		RETURNING role_id`,
+		req.OrganizationID, req.Email, req.TokenHash).Scan(&roleID)
+	if err == sql.ErrNoRows {
+		return customerror.NewCustomError(err, "invitation not found", http.StatusNotFound)
+	}
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	userID := req.UserID
+	if req.NewUser != nil {
+		err = tx.QueryRow(`INSERT INTO users (email, fullname, password_hash) VALUES ($1, $2, $3) RETURNING id`,
+			req.NewUser.Email, req.NewUser.Fullname, req.NewUser.PasswordHash).Scan(&userID)
+		if err != nil {
+			return customerror.NewPostgresError(err)
+		}
+	}
+
+	// A user who is already a member keeps their current role
+	_, err = tx.Exec(`
+		INSERT INTO organization_members (organization_id, user_id, role_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (organization_id, user_id) DO NOTHING`,
+		req.OrganizationID, userID, roleID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+// queryRower is implemented by both *sql.DB and *sql.Tx
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func (ap *authPostgres) ExportUserData(userID string) (*UserDataExport, *customerror.CustomError) {
+	user, cuserr := ap.GetUserByID(userID)
+	if cuserr != nil {
+		return nil, cuserr
+	}
+
+	export := &UserDataExport{
+		Account: UserDataAccount{
+			ID:                  user.ID,
+			Email:               user.Email,
+			Fullname:            user.Fullname,
+			HasPassword:         user.PasswordHash != "",
+			CreatedAt:           user.CreatedAt,
+			UpdatedAt:           user.UpdatedAt,
+			DeletionScheduledAt: user.DeletionScheduledAt,
+			Locale:              user.Locale,
+		},
+		Sessions:      []UserDataSession{},
+		Organizations: []UserDataMembership{},
+		Identities:    []UserDataIdentity{},
+		Passkeys:      []UserDataPasskey{},
+		APIKeys:       []UserDataAPIKey{},
+	}
+
+	sessionRows, err := ap.db.Query(`
+		SELECT family_id, COALESCE(user_agent, ''), COALESCE(ip_address, ''), created_at, expires_at, rotated_at, revoked_at
+		FROM sessions WHERE user_id = $1
+		ORDER BY created_at`,
+		userID)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	defer sessionRows.Close()
+	for sessionRows.Next() {
+		var session UserDataSession
+		if err := sessionRows.Scan(&session.FamilyID, &session.UserAgent, &session.IPAddress,
+			&session.CreatedAt, &session.ExpiresAt, &session.RotatedAt, &session.RevokedAt); err != nil {
+			return nil, customerror.NewPostgresError(err)
+		}
+		export.Sessions = append(export.Sessions, session)
+	}
+	if err := sessionRows.Err(); err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+
+	identityRows, err := ap.db.Query(`
+		SELECT provider, subject, email, created_at
+		FROM user_identities WHERE user_id = $1
+		ORDER BY created_at`,
+		userID)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	defer identityRows.Close()
+	for identityRows.Next() {
+		var identity UserDataIdentity
+		if err := identityRows.Scan(&identity.Provider, &identity.Subject, &identity.Email, &identity.CreatedAt); err != nil {
+			return nil, customerror.NewPostgresError(err)
+		}
+		export.Identities = append(export.Identities, identity)
+	}
+	if err := identityRows.Err(); err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+
+	access, cuserr := ap.GetUserAccess(userID)
+	if cuserr != nil {
+		return nil, cuserr
+	}
+	export.Roles = access.Roles
+
+	memberships, cuserr := ap.ListUserOrganizations(userID)
+	if cuserr != nil {
+		return nil, cuserr
+	}
+	for _, membership := range memberships {
+		export.Organizations = append(export.Organizations, UserDataMembership{
+			OrganizationID:   membership.OrganizationID,
+			OrganizationName: membership.OrganizationName,
+			Role:             membership.Role,
+			JoinedAt:         membership.CreatedAt,
+		})
+	}
+
+	credentials, cuserr := ap.ListWebAuthnCredentials(userID)
+	if cuserr != nil {
+		return nil, cuserr
+	}
+	for _, credential := range credentials {
+		export.Passkeys = append(export.Passkeys, UserDataPasskey{
+			Name:       credential.Name,
+			Transports: credential.Transports,
+			LastUsedAt: credential.LastUsedAt,
+			CreatedAt:  credential.CreatedAt,
+		})
+	}
+
+	apiKeys, cuserr := ap.ListAPIKeys(userID)
+	if cuserr != nil {
+		return nil, cuserr
+	}
+	for _, apiKey := range apiKeys {
+		export.APIKeys = append(export.APIKeys, UserDataAPIKey{
+			ID:             apiKey.ID,
+			Name:           apiKey.Name,
+			Scopes:         apiKey.Scopes,
+			OrganizationID: apiKey.OrganizationID,
+			ExpiresAt:      apiKey.ExpiresAt,
+			LastUsedAt:     apiKey.LastUsedAt,
+			CreatedAt:      apiKey.CreatedAt,
+		})
+	}
+
+	userTOTP, cuserr := ap.GetUserTOTP(userID)
+	if cuserr != nil && cuserr.Code() != http.StatusNotFound {
+		return nil, cuserr
+	}
+	if userTOTP != nil {
+		export.TwoFactor = UserDataTwoFactor{
+			Enabled:     userTOTP.ConfirmedAt != nil,
+			ConfirmedAt: userTOTP.ConfirmedAt,
+		}
+	}
+
+	return export, nil
+}
+
+// soleOwnedOrganizationsQuery selects the organizations where user $1 is the only member
+// with role $2, the owner role
+const soleOwnedOrganizationsQuery = `
+	SELECT om.organization_id
+	FROM organization_members om
+	JOIN roles r ON r.id = om.role_id
+	WHERE om.user_id = $1 AND r.name = $2
+	  AND NOT EXISTS (
+		SELECT 1 FROM organization_members other
+		JOIN roles other_role ON other_role.id = other.role_id
+		WHERE other.organization_id = om.organization_id
+		  AND other.user_id <> $1 AND other_role.name = $2
+	  )`
+
+func (ap *authPostgres) ScheduleUserDeletion(userID string, graceDays int) (time.Time, *customerror.CustomError) {
+	// Members would be left without anyone to manage the organization, so ownership is handed over first
+	var orphaned int
+	err := ap.db.QueryRow(`
+		SELECT COUNT(*) FROM organization_members
+		WHERE organization_id IN (`+soleOwnedOrganizationsQuery+`)
+		  AND user_id <> $1`,
+		userID, OrganizationRoleOwner).Scan(&orphaned)
+	if err != nil {
+		return time.Time{}, customerror.NewPostgresError(err)
+	}
+	if orphaned > 0 {
+		return time.Time{}, customerror.NewCustomError(nil, "organization must keep an owner", http.StatusConflict)
+	}
+
+	// Requesting again keeps the first schedule, so the grace period cannot be pushed back by accident
+	var scheduledAt time.Time
+	err = ap.db.QueryRow(`
+		UPDATE users
+		SET deletion_scheduled_at = COALESCE(deletion_scheduled_at, NOW() + ($2 || ' days')::interval)
+		WHERE id = $1
+		RETURNING deletion_scheduled_at`,
+		userID, graceDays).Scan(&scheduledAt)
+	if err == sql.ErrNoRows {
+		return time.Time{}, customerror.NewCustomError(err, "user not found", http.StatusNotFound)
+	}
+	if err != nil {
+		return time.Time{}, customerror.NewPostgresError(err)
+	}
+	return scheduledAt, nil
+}
+
+func (ap *authPostgres) CancelUserDeletion(userID string) *customerror.CustomError {
+	result, err := ap.db.Exec(`
+		UPDATE users
+		SET deletion_scheduled_at = NULL
+		WHERE id = $1 AND deletion_scheduled_at IS NOT NULL`,
+		userID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	if rows == 0 {
+		return customerror.NewCustomError(nil, "account deletion not scheduled", http.StatusNotFound)
+	}
+	return nil
+}
+
+func (ap *authPostgres) ListDueUserDeletions() ([]string, *customerror.CustomError) {
+	userIDs, err := queryStrings(ap.db, `
+		SELECT id FROM users
+		WHERE deletion_scheduled_at <= NOW()
+		ORDER BY deletion_scheduled_at`)
+	if err != nil {
+		return nil, customerror.NewPostgresError(err)
+	}
+	return userIDs, nil
+}
+
+func (ap *authPostgres) PurgeUser(userID string) *customerror.CustomError {
+	tx, err := ap.db.Begin()
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	defer tx.Rollback()
+
+	// The row lock keeps a concurrent cancel from racing the purge
+	var email string
+	err = tx.QueryRow(`
+		SELECT email FROM users
+		WHERE id = $1 AND deletion_scheduled_at <= NOW()
+		FOR UPDATE`,
+		userID).Scan(&email)
+	if err == sql.ErrNoRows {
+		return customerror.NewCustomError(err, "account deletion not due", http.StatusNotFound)
+	}
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	ownerRoleID, cuserr := organizationRoleID(tx, OrganizationRoleOwner)
+	if cuserr != nil {
+		return cuserr
+	}
+
+	// Members who joined during the grace period still need an owner, the longest-standing one takes over
+	_, err = tx.Exec(`
+		UPDATE organization_members om
+		SET role_id = $3
+		FROM (
+			SELECT DISTINCT ON (organization_id) organization_id, user_id
+			FROM organization_members
+			WHERE organization_id IN (`+soleOwnedOrganizationsQuery+`)
+			  AND user_id <> $1
+			ORDER BY organization_id, created_at, user_id
+		) heir
+		WHERE om.organization_id = heir.organization_id AND om.user_id = heir.user_id`,
+		userID, OrganizationRoleOwner, ownerRoleID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	// What the user still solely owns has no other members and goes with the account
+	_, err = tx.Exec(`DELETE FROM organizations WHERE id IN (`+soleOwnedOrganizationsQuery+`)`,
+		userID, OrganizationRoleOwner)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	// Rows keyed by email are not removed by the cascade
+	_, err = tx.Exec(`DELETE FROM activation_tokens WHERE email = $1`, email)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	_, err = tx.Exec(`DELETE FROM organization_invitations WHERE email = $1`, email)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	_, err = tx.Exec(`DELETE FROM email_outbox WHERE $1 = ANY(recipients)`, email)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	// Sessions, credentials, keys and memberships cascade, and invitations sent by the user
+	// keep no reference to them
+	_, err = tx.Exec(`DELETE FROM users WHERE id = $1`, userID)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
+
+// organizationRoleID returns the ID of a role that can be given to organization members
+func organizationRoleID(q queryRower, role string) (int, *customerror.CustomError) {
+	var roleID int
+	err := q.QueryRow(`SELECT id FROM roles WHERE name = $1 AND organization_role`, role).Scan(&roleID)
+	if err == sql.ErrNoRows {
+		return 0, customerror.NewCustomError(err, "role not found", http.StatusNotFound)
+	}
+	if err != nil {
+		return 0, customerror.NewPostgresError(err)
+	}
+	return roleID, nil
+}
+
+// ensureOtherOwner rejects removing or demoting the last owner of an organization. The owner
+// memberships are locked, so concurrent changes to two owners cannot both see the other one
+func ensureOtherOwner(tx *sql.Tx, organizationID, userID string) *customerror.CustomError {
+	owners, err := queryStrings(tx, `
+		SELECT om.user_id
+		FROM organization_members om
+		JOIN roles r ON r.id = om.role_id
+		WHERE om.organization_id = $1 AND r.name = $2 AND r.organization_role
+		FOR UPDATE OF om`,
+		organizationID, OrganizationRoleOwner)
+	if err != nil {
+		return customerror.NewPostgresError(err)
+	}
+
+	var targetOwner, otherOwner bool
+	for _, owner := range owners {
+		if owner == userID {
+			targetOwner = true
+		} else {
+			otherOwner = true
+		}
+	}
+	if targetOwner && !otherOwner {
+		return customerror.NewCustomError(nil, "organization must keep an owner", http.StatusConflict)
+	}
+	return nil
+}
+
+// apiKeyColumns are the api_keys columns read by scanAPIKey
+const apiKeyColumns = `key_id, user_id, name, secret_hash, scopes, expires_at, last_used_at, created_at,
+	COALESCE(organization_id::text, '')`
+
+func scanAPIKey(row rowScanner) (*APIKey, error) {
+	apiKey := &APIKey{}
+	err := row.Scan(&apiKey.ID, &apiKey.UserID, &apiKey.Name, &apiKey.SecretHash,
+		pq.Array(&apiKey.Scopes), &apiKey.ExpiresAt, &apiKey.LastUsedAt, &apiKey.CreatedAt, &apiKey.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	if apiKey.Scopes == nil {
+		apiKey.Scopes = []string{}
+	}
+	return apiKey, nil
+}
+
+// querier runs queries on a database or within a transaction
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+}
+
+// queryStrings returns the single text column of every row
+func queryStrings(q querier, query string, args ...any) ([]string, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := []string{}
+	for rows.Next() {
+		var value string
+		if err := rows.Scan(&value); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, rows.Err()
+}
+
+func (ap *authPostgres) EnqueueEmail(email *outbox.Email) *customerror.CustomError {
+	if err := outbox.Enqueue(context.Background(), ap.db, email); err != nil {
+		return customerror.NewPostgresError(err)
+	}
+	return nil
+}
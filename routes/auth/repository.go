@@ -1,6 +1,11 @@
 package auth
 
-import "github.com/yantology/golang-starter-template/pkg/customerror"
+import (
+	"time"
+
+	"github.com/yantology/golang-starter-template/pkg/customerror"
+	"github.com/yantology/golang-starter-template/pkg/outbox"
+)
 
 type AuthRepository struct {
 	db AuthDBInterface
@@ -26,6 +31,14 @@ func (ar *AuthRepository) GetActivationToken(req *GetActivationTokenRequest) (st
 	return ar.db.GetActivationToken(req)
 }
 
+func (ar *AuthRepository) ConsumeActivationToken(req *GetActivationTokenRequest) (*ActivationToken, *customerror.CustomError) {
+	return ar.db.ConsumeActivationToken(req)
+}
+
+func (ar *AuthRepository) RestoreActivationToken(token *ActivationToken, maxAttempts int) *customerror.CustomError {
+	return ar.db.RestoreActivationToken(token, maxAttempts)
+}
+
 func (ar *AuthRepository) CreateUser(req *CreateUserRequest) *customerror.CustomError {
 	return ar.db.CreateUser(req)
 }
@@ -34,6 +47,222 @@ func (ar *AuthRepository) GetUserByEmail(email string) (*User, *customerror.Cust
 	return ar.db.GetUserByEmail(email)
 }
 
+func (ar *AuthRepository) GetUserByID(userID string) (*User, *customerror.CustomError) {
+	return ar.db.GetUserByID(userID)
+}
+
 func (ar *AuthRepository) UpdateUserPassword(req *UpdatePasswordRequest) *customerror.CustomError {
 	return ar.db.UpdateUserPassword(req)
 }
+
+func (ar *AuthRepository) UpdateUserProfile(req *UpdateProfileRequest) (*User, *customerror.CustomError) {
+	return ar.db.UpdateUserProfile(req)
+}
+
+func (ar *AuthRepository) UpdateUserEmail(req *UpdateEmailRequest) *customerror.CustomError {
+	return ar.db.UpdateUserEmail(req)
+}
+
+func (ar *AuthRepository) CreateSession(req *CreateSessionRequest) *customerror.CustomError {
+	return ar.db.CreateSession(req)
+}
+
+func (ar *AuthRepository) GetSessionByTokenHash(tokenHash string) (*Session, *customerror.CustomError) {
+	return ar.db.GetSessionByTokenHash(tokenHash)
+}
+
+func (ar *AuthRepository) RotateSession(req *RotateSessionRequest) *customerror.CustomError {
+	return ar.db.RotateSession(req)
+}
+
+func (ar *AuthRepository) RevokeSessionFamily(familyID string) *customerror.CustomError {
+	return ar.db.RevokeSessionFamily(familyID)
+}
+
+func (ar *AuthRepository) GetActiveSessions(userID string) ([]ActiveSession, *customerror.CustomError) {
+	return ar.db.GetActiveSessions(userID)
+}
+
+func (ar *AuthRepository) RevokeUserSession(userID, familyID string) *customerror.CustomError {
+	return ar.db.RevokeUserSession(userID, familyID)
+}
+
+func (ar *AuthRepository) RevokeOtherSessions(userID, keepFamilyID string) *customerror.CustomError {
+	return ar.db.RevokeOtherSessions(userID, keepFamilyID)
+}
+
+func (ar *AuthRepository) SaveTOTPSecret(userID, secret string) *customerror.CustomError {
+	return ar.db.SaveTOTPSecret(userID, secret)
+}
+
+func (ar *AuthRepository) GetUserTOTP(userID string) (*UserTOTP, *customerror.CustomError) {
+	return ar.db.GetUserTOTP(userID)
+}
+
+func (ar *AuthRepository) ConfirmTOTP(req *ConfirmTOTPRequest) *customerror.CustomError {
+	return ar.db.ConfirmTOTP(req)
+}
+
+func (ar *AuthRepository) UseTOTPStep(userID string, step int64) *customerror.CustomError {
+	return ar.db.UseTOTPStep(userID, step)
+}
+
+func (ar *AuthRepository) UseRecoveryCode(userID, codeHash string) *customerror.CustomError {
+	return ar.db.UseRecoveryCode(userID, codeHash)
+}
+
+func (ar *AuthRepository) DisableTOTP(userID string) *customerror.CustomError {
+	return ar.db.DisableTOTP(userID)
+}
+
+func (ar *AuthRepository) SaveWebAuthnChallenge(req *SaveWebAuthnChallengeRequest) *customerror.CustomError {
+	return ar.db.SaveWebAuthnChallenge(req)
+}
+
+func (ar *AuthRepository) ConsumeWebAuthnChallenge(challengeHash, challengeType string) (*WebAuthnChallenge, *customerror.CustomError) {
+	return ar.db.ConsumeWebAuthnChallenge(challengeHash, challengeType)
+}
+
+func (ar *AuthRepository) CreateWebAuthnCredential(req *CreateWebAuthnCredentialRequest) *customerror.CustomError {
+	return ar.db.CreateWebAuthnCredential(req)
+}
+
+func (ar *AuthRepository) GetWebAuthnCredential(credentialID string) (*WebAuthnCredential, *customerror.CustomError) {
+	return ar.db.GetWebAuthnCredential(credentialID)
+}
+
+func (ar *AuthRepository) ListWebAuthnCredentials(userID string) ([]WebAuthnCredential, *customerror.CustomError) {
+	return ar.db.ListWebAuthnCredentials(userID)
+}
+
+func (ar *AuthRepository) UpdateWebAuthnSignCount(credentialID string, signCount uint32) *customerror.CustomError {
+	return ar.db.UpdateWebAuthnSignCount(credentialID, signCount)
+}
+
+func (ar *AuthRepository) DeleteWebAuthnCredential(userID, id string) *customerror.CustomError {
+	return ar.db.DeleteWebAuthnCredential(userID, id)
+}
+
+func (ar *AuthRepository) GetUserByIdentity(provider, subject string) (*User, *customerror.CustomError) {
+	return ar.db.GetUserByIdentity(provider, subject)
+}
+
+func (ar *AuthRepository) CreateUserIdentity(req *CreateUserIdentityRequest) *customerror.CustomError {
+	return ar.db.CreateUserIdentity(req)
+}
+
+func (ar *AuthRepository) CreateUserWithIdentity(req *CreateUserWithIdentityRequest) (*User, *customerror.CustomError) {
+	return ar.db.CreateUserWithIdentity(req)
+}
+
+func (ar *AuthRepository) GetUserAccess(userID string) (*UserAccess, *customerror.CustomError) {
+	return ar.db.GetUserAccess(userID)
+}
+
+func (ar *AuthRepository) ListRoles() ([]Role, *customerror.CustomError) {
+	return ar.db.ListRoles()
+}
+
+func (ar *AuthRepository) AssignUserRole(userID, role string) *customerror.CustomError {
+	return ar.db.AssignUserRole(userID, role)
+}
+
+func (ar *AuthRepository) RemoveUserRole(userID, role string) *customerror.CustomError {
+	return ar.db.RemoveUserRole(userID, role)
+}
+
+func (ar *AuthRepository) CreateAPIKey(req *CreateAPIKeyRequest) (*APIKey, *customerror.CustomError) {
+	return ar.db.CreateAPIKey(req)
+}
+
+func (ar *AuthRepository) GetAPIKey(keyID string) (*APIKey, *customerror.CustomError) {
+	return ar.db.GetAPIKey(keyID)
+}
+
+func (ar *AuthRepository) ListAPIKeys(userID string) ([]APIKey, *customerror.CustomError) {
+	return ar.db.ListAPIKeys(userID)
+}
+
+func (ar *AuthRepository) UpdateAPIKey(req *UpdateAPIKeyRequest) (*APIKey, *customerror.CustomError) {
+	return ar.db.UpdateAPIKey(req)
+}
+
+func (ar *AuthRepository) DeleteAPIKey(userID, keyID string) *customerror.CustomError {
+	return ar.db.DeleteAPIKey(userID, keyID)
+}
+
+func (ar *AuthRepository) TouchAPIKey(keyID string) *customerror.CustomError {
+	return ar.db.TouchAPIKey(keyID)
+}
+
+func (ar *AuthRepository) CreateOrganization(req *CreateOrganizationRequest) (*Organization, *customerror.CustomError) {
+	return ar.db.CreateOrganization(req)
+}
+
+func (ar *AuthRepository) GetOrganization(organizationID string) (*Organization, *customerror.CustomError) {
+	return ar.db.GetOrganization(organizationID)
+}
+
+func (ar *AuthRepository) ListUserOrganizations(userID string) ([]OrganizationMembership, *customerror.CustomError) {
+	return ar.db.ListUserOrganizations(userID)
+}
+
+func (ar *AuthRepository) GetOrganizationMembership(organizationID, userID string) (*OrganizationMembership, *customerror.CustomError) {
+	return ar.db.GetOrganizationMembership(organizationID, userID)
+}
+
+func (ar *AuthRepository) ListOrganizationMembers(organizationID string) ([]OrganizationMember, *customerror.CustomError) {
+	return ar.db.ListOrganizationMembers(organizationID)
+}
+
+func (ar *AuthRepository) UpdateOrganizationMemberRole(organizationID, userID, role string) *customerror.CustomError {
+	return ar.db.UpdateOrganizationMemberRole(organizationID, userID, role)
+}
+
+func (ar *AuthRepository) RemoveOrganizationMember(organizationID, userID string) *customerror.CustomError {
+	return ar.db.RemoveOrganizationMember(organizationID, userID)
+}
+
+func (ar *AuthRepository) SaveOrganizationInvitation(req *SaveOrganizationInvitationRequest) *customerror.CustomError {
+	return ar.db.SaveOrganizationInvitation(req)
+}
+
+func (ar *AuthRepository) ListOrganizationInvitations(organizationID string) ([]OrganizationInvitation, *customerror.CustomError) {
+	return ar.db.ListOrganizationInvitations(organizationID)
+}
+
+func (ar *AuthRepository) GetOrganizationInvitation(organizationID, invitationID string) (*OrganizationInvitation, *customerror.CustomError) {
+	return ar.db.GetOrganizationInvitation(organizationID, invitationID)
+}
+
+func (ar *AuthRepository) DeleteOrganizationInvitation(organizationID, invitationID string) *customerror.CustomError {
+	return ar.db.DeleteOrganizationInvitation(organizationID, invitationID)
+}
+
+func (ar *AuthRepository) AcceptOrganizationInvitation(req *AcceptOrganizationInvitationRequest) *customerror.CustomError {
+	return ar.db.AcceptOrganizationInvitation(req)
+}
+
+func (ar *AuthRepository) ExportUserData(userID string) (*UserDataExport, *customerror.CustomError) {
+	return ar.db.ExportUserData(userID)
+}
+
+func (ar *AuthRepository) ScheduleUserDeletion(userID string, graceDays int) (time.Time, *customerror.CustomError) {
+	return ar.db.ScheduleUserDeletion(userID, graceDays)
+}
+
+func (ar *AuthRepository) CancelUserDeletion(userID string) *customerror.CustomError {
+	return ar.db.CancelUserDeletion(userID)
+}
+
+func (ar *AuthRepository) ListDueUserDeletions() ([]string, *customerror.CustomError) {
+	return ar.db.ListDueUserDeletions()
+}
+
+func (ar *AuthRepository) PurgeUser(userID string) *customerror.CustomError {
+	return ar.db.PurgeUser(userID)
+}
+
+func (ar *AuthRepository) EnqueueEmail(email *outbox.Email) *customerror.CustomError {
+	return ar.db.EnqueueEmail(email)
+}
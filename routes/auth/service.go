@@ -1,17 +1,30 @@
 package auth
 
 import (
-	"fmt"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
 	"log"
+	"math/big"
 	"net/http"
 	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/golang-jwt/jwt"
 	"github.com/yantology/golang-starter-template/config"
+	"github.com/yantology/golang-starter-template/middleware"
 	"github.com/yantology/golang-starter-template/pkg/customerror"
 	jwtPkg "github.com/yantology/golang-starter-template/pkg/jwt"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/yantology/golang-starter-template/pkg/oidc"
+	"github.com/yantology/golang-starter-template/pkg/password"
+	"github.com/yantology/golang-starter-template/pkg/passwordpolicy"
+	"github.com/yantology/golang-starter-template/pkg/signedtoken"
+	"github.com/yantology/golang-starter-template/pkg/totp"
+	"github.com/yantology/golang-starter-template/pkg/webauthn"
 )
 
 // AuthService defines the interface for authentication operations
@@ -23,25 +36,93 @@ type AuthService interface {
 	ValidateRegistrationInput(req RegistrationRequest) *customerror.CustomError
 	HashString(input string) (string, *customerror.CustomError)
 	VerifyHash(hashedString, input string) *customerror.CustomError
+	NeedsRehash(hashedString string) bool
 	ValidatePasswordInput(password, passwordConfirmation string) *customerror.CustomError
+	ValidatePasswordPolicy(password, email, fullname string) []PasswordViolation
 
 	// Token operations
-	GenerateTokenPairCookies(Writer http.ResponseWriter, req TokenPairRequest) *customerror.CustomError
+	GenerateTokenPair(req TokenPairRequest) (*TokenPair, *customerror.CustomError)
+	SetTokenPairCookies(Writer http.ResponseWriter, pair *TokenPair)
 	GenerateLogoutCookies(Writer http.ResponseWriter)
-	ValidateRefreshTokenClaims(token string) (*jwtPkg.TokenClaims, *customerror.CustomError)
+	ValidateRefreshTokenClaims(token string) (*middleware.UserClaims, *customerror.CustomError)
+	RevokeAccessToken(token string) *customerror.CustomError
+	JWKS() jwtPkg.JWKSet
+
+	// Session operations
+	GenerateSessionFamilyID() (string, *customerror.CustomError)
+	HashToken(token string) string
+
+	// Two-factor authentication
+	GenerateTOTPSecret() (string, *customerror.CustomError)
+	BuildTOTPURI(email, secret string) string
+	VerifyTOTPCode(secret, code string) (int64, *customerror.CustomError)
+	GenerateRecoveryCodes() ([]string, *customerror.CustomError)
+	HashRecoveryCode(code string) string
+	GenerateMFAChallengeToken() (string, *customerror.CustomError)
+	GenerateWebAuthnChallenge() (string, *customerror.CustomError)
+
+	// External identity providers
+	NewOIDCFlow(provider string, expiry time.Duration) (*OIDCFlow, *customerror.CustomError)
+	SetOIDCFlowCookie(Writer http.ResponseWriter, flow *OIDCFlow) *customerror.CustomError
+	ReadOIDCFlow(value string) (*OIDCFlow, *customerror.CustomError)
+	ClearOIDCFlowCookie(Writer http.ResponseWriter)
+
+	// Passwordless login
+	GenerateLoginLink(email, code string, expiry time.Duration) (string, *customerror.CustomError)
+	ParseLoginLink(token string) (*LoginLinkClaims, *customerror.CustomError)
+
+	// API keys
+	GenerateAPIKey(prefix string) (keyID, key string, cuserr *customerror.CustomError)
+	ParseAPIKey(key string) (keyID, secret string, ok bool)
+
+	// Organization invitations
+	GenerateInvitationToken(organizationID, email string, expiry time.Duration) (token, link string, cuserr *customerror.CustomError)
+	ParseInvitationToken(token string) (*InvitationClaims, *customerror.CustomError)
 }
 
+// oidcFlowCookieName is the cookie carrying the state, nonce and PKCE verifier to the callback
+const oidcFlowCookieName = "oidc_flow"
+
 type authService struct {
-	jwtService  jwtPkg.JWTService
-	tokenConfig *config.TokenConfig
+	jwtService         jwtPkg.Service[middleware.UserClaims]
+	tokenConfig        *config.TokenConfig
+	mfaConfig          *config.MFAConfig
+	passwordlessConfig *config.PasswordlessConfig
+	activationConfig   *config.ActivationConfig
+	loginLinkSigner    *signedtoken.Signer
+	passwordHasher     password.PasswordHasher
+	passwordPolicy     *passwordpolicy.Policy
+
+	organizationConfig *config.OrganizationConfig
+	invitationSigner   *signedtoken.Signer
+	oidcFlowSigner     *signedtoken.Signer
 }
 
 // NewAuthService creates a new instance of the AuthService
-func NewAuthService(jwtService jwtPkg.JWTService, tokenConfig *config.TokenConfig) AuthService {
+func NewAuthService(
+	jwtService jwtPkg.Service[middleware.UserClaims],
+	tokenConfig *config.TokenConfig,
+	mfaConfig *config.MFAConfig,
+	passwordlessConfig *config.PasswordlessConfig,
+	activationConfig *config.ActivationConfig,
+	passwordHasher password.PasswordHasher,
+	passwordPolicy *passwordpolicy.Policy,
+	organizationConfig *config.OrganizationConfig,
+	oidcConfig *config.OIDCConfig,
+) AuthService {
 	// Compile email regex once during initialization
 	return &authService{
-		jwtService:  jwtService,
-		tokenConfig: tokenConfig,
+		jwtService:         jwtService,
+		tokenConfig:        tokenConfig,
+		mfaConfig:          mfaConfig,
+		passwordlessConfig: passwordlessConfig,
+		activationConfig:   activationConfig,
+		loginLinkSigner:    signedtoken.New([]byte(passwordlessConfig.LinkSecret)),
+		passwordHasher:     passwordHasher,
+		passwordPolicy:     passwordPolicy,
+		organizationConfig: organizationConfig,
+		invitationSigner:   signedtoken.New([]byte(organizationConfig.InvitationSecret)),
+		oidcFlowSigner:     signedtoken.New([]byte(oidcConfig.FlowSecret)),
 	}
 }
 
@@ -57,11 +138,21 @@ func (s *authService) ValidateEmail(email string) *customerror.CustomError {
 	return nil
 }
 
-// GenerateActivationToken generates a 6-digit token for activation
+// GenerateActivationToken generates a random activation code with the configured length and alphabet
 func (s *authService) GenerateActivationToken() (string, *customerror.CustomError) {
-	// Generate a 6-digit numeric token
-	token := fmt.Sprintf("%06d", time.Now().UnixNano()%1000000)
-	return token, nil
+	alphabet := []rune(s.activationConfig.CodeAlphabet)
+	max := big.NewInt(int64(len(alphabet)))
+
+	code := make([]rune, s.activationConfig.CodeLength)
+	for i := range code {
+		// rand.Int is uniform, so no character is more likely than another
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", customerror.NewCustomError(err, "Gagal membuat kode aktivasi", http.StatusInternalServerError)
+		}
+		code[i] = alphabet[n.Int64()]
+	}
+	return string(code), nil
 }
 
 // ValidateRegistrationInput validates user registration input
@@ -80,11 +171,6 @@ func (s *authService) ValidateRegistrationInput(req RegistrationRequest) *custom
 		return customerror.NewCustomError(nil, "Password tidak boleh kosong", http.StatusBadRequest)
 	}
 
-	// Validate password complexity
-	if len(req.Password) < 8 || len(req.Password) > 20 {
-		return customerror.NewCustomError(nil, "Password harus antara 8 dan 20 karakter", http.StatusBadRequest)
-	}
-
 	// Validate password match
 	if req.Password != req.PasswordConfirmation {
 		return customerror.NewCustomError(nil, "Password tidak cocok dengan konfirmasi", http.StatusBadRequest)
@@ -93,24 +179,32 @@ func (s *authService) ValidateRegistrationInput(req RegistrationRequest) *custom
 	return nil
 }
 
-// HashString securely hashes a string using bcrypt
+// HashString securely hashes a string with the configured password hasher
 func (s *authService) HashString(input string) (string, *customerror.CustomError) {
-	hashedString, err := bcrypt.GenerateFromPassword([]byte(input), bcrypt.DefaultCost)
+	hashedString, err := s.passwordHasher.Hash(input)
+	if errors.Is(err, password.ErrPasswordTooLong) {
+		return "", customerror.NewCustomError(err, "Password terlalu panjang", http.StatusBadRequest)
+	}
 	if err != nil {
 		return "", customerror.NewCustomError(err, "Gagal mengenkripsi string", http.StatusInternalServerError)
 	}
-	return string(hashedString), nil
+	return hashedString, nil
 }
 
 // VerifyHash verifies if the provided input matches the stored hash
 func (s *authService) VerifyHash(hashedString, input string) *customerror.CustomError {
-	err := bcrypt.CompareHashAndPassword([]byte(hashedString), []byte(input))
+	err := s.passwordHasher.Verify(hashedString, input)
 	if err != nil {
 		return customerror.NewCustomError(err, "Hash tidak cocok", http.StatusUnauthorized)
 	}
 	return nil
 }
 
+// NeedsRehash reports whether a stored hash uses an outdated algorithm or parameters
+func (s *authService) NeedsRehash(hashedString string) bool {
+	return s.passwordHasher.NeedsRehash(hashedString)
+}
+
 // Generate cookies for logout
 func (s *authService) GenerateLogoutCookies(Writer http.ResponseWriter) {
 	accessTokenCookie := &http.Cookie{
@@ -140,20 +234,43 @@ func (s *authService) GenerateLogoutCookies(Writer http.ResponseWriter) {
 }
 
 // GenerateTokenPair generates an access token and refresh token pair
-func (s *authService) GenerateTokenPairCookies(Writer http.ResponseWriter, req TokenPairRequest) *customerror.CustomError {
-	accessToken, err := s.jwtService.GenerateAccesToken(req.UserID, req.Email)
+func (s *authService) GenerateTokenPair(req TokenPairRequest) (*TokenPair, *customerror.CustomError) {
+	accessToken, err := s.jwtService.GenerateAccessToken(middleware.UserClaims{
+		UserID:         req.UserID,
+		Email:          req.Email,
+		Roles:          req.Roles,
+		Permissions:    req.Permissions,
+		OrganizationID: req.OrganizationID,
+		SessionID:      req.SessionID,
+
+		OrganizationRole:        req.OrganizationRole,
+		OrganizationPermissions: req.OrganizationPermissions,
+	})
 	if err != nil {
-		return customerror.NewCustomError(err, "Gagal membuat access token", http.StatusInternalServerError)
+		return nil, customerror.NewCustomError(err, "Gagal membuat access token", http.StatusInternalServerError)
 	}
 
-	refreshToken, err := s.jwtService.GenerateRefreshToken(req.UserID, req.Email)
+	// The active organization survives refreshes; roles are looked up again
+	refreshToken, err := s.jwtService.GenerateRefreshToken(middleware.UserClaims{
+		UserID:         req.UserID,
+		Email:          req.Email,
+		OrganizationID: req.OrganizationID,
+	})
 	if err != nil {
-		return customerror.NewCustomError(err, "Gagal membuat refresh token", http.StatusInternalServerError)
+		return nil, customerror.NewCustomError(err, "Gagal membuat refresh token", http.StatusInternalServerError)
 	}
 
+	return &TokenPair{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+	}, nil
+}
+
+// SetTokenPairCookies writes the access and refresh token cookies
+func (s *authService) SetTokenPairCookies(Writer http.ResponseWriter, pair *TokenPair) {
 	accessTokenCookie := &http.Cookie{
 		Name:     s.tokenConfig.AccessTokenName,
-		Value:    accessToken,
+		Value:    pair.AccessToken,
 		Path:     s.tokenConfig.CookiePath,
 		Domain:   s.tokenConfig.CookieDomain,
 		Secure:   s.tokenConfig.SecureCookie,
@@ -164,7 +281,7 @@ func (s *authService) GenerateTokenPairCookies(Writer http.ResponseWriter, req T
 
 	refreshTokenCookie := &http.Cookie{
 		Name:     s.tokenConfig.RefreshTokenName,
-		Value:    refreshToken,
+		Value:    pair.RefreshToken,
 		Path:     s.tokenConfig.CookiePath,
 		Domain:   s.tokenConfig.CookieDomain,
 		Secure:   s.tokenConfig.SecureCookie,
@@ -175,8 +292,6 @@ func (s *authService) GenerateTokenPairCookies(Writer http.ResponseWriter, req T
 	http.SetCookie(Writer, refreshTokenCookie)
 
 	http.SetCookie(Writer, accessTokenCookie)
-
-	return nil
 }
 
 // ValidatePasswordInput validates password reset input
@@ -187,27 +302,359 @@ func (s *authService) ValidatePasswordInput(password, passwordConfirmation strin
 	return nil
 }
 
+// ValidatePasswordPolicy returns every password policy rule the password breaks
+func (s *authService) ValidatePasswordPolicy(password, email, fullname string) []PasswordViolation {
+	violations := s.passwordPolicy.Validate(password, passwordpolicy.UserInfo{
+		Email:    email,
+		FullName: fullname,
+	})
+
+	response := make([]PasswordViolation, 0, len(violations))
+	for _, violation := range violations {
+		response = append(response, PasswordViolation{
+			Code:    violation.Code,
+			Message: passwordViolationMessage(violation),
+		})
+	}
+	return response
+}
+
+func passwordViolationMessage(violation passwordpolicy.Violation) string {
+	switch violation.Code {
+	case passwordpolicy.TooShort:
+		return "Password minimal " + strconv.Itoa(violation.Limit) + " karakter"
+	case passwordpolicy.TooLong:
+		return "Password maksimal " + strconv.Itoa(violation.Limit) + " karakter"
+	case passwordpolicy.MissingLowercase:
+		return "Password harus mengandung huruf kecil"
+	case passwordpolicy.MissingUppercase:
+		return "Password harus mengandung huruf besar"
+	case passwordpolicy.MissingDigit:
+		return "Password harus mengandung angka"
+	case passwordpolicy.MissingSymbol:
+		return "Password harus mengandung simbol"
+	case passwordpolicy.TooWeak:
+		return "Password terlalu mudah ditebak"
+	case passwordpolicy.ContainsPersonalInfo:
+		return "Password tidak boleh mengandung email atau nama"
+	case passwordpolicy.Breached:
+		return "Password pernah bocor di internet, gunakan password lain"
+	default:
+		return "Password tidak memenuhi kebijakan"
+	}
+}
+
 // ValidateTokenClaims validates and extracts claims from a JWT token
-func (s *authService) ValidateRefreshTokenClaims(token string) (*jwtPkg.TokenClaims, *customerror.CustomError) {
-	claims, err := s.jwtService.ValidateRefreshTokenClaims(token)
+func (s *authService) ValidateRefreshTokenClaims(token string) (*middleware.UserClaims, *customerror.CustomError) {
+	claims, err := s.jwtService.ValidateRefreshToken(token)
 	if err != nil {
 		var message string
 		var statusCode int
 
-		if ve, ok := err.(*jwt.ValidationError); ok {
-			if ve.Errors&jwt.ValidationErrorExpired != 0 {
-				message = "Token sudah kadaluarsa"
-			} else {
-				log.Println("Token tidak valid:", err)
-				message = "Token tidak valid"
-			}
+		switch {
+		case errors.Is(err, jwtPkg.ErrTokenExpired):
+			message = "Token sudah kadaluarsa"
 			statusCode = http.StatusUnauthorized
-		} else {
+		case errors.Is(err, jwtPkg.ErrDenylistUnavailable):
 			message = "Gagal memvalidasi token"
 			statusCode = http.StatusInternalServerError
+		default:
+			log.Println("Token tidak valid:", err)
+			message = "Token tidak valid"
+			statusCode = http.StatusUnauthorized
 		}
 
 		return nil, customerror.NewCustomError(err, message, statusCode)
 	}
-	return claims, nil
+	return &claims.Custom, nil
+}
+
+// RevokeAccessToken rejects an access token for the rest of its lifetime.
+// Tokens that are already invalid are ignored.
+func (s *authService) RevokeAccessToken(token string) *customerror.CustomError {
+	claims, err := s.jwtService.ValidateAccessToken(token)
+	if err != nil {
+		return nil
+	}
+	if err := s.jwtService.RevokeToken(claims); err != nil {
+		return customerror.NewCustomError(err, "Gagal mencabut token", http.StatusInternalServerError)
+	}
+	return nil
+}
+
+// JWKS returns the public keys other services use to verify access tokens
+func (s *authService) JWKS() jwtPkg.JWKSet {
+	return s.jwtService.JWKS()
+}
+
+// GenerateSessionFamilyID generates a random identifier shared by all refresh tokens of one login
+func (s *authService) GenerateSessionFamilyID() (string, *customerror.CustomError) {
+	familyID, err := randomHex(16)
+	if err != nil {
+		return "", customerror.NewCustomError(err, "Gagal membuat sesi", http.StatusInternalServerError)
+	}
+	return familyID, nil
+}
+
+// HashToken returns the SHA-256 hash of a high-entropy token so it can be looked up in the database
+func (s *authService) HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateTOTPSecret generates a new secret for an authenticator app
+func (s *authService) GenerateTOTPSecret() (string, *customerror.CustomError) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", customerror.NewCustomError(err, "Gagal membuat secret 2FA", http.StatusInternalServerError)
+	}
+	return secret, nil
+}
+
+// BuildTOTPURI builds the otpauth URI shown as a QR code during enrollment
+func (s *authService) BuildTOTPURI(email, secret string) string {
+	return totp.URI(s.mfaConfig.TOTPIssuer, email, secret)
+}
+
+// VerifyTOTPCode verifies a TOTP code and returns the matching time step
+func (s *authService) VerifyTOTPCode(secret, code string) (int64, *customerror.CustomError) {
+	step, ok := totp.Validate(secret, code, time.Now(), s.mfaConfig.TOTPSkew)
+	if !ok {
+		return 0, customerror.NewCustomError(nil, "Kode 2FA tidak valid", http.StatusUnauthorized)
+	}
+	return step, nil
+}
+
+// GenerateRecoveryCodes generates one-time recovery codes in the form xxxxx-xxxxx
+func (s *authService) GenerateRecoveryCodes() ([]string, *customerror.CustomError) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, 0, s.mfaConfig.RecoveryCodeCount)
+	for i := 0; i < s.mfaConfig.RecoveryCodeCount; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, customerror.NewCustomError(err, "Gagal membuat kode pemulihan", http.StatusInternalServerError)
+		}
+		code := strings.ToLower(encoding.EncodeToString(b))[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode normalizes and hashes a recovery code for storage and lookup
+func (s *authService) HashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.TrimSpace(code))
+	normalized = strings.ReplaceAll(normalized, "-", "")
+	normalized = strings.ReplaceAll(normalized, " ", "")
+	return s.HashToken(normalized)
+}
+
+// GenerateMFAChallengeToken generates the token that links a password login to its second factor
+func (s *authService) GenerateMFAChallengeToken() (string, *customerror.CustomError) {
+	token, err := randomHex(32)
+	if err != nil {
+		return "", customerror.NewCustomError(err, "Gagal membuat token 2FA", http.StatusInternalServerError)
+	}
+	return token, nil
+}
+
+// GenerateWebAuthnChallenge generates the random challenge signed during a passkey ceremony
+func (s *authService) GenerateWebAuthnChallenge() (string, *customerror.CustomError) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", customerror.NewCustomError(err, "Gagal membuat challenge passkey", http.StatusInternalServerError)
+	}
+	return challenge, nil
+}
+
+// NewOIDCFlow generates the state, nonce and PKCE verifier of a new authorization code flow
+func (s *authService) NewOIDCFlow(provider string, expiry time.Duration) (*OIDCFlow, *customerror.CustomError) {
+	state, err := oidc.NewState()
+	if err != nil {
+		return nil, customerror.NewCustomError(err, "Gagal memulai login", http.StatusInternalServerError)
+	}
+
+	nonce, err := oidc.NewState()
+	if err != nil {
+		return nil, customerror.NewCustomError(err, "Gagal memulai login", http.StatusInternalServerError)
+	}
+
+	return &OIDCFlow{
+		Provider:     provider,
+		State:        state,
+		Nonce:        nonce,
+		CodeVerifier: oidc.NewCodeVerifier(),
+		ExpiresAt:    time.Now().Add(expiry),
+	}, nil
+}
+
+// oidcFlowPurpose binds the signed flow cookie to the OIDC callback
+const oidcFlowPurpose = "oidc-flow"
+
+// SetOIDCFlowCookie stores the flow in a signed cookie that survives the redirect back from the provider
+func (s *authService) SetOIDCFlowCookie(Writer http.ResponseWriter, flow *OIDCFlow) *customerror.CustomError {
+	value, err := s.oidcFlowSigner.Sign(oidcFlowPurpose, flow, time.Until(flow.ExpiresAt))
+	if err != nil {
+		return customerror.NewCustomError(err, "Gagal memulai login", http.StatusInternalServerError)
+	}
+
+	http.SetCookie(Writer, &http.Cookie{
+		Name:     oidcFlowCookieName,
+		Value:    value,
+		Path:     s.tokenConfig.CookiePath,
+		Domain:   s.tokenConfig.CookieDomain,
+		Secure:   s.tokenConfig.SecureCookie,
+		HttpOnly: true,
+		Expires:  flow.ExpiresAt,
+		// Lax is required because the callback is a cross-site top-level navigation
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// ReadOIDCFlow verifies the signature of the flow cookie and rejects expired flows
+func (s *authService) ReadOIDCFlow(value string) (*OIDCFlow, *customerror.CustomError) {
+	var flow OIDCFlow
+	if err := s.oidcFlowSigner.Verify(oidcFlowPurpose, value, &flow); err != nil {
+		if err == signedtoken.ErrExpiredToken {
+			return nil, customerror.NewCustomError(err, "Sesi login sudah kadaluarsa", http.StatusUnauthorized)
+		}
+		return nil, customerror.NewCustomError(err, "Sesi login tidak valid", http.StatusUnauthorized)
+	}
+	return &flow, nil
+}
+
+// ClearOIDCFlowCookie removes the flow cookie once the callback was handled
+func (s *authService) ClearOIDCFlowCookie(Writer http.ResponseWriter) {
+	http.SetCookie(Writer, &http.Cookie{
+		Name:     oidcFlowCookieName,
+		Value:    "",
+		Path:     s.tokenConfig.CookiePath,
+		Domain:   s.tokenConfig.CookieDomain,
+		Secure:   s.tokenConfig.SecureCookie,
+		HttpOnly: true,
+		MaxAge:   -1,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// loginLinkPurpose binds signed login links to the passwordless login flow
+const loginLinkPurpose = "login-link"
+
+// GenerateLoginLink builds the signed one-time login link sent next to the code.
+// It returns an empty string when login links are not configured.
+func (s *authService) GenerateLoginLink(email, code string, expiry time.Duration) (string, *customerror.CustomError) {
+	if s.passwordlessConfig.LinkURL == "" {
+		return "", nil
+	}
+
+	token, err := s.loginLinkSigner.Sign(loginLinkPurpose, LoginLinkClaims{Email: email, Code: code}, expiry)
+	if err != nil {
+		return "", customerror.NewCustomError(err, "Gagal membuat link login", http.StatusInternalServerError)
+	}
+
+	link, err := url.Parse(s.passwordlessConfig.LinkURL)
+	if err != nil {
+		return "", customerror.NewCustomError(err, "URL link login tidak valid", http.StatusInternalServerError)
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return link.String(), nil
+}
+
+// ParseLoginLink verifies a login link token and returns the email and code it carries
+func (s *authService) ParseLoginLink(token string) (*LoginLinkClaims, *customerror.CustomError) {
+	if s.passwordlessConfig.LinkURL == "" {
+		return nil, customerror.NewCustomError(nil, "Link login tidak diaktifkan", http.StatusBadRequest)
+	}
+
+	var claims LoginLinkClaims
+	if err := s.loginLinkSigner.Verify(loginLinkPurpose, token, &claims); err != nil {
+		if err == signedtoken.ErrExpiredToken {
+			return nil, customerror.NewCustomError(err, "Link login sudah kadaluarsa", http.StatusUnauthorized)
+		}
+		return nil, customerror.NewCustomError(err, "Link login tidak valid", http.StatusUnauthorized)
+	}
+	return &claims, nil
+}
+
+// GenerateAPIKey generates a key of the form <prefix>_<id>_<secret> and returns it with
+// its displayable ID <prefix>_<id>. Only the ID and the hash of the secret are stored.
+func (s *authService) GenerateAPIKey(prefix string) (string, string, *customerror.CustomError) {
+	id, err := randomHex(8)
+	if err != nil {
+		return "", "", customerror.NewCustomError(err, "Gagal membuat API key", http.StatusInternalServerError)
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", "", customerror.NewCustomError(err, "Gagal membuat API key", http.StatusInternalServerError)
+	}
+
+	keyID := prefix + "_" + id
+	return keyID, keyID + "_" + secret, nil
+}
+
+// ParseAPIKey splits a key into its displayable ID and secret
+func (s *authService) ParseAPIKey(key string) (string, string, bool) {
+	i := strings.LastIndex(key, "_")
+	if i <= 0 || i == len(key)-1 {
+		return "", "", false
+	}
+	return key[:i], key[i+1:], true
+}
+
+// invitationPurpose binds signed tokens to organization invitations
+const invitationPurpose = "organization-invitation"
+
+// GenerateInvitationToken signs an invitation to an organization and builds the link
+// to the invitation page, empty when no page is configured. Only the hash of the token
+// is stored, so sending a new token invalidates the previous one.
+func (s *authService) GenerateInvitationToken(organizationID, email string, expiry time.Duration) (string, string, *customerror.CustomError) {
+	nonce, err := randomHex(16)
+	if err != nil {
+		return "", "", customerror.NewCustomError(err, "Gagal membuat undangan", http.StatusInternalServerError)
+	}
+
+	claims := InvitationClaims{OrganizationID: organizationID, Email: email, Nonce: nonce}
+	token, err := s.invitationSigner.Sign(invitationPurpose, claims, expiry)
+	if err != nil {
+		return "", "", customerror.NewCustomError(err, "Gagal membuat undangan", http.StatusInternalServerError)
+	}
+
+	if s.organizationConfig.InvitationURL == "" {
+		return token, "", nil
+	}
+
+	link, err := url.Parse(s.organizationConfig.InvitationURL)
+	if err != nil {
+		return "", "", customerror.NewCustomError(err, "URL undangan tidak valid", http.StatusInternalServerError)
+	}
+
+	query := link.Query()
+	query.Set("token", token)
+	link.RawQuery = query.Encode()
+	return token, link.String(), nil
+}
+
+// ParseInvitationToken verifies an invitation token and returns the organization and email it was sent for
+func (s *authService) ParseInvitationToken(token string) (*InvitationClaims, *customerror.CustomError) {
+	var claims InvitationClaims
+	if err := s.invitationSigner.Verify(invitationPurpose, token, &claims); err != nil {
+		if err == signedtoken.ErrExpiredToken {
+			return nil, customerror.NewCustomError(err, "Undangan sudah kadaluarsa", http.StatusUnauthorized)
+		}
+		return nil, customerror.NewCustomError(err, "Undangan tidak valid", http.StatusUnauthorized)
+	}
+	return &claims, nil
+}
+
+// randomHex returns n random bytes encoded as hex
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
 }